@@ -0,0 +1,67 @@
+package awsclient
+
+import (
+	"errors"
+
+	"github.com/nicola-strappazzon/argos/internal/config/aws"
+	"github.com/nicola-strappazzon/argos/internal/toolerr"
+
+	"github.com/aws/aws-sdk-go-v2/aws/ratelimit"
+	"github.com/aws/smithy-go"
+)
+
+func init() {
+	toolerr.Register(classify)
+}
+
+// classify maps AWS API error codes to tool error kinds.
+func classify(err error) (toolerr.Kind, string, bool) {
+	if errors.Is(err, ErrSecretNotFound) {
+		return toolerr.NotFound, "list the secrets with aws_secrets_list", true
+	}
+
+	if kind, hint, ok := classifyCode(err); ok {
+		return kind, hint, true
+	}
+
+	var cerr *awsconfig.CredentialsError
+	if errors.As(err, &cerr) {
+		return toolerr.Auth, "refresh the AWS credentials (e.g. aws sso login) or set AWS_PROFILE", true
+	}
+	var qerr *ratelimit.QuotaExceededError
+	if errors.As(err, &qerr) {
+		return toolerr.Unavailable, "AWS is throttling requests: retry later", true
+	}
+
+	return "", "", false
+}
+
+// classifyCode maps the error code of an AWS API error.
+func classifyCode(err error) (toolerr.Kind, string, bool) {
+	var aerr smithy.APIError
+	if !errors.As(err, &aerr) {
+		return "", "", false
+	}
+
+	switch aerr.ErrorCode() {
+	case "ExpiredToken", "ExpiredTokenException", "RequestExpired",
+		"InvalidClientTokenId", "UnrecognizedClientException", "SignatureDoesNotMatch":
+		return toolerr.Auth, "refresh the AWS credentials (e.g. aws sso login) or set AWS_PROFILE", true
+	case "AccessDenied", "AccessDeniedException", "UnauthorizedOperation", "AuthorizationError":
+		return toolerr.PermissionDenied, "grant the missing IAM permission to the AWS identity in use", true
+	case "SubscriptionRequiredException":
+		return toolerr.PermissionDenied, "the AWS Health API requires a Business or Enterprise support plan", true
+	case "DBInstanceNotFound", "DBInstanceNotFoundFault", "DBSnapshotNotFound", "DBParameterGroupNotFound":
+		return toolerr.NotFound, "list the instances with aws_rds_instances", true
+	case "DBLogFileNotFoundFault":
+		return toolerr.NotFound, "list the log files of the instance with aws_rds_logs", true
+	case "InvalidInstanceID.NotFound":
+		return toolerr.NotFound, "list the EC2 instances with aws_ec2_list", true
+	case "ResourceNotFoundException":
+		return toolerr.NotFound, "check the resource name or ARN", true
+	case "Throttling", "ThrottlingException", "RequestLimitExceeded":
+		return toolerr.Unavailable, "AWS is throttling requests: retry later", true
+	}
+
+	return "", "", false
+}
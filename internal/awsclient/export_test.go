@@ -0,0 +1,3 @@
+package awsclient
+
+var Load = load
@@ -0,0 +1,190 @@
+// Package awsclient builds the AWS API clients used by the aws_* tools. Every
+// client shares the cached config of its target and an adaptive retryer per
+// service and target, which retries throttled calls with backoff and slows
+// down while AWS keeps throttling. Tests replace the clients through Use.
+package awsclient
+
+import (
+	"context"
+	"sync"
+
+	"github.com/nicola-strappazzon/argos/internal/config/aws"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/health"
+	"github.com/aws/aws-sdk-go-v2/service/pi"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+)
+
+// RDSAPI is the part of the RDS API the tools call.
+type RDSAPI interface {
+	DescribeDBInstances(context.Context, *rds.DescribeDBInstancesInput, ...func(*rds.Options)) (*rds.DescribeDBInstancesOutput, error)
+	DescribeDBLogFiles(context.Context, *rds.DescribeDBLogFilesInput, ...func(*rds.Options)) (*rds.DescribeDBLogFilesOutput, error)
+	DescribeDBParameters(context.Context, *rds.DescribeDBParametersInput, ...func(*rds.Options)) (*rds.DescribeDBParametersOutput, error)
+	DescribeDBSnapshots(context.Context, *rds.DescribeDBSnapshotsInput, ...func(*rds.Options)) (*rds.DescribeDBSnapshotsOutput, error)
+	DescribeEvents(context.Context, *rds.DescribeEventsInput, ...func(*rds.Options)) (*rds.DescribeEventsOutput, error)
+	DescribePendingMaintenanceActions(context.Context, *rds.DescribePendingMaintenanceActionsInput, ...func(*rds.Options)) (*rds.DescribePendingMaintenanceActionsOutput, error)
+	DownloadDBLogFilePortion(context.Context, *rds.DownloadDBLogFilePortionInput, ...func(*rds.Options)) (*rds.DownloadDBLogFilePortionOutput, error)
+}
+
+// EC2API is the part of the EC2 API the tools call.
+type EC2API interface {
+	DescribeInstances(context.Context, *ec2.DescribeInstancesInput, ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error)
+}
+
+// CloudWatchAPI is the part of the CloudWatch API the tools call.
+type CloudWatchAPI interface {
+	GetMetricData(context.Context, *cloudwatch.GetMetricDataInput, ...func(*cloudwatch.Options)) (*cloudwatch.GetMetricDataOutput, error)
+}
+
+// CloudWatchLogsAPI is the part of the CloudWatch Logs API the tools call.
+type CloudWatchLogsAPI interface {
+	FilterLogEvents(context.Context, *cloudwatchlogs.FilterLogEventsInput, ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.FilterLogEventsOutput, error)
+}
+
+// PIAPI is the part of the Performance Insights API the tools call.
+type PIAPI interface {
+	DescribeDimensionKeys(context.Context, *pi.DescribeDimensionKeysInput, ...func(*pi.Options)) (*pi.DescribeDimensionKeysOutput, error)
+}
+
+// HealthAPI is the part of the AWS Health API the tools call.
+type HealthAPI interface {
+	DescribeEvents(context.Context, *health.DescribeEventsInput, ...func(*health.Options)) (*health.DescribeEventsOutput, error)
+	DescribeEventDetails(context.Context, *health.DescribeEventDetailsInput, ...func(*health.Options)) (*health.DescribeEventDetailsOutput, error)
+}
+
+// SecretsManagerAPI is the part of the Secrets Manager API the tools call.
+type SecretsManagerAPI interface {
+	GetSecretValue(context.Context, *secretsmanager.GetSecretValueInput, ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error)
+	ListSecrets(context.Context, *secretsmanager.ListSecretsInput, ...func(*secretsmanager.Options)) (*secretsmanager.ListSecretsOutput, error)
+}
+
+// Factory creates the API clients of a target.
+type Factory interface {
+	// Region returns the region the target resolves to.
+	Region(t awsconfig.Target) (string, error)
+	RDS(t awsconfig.Target) (RDSAPI, error)
+	EC2(t awsconfig.Target) (EC2API, error)
+	CloudWatch(t awsconfig.Target) (CloudWatchAPI, error)
+	CloudWatchLogs(t awsconfig.Target) (CloudWatchLogsAPI, error)
+	PI(t awsconfig.Target) (PIAPI, error)
+	Health(t awsconfig.Target) (HealthAPI, error)
+	SecretsManager(t awsconfig.Target) (SecretsManagerAPI, error)
+}
+
+var (
+	mu      sync.RWMutex
+	factory Factory = configFactory{}
+)
+
+// Use replaces the factory, e.g. with fake clients, and returns a function
+// that restores the previous one.
+func Use(f Factory) (restore func()) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	previous := factory
+	factory = f
+
+	return func() {
+		mu.Lock()
+		defer mu.Unlock()
+		factory = previous
+	}
+}
+
+func current() Factory {
+	mu.RLock()
+	defer mu.RUnlock()
+	return factory
+}
+
+func Region(t awsconfig.Target) (string, error) { return current().Region(t) }
+
+func RDS(t awsconfig.Target) (RDSAPI, error) { return current().RDS(t) }
+
+func EC2(t awsconfig.Target) (EC2API, error) { return current().EC2(t) }
+
+func CloudWatch(t awsconfig.Target) (CloudWatchAPI, error) { return current().CloudWatch(t) }
+
+func CloudWatchLogs(t awsconfig.Target) (CloudWatchLogsAPI, error) {
+	return current().CloudWatchLogs(t)
+}
+
+func PI(t awsconfig.Target) (PIAPI, error) { return current().PI(t) }
+
+func Health(t awsconfig.Target) (HealthAPI, error) { return current().Health(t) }
+
+func SecretsManager(t awsconfig.Target) (SecretsManagerAPI, error) {
+	return current().SecretsManager(t)
+}
+
+// configFactory builds real clients on the cached configs of awsconfig.
+type configFactory struct{}
+
+func (configFactory) Region(t awsconfig.Target) (string, error) {
+	cfg, err := awsconfig.Config(t)
+	if err != nil {
+		return "", err
+	}
+	return cfg.Region, nil
+}
+
+func (configFactory) RDS(t awsconfig.Target) (RDSAPI, error) {
+	cfg, err := load(t, rds.ServiceID)
+	if err != nil {
+		return nil, err
+	}
+	return rds.NewFromConfig(cfg), nil
+}
+
+func (configFactory) EC2(t awsconfig.Target) (EC2API, error) {
+	cfg, err := load(t, ec2.ServiceID)
+	if err != nil {
+		return nil, err
+	}
+	return ec2.NewFromConfig(cfg), nil
+}
+
+func (configFactory) CloudWatch(t awsconfig.Target) (CloudWatchAPI, error) {
+	cfg, err := load(t, cloudwatch.ServiceID)
+	if err != nil {
+		return nil, err
+	}
+	return cloudwatch.NewFromConfig(cfg), nil
+}
+
+func (configFactory) CloudWatchLogs(t awsconfig.Target) (CloudWatchLogsAPI, error) {
+	cfg, err := load(t, cloudwatchlogs.ServiceID)
+	if err != nil {
+		return nil, err
+	}
+	return cloudwatchlogs.NewFromConfig(cfg), nil
+}
+
+func (configFactory) PI(t awsconfig.Target) (PIAPI, error) {
+	cfg, err := load(t, pi.ServiceID)
+	if err != nil {
+		return nil, err
+	}
+	return pi.NewFromConfig(cfg), nil
+}
+
+func (configFactory) Health(t awsconfig.Target) (HealthAPI, error) {
+	cfg, err := load(t, health.ServiceID)
+	if err != nil {
+		return nil, err
+	}
+	return health.NewFromConfig(cfg), nil
+}
+
+func (configFactory) SecretsManager(t awsconfig.Target) (SecretsManagerAPI, error) {
+	cfg, err := load(t, secretsmanager.ServiceID)
+	if err != nil {
+		return nil, err
+	}
+	return secretsmanager.NewFromConfig(cfg), nil
+}
@@ -0,0 +1,119 @@
+package awsclient_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/nicola-strappazzon/argos/internal/awsclient"
+	"github.com/nicola-strappazzon/argos/internal/config/aws"
+	"github.com/nicola-strappazzon/argos/internal/toolerr"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/smithy-go"
+)
+
+// isolate keeps the shared config files and credentials of the host out of
+// the test.
+func isolate(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+	t.Setenv("AWS_PROFILE", "")
+	t.Setenv("AWS_REGION", "eu-west-1")
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+}
+
+func retryer(t *testing.T, target awsconfig.Target, service string) aws.RetryerV2 {
+	t.Helper()
+	cfg, err := awsclient.Load(target, service)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, ok := cfg.Retryer().(aws.RetryerV2)
+	if !ok {
+		t.Fatalf("expected a retryer with attempt tokens, got %T", cfg.Retryer())
+	}
+	return r
+}
+
+func TestRetryerShared(t *testing.T) {
+	isolate(t)
+	const role = "arn:aws:iam::111111111111:role/argos"
+
+	r := retryer(t, awsconfig.Target{RoleARN: role}, "RDS")
+	if retryer(t, awsconfig.Target{Region: "eu-west-1", RoleARN: role}, "RDS") != r {
+		t.Error("expected the calls to a service in the same account and region to share the retryer")
+	}
+	for name, other := range map[string]aws.RetryerV2{
+		"account": retryer(t, awsconfig.Target{RoleARN: "arn:aws:iam::222222222222:role/argos"}, "RDS"),
+		"region":  retryer(t, awsconfig.Target{Region: "us-east-1", RoleARN: role}, "RDS"),
+		"service": retryer(t, awsconfig.Target{RoleARN: role}, "CloudWatch"),
+	} {
+		if other == r {
+			t.Errorf("expected another %s to get its own retryer", name)
+		}
+	}
+}
+
+func TestRetryerAttempts(t *testing.T) {
+	isolate(t)
+
+	if n := retryer(t, awsconfig.Target{Region: "ap-south-1"}, "RDS").MaxAttempts(); n != awsclient.DefaultMaxRetries+1 {
+		t.Errorf("expected %d attempts by default, got %d", awsclient.DefaultMaxRetries+1, n)
+	}
+
+	t.Setenv("ARGOS_AWS_MAX_RETRIES", "0")
+	r := retryer(t, awsconfig.Target{Region: "ap-south-2"}, "RDS")
+	if n := r.MaxAttempts(); n != 1 {
+		t.Errorf("expected a single attempt without retries, got %d", n)
+	}
+
+	throttled := &smithy.GenericAPIError{Code: "ThrottlingException"}
+	if !r.IsErrorRetryable(throttled) {
+		t.Error("expected throttling to be retried")
+	}
+	if r.IsErrorRetryable(&smithy.GenericAPIError{Code: "AccessDenied"}) {
+		t.Error("expected a permission error not to be retried")
+	}
+}
+
+func TestRetryerThrottled(t *testing.T) {
+	isolate(t)
+	throttled := retryer(t, awsconfig.Target{Region: "sa-east-1", RoleARN: "arn:aws:iam::111111111111:role/argos"}, "RDS")
+	other := retryer(t, awsconfig.Target{Region: "sa-east-1", RoleARN: "arn:aws:iam::222222222222:role/argos"}, "RDS")
+
+	release, err := throttled.GetAttemptToken(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	release(&smithy.GenericAPIError{Code: "ThrottlingException"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := throttled.GetAttemptToken(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the wait for the rate limit to stop with the context, got %v", err)
+	}
+	if _, err := other.GetAttemptToken(ctx); err != nil {
+		t.Errorf("expected another account not to be slowed down, got %v", err)
+	}
+}
+
+func TestClassify(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		kind toolerr.Kind
+	}{
+		{&smithy.GenericAPIError{Code: "ThrottlingException"}, toolerr.Unavailable},
+		{&smithy.GenericAPIError{Code: "AccessDeniedException"}, toolerr.PermissionDenied},
+		{&smithy.GenericAPIError{Code: "DBInstanceNotFound"}, toolerr.NotFound},
+		{&awsconfig.CredentialsError{Err: errors.New("no EC2 IMDS role found")}, toolerr.Auth},
+	} {
+		if got := toolerr.Classify(tc.err).Kind; got != tc.kind {
+			t.Errorf("%v: expected %s, got %s", tc.err, tc.kind, got)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package awsclient
+
+import (
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/nicola-strappazzon/argos/internal/config/aws"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+)
+
+// DefaultMaxRetries is the number of retries when ARGOS_AWS_MAX_RETRIES is not set.
+const DefaultMaxRetries = 5
+
+// retryerKey identifies the calls that share a retryer: one service in one
+// region of one account, reached through a profile or a role chain.
+type retryerKey struct {
+	service string
+	target  awsconfig.Target
+}
+
+var (
+	retryersMu sync.Mutex
+	retryers   = map[retryerKey]aws.Retryer{}
+)
+
+// load returns the config of the target with the shared retryer of the
+// service.
+func load(t awsconfig.Target, service string) (aws.Config, error) {
+	cfg, err := awsconfig.Config(t)
+	if err != nil {
+		return aws.Config{}, err
+	}
+
+	t = t.Resolve()
+	t.Region = cfg.Region
+	r := retryerFor(service, t)
+	cfg.Retryer = func() aws.Retryer { return r }
+
+	return cfg, nil
+}
+
+// retryerFor returns the retryer of a service in a resolved target. It
+// retries with exponential backoff and jitter, and its adaptive rate limit
+// spaces out the calls while AWS throttles them, so concurrent tools back off
+// together instead of each burning its retries. Waiting for the rate limit
+// stops when the context of the call is done.
+func retryerFor(service string, t awsconfig.Target) aws.Retryer {
+	retryersMu.Lock()
+	defer retryersMu.Unlock()
+
+	key := retryerKey{service: service, target: t}
+	r, ok := retryers[key]
+	if !ok {
+		r = retry.NewAdaptiveMode(func(o *retry.AdaptiveModeOptions) {
+			o.StandardOptions = append(o.StandardOptions, func(o *retry.StandardOptions) {
+				o.MaxAttempts = maxRetries() + 1
+			})
+		})
+		retryers[key] = r
+	}
+	return r
+}
+
+func maxRetries() int {
+	if n, err := strconv.Atoi(os.Getenv("ARGOS_AWS_MAX_RETRIES")); err == nil && n >= 0 {
+		return n
+	}
+	return DefaultMaxRetries
+}
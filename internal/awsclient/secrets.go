@@ -0,0 +1,38 @@
+package awsclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/nicola-strappazzon/argos/internal/config/aws"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+)
+
+// ErrSecretNotFound is returned by GetSecretString when the secret does not exist.
+var ErrSecretNotFound = errors.New("secret not found")
+
+// GetSecretString returns the SecretString of the given Secrets Manager secret
+// name or ARN, read through the target's config.
+func GetSecretString(ctx context.Context, t awsconfig.Target, name string) (string, error) {
+	svc, err := SecretsManager(t)
+	if err != nil {
+		return "", err
+	}
+
+	result, err := svc.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(name),
+	})
+	if err != nil {
+		var nerr *types.ResourceNotFoundException
+		if errors.As(err, &nerr) {
+			return "", fmt.Errorf("getting secret %s: %w", name, ErrSecretNotFound)
+		}
+		return "", fmt.Errorf("getting secret %s: %w", name, err)
+	}
+
+	return aws.ToString(result.SecretString), nil
+}
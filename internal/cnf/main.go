@@ -2,11 +2,15 @@ package cnf
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
 )
 
+// ErrSectionNotFound is returned by Load when the file has no such section.
+var ErrSectionNotFound = errors.New("section not found")
+
 // Load reads key=value pairs from the given section in a CNF-style file.
 // Returns an error if the file cannot be opened or the section is not found.
 func Load(path, section string) (map[string]string, error) {
@@ -53,7 +57,7 @@ func Load(path, section string) (map[string]string, error) {
 	}
 
 	if !found {
-		return nil, fmt.Errorf("section [%s] not found in %s", section, path)
+		return nil, fmt.Errorf("%w: [%s] in %s", ErrSectionNotFound, section, path)
 	}
 
 	return result, nil
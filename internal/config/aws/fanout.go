@@ -0,0 +1,42 @@
+package awsconfig
+
+import (
+	"context"
+	"sync"
+)
+
+// TargetError reports a target that failed during a fan-out.
+type TargetError struct {
+	Target string `json:"target"`
+	Error  string `json:"error"`
+}
+
+// FanOut calls fn concurrently for every target and returns the results in
+// target order. A failing target does not fail the others; it is reported in
+// the returned errors instead.
+func FanOut[T any](ctx context.Context, targets []Target, fn func(ctx context.Context, t Target) ([]T, error)) ([]T, []TargetError) {
+	results := make([][]T, len(targets))
+	failures := make([]error, len(targets))
+
+	var wg sync.WaitGroup
+	for i, t := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], failures[i] = fn(ctx, t)
+		}()
+	}
+	wg.Wait()
+
+	merged := []T{}
+	errs := []TargetError{}
+	for i, t := range targets {
+		if failures[i] != nil {
+			errs = append(errs, TargetError{Target: t.String(), Error: failures[i].Error()})
+			continue
+		}
+		merged = append(merged, results[i]...)
+	}
+
+	return merged, errs
+}
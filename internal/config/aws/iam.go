@@ -0,0 +1,63 @@
+package awsconfig
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/rds/auth"
+)
+
+// RDS IAM auth tokens are valid for 15 minutes. They are only checked when a
+// connection is opened, so a token is reused until tokenRefreshAfter and a new
+// one is generated for any connection opened afterwards.
+const tokenRefreshAfter = 10 * time.Minute
+
+// AuthTokenSource generates and caches RDS IAM database authentication tokens
+// for one endpoint and database user.
+type AuthTokenSource struct {
+	Endpoint string
+	Region   string
+	User     string
+
+	mu        sync.Mutex
+	token     string
+	generated time.Time
+}
+
+// NewAuthTokenSource returns a token source for host:port and user. If region
+// is empty the region of the default target is used.
+func NewAuthTokenSource(host string, port int, user, region string) *AuthTokenSource {
+	return &AuthTokenSource{
+		Endpoint: fmt.Sprintf("%s:%d", host, port),
+		Region:   region,
+		User:     user,
+	}
+}
+
+// Token returns a valid auth token, generating a new one when the cached token
+// is close to expiring. ctx bounds the retrieval of the AWS credentials.
+func (s *AuthTokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != "" && time.Since(s.generated) < tokenRefreshAfter {
+		return s.token, nil
+	}
+
+	cfg, err := Config(Target{Region: s.Region})
+	if err != nil {
+		return "", err
+	}
+
+	token, err := auth.BuildAuthToken(ctx, s.Endpoint, cfg.Region, s.User, cfg.Credentials)
+	if err != nil {
+		return "", fmt.Errorf("building IAM auth token for %s: %w", s.Endpoint, err)
+	}
+
+	s.token = token
+	s.generated = time.Now()
+
+	return token, nil
+}
@@ -1,29 +1,105 @@
 package awsconfig
 
 import (
+	"context"
 	"fmt"
-	"os"
+	"strings"
+	"sync"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/nicola-strappazzon/argos/internal/toolerr"
 )
 
-func NewSession() (*session.Session, error) {
-	region := os.Getenv("AWS_REGION")
-	if region == "" {
-		return nil, fmt.Errorf("AWS_REGION environment variable is not set")
+// RoleSessionName identifies Argos in the CloudTrail events of assumed roles.
+const RoleSessionName = "argos"
+
+var (
+	mu      sync.Mutex
+	configs = map[Target]aws.Config{}
+)
+
+// Config returns the cached config of the target. Empty fields fall back to
+// AWS_REGION and AWS_PROFILE; each role of RoleARN is assumed in turn with
+// the credentials of the previous one. Assumed credentials are refreshed
+// before they expire.
+func Config(t Target) (aws.Config, error) {
+	t = t.Resolve()
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if cfg, ok := configs[t]; ok {
+		return cfg, nil
 	}
 
-	opts := session.Options{
-		Config: aws.Config{
-			Region: aws.String(region),
-		},
-		SharedConfigState: session.SharedConfigEnable,
+	opts := []func(*config.LoadOptions) error{}
+	if t.Profile != "" {
+		opts = append(opts, config.WithSharedConfigProfile(t.Profile))
+	}
+	if t.Region != "" {
+		opts = append(opts, config.WithRegion(t.Region))
 	}
 
-	if profile := os.Getenv("AWS_PROFILE"); profile != "" {
-		opts.Profile = profile
+	cfg, err := config.LoadDefaultConfig(context.Background(), opts...)
+	if err != nil {
+		return aws.Config{}, err
+	}
+	if cfg.Region == "" {
+		return aws.Config{}, toolerr.Errorf(toolerr.Misconfigured, "set AWS_REGION in the environment of the server, or pass region",
+			"AWS_REGION environment variable is not set")
 	}
 
-	return session.NewSessionWithOptions(opts)
+	for _, arn := range t.roles() {
+		provider := stscreds.NewAssumeRoleProvider(sts.NewFromConfig(cfg), arn, func(o *stscreds.AssumeRoleOptions) {
+			o.RoleSessionName = RoleSessionName
+		})
+		cfg.Credentials = aws.NewCredentialsCache(provider)
+	}
+	if cfg.Credentials != nil {
+		cfg.Credentials = credentials{cfg.Credentials}
+	}
+
+	configs[t] = cfg
+
+	return cfg, nil
+}
+
+// CredentialsError reports that no credentials could be retrieved for a
+// target, e.g. an expired SSO session or a role that cannot be assumed.
+type CredentialsError struct {
+	Err error
+}
+
+func (e *CredentialsError) Error() string {
+	return fmt.Sprintf("retrieving AWS credentials: %v", e.Err)
+}
+
+func (e *CredentialsError) Unwrap() error {
+	return e.Err
+}
+
+// credentials marks the failures of a provider as CredentialsError.
+type credentials struct {
+	aws.CredentialsProvider
+}
+
+func (c credentials) Retrieve(ctx context.Context) (aws.Credentials, error) {
+	creds, err := c.CredentialsProvider.Retrieve(ctx)
+	if err != nil {
+		return creds, &CredentialsError{Err: err}
+	}
+	return creds, nil
+}
+
+func split(list string) []string {
+	items := []string{}
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
 }
@@ -0,0 +1,125 @@
+package awsconfig
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/nicola-strappazzon/argos/internal/toolerr"
+)
+
+// Target selects the region and account an AWS tool talks to.
+type Target struct {
+	Region  string `json:"region,omitempty"`
+	Profile string `json:"profile,omitempty"`
+	// RoleARN is a comma separated chain of roles assumed in order.
+	RoleARN string `json:"role_arn,omitempty"`
+}
+
+// TargetProperties returns the tool input properties merged with the
+// region, profile and role_arn arguments read by TargetFromArgs.
+func TargetProperties(properties map[string]any) map[string]any {
+	merged := map[string]any{
+		"region": map[string]any{
+			"type":        "string",
+			"description": "AWS region (e.g. eu-west-1). Defaults to AWS_REGION.",
+		},
+		"profile": map[string]any{
+			"type":        "string",
+			"description": "Shared config profile to use. Defaults to AWS_PROFILE.",
+		},
+		"role_arn": map[string]any{
+			"type":        "string",
+			"description": "IAM role to assume, e.g. to reach another account. A comma separated list is assumed as a chain.",
+		},
+	}
+	for k, v := range properties {
+		merged[k] = v
+	}
+	return merged
+}
+
+// TargetFromArgs reads the region, profile and role_arn tool arguments.
+func TargetFromArgs(args map[string]any) (Target, error) {
+	t := Target{}
+	t.Region, _ = args["region"].(string)
+	t.Profile, _ = args["profile"].(string)
+	t.RoleARN, _ = args["role_arn"].(string)
+
+	for _, arn := range t.roles() {
+		if !strings.HasPrefix(arn, "arn:") || !strings.Contains(arn, ":role/") {
+			return t, toolerr.Errorf(toolerr.InvalidInput, "pass a role ARN like arn:aws:iam::123456789012:role/name",
+				"invalid role_arn %q", arn)
+		}
+	}
+
+	return t, nil
+}
+
+// Targets returns the regions and accounts to fan out to: every region of
+// ARGOS_AWS_REGIONS (default AWS_REGION) for every account, reached either
+// through a profile of ARGOS_AWS_PROFILES or a role of ARGOS_AWS_ROLES. With
+// neither set, the default credentials are used.
+func Targets() []Target {
+	regions := split(os.Getenv("ARGOS_AWS_REGIONS"))
+	if len(regions) == 0 {
+		regions = []string{""}
+	}
+
+	accounts := []Target{}
+	for _, profile := range split(os.Getenv("ARGOS_AWS_PROFILES")) {
+		accounts = append(accounts, Target{Profile: profile})
+	}
+	for _, role := range split(os.Getenv("ARGOS_AWS_ROLES")) {
+		accounts = append(accounts, Target{RoleARN: role})
+	}
+	if len(accounts) == 0 {
+		accounts = []Target{{}}
+	}
+
+	targets := make([]Target, 0, len(regions)*len(accounts))
+	for _, account := range accounts {
+		for _, region := range regions {
+			t := account
+			t.Region = region
+			targets = append(targets, t.Resolve())
+		}
+	}
+
+	return targets
+}
+
+// Account returns a short label of the account: the account ID of the last
+// assumed role, or the profile name.
+func (t Target) Account() string {
+	if roles := t.roles(); len(roles) > 0 {
+		// arn:aws:iam::123456789012:role/name
+		if parts := strings.Split(roles[len(roles)-1], ":"); len(parts) > 4 && parts[4] != "" {
+			return parts[4]
+		}
+	}
+	return t.Profile
+}
+
+func (t Target) String() string {
+	if account := t.Account(); account != "" {
+		return fmt.Sprintf("%s/%s", account, t.Region)
+	}
+	return t.Region
+}
+
+func (t Target) roles() []string {
+	return split(t.RoleARN)
+}
+
+// Resolve returns the target with an empty region and profile taken from
+// AWS_REGION and AWS_PROFILE.
+func (t Target) Resolve() Target {
+	if t.Region == "" {
+		t.Region = os.Getenv("AWS_REGION")
+	}
+	if t.Profile == "" {
+		t.Profile = os.Getenv("AWS_PROFILE")
+	}
+	return t
+}
@@ -0,0 +1,67 @@
+package credentials
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// envProvider reads variables named ARGOS_<ENGINE>_<INSTANCE>_<KEY>, where
+// INSTANCE is the instance identifier upper-cased with every character other
+// than letters and digits replaced by "_". For example:
+//
+//	ARGOS_MYSQL_MY_INSTANCE_NODE01_HOST=...
+//	ARGOS_MYSQL_MY_INSTANCE_NODE01_USER=...
+//
+// Only the known keys are read, so that ARGOS_MYSQL_DB_REPLICA_HOST is not
+// taken for a key of instance "db".
+type envProvider struct{}
+
+// envKeys are the keys the env provider reads.
+var envKeys = map[string]bool{
+	"host": true, "port": true, "user": true, "password": true, "database": true,
+	"auth": true, "region": true, "tls": true, "tls_ca_file": true,
+	"ssh_host": true, "ssh_port": true, "ssh_user": true, "ssh_key": true,
+	"ssh_key_passphrase": true, "ssh_known_hosts": true,
+	"ssh_strict_host_key_checking": true, "ssh_proxy_jump": true, "ssh_keepalive": true,
+}
+
+func (envProvider) Name() string { return "env" }
+
+func (envProvider) Lookup(_ context.Context, engine, instanceID string) (map[string]string, error) {
+	prefix := envPrefix(engine, instanceID)
+
+	keys := map[string]string{}
+	for _, kv := range os.Environ() {
+		name, value, ok := strings.Cut(kv, "=")
+		if !ok || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		key := strings.ToLower(strings.TrimPrefix(name, prefix))
+		if envKeys[key] {
+			keys[key] = value
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no %s* variables set: %w", prefix, ErrNotFound)
+	}
+
+	return keys, nil
+}
+
+// envPrefix returns the environment variable prefix used for an instance.
+func envPrefix(engine, instanceID string) string {
+	id := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return '_'
+	}, instanceID)
+
+	return "ARGOS_" + strings.ToUpper(engine) + "_" + id + "_"
+}
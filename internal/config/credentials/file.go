@@ -0,0 +1,134 @@
+package credentials
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/nicola-strappazzon/argos/internal/cnf"
+)
+
+// mergeSection adds the keys of an optional CNF section to keys, without
+// overriding them. A missing file or section is not an error.
+func mergeSection(keys map[string]string, path, section string) map[string]string {
+	extra, err := cnf.Load(path, section)
+	if err != nil {
+		return keys
+	}
+
+	for k, v := range extra {
+		if _, ok := keys[k]; !ok {
+			keys[k] = v
+		}
+	}
+
+	return keys
+}
+
+// pgpassIAM is the ~/.pgpass password value that enables IAM authentication,
+// since the format has no room for extra keys.
+const pgpassIAM = "@iam"
+
+// fileProvider reads the per-engine dotfiles in the home directory:
+// ~/.my.cnf and ~/.docdb sections, and ~/.pgpass lines completed by the
+// matching ~/.pgpass.cnf section.
+type fileProvider struct{}
+
+func (fileProvider) Name() string { return "file" }
+
+func (fileProvider) Lookup(_ context.Context, engine, instanceID string) (map[string]string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("getting home directory: %w", err)
+	}
+
+	switch engine {
+	case MySQL:
+		return loadSection(filepath.Join(home, ".my.cnf"), instanceID)
+	case DocDB:
+		return loadSection(filepath.Join(home, ".docdb"), instanceID)
+	case PostgreSQL:
+		keys, err := loadPgpass(filepath.Join(home, ".pgpass"), instanceID)
+		if err != nil {
+			return nil, err
+		}
+		return mergeSection(keys, filepath.Join(home, ".pgpass.cnf"), instanceID), nil
+	}
+
+	return nil, fmt.Errorf("unsupported engine %s", engine)
+}
+
+func loadSection(path, section string) (map[string]string, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, fmt.Errorf("%s does not exist: %w", path, ErrNotFound)
+	}
+
+	keys, err := cnf.Load(path, section)
+	if errors.Is(err, cnf.ErrSectionNotFound) {
+		return nil, fmt.Errorf("%v: %w", err, ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// loadPgpass matches lines where the hostname equals the instanceID or starts with instanceID+"."
+// (i.e. the RDS endpoint prefix matches the instance identifier).
+// The ~/.pgpass format is: hostname:port:database:username:password
+// A password of pgpassIAM selects IAM authentication for the entry.
+func loadPgpass(path, instanceID string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%s does not exist: %w", path, ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("opening %s: %w", path, err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.SplitN(line, ":", 5)
+		if len(fields) != 5 {
+			continue
+		}
+
+		hostname := fields[0]
+		if hostname != instanceID && !strings.HasPrefix(hostname, instanceID+".") {
+			continue
+		}
+
+		keys := map[string]string{
+			"host":     hostname,
+			"database": fields[2],
+			"user":     fields[3],
+			"password": fields[4],
+		}
+		if fields[1] != "*" {
+			keys["port"] = fields[1]
+		}
+		if fields[4] == pgpassIAM {
+			keys["auth"] = AuthIAM
+			delete(keys, "password")
+		}
+
+		return keys, nil
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	return nil, fmt.Errorf("no entry for [%s] in %s: %w", instanceID, path, ErrNotFound)
+}
@@ -0,0 +1,134 @@
+package credentials
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/nicola-strappazzon/argos/internal/toolerr"
+)
+
+// Engines supported by the credential providers. The engine selects which
+// dotfile the file provider reads and which prefix the env provider uses.
+const (
+	MySQL      = "mysql"
+	PostgreSQL = "postgresql"
+	DocDB      = "docdb"
+)
+
+// Authentication modes selected with the "auth" key. With AuthIAM the
+// password is ignored and a short-lived RDS IAM auth token is used instead.
+const (
+	AuthPassword = "password"
+	AuthIAM      = "iam"
+)
+
+// DefaultOrder is used when ARGOS_CREDENTIAL_PROVIDERS is not set.
+const DefaultOrder = "file,env"
+
+// ErrNotFound is returned by a Provider that has no entry for the instance,
+// so the chain moves on to the next provider.
+var ErrNotFound = errors.New("credentials not found")
+
+// Provider resolves connection settings for a database instance.
+// Keys use the ~/.my.cnf vocabulary: host, port, user, password, database,
+// plus any engine specific key (ssh_host, tls, tls_ca_file, ...).
+type Provider interface {
+	Name() string
+	Lookup(ctx context.Context, engine, instanceID string) (map[string]string, error)
+}
+
+var providers = map[string]Provider{
+	"file":           fileProvider{},
+	"env":            envProvider{},
+	"secretsmanager": secretsProvider{},
+}
+
+// Chain returns the providers listed in ARGOS_CREDENTIAL_PROVIDERS
+// (comma separated), or DefaultOrder when the variable is empty.
+func Chain() ([]Provider, error) {
+	order := os.Getenv("ARGOS_CREDENTIAL_PROVIDERS")
+	if order == "" {
+		order = DefaultOrder
+	}
+
+	chain := []Provider{}
+	for _, name := range strings.Split(order, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		p, ok := providers[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown credential provider %q in ARGOS_CREDENTIAL_PROVIDERS", name)
+		}
+		chain = append(chain, p)
+	}
+
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("ARGOS_CREDENTIAL_PROVIDERS does not list any provider")
+	}
+
+	return chain, nil
+}
+
+// Lookup walks the configured chain and returns the keys from the first
+// provider that knows the instance. Errors other than ErrNotFound are kept
+// and reported only if no provider succeeds. ctx bounds the providers that
+// call a remote service, such as secretsmanager.
+func Lookup(ctx context.Context, engine, instanceID string) (map[string]string, error) {
+	chain, err := Chain()
+	if err != nil {
+		return nil, err
+	}
+
+	var reasons []string
+	missing := true
+	for _, p := range chain {
+		keys, err := p.Lookup(ctx, engine, instanceID)
+		if err == nil {
+			return keys, nil
+		}
+		reasons = append(reasons, fmt.Sprintf("%s: %v", p.Name(), err))
+		missing = missing && errors.Is(err, ErrNotFound)
+	}
+
+	err = fmt.Errorf("no %s credentials for [%s] (%s)", engine, instanceID, strings.Join(reasons, "; "))
+	if missing {
+		return nil, toolerr.New(toolerr.NotFound, Hint(engine, instanceID), err)
+	}
+
+	return nil, err
+}
+
+// Hint tells how to configure credentials for the instance with the file
+// and env providers.
+func Hint(engine, instanceID string) string {
+	prefix := envPrefix(engine, instanceID)
+	env := fmt.Sprintf("set %sHOST, %sUSER and %sPASSWORD", prefix, prefix, prefix)
+
+	switch engine {
+	case MySQL:
+		return fmt.Sprintf("add section [%s] to ~/.my.cnf, or %s", instanceID, env)
+	case PostgreSQL:
+		return fmt.Sprintf("add a line %s.<endpoint>:5432:<database>:<user>:<password> to ~/.pgpass, or %s", instanceID, env)
+	case DocDB:
+		return fmt.Sprintf("add section [%s] to ~/.docdb, or %s", instanceID, env)
+	}
+
+	return env
+}
+
+// Auth returns the authentication mode configured in keys, AuthPassword by default.
+func Auth(keys map[string]string) (string, error) {
+	switch v := strings.ToLower(keys["auth"]); v {
+	case "", AuthPassword:
+		return AuthPassword, nil
+	case AuthIAM:
+		return AuthIAM, nil
+	default:
+		return "", fmt.Errorf("unknown auth mode %q, expected %q or %q", v, AuthPassword, AuthIAM)
+	}
+}
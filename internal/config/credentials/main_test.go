@@ -0,0 +1,77 @@
+package credentials_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nicola-strappazzon/argos/internal/config/credentials"
+	"github.com/nicola-strappazzon/argos/internal/toolerr"
+)
+
+func TestEnvIgnoresOtherInstances(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("ARGOS_CREDENTIAL_PROVIDERS", "env")
+	t.Setenv("ARGOS_MYSQL_DB_REPLICA_HOST", "replica.example.com")
+	t.Setenv("ARGOS_MYSQL_DB_REPLICA_USER", "app")
+
+	if _, err := credentials.Lookup(context.Background(), credentials.MySQL, "db"); toolerr.Classify(err).Kind != toolerr.NotFound {
+		t.Fatalf("instance db picked up the keys of db-replica: %v", err)
+	}
+
+	keys, err := credentials.Lookup(context.Background(), credentials.MySQL, "db-replica")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys["host"] != "replica.example.com" || keys["user"] != "app" {
+		t.Errorf("unexpected keys: %v", keys)
+	}
+}
+
+func TestEnvFallsThrough(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("ARGOS_CREDENTIAL_PROVIDERS", "env,file")
+	t.Setenv("ARGOS_MYSQL_DB_REPLICA_HOST", "replica.example.com")
+	if err := os.WriteFile(filepath.Join(home, ".my.cnf"), []byte("[db]\nhost=db.example.com\nuser=app\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := credentials.Lookup(context.Background(), credentials.MySQL, "db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys["host"] != "db.example.com" {
+		t.Errorf("unexpected keys: %v", keys)
+	}
+}
+
+func TestFileErrors(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("ARGOS_CREDENTIAL_PROVIDERS", "file")
+
+	// A missing file or section is not found.
+	if _, err := credentials.Lookup(context.Background(), credentials.MySQL, "db"); toolerr.Classify(err).Kind != toolerr.NotFound {
+		t.Errorf("missing file: %v", err)
+	}
+	path := filepath.Join(home, ".my.cnf")
+	if err := os.WriteFile(path, []byte("[other]\nhost=x\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := credentials.Lookup(context.Background(), credentials.MySQL, "db"); toolerr.Classify(err).Kind != toolerr.NotFound {
+		t.Errorf("missing section: %v", err)
+	}
+
+	// A file that cannot be read is an error of its own, e.g. a directory.
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(path, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := credentials.Lookup(context.Background(), credentials.MySQL, "db"); err == nil || toolerr.Classify(err).Kind == toolerr.NotFound {
+		t.Errorf("unreadable file: %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+package credentials
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/nicola-strappazzon/argos/internal/awsclient"
+	awsconfig "github.com/nicola-strappazzon/argos/internal/config/aws"
+)
+
+// DefaultSecretName is the secret name template used when
+// ARGOS_SECRET_NAME_TEMPLATE is not set. {engine} and {instance} are replaced
+// by the engine name and instance identifier.
+const DefaultSecretName = "argos/{engine}/{instance}"
+
+// secretsProvider reads a JSON secret from AWS Secrets Manager. Both the RDS
+// managed secret format (username, password, host, port, dbname) and the
+// ~/.my.cnf key names are accepted.
+type secretsProvider struct{}
+
+func (secretsProvider) Name() string { return "secretsmanager" }
+
+func (secretsProvider) Lookup(ctx context.Context, engine, instanceID string) (map[string]string, error) {
+	template := os.Getenv("ARGOS_SECRET_NAME_TEMPLATE")
+	if template == "" {
+		template = DefaultSecretName
+	}
+	name := strings.NewReplacer("{engine}", engine, "{instance}", instanceID).Replace(template)
+
+	value, err := awsclient.GetSecretString(ctx, awsconfig.Target{}, name)
+	if errors.Is(err, awsclient.ErrSecretNotFound) {
+		return nil, fmt.Errorf("%v: %w", err, ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed map[string]any
+	if err := json.Unmarshal([]byte(value), &parsed); err != nil {
+		return nil, fmt.Errorf("secret %s is not a JSON object: %w", name, err)
+	}
+
+	keys := map[string]string{}
+	for k, v := range parsed {
+		k = strings.ToLower(k)
+		switch k {
+		case "username":
+			k = "user"
+		case "dbname":
+			k = "database"
+		}
+
+		switch t := v.(type) {
+		case string:
+			keys[k] = t
+		case float64:
+			keys[k] = strconv.FormatFloat(t, 'f', -1, 64)
+		case bool:
+			keys[k] = strconv.FormatBool(t)
+		}
+	}
+
+	return keys, nil
+}
@@ -1,11 +1,12 @@
 package docdbconfig
 
 import (
+	"context"
 	"fmt"
-	"os"
-	"path/filepath"
 
-	"github.com/nicola-strappazzon/argos/internal/cnf"
+	"github.com/nicola-strappazzon/argos/internal/config/credentials"
+	"github.com/nicola-strappazzon/argos/internal/config/ssh"
+	"github.com/nicola-strappazzon/argos/internal/toolerr"
 )
 
 type Credentials struct {
@@ -15,18 +16,14 @@ type Credentials struct {
 	Password  string
 	TLS       bool
 	TLSCAFile string
+	SSH       *sshconfig.Config
 }
 
-// Load reads credentials for the given section name from ~/.docdb.
+// Load resolves credentials for the given section name through the configured
+// credential providers (by default the ~/.docdb section, then environment variables).
 // The section name is typically the DocumentDB instance identifier.
-func Load(section string) (*Credentials, error) {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return nil, fmt.Errorf("getting home directory: %w", err)
-	}
-
-	path := filepath.Join(home, ".docdb")
-	keys, err := cnf.Load(path, section)
+func Load(ctx context.Context, section string) (*Credentials, error) {
+	keys, err := credentials.Lookup(ctx, credentials.DocDB, section)
 	if err != nil {
 		return nil, err
 	}
@@ -37,6 +34,7 @@ func Load(section string) (*Credentials, error) {
 	creds.User = keys["user"]
 	creds.Password = keys["password"]
 	creds.TLSCAFile = keys["tls_ca_file"]
+	creds.SSH = sshconfig.FromKeys(keys)
 	if v, ok := keys["port"]; ok {
 		fmt.Sscanf(v, "%d", &creds.Port)
 	}
@@ -45,7 +43,8 @@ func Load(section string) (*Credentials, error) {
 	}
 
 	if creds.User == "" {
-		return nil, fmt.Errorf("section [%s] not found in ~/.docdb", section)
+		hint := fmt.Sprintf("set user for [%s] in ~/.docdb or in the configured credential provider", section)
+		return nil, toolerr.Errorf(toolerr.Auth, hint, "no user configured for [%s]", section)
 	}
 
 	return creds, nil
@@ -0,0 +1,98 @@
+package httpconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Config holds the authentication of the HTTP transport.
+type Config struct {
+	// Tokens maps accepted bearer tokens to the name of their holder.
+	Tokens map[string]string
+	// TLS is set when ARGOS_TLS_CERT and ARGOS_TLS_KEY are; it requires
+	// client certificates signed by ARGOS_TLS_CLIENT_CA when that is set.
+	TLS *tls.Config
+}
+
+// Load reads the HTTP transport settings from the environment:
+//
+//	ARGOS_HTTP_TOKENS     comma separated bearer tokens, optionally "name:token";
+//	                      a token cannot contain ':' or ','
+//	ARGOS_TLS_CERT        server certificate (PEM)
+//	ARGOS_TLS_KEY         server private key (PEM)
+//	ARGOS_TLS_CLIENT_CA   CA bundle (PEM) client certificates must chain to
+func Load() (*Config, error) {
+	cfg := &Config{Tokens: map[string]string{}}
+
+	for i, entry := range strings.Split(os.Getenv("ARGOS_HTTP_TOKENS"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, token, ok := strings.Cut(entry, ":")
+		if !ok {
+			name, token = "", entry
+		}
+		name, token = strings.TrimSpace(name), strings.TrimSpace(token)
+		if name == "" {
+			name = fmt.Sprintf("token%d", i+1)
+		}
+		if token == "" {
+			return nil, fmt.Errorf("ARGOS_HTTP_TOKENS: empty token for %q", name)
+		}
+		if strings.Contains(token, ":") {
+			return nil, fmt.Errorf("ARGOS_HTTP_TOKENS: the token for %q contains ':', which separates the name from the token", name)
+		}
+		cfg.Tokens[token] = name
+	}
+
+	cert, key := os.Getenv("ARGOS_TLS_CERT"), os.Getenv("ARGOS_TLS_KEY")
+	clientCA := os.Getenv("ARGOS_TLS_CLIENT_CA")
+
+	if (cert == "") != (key == "") {
+		return nil, fmt.Errorf("ARGOS_TLS_CERT and ARGOS_TLS_KEY must be set together")
+	}
+	if cert == "" {
+		if clientCA != "" {
+			return nil, fmt.Errorf("ARGOS_TLS_CLIENT_CA requires ARGOS_TLS_CERT and ARGOS_TLS_KEY")
+		}
+		return cfg, nil
+	}
+
+	pair, err := tls.LoadX509KeyPair(cert, key)
+	if err != nil {
+		return nil, fmt.Errorf("loading TLS certificate: %w", err)
+	}
+	cfg.TLS = &tls.Config{
+		Certificates: []tls.Certificate{pair},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if clientCA != "" {
+		pem, err := os.ReadFile(clientCA)
+		if err != nil {
+			return nil, fmt.Errorf("reading client CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", clientCA)
+		}
+		cfg.TLS.ClientCAs = pool
+		cfg.TLS.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return cfg, nil
+}
+
+// MutualTLS reports whether clients must present a certificate.
+func (c *Config) MutualTLS() bool {
+	return c.TLS != nil && c.TLS.ClientAuth == tls.RequireAndVerifyClientCert
+}
+
+// Authenticated reports whether any authentication is configured.
+func (c *Config) Authenticated() bool {
+	return len(c.Tokens) > 0 || c.MutualTLS()
+}
@@ -1,65 +1,53 @@
 package mysqlconfig
 
 import (
+	"context"
 	"fmt"
-	"os"
-	"path/filepath"
 
-	"github.com/nicola-strappazzon/argos/internal/cnf"
+	"github.com/nicola-strappazzon/argos/internal/config/credentials"
+	"github.com/nicola-strappazzon/argos/internal/config/ssh"
+	"github.com/nicola-strappazzon/argos/internal/toolerr"
 )
 
-type SSHConfig struct {
-	Host string
-	Port int
-	User string
-	Key  string
-}
-
 type Credentials struct {
-	Host     string
-	Port     int
-	User     string
-	Password string
-	SSH      *SSHConfig
+	Host      string
+	Port      int
+	User      string
+	Password  string
+	Auth      string
+	Region    string
+	TLSCAFile string
+	SSH       *sshconfig.Config
 }
 
-// Load reads credentials for the given section name from ~/.my.cnf.
+// Load resolves credentials for the given section name through the configured
+// credential providers (by default the ~/.my.cnf section, then environment variables).
 // The section name is typically the RDS instance identifier.
-func Load(section string) (*Credentials, error) {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return nil, fmt.Errorf("getting home directory: %w", err)
-	}
-
-	path := filepath.Join(home, ".my.cnf")
-	keys, err := cnf.Load(path, section)
+func Load(ctx context.Context, section string) (*Credentials, error) {
+	keys, err := credentials.Lookup(ctx, credentials.MySQL, section)
 	if err != nil {
 		return nil, err
 	}
 
 	creds := &Credentials{Port: 3306}
-	ssh := &SSHConfig{Port: 22}
 
 	creds.Host = keys["host"]
 	creds.User = keys["user"]
 	creds.Password = keys["password"]
+	creds.Region = keys["region"]
+	creds.TLSCAFile = keys["tls_ca_file"]
 	if v, ok := keys["port"]; ok {
 		fmt.Sscanf(v, "%d", &creds.Port)
 	}
-
-	ssh.Host = keys["ssh_host"]
-	ssh.User = keys["ssh_user"]
-	ssh.Key = keys["ssh_key"]
-	if v, ok := keys["ssh_port"]; ok {
-		fmt.Sscanf(v, "%d", &ssh.Port)
+	if creds.Auth, err = credentials.Auth(keys); err != nil {
+		return nil, fmt.Errorf("section [%s]: %w", section, err)
 	}
 
-	if ssh.Host != "" {
-		creds.SSH = ssh
-	}
+	creds.SSH = sshconfig.FromKeys(keys)
 
 	if creds.User == "" {
-		return nil, fmt.Errorf("section [%s] not found in ~/.my.cnf", section)
+		hint := fmt.Sprintf("set user for [%s] in ~/.my.cnf or in the configured credential provider", section)
+		return nil, toolerr.Errorf(toolerr.Auth, hint, "no user configured for [%s]", section)
 	}
 
 	return creds, nil
@@ -1,11 +1,12 @@
 package psqlconfig
 
 import (
-	"bufio"
+	"context"
 	"fmt"
-	"os"
-	"path/filepath"
-	"strings"
+
+	"github.com/nicola-strappazzon/argos/internal/config/credentials"
+	"github.com/nicola-strappazzon/argos/internal/config/ssh"
+	"github.com/nicola-strappazzon/argos/internal/toolerr"
 )
 
 type Credentials struct {
@@ -14,64 +15,47 @@ type Credentials struct {
 	Database string
 	User     string
 	Password string
+	Auth     string
+	Region   string
+	SSH      *sshconfig.Config
 }
 
-// Load reads credentials for the given instance from ~/.pgpass.
-// It matches lines where the hostname equals the instanceID or starts with instanceID+"."
-// (i.e. the RDS endpoint prefix matches the instance identifier).
-// The ~/.pgpass format is: hostname:port:database:username:password
-func Load(instanceID string) (*Credentials, error) {
-	home, err := os.UserHomeDir()
+// Load resolves credentials for the given instance through the configured
+// credential providers (by default ~/.pgpass, then environment variables).
+// In ~/.pgpass the instance identifier is matched against the hostname
+// (i.e. the RDS endpoint prefix matches the instance identifier), and extra
+// keys such as ssh_host are read from the [instance] section of ~/.pgpass.cnf.
+func Load(ctx context.Context, instanceID string) (*Credentials, error) {
+	keys, err := credentials.Lookup(ctx, credentials.PostgreSQL, instanceID)
 	if err != nil {
-		return nil, fmt.Errorf("getting home directory: %w", err)
+		return nil, err
 	}
 
-	path := filepath.Join(home, ".pgpass")
-	f, err := os.Open(path)
-	if err != nil {
-		return nil, fmt.Errorf("opening ~/.pgpass: %w", err)
+	creds := &Credentials{
+		Host:     keys["host"],
+		Port:     5432,
+		Database: keys["database"],
+		User:     keys["user"],
+		Password: keys["password"],
+		Region:   keys["region"],
+		SSH:      sshconfig.FromKeys(keys),
 	}
-	defer f.Close()
-
-	scanner := bufio.NewScanner(f)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
-		}
-
-		fields := strings.SplitN(line, ":", 5)
-		if len(fields) != 5 {
-			continue
-		}
-
-		hostname := fields[0]
-		if hostname != instanceID && !strings.HasPrefix(hostname, instanceID+".") {
-			continue
-		}
 
-		creds := &Credentials{
-			Host:     hostname,
-			Port:     5432,
-			Database: fields[2],
-			User:     fields[3],
-			Password: fields[4],
-		}
-
-		if fields[1] != "*" {
-			fmt.Sscanf(fields[1], "%d", &creds.Port)
-		}
-
-		if creds.Database == "*" {
-			creds.Database = "postgres"
-		}
+	if v, ok := keys["port"]; ok {
+		fmt.Sscanf(v, "%d", &creds.Port)
+	}
+	if creds.Auth, err = credentials.Auth(keys); err != nil {
+		return nil, fmt.Errorf("[%s]: %w", instanceID, err)
+	}
 
-		return creds, nil
+	if creds.Database == "" || creds.Database == "*" {
+		creds.Database = "postgres"
 	}
 
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("reading ~/.pgpass: %w", err)
+	if creds.User == "" {
+		hint := fmt.Sprintf("set user for [%s] in ~/.pgpass or in the configured credential provider", instanceID)
+		return nil, toolerr.Errorf(toolerr.Auth, hint, "no user configured for [%s]", instanceID)
 	}
 
-	return nil, fmt.Errorf("no entry for [%s] found in ~/.pgpass", instanceID)
+	return creds, nil
 }
@@ -0,0 +1,88 @@
+package sshconfig
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const defaultKeepAlive = 30 * time.Second
+
+// Config describes the SSH bastion used to reach a database instance.
+type Config struct {
+	Host          string
+	Port          int
+	User          string
+	Key           string
+	KeyPassphrase string
+	// KnownHosts is the known_hosts file used to verify host keys.
+	// Empty means ~/.ssh/known_hosts.
+	KnownHosts string
+	// StrictHostKeyChecking rejects hosts whose key is unknown or changed.
+	StrictHostKeyChecking bool
+	// ProxyJump lists the [user@]host[:port] hops to go through before
+	// reaching Host, in order, like OpenSSH's ProxyJump.
+	ProxyJump []string
+	// KeepAlive is the interval between keepalive requests. Zero disables them.
+	KeepAlive time.Duration
+}
+
+// FromKeys reads the ssh_* keys shared by every engine's credentials.
+// Returns nil when ssh_host is not set.
+func FromKeys(keys map[string]string) *Config {
+	cfg := &Config{
+		Port:                  22,
+		StrictHostKeyChecking: true,
+		KeepAlive:             defaultKeepAlive,
+	}
+
+	cfg.Host = keys["ssh_host"]
+	cfg.User = keys["ssh_user"]
+	cfg.Key = keys["ssh_key"]
+	cfg.KeyPassphrase = keys["ssh_key_passphrase"]
+	cfg.KnownHosts = keys["ssh_known_hosts"]
+	if v, ok := keys["ssh_port"]; ok {
+		fmt.Sscanf(v, "%d", &cfg.Port)
+	}
+	if v := keys["ssh_strict_host_key_checking"]; v == "false" || v == "0" || v == "no" {
+		cfg.StrictHostKeyChecking = false
+	}
+	for _, hop := range strings.Split(keys["ssh_proxy_jump"], ",") {
+		if hop = strings.TrimSpace(hop); hop != "" {
+			cfg.ProxyJump = append(cfg.ProxyJump, hop)
+		}
+	}
+	if v, ok := keys["ssh_keepalive"]; ok {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.KeepAlive = d
+		}
+	}
+
+	if cfg.Host == "" {
+		return nil
+	}
+
+	return cfg
+}
+
+// Addr returns the host:port address of the bastion.
+func (c *Config) Addr() string {
+	return fmt.Sprintf("%s:%d", c.Host, c.Port)
+}
+
+// ID identifies the SSH route: instances with the same ID share a tunnel.
+func (c *Config) ID() string {
+	return strings.Join(append(append([]string{}, c.ProxyJump...), c.User+"@"+c.Addr()), ",")
+}
+
+// Hop parses a ProxyJump entry, defaulting to the bastion's user and port 22.
+func (c *Config) Hop(hop string) (user, addr string) {
+	user = c.User
+	if u, h, ok := strings.Cut(hop, "@"); ok {
+		user, hop = u, h
+	}
+	if !strings.Contains(hop, ":") {
+		hop += ":22"
+	}
+	return user, hop
+}
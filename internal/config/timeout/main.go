@@ -0,0 +1,56 @@
+package timeoutconfig
+
+import (
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// Default is the deadline of a tool call when neither the environment
+	// nor the tool sets one.
+	Default = 30 * time.Second
+	// DefaultLock is the PostgreSQL lock_timeout when ARGOS_LOCK_TIMEOUT is not set.
+	DefaultLock = 5 * time.Second
+)
+
+// For returns the deadline of a tool call, from the first of:
+// ARGOS_TIMEOUT_<TOOL> (e.g. ARGOS_TIMEOUT_MYSQL_EXPLAIN), ARGOS_TIMEOUT,
+// the tool's own default and Default. Values are Go durations such as "90s".
+func For(tool string, toolDefault time.Duration) time.Duration {
+	if d, ok := lookup(EnvName(tool)); ok {
+		return d
+	}
+	if d, ok := lookup("ARGOS_TIMEOUT"); ok {
+		return d
+	}
+	if toolDefault > 0 {
+		return toolDefault
+	}
+	return Default
+}
+
+// EnvName returns the environment variable that overrides the tool's deadline.
+func EnvName(tool string) string {
+	return "ARGOS_TIMEOUT_" + strings.ToUpper(tool)
+}
+
+// Lock returns how long a PostgreSQL statement waits for a lock, from ARGOS_LOCK_TIMEOUT.
+func Lock() time.Duration {
+	if d, ok := lookup("ARGOS_LOCK_TIMEOUT"); ok {
+		return d
+	}
+	return DefaultLock
+}
+
+func lookup(name string) (time.Duration, bool) {
+	v := os.Getenv(name)
+	if v == "" {
+		return 0, false
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		return 0, false
+	}
+	return d, true
+}
@@ -0,0 +1,119 @@
+package toolsconfig
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/nicola-strappazzon/argos/internal/cnf"
+)
+
+// Section is the section of the config file that selects the tools.
+const Section = "tools"
+
+// Locked is a comma separated list of patterns that are always disabled,
+// whatever the config file or the flags say. It is meant to be set at build
+// time to ship a locked-down binary:
+//
+//	go build -ldflags "-X github.com/nicola-strappazzon/argos/internal/config/tools.Locked=aws_secrets_get"
+var Locked string
+
+// Filter selects the tools exposed to clients. A pattern is a group name
+// (artifacts, aws, mysql, postgresql, percona), a tool name, or a glob on
+// the tool name such as "mysql_table_*".
+type Filter struct {
+	// Enable lists the tools to expose; empty means all of them.
+	Enable []string
+	// Disable lists the tools to hide, even if enabled.
+	Disable []string
+}
+
+// DefaultPath returns ~/.argos.cnf.
+func DefaultPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("getting home directory: %w", err)
+	}
+	return filepath.Join(home, ".argos.cnf"), nil
+}
+
+// Load reads the enable and disable keys of the [tools] section of the
+// config file. A missing file is not an error unless required is set, i.e.
+// the path was given explicitly.
+func Load(path string, required bool) (*Filter, error) {
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) && !required {
+		return &Filter{}, nil
+	}
+
+	keys, err := cnf.Load(path, Section)
+	if err != nil {
+		if !required && errors.Is(err, cnf.ErrSectionNotFound) {
+			return &Filter{}, nil
+		}
+		return nil, err
+	}
+
+	return &Filter{
+		Enable:  Split(keys["enable"]),
+		Disable: Split(keys["disable"]),
+	}, nil
+}
+
+// Split parses a comma separated list of patterns.
+func Split(list string) []string {
+	patterns := []string{}
+	for _, p := range strings.Split(list, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			patterns = append(patterns, p)
+		}
+	}
+	return patterns
+}
+
+// Allowed reports whether the tool is exposed: it must match an Enable
+// pattern (if any), and no Disable or Locked pattern.
+func (f *Filter) Allowed(name, group string) bool {
+	if matchAny(Split(Locked), name, group) || matchAny(f.Disable, name, group) {
+		return false
+	}
+	return len(f.Enable) == 0 || matchAny(f.Enable, name, group)
+}
+
+// Unused returns the patterns that match none of the given tools, which
+// usually are typos. tools maps tool names to their group.
+func (f *Filter) Unused(tools map[string]string) []string {
+	unused := []string{}
+	for _, p := range append(append([]string{}, f.Enable...), f.Disable...) {
+		used := false
+		for name, group := range tools {
+			if match(p, name, group) {
+				used = true
+				break
+			}
+		}
+		if !used {
+			unused = append(unused, p)
+		}
+	}
+	return unused
+}
+
+func matchAny(patterns []string, name, group string) bool {
+	for _, p := range patterns {
+		if match(p, name, group) {
+			return true
+		}
+	}
+	return false
+}
+
+func match(pattern, name, group string) bool {
+	if pattern == group || pattern == name {
+		return true
+	}
+	ok, err := path.Match(pattern, name)
+	return err == nil && ok
+}
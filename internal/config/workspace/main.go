@@ -0,0 +1,48 @@
+package workspaceconfig
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+const (
+	// DefaultRetention is how long artifacts are kept when
+	// ARGOS_WORKSPACE_RETENTION is not set.
+	DefaultRetention = 7 * 24 * time.Hour
+	// DefaultMaxSizeMB caps the workspace when ARGOS_WORKSPACE_MAX_SIZE_MB is
+	// not set.
+	DefaultMaxSizeMB = 10240
+)
+
+// Root returns the directory artifacts are written to: ARGOS_WORKSPACE, or
+// "argos" in the user cache directory (~/.cache/argos on Linux), or in the
+// temporary directory when there is no cache directory.
+func Root() string {
+	if dir := os.Getenv("ARGOS_WORKSPACE"); dir != "" {
+		return dir
+	}
+	if dir, err := os.UserCacheDir(); err == nil {
+		return filepath.Join(dir, "argos")
+	}
+	return filepath.Join(os.TempDir(), "argos")
+}
+
+// Retention returns how long an artifact is kept after its last write, from
+// ARGOS_WORKSPACE_RETENTION (a Go duration such as "72h").
+func Retention() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("ARGOS_WORKSPACE_RETENTION")); err == nil && d > 0 {
+		return d
+	}
+	return DefaultRetention
+}
+
+// MaxBytes returns the size the workspace is pruned down to, from
+// ARGOS_WORKSPACE_MAX_SIZE_MB.
+func MaxBytes() int64 {
+	if n, err := strconv.ParseInt(os.Getenv("ARGOS_WORKSPACE_MAX_SIZE_MB"), 10, 64); err == nil && n > 0 {
+		return n << 20
+	}
+	return DefaultMaxSizeMB << 20
+}
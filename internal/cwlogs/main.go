@@ -0,0 +1,103 @@
+// Package cwlogs reads the logs an RDS instance publishes to CloudWatch
+// Logs, for instances that export them instead of, or as well as, keeping
+// them on the instance.
+package cwlogs
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/nicola-strappazzon/argos/internal/awsclient"
+	"github.com/nicola-strappazzon/argos/internal/toolerr"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// Types are the logs an RDS instance can export: slowquery, error and
+// general for MySQL and MariaDB, postgresql for PostgreSQL.
+func Types() []string {
+	return []string{"slowquery", "error", "general", "postgresql"}
+}
+
+// Group returns the log group an instance exports a log type to.
+func Group(instanceID, logType string) string {
+	return "/aws/rds/instance/" + instanceID + "/" + logType
+}
+
+type Event struct {
+	Time    time.Time `json:"time"`
+	Stream  string    `json:"log_stream"`
+	Message string    `json:"message"`
+}
+
+// Query selects the events of a log group.
+type Query struct {
+	Group string
+	Start time.Time
+	End   time.Time
+	// Pattern is a CloudWatch Logs filter pattern, e.g. "ERROR" or
+	// "\"Query_time\"". Empty matches every event.
+	Pattern string
+	// Limit stops after that many events; zero reads them all.
+	Limit int
+}
+
+// Filter calls fn with the events of q in the order CloudWatch Logs returns
+// them, and reports whether Limit stopped it before the last page.
+func Filter(ctx context.Context, svc awsclient.CloudWatchLogsAPI, q Query, fn func(Event) error) (bool, error) {
+	in := &cloudwatchlogs.FilterLogEventsInput{
+		LogGroupName: aws.String(q.Group),
+		StartTime:    aws.Int64(q.Start.UnixMilli()),
+		EndTime:      aws.Int64(q.End.UnixMilli()),
+	}
+	if q.Pattern != "" {
+		in.FilterPattern = aws.String(q.Pattern)
+	}
+
+	n := 0
+	for {
+		if q.Limit > 0 {
+			in.Limit = aws.Int32(int32(min(q.Limit-n, 10000)))
+		}
+
+		out, err := svc.FilterLogEvents(ctx, in)
+		if err != nil {
+			return false, notFound(err, q.Group)
+		}
+
+		for _, e := range out.Events {
+			if q.Limit > 0 && n == q.Limit {
+				return true, nil
+			}
+			if err := fn(Event{
+				Time:    time.UnixMilli(aws.ToInt64(e.Timestamp)).UTC(),
+				Stream:  aws.ToString(e.LogStreamName),
+				Message: aws.ToString(e.Message),
+			}); err != nil {
+				return false, err
+			}
+			n++
+		}
+
+		if out.NextToken == nil {
+			return false, nil
+		}
+		if q.Limit > 0 && n == q.Limit {
+			return true, nil
+		}
+		in.NextToken = out.NextToken
+	}
+}
+
+// notFound explains a missing log group: the log is not exported.
+func notFound(err error, group string) error {
+	var nerr *types.ResourceNotFoundException
+	if errors.As(err, &nerr) {
+		return toolerr.Errorf(toolerr.NotFound, "enable the export of this log to CloudWatch Logs in the instance settings, or use aws_rds_logs",
+			"log group %s not found", group)
+	}
+	return err
+}
@@ -0,0 +1,210 @@
+// Package digest holds what the log digests of slowlog and pglog share: the
+// filter, the statistics of a metric and the classes of queries by
+// fingerprint, with their ranking.
+package digest
+
+import (
+	"context"
+	"io"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/nicola-strappazzon/argos/internal/display"
+)
+
+// Filter selects the entries to digest. Zero values select everything.
+type Filter struct {
+	Since    time.Time
+	Until    time.Time
+	Database string
+}
+
+// InRange reports whether t is within Since and Until.
+func (f Filter) InRange(t time.Time) bool {
+	if !f.Since.IsZero() && t.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && t.After(f.Until) {
+		return false
+	}
+	return true
+}
+
+// Stats summarizes a metric over the queries of a class.
+type Stats struct {
+	Total float64 `json:"total"`
+	Avg   float64 `json:"avg"`
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	P95   float64 `json:"p95"`
+}
+
+// Summarize computes the summary of values rounded to decimals; P95 is the
+// nearest-rank percentile.
+func Summarize(values []float64, decimals int) Stats {
+	if len(values) == 0 {
+		return Stats{}
+	}
+
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+
+	var total float64
+	for _, v := range sorted {
+		total += v
+	}
+	rank := int(math.Ceil(0.95*float64(len(sorted)))) - 1
+
+	return Stats{
+		Total: display.Round(total, decimals),
+		Avg:   display.Round(total/float64(len(sorted)), decimals),
+		Min:   display.Round(sorted[0], decimals),
+		Max:   display.Round(sorted[len(sorted)-1], decimals),
+		P95:   display.Round(sorted[rank], decimals),
+	}
+}
+
+// Format returns t in RFC 3339, or "" when zero.
+func Format(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// Class is the part of a class of queries sharing a fingerprint that both
+// digests have, embedded in their own Class with the metrics they track.
+type Class struct {
+	Rank        int    `json:"rank"`
+	Fingerprint string `json:"fingerprint"`
+	Count       int    `json:"count"`
+	// LoadPct is the share of the total time of the log.
+	LoadPct   float64  `json:"load_pct"`
+	Databases []string `json:"databases"`
+	Users     []string `json:"users"`
+	FirstSeen string   `json:"first_seen"`
+	LastSeen  string   `json:"last_seen"`
+	// Sample is the slowest query of the class.
+	Sample         string `json:"sample"`
+	SampleDatabase string `json:"sample_database,omitempty"`
+
+	sampleTime  float64
+	first, last time.Time
+}
+
+// Add counts a query that took the given time.
+func (c *Class) Add(t time.Time, database, user, query string, took float64) {
+	if c.Count == 0 {
+		c.first = t
+	}
+	c.Count++
+
+	if database != "" && !slices.Contains(c.Databases, database) {
+		c.Databases = append(c.Databases, database)
+	}
+	if user != "" && !slices.Contains(c.Users, user) {
+		c.Users = append(c.Users, user)
+	}
+	if t.Before(c.first) {
+		c.first = t
+	}
+	if t.After(c.last) {
+		c.last = t
+	}
+	if c.Sample == "" || took > c.sampleTime {
+		c.Sample = query
+		c.SampleDatabase = database
+		c.sampleTime = took
+	}
+}
+
+// Longest returns the time the sample took, the longest of the class.
+func (c *Class) Longest() float64 {
+	return c.sampleTime
+}
+
+// Finish fills in the share of the class in the total time of the log, and
+// its first and last seen times.
+func (c *Class) Finish(sum, total float64, decimals int) {
+	if total > 0 {
+		c.LoadPct = display.Round(sum/total*100, decimals)
+	}
+	c.FirstSeen = Format(c.first)
+	c.LastSeen = Format(c.last)
+}
+
+func (c *Class) class() *Class {
+	return c
+}
+
+// Ranked is implemented by the classes of the digests, through the Class
+// they embed.
+type Ranked interface {
+	class() *Class
+}
+
+// Order names the metrics classes are ranked by.
+const (
+	ByTotalTime = "total_time"
+	ByAvgTime   = "avg_time"
+	ByCount     = "count"
+)
+
+// Orders maps the names of the orders of a digest to the metric of a class
+// they rank by.
+type Orders[C Ranked] map[string]func(C) float64
+
+// Rank returns the classes ranked by order (total_time when unknown),
+// largest first and by fingerprint on ties, at most limit of them when
+// limit is positive.
+func (o Orders[C]) Rank(all []C, order string, limit int) []C {
+	key, ok := o[order]
+	if !ok {
+		key = o[ByTotalTime]
+	}
+
+	slices.SortFunc(all, func(a, b C) int {
+		if ka, kb := key(a), key(b); ka != kb {
+			if ka > kb {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a.class().Fingerprint, b.class().Fingerprint)
+	})
+
+	all = Top(all, limit)
+	for i, c := range all {
+		c.class().Rank = i + 1
+	}
+	return all
+}
+
+// Top returns the first limit elements of s, all of them when limit is not
+// positive.
+func Top[T any](s []T, limit int) []T {
+	if limit > 0 && len(s) > limit {
+		return s[:limit]
+	}
+	return s
+}
+
+// NewReader returns a reader of r that stops reading a large log when ctx
+// is cancelled.
+func NewReader(ctx context.Context, r io.Reader) io.Reader {
+	return contextReader{ctx, r}
+}
+
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
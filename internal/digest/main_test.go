@@ -0,0 +1,70 @@
+package digest_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nicola-strappazzon/argos/internal/digest"
+)
+
+type class struct {
+	digest.Class
+	Took float64 `json:"took"`
+}
+
+func TestSummarize(t *testing.T) {
+	got := digest.Summarize([]float64{0.5, 2.5, 1.5, 1.0000004}, 6)
+	want := digest.Stats{Total: 5.5, Avg: 1.375, Min: 0.5, Max: 2.5, P95: 2.5}
+	if got != want {
+		t.Errorf("Summarize = %+v, want %+v", got, want)
+	}
+	if got := digest.Summarize(nil, 3); got != (digest.Stats{}) {
+		t.Errorf("expected no stats without values, got %+v", got)
+	}
+}
+
+func TestClassRank(t *testing.T) {
+	at := time.Date(2024, 5, 14, 9, 0, 0, 0, time.UTC)
+	a := &class{Class: digest.Class{Fingerprint: "select a"}}
+	a.Add(at.Add(time.Minute), "shop", "app", "SELECT a FROM t WHERE id = 1", 1)
+	a.Add(at, "shop", "report", "SELECT a FROM t WHERE id = 2", 3)
+	b := &class{Class: digest.Class{Fingerprint: "select b"}}
+	b.Add(at, "crm", "app", "SELECT b", 4)
+
+	a.Finish(4, 8, 3)
+	if a.Count != 2 || a.Sample != "SELECT a FROM t WHERE id = 2" || a.Longest() != 3 || a.LoadPct != 50 ||
+		a.FirstSeen != "2024-05-14T09:00:00Z" || a.LastSeen != "2024-05-14T09:01:00Z" || len(a.Users) != 2 {
+		t.Errorf("unexpected class: %+v", a.Class)
+	}
+
+	orders := digest.Orders[*class]{
+		digest.ByTotalTime: func(c *class) float64 { return 4 },
+		digest.ByCount:     func(c *class) float64 { return float64(c.Count) },
+	}
+	if top := orders.Rank([]*class{b, a}, "unknown", 0); top[0] != a || a.Rank != 1 || b.Rank != 2 {
+		t.Errorf("expected ties ranked by fingerprint, got %s first", top[0].Fingerprint)
+	}
+	if top := orders.Rank([]*class{b, a}, digest.ByCount, 1); len(top) != 1 || top[0] != a {
+		t.Errorf("expected the most frequent class only, got %d classes", len(top))
+	}
+
+	data, _ := json.Marshal(a)
+	if !strings.Contains(string(data), `"fingerprint":"select a"`) || !strings.Contains(string(data), `"took":0`) {
+		t.Errorf("expected the embedded fields to be flattened, got %s", data)
+	}
+}
+
+func TestNewReader(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := digest.NewReader(ctx, strings.NewReader("line\n"))
+
+	cancel()
+	if _, err := io.ReadAll(r); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the read to stop once cancelled, got %v", err)
+	}
+}
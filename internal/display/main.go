@@ -0,0 +1,26 @@
+// Package display shapes the values the tools return for reading: text cut
+// to a size and numbers rounded to a few decimals.
+package display
+
+import (
+	"math"
+	"unicode/utf8"
+)
+
+// Cut cuts s to at most max bytes, before the rune max falls in rather than
+// through it.
+func Cut(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	for max > 0 && !utf8.RuneStart(s[max]) {
+		max--
+	}
+	return s[:max]
+}
+
+// Round rounds f to decimals.
+func Round(f float64, decimals int) float64 {
+	scale := math.Pow10(decimals)
+	return math.Round(f*scale) / scale
+}
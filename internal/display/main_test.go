@@ -0,0 +1,34 @@
+package display_test
+
+import (
+	"testing"
+
+	"github.com/nicola-strappazzon/argos/internal/display"
+)
+
+func TestCut(t *testing.T) {
+	for _, tc := range []struct {
+		s    string
+		max  int
+		want string
+	}{
+		{"SELECT 1", 20, "SELECT 1"},
+		{"SELECT 1", 6, "SELECT"},
+		{"SELECT 'añejo'", 10, "SELECT 'a"},
+		{"SELECT 'añejo'", 11, "SELECT 'añ"},
+		{"日本", 2, ""},
+	} {
+		if got := display.Cut(tc.s, tc.max); got != tc.want {
+			t.Errorf("Cut(%q, %d) = %q, want %q", tc.s, tc.max, got, tc.want)
+		}
+	}
+}
+
+func TestRound(t *testing.T) {
+	if got := display.Round(2.34567, 3); got != 2.346 {
+		t.Errorf("unexpected round to 3 decimals: %v", got)
+	}
+	if got := display.Round(12.5, 0); got != 13 {
+		t.Errorf("unexpected round to 0 decimals: %v", got)
+	}
+}
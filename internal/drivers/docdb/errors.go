@@ -0,0 +1,37 @@
+package docdb
+
+import (
+	"errors"
+
+	"github.com/nicola-strappazzon/argos/internal/toolerr"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+func init() {
+	toolerr.Register(classify)
+}
+
+// classify maps DocumentDB command error codes to tool error kinds.
+func classify(err error) (toolerr.Kind, string, bool) {
+	if mongo.IsTimeout(err) {
+		return toolerr.Timeout, "retry with a narrower request", true
+	}
+
+	var cmdErr mongo.CommandError
+	if !errors.As(err, &cmdErr) {
+		return "", "", false
+	}
+
+	switch cmdErr.Code {
+	case 18: // AuthenticationFailed
+		return toolerr.Auth, "check the user and password configured for the instance in ~/.docdb or the credential provider", true
+	case 13: // Unauthorized
+		return toolerr.PermissionDenied, "grant the missing role to the database user", true
+	case 26: // NamespaceNotFound
+		return toolerr.NotFound, "list the databases with aws_docdb_databases and the collections with aws_docdb_collections", true
+	case 50: // MaxTimeMSExpired
+		return toolerr.Timeout, "retry with a narrower request", true
+	}
+
+	return "", "", false
+}
@@ -1,20 +1,43 @@
 package docdb
 
 import (
+	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
 	"os"
 
 	docdbconfig "github.com/nicola-strappazzon/argos/internal/config/docdb"
+	"github.com/nicola-strappazzon/argos/internal/drivers/pool"
+	"github.com/nicola-strappazzon/argos/internal/drivers/tunnel"
 	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
 
-// Connect opens a MongoDB/DocumentDB connection for the given instance identifier.
-// Credentials are read from ~/.docdb using the instance ID as the section name.
-func Connect(instanceID string) (*mongo.Client, error) {
-	creds, err := docdbconfig.Load(instanceID)
+var clients = pool.New("docdb", open,
+	func(ctx context.Context, client *mongo.Client) error { return client.Ping(ctx, nil) },
+	func(client *mongo.Client) error { return client.Disconnect(context.Background()) },
+)
+
+// Connect returns the shared MongoDB/DocumentDB client for the given instance identifier,
+// opening it on first use. The client is cached across tool calls and held until ctx is done:
+// callers must not disconnect it.
+func Connect(ctx context.Context, instanceID string) (*mongo.Client, error) {
+	return clients.Get(ctx, instanceID)
+}
+
+// Use makes Connect return client for the instance until restore is called.
+// It lets tests run the tools against a stand-in server.
+func Use(instanceID string, client *mongo.Client) (restore func()) {
+	return clients.Use(instanceID, client)
+}
+
+// open opens a MongoDB/DocumentDB connection for the given instance identifier.
+// Credentials are resolved by docdbconfig.Load using the instance ID as the section name.
+// If ssh_host is present in the config, the connection is routed through an SSH tunnel.
+// The client connects lazily, so it is pinged within ctx before being cached.
+func open(ctx context.Context, instanceID string) (*mongo.Client, error) {
+	creds, err := docdbconfig.Load(ctx, instanceID)
 	if err != nil {
 		return nil, err
 	}
@@ -22,7 +45,17 @@ func Connect(instanceID string) (*mongo.Client, error) {
 	uri := fmt.Sprintf("mongodb://%s:%s@%s:%d/?directConnection=true",
 		creds.User, creds.Password, creds.Host, creds.Port)
 
-	clientOpts := options.Client().ApplyURI(uri)
+	clientOpts := options.Client().ApplyURI(uri).
+		SetMaxPoolSize(uint64(pool.MaxOpen())).
+		SetMaxConnIdleTime(pool.IdleTimeout())
+
+	if creds.SSH != nil {
+		dialer, err := tunnel.New(creds.SSH)
+		if err != nil {
+			return nil, err
+		}
+		clientOpts.SetDialer(dialer)
+	}
 
 	if creds.TLS {
 		tlsCfg := &tls.Config{}
@@ -47,5 +80,10 @@ func Connect(instanceID string) (*mongo.Client, error) {
 		return nil, fmt.Errorf("connecting to %s: %w", instanceID, err)
 	}
 
+	if err := client.Ping(ctx, nil); err != nil {
+		client.Disconnect(context.Background())
+		return nil, fmt.Errorf("connecting to %s: %w", instanceID, err)
+	}
+
 	return client, nil
 }
@@ -0,0 +1,41 @@
+package mysql
+
+import (
+	"errors"
+
+	gomysql "github.com/go-sql-driver/mysql"
+	"github.com/nicola-strappazzon/argos/internal/toolerr"
+)
+
+func init() {
+	toolerr.Register(classify)
+}
+
+// classify maps MySQL server error numbers to tool error kinds.
+func classify(err error) (toolerr.Kind, string, bool) {
+	var myErr *gomysql.MySQLError
+	if !errors.As(err, &myErr) {
+		return "", "", false
+	}
+
+	switch myErr.Number {
+	case 1045: // ER_ACCESS_DENIED_ERROR
+		return toolerr.Auth, "check the user and password configured for the instance in ~/.my.cnf or the credential provider; with auth=iam, check the rds-db:connect IAM permission", true
+	case 1044, 1142, 1143, 1227, 1370: // database, table, column, operation and routine access denied
+		return toolerr.PermissionDenied, "grant the missing privilege to the database user", true
+	case 1049: // ER_BAD_DB_ERROR
+		return toolerr.NotFound, "list the databases with mysql_databases", true
+	case 1146: // ER_NO_SUCH_TABLE
+		return toolerr.NotFound, "list the tables with mysql_tables", true
+	case 1054: // ER_BAD_FIELD_ERROR
+		return toolerr.NotFound, "list the columns with mysql_describe_table", true
+	case 1064: // ER_PARSE_ERROR
+		return toolerr.InvalidInput, "fix the SQL syntax", true
+	case 1792: // ER_CANT_EXECUTE_IN_READ_ONLY_TRANSACTION
+		return toolerr.InvalidInput, "only read-only statements are allowed", true
+	case 3024, 1205: // max_execution_time exceeded, lock wait timeout
+		return toolerr.Timeout, "retry with a narrower query", true
+	}
+
+	return "", "", false
+}
@@ -1,42 +1,139 @@
 package mysql
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"database/sql"
+	"database/sql/driver"
+	"errors"
 	"fmt"
+	"os"
+	"time"
 
-	_ "github.com/go-sql-driver/mysql"
+	gomysql "github.com/go-sql-driver/mysql"
+	awsconfig "github.com/nicola-strappazzon/argos/internal/config/aws"
+	"github.com/nicola-strappazzon/argos/internal/config/credentials"
 	mysqlconfig "github.com/nicola-strappazzon/argos/internal/config/mysql"
+	"github.com/nicola-strappazzon/argos/internal/drivers/pool"
+	"github.com/nicola-strappazzon/argos/internal/drivers/session"
 )
 
-// Connect opens a MySQL connection for the given RDS instance identifier.
-// Credentials are read from ~/.my.cnf using the instance ID as the section name.
+var dbs = pool.New("mysql", open,
+	func(ctx context.Context, db *sql.DB) error { return db.PingContext(ctx) },
+	func(db *sql.DB) error { return db.Close() },
+)
+
+// Connect returns the shared MySQL connection pool for the given RDS instance identifier,
+// opening it on first use. The handle is cached across tool calls and held until ctx is done:
+// callers must not close it.
+func Connect(ctx context.Context, instanceID string) (*sql.DB, error) {
+	return dbs.Get(ctx, instanceID)
+}
+
+// Use makes Connect return db for the instance until restore is called. It
+// lets tests run the tools against a stand-in database.
+func Use(instanceID string, db *sql.DB) (restore func()) {
+	return dbs.Use(instanceID, db)
+}
+
+// open opens a MySQL connection for the given RDS instance identifier.
+// Credentials are resolved by mysqlconfig.Load using the instance ID as the section name.
 // If ssh_host is present in the config, the connection is routed through an SSH tunnel.
-func Connect(instanceID string) (*sql.DB, error) {
-	creds, err := mysqlconfig.Load(instanceID)
+// With auth=iam every new pooled connection authenticates with an RDS IAM auth token.
+// Every session is set to SESSION TRANSACTION READ ONLY, and queries are
+// bounded server-side by max_execution_time according to the context deadline.
+// The first connection is opened within ctx.
+func open(ctx context.Context, instanceID string) (*sql.DB, error) {
+	creds, err := mysqlconfig.Load(ctx, instanceID)
 	if err != nil {
 		return nil, err
 	}
 
-	var dsn string
+	cfg := gomysql.NewConfig()
+	cfg.User = creds.User
+	cfg.Passwd = creds.Password
+	cfg.Net = "tcp"
+	cfg.Addr = fmt.Sprintf("%s:%d", creds.Host, creds.Port)
+
 	if creds.SSH != nil {
 		if err := ensureSSHTunnel(instanceID, creds); err != nil {
 			return nil, err
 		}
-		dialerName := "mysql+ssh+" + instanceID
-		dsn = fmt.Sprintf("%s:%s@%s(%s:%d)/", creds.User, creds.Password, dialerName, creds.Host, creds.Port)
-	} else {
-		dsn = fmt.Sprintf("%s:%s@tcp(%s:%d)/", creds.User, creds.Password, creds.Host, creds.Port)
+		cfg.Net = "mysql+ssh+" + instanceID
+	}
+
+	if creds.Auth == credentials.AuthIAM {
+		if err := applyIAMAuth(cfg, creds); err != nil {
+			return nil, err
+		}
 	}
 
-	db, err := sql.Open("mysql", dsn)
+	connector, err := gomysql.NewConnector(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("opening mysql connection: %w", err)
 	}
 
-	if err := db.Ping(); err != nil {
+	db := sql.OpenDB(session.NewConnector(connector, hooks))
+	db.SetMaxOpenConns(pool.MaxOpen())
+	db.SetMaxIdleConns(pool.MaxOpen())
+	db.SetConnMaxIdleTime(pool.IdleTimeout())
+
+	if err := db.PingContext(ctx); err != nil {
 		db.Close()
 		return nil, fmt.Errorf("connecting to %s: %w", instanceID, err)
 	}
 
 	return db, nil
 }
+
+// hooks make every new session read-only, so that even a query that slips
+// past sqlguard cannot modify data, and bound SELECTs with max_execution_time.
+var hooks = session.Hooks{
+	Init: func(ctx context.Context, conn driver.ExecerContext) error {
+		if _, err := conn.ExecContext(ctx, "SET SESSION TRANSACTION READ ONLY", nil); err != nil {
+			return fmt.Errorf("setting session read only: %w", err)
+		}
+		return nil
+	},
+	Limit: func(ctx context.Context, conn driver.ExecerContext, d time.Duration) error {
+		_, err := conn.ExecContext(ctx, fmt.Sprintf("SET SESSION max_execution_time = %d", d.Milliseconds()), nil)
+		return err
+	},
+	IsTimeout: func(err error) bool {
+		var myErr *gomysql.MySQLError
+		// 3024: maximum statement execution time exceeded, 1205: lock wait timeout.
+		return errors.As(err, &myErr) && (myErr.Number == 3024 || myErr.Number == 1205)
+	},
+}
+
+// applyIAMAuth enables TLS and the cleartext auth plugin, both required by
+// RDS IAM authentication, and sets a fresh token before every new connection.
+func applyIAMAuth(cfg *gomysql.Config, creds *mysqlconfig.Credentials) error {
+	tlsCfg := &tls.Config{ServerName: creds.Host}
+	if creds.TLSCAFile != "" {
+		caCert, err := os.ReadFile(creds.TLSCAFile)
+		if err != nil {
+			return fmt.Errorf("reading TLS CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caCert) {
+			return fmt.Errorf("parsing TLS CA certificate from %s", creds.TLSCAFile)
+		}
+		tlsCfg.RootCAs = pool
+	}
+
+	cfg.TLS = tlsCfg
+	cfg.AllowCleartextPasswords = true
+
+	tokens := awsconfig.NewAuthTokenSource(creds.Host, creds.Port, creds.User, creds.Region)
+
+	return cfg.Apply(gomysql.BeforeConnect(func(ctx context.Context, c *gomysql.Config) error {
+		token, err := tokens.Token(ctx)
+		if err != nil {
+			return err
+		}
+		c.Passwd = token
+		return nil
+	}))
+}
@@ -0,0 +1,9 @@
+package pool
+
+import "time"
+
+// SetHealthInterval changes how long a handle goes unchecked.
+func (p *Pool[T]) SetHealthInterval(d time.Duration) { p.health = d }
+
+// EvictIdle runs one pass of the janitor.
+func (p *Pool[T]) EvictIdle(idle time.Duration) { p.evictIdle(idle) }
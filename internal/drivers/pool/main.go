@@ -0,0 +1,299 @@
+package pool
+
+import (
+	"context"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultIdleTimeout = 10 * time.Minute
+	defaultMaxOpen     = 5
+	healthInterval     = 30 * time.Second
+	pingTimeout        = 5 * time.Second
+	janitorInterval    = time.Minute
+)
+
+var (
+	registryMu sync.Mutex
+	pools      []interface{ CloseAll() }
+)
+
+// Pool caches one connection handle (*sql.DB, *mongo.Client, ...) per key,
+// typically the instance identifier. A handle is held by the callers of Get
+// until their context is done: it is health checked before being handed out
+// when nobody holds it and it has not been checked recently, and closed once
+// nobody has held it for IdleTimeout(). A handle is dialed and checked with
+// the context of the caller that needs it, while the others wait for it as
+// long as their own context allows.
+type Pool[T any] struct {
+	name   string
+	dial   func(ctx context.Context, key string) (T, error)
+	ping   func(ctx context.Context, conn T) error
+	close  func(conn T) error
+	health time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*entry[T]
+	once    sync.Once
+}
+
+type entry[T any] struct {
+	mu       sync.Mutex
+	conn     T
+	open     bool
+	evicted  bool
+	refs     int
+	lastUsed time.Time
+	checked  time.Time
+	// dialing is closed when the caller dialing or checking the handle is
+	// done, meanwhile the entry does not own it.
+	dialing chan struct{}
+}
+
+// New returns a pool and registers it so CloseAll can shut it down.
+func New[T any](name string, dial func(ctx context.Context, key string) (T, error), ping func(ctx context.Context, conn T) error, close func(conn T) error) *Pool[T] {
+	p := &Pool[T]{
+		name:    name,
+		dial:    dial,
+		ping:    ping,
+		close:   close,
+		health:  healthInterval,
+		entries: map[string]*entry[T]{},
+	}
+
+	registryMu.Lock()
+	pools = append(pools, p)
+	registryMu.Unlock()
+
+	return p
+}
+
+// Get returns the cached handle for key, opening a new one if there is none
+// or if the cached handle fails its health ping. The handle is held until ctx
+// is done, so a tool call passes its own context. While another caller dials
+// or checks the handle, Get waits for it until ctx is done.
+func (p *Pool[T]) Get(ctx context.Context, key string) (T, error) {
+	p.once.Do(func() { go p.janitor() })
+
+	var zero T
+	for {
+		p.mu.Lock()
+		e, ok := p.entries[key]
+		if !ok {
+			e = &entry[T]{}
+			p.entries[key] = e
+		}
+		p.mu.Unlock()
+
+		e.mu.Lock()
+		if e.evicted {
+			e.mu.Unlock()
+			continue
+		}
+
+		if dialing := e.dialing; dialing != nil {
+			e.mu.Unlock()
+			select {
+			case <-dialing:
+				continue
+			case <-ctx.Done():
+				return zero, ctx.Err()
+			}
+		}
+
+		// A handle held by other callers is not pinged: the ping would wait for
+		// a free connection when they use all of them, and the handle cannot be
+		// closed under them anyway.
+		if e.open && (e.refs > 0 || time.Since(e.checked) <= p.health) {
+			conn := p.hold(ctx, e)
+			e.mu.Unlock()
+			return conn, nil
+		}
+
+		conn, open := e.conn, e.open
+		e.conn, e.open = zero, false
+		dialing := make(chan struct{})
+		e.dialing = dialing
+		e.mu.Unlock()
+
+		conn, err := p.acquire(ctx, key, conn, open)
+
+		e.mu.Lock()
+		e.dialing = nil
+		close(dialing)
+		if err != nil {
+			e.mu.Unlock()
+			return zero, err
+		}
+		if e.evicted {
+			// Closed by CloseAll or replaced by Use meanwhile.
+			e.mu.Unlock()
+			p.close(conn)
+			continue
+		}
+		e.conn, e.open = conn, true
+		e.checked = time.Now()
+		conn = p.hold(ctx, e)
+		e.mu.Unlock()
+
+		return conn, nil
+	}
+}
+
+// acquire pings conn when it is open, and dials a new handle when it is not
+// or the ping fails. It runs without e.mu, so that the callers waiting for
+// the handle can give up when their context is done. The ping has a timeout
+// of its own: a caller giving up must not close a healthy handle.
+func (p *Pool[T]) acquire(ctx context.Context, key string, conn T, open bool) (T, error) {
+	if open {
+		pctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+		err := p.ping(pctx, conn)
+		cancel()
+		if err == nil {
+			return conn, nil
+		}
+		log.Printf("%s pool: health check failed for %s, reconnecting: %v", p.name, key, err)
+		p.close(conn)
+	}
+
+	return p.dial(ctx, key)
+}
+
+// hold takes a hold on the handle of e until ctx is done. e.mu is held.
+func (p *Pool[T]) hold(ctx context.Context, e *entry[T]) T {
+	e.refs++
+	e.lastUsed = time.Now()
+	context.AfterFunc(ctx, func() { p.release(e) })
+	return e.conn
+}
+
+// release drops a hold taken by Get.
+func (p *Pool[T]) release(e *entry[T]) {
+	e.mu.Lock()
+	e.refs--
+	e.lastUsed = time.Now()
+	e.mu.Unlock()
+}
+
+// janitor closes handles that have been idle for longer than IdleTimeout().
+func (p *Pool[T]) janitor() {
+	ticker := time.NewTicker(janitorInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		p.evictIdle(IdleTimeout())
+	}
+}
+
+// evictIdle closes the handles nobody has held for longer than idle. They are
+// closed after p.mu is released so Get is not blocked meanwhile.
+func (p *Pool[T]) evictIdle(idle time.Duration) {
+	stale := map[string]T{}
+
+	p.mu.Lock()
+	for key, e := range p.entries {
+		if !e.mu.TryLock() {
+			continue
+		}
+		if e.refs == 0 && e.dialing == nil && (!e.open || time.Since(e.lastUsed) > idle) {
+			if e.open {
+				stale[key] = e.conn
+			}
+			e.open = false
+			e.evicted = true
+			delete(p.entries, key)
+		}
+		e.mu.Unlock()
+	}
+	p.mu.Unlock()
+
+	for key, conn := range stale {
+		log.Printf("%s pool: closing idle connection to %s", p.name, key)
+		p.close(conn)
+	}
+}
+
+// Use caches conn for key in place of a dialed handle, e.g. to hand a fake
+// database to the tools in tests. The returned function forgets it again:
+// the caller keeps ownership of conn and closes it.
+func (p *Pool[T]) Use(key string, conn T) (restore func()) {
+	// checked lies in the future so the handle is never health pinged.
+	e := &entry[T]{conn: conn, open: true, checked: time.Now().Add(24 * time.Hour), lastUsed: time.Now()}
+
+	p.mu.Lock()
+	p.entries[key] = e
+	p.mu.Unlock()
+
+	return func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		e.mu.Lock()
+		e.open = false
+		e.evicted = true
+		e.mu.Unlock()
+
+		if p.entries[key] == e {
+			delete(p.entries, key)
+		}
+	}
+}
+
+// CloseAll closes every cached handle of the pool, held or not.
+func (p *Pool[T]) CloseAll() {
+	var open []T
+
+	p.mu.Lock()
+	for key, e := range p.entries {
+		e.mu.Lock()
+		if e.open {
+			open = append(open, e.conn)
+		}
+		e.open = false
+		e.evicted = true
+		e.mu.Unlock()
+		delete(p.entries, key)
+	}
+	p.mu.Unlock()
+
+	for _, conn := range open {
+		p.close(conn)
+	}
+}
+
+// CloseAll closes the cached handles of every pool. It is called when the
+// MCP server exits.
+func CloseAll() {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	for _, p := range pools {
+		p.CloseAll()
+	}
+}
+
+// IdleTimeout returns how long an unused handle is kept open, from
+// ARGOS_POOL_IDLE_TIMEOUT (a Go duration such as "5m"). Default: 10m.
+func IdleTimeout() time.Duration {
+	if v := os.Getenv("ARGOS_POOL_IDLE_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			return d
+		}
+	}
+	return defaultIdleTimeout
+}
+
+// MaxOpen returns the maximum number of open connections per instance, from
+// ARGOS_POOL_MAX_OPEN. Default: 5.
+func MaxOpen() int {
+	if v := os.Getenv("ARGOS_POOL_MAX_OPEN"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			return n
+		}
+	}
+	return defaultMaxOpen
+}
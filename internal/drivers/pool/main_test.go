@@ -0,0 +1,235 @@
+package pool_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nicola-strappazzon/argos/internal/drivers/pool"
+)
+
+type conn struct {
+	key     string
+	healthy atomic.Bool
+	closed  atomic.Bool
+}
+
+type fake struct {
+	mu     sync.Mutex
+	dialed []*conn
+}
+
+func (f *fake) pool(t *testing.T) *pool.Pool[*conn] {
+	p := pool.New(t.Name(),
+		func(_ context.Context, key string) (*conn, error) {
+			c := &conn{key: key}
+			c.healthy.Store(true)
+			f.mu.Lock()
+			f.dialed = append(f.dialed, c)
+			f.mu.Unlock()
+			return c, nil
+		},
+		func(ctx context.Context, c *conn) error {
+			if c.closed.Load() {
+				return errors.New("closed")
+			}
+			if !c.healthy.Load() {
+				return errors.New("unhealthy")
+			}
+			return nil
+		},
+		func(c *conn) error {
+			if c.closed.Swap(true) {
+				return fmt.Errorf("%s closed twice", c.key)
+			}
+			return nil
+		},
+	)
+	t.Cleanup(p.CloseAll)
+	return p
+}
+
+func (f *fake) dials() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.dialed)
+}
+
+// released waits for the holds of a canceled context to be dropped, which
+// happens on a goroutine of its own.
+func released(t *testing.T, p *pool.Pool[*conn], c *conn) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		p.EvictIdle(0)
+		if c.closed.Load() {
+			return
+		}
+	}
+	t.Fatalf("%s was not released", c.key)
+}
+
+func TestReuse(t *testing.T) {
+	var f fake
+	p := f.pool(t)
+	ctx := context.Background()
+
+	a1, _ := p.Get(ctx, "a")
+	a2, _ := p.Get(ctx, "a")
+	b, _ := p.Get(ctx, "b")
+	if a1 != a2 || a1 == b || f.dials() != 2 {
+		t.Fatalf("expected one handle per key, dialed %d", f.dials())
+	}
+}
+
+func TestIdleEviction(t *testing.T) {
+	var f fake
+	p := f.pool(t)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	c, _ := p.Get(ctx, "a")
+	p.EvictIdle(0)
+	if c.closed.Load() {
+		t.Fatal("a held handle was closed")
+	}
+
+	cancel()
+	released(t, p, c)
+
+	if again, _ := p.Get(context.Background(), "a"); again == c || f.dials() != 2 {
+		t.Fatal("an evicted handle was handed out")
+	}
+}
+
+func TestHealthFailure(t *testing.T) {
+	var f fake
+	p := f.pool(t)
+	p.SetHealthInterval(0)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c, _ := p.Get(ctx, "a")
+	c.healthy.Store(false)
+
+	// Another caller holds the handle: it is neither pinged nor closed.
+	if again, _ := p.Get(context.Background(), "a"); again != c || c.closed.Load() {
+		t.Fatal("a held handle was replaced")
+	}
+}
+
+func TestHealthFailureWhenIdle(t *testing.T) {
+	var f fake
+	p := f.pool(t)
+	p.SetHealthInterval(0)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	c, _ := p.Get(ctx, "a")
+	c.healthy.Store(false)
+	cancel()
+
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(time.Millisecond) {
+		// Each attempt lets go of the handle at once, so it is idle again
+		// when the hold of c is dropped.
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		next, err := p.Get(ctx, "a")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if next != c {
+			if !c.closed.Load() || next.closed.Load() || f.dials() != 2 {
+				t.Fatal("the unhealthy handle was not replaced")
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the unhealthy handle was kept")
+		}
+	}
+}
+
+func TestConcurrency(t *testing.T) {
+	var f fake
+	p := f.pool(t)
+	p.SetHealthInterval(0)
+
+	var wg sync.WaitGroup
+	for i := range 64 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			key := fmt.Sprint("k", i%4)
+			c, err := p.Get(ctx, key)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if c.key != key || c.closed.Load() {
+				t.Errorf("got %s (closed %t) for %s", c.key, c.closed.Load(), key)
+			}
+			if i%8 == 0 {
+				p.EvictIdle(0)
+			}
+		}()
+	}
+	wg.Wait()
+
+	p.CloseAll()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, c := range f.dialed {
+		if !c.closed.Load() {
+			t.Errorf("%s was left open", c.key)
+		}
+	}
+}
+
+func TestSlowDial(t *testing.T) {
+	dialing := make(chan struct{}, 2)
+	reachable := make(chan struct{})
+	p := pool.New(t.Name(),
+		func(ctx context.Context, key string) (*conn, error) {
+			dialing <- struct{}{}
+			select {
+			case <-reachable:
+				return &conn{key: key}, nil
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		},
+		func(ctx context.Context, c *conn) error { return nil },
+		func(c *conn) error { return nil },
+	)
+	t.Cleanup(p.CloseAll)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error)
+	go func() {
+		_, err := p.Get(ctx, "a")
+		first <- err
+	}()
+	<-dialing
+
+	// Another caller waits for the dial only until its own deadline.
+	short, stop := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer stop()
+	if _, err := p.Get(short, "a"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the wait to end with the deadline, got %v", err)
+	}
+
+	cancel()
+	if err := <-first; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the dial to stop once cancelled, got %v", err)
+	}
+
+	close(reachable)
+	if c, err := p.Get(context.Background(), "a"); err != nil || c.key != "a" || len(dialing) != 1 {
+		t.Fatalf("expected a new dial, got %v", err)
+	}
+}
@@ -0,0 +1,41 @@
+package postgresql
+
+import (
+	"errors"
+
+	"github.com/lib/pq"
+	"github.com/nicola-strappazzon/argos/internal/toolerr"
+)
+
+func init() {
+	toolerr.Register(classify)
+}
+
+// classify maps PostgreSQL SQLSTATE codes to tool error kinds.
+func classify(err error) (toolerr.Kind, string, bool) {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return "", "", false
+	}
+
+	switch pqErr.Code {
+	case "28P01", "28000": // invalid_password, invalid_authorization_specification
+		return toolerr.Auth, "check the user and password configured for the instance in ~/.pgpass or the credential provider; with auth=iam, check the rds-db:connect IAM permission", true
+	case "42501": // insufficient_privilege
+		return toolerr.PermissionDenied, "grant the missing privilege to the database user", true
+	case "3D000": // invalid_catalog_name
+		return toolerr.NotFound, "list the databases with postgresql_databases", true
+	case "42P01": // undefined_table
+		return toolerr.NotFound, "list the tables with postgresql_tables", true
+	case "42703", "42883": // undefined_column, undefined_function
+		return toolerr.NotFound, "check the column and function names", true
+	case "42601": // syntax_error
+		return toolerr.InvalidInput, "fix the SQL syntax", true
+	case "25006": // read_only_sql_transaction
+		return toolerr.InvalidInput, "only read-only statements are allowed", true
+	case "57014", "55P03": // query_canceled, lock_not_available
+		return toolerr.Timeout, "retry with a narrower query", true
+	}
+
+	return "", "", false
+}
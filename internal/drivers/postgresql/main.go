@@ -1,46 +1,150 @@
 package postgresql
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
+	awsconfig "github.com/nicola-strappazzon/argos/internal/config/aws"
+	"github.com/nicola-strappazzon/argos/internal/config/credentials"
 	"github.com/nicola-strappazzon/argos/internal/config/psql"
+	"github.com/nicola-strappazzon/argos/internal/config/timeout"
+	"github.com/nicola-strappazzon/argos/internal/drivers/pool"
+	"github.com/nicola-strappazzon/argos/internal/drivers/session"
+	"github.com/nicola-strappazzon/argos/internal/drivers/tunnel"
 )
 
-// Connect opens a PostgreSQL connection for the given RDS instance identifier.
-// Credentials are read from ~/.pgpass matching the instance identifier against the hostname.
-func Connect(instanceID string) (*sql.DB, error) {
-	creds, err := psqlconfig.Load(instanceID)
-	if err != nil {
-		return nil, err
-	}
-	return open(creds.Host, creds.Port, creds.User, creds.Password, creds.Database, instanceID)
+// Pool keys are "<instance>/<database>"; an empty database selects the one
+// configured for the instance.
+var dbs = pool.New("postgresql",
+	func(ctx context.Context, key string) (*sql.DB, error) {
+		instanceID, database, _ := strings.Cut(key, "/")
+		creds, err := psqlconfig.Load(ctx, instanceID)
+		if err != nil {
+			return nil, err
+		}
+		if database == "" {
+			database = creds.Database
+		}
+		return open(ctx, creds, database, instanceID)
+	},
+	func(ctx context.Context, db *sql.DB) error { return db.PingContext(ctx) },
+	func(db *sql.DB) error { return db.Close() },
+)
+
+// Connect returns the shared PostgreSQL connection pool for the given RDS instance identifier.
+// Credentials are resolved by psqlconfig.Load (by default ~/.pgpass matching the instance identifier against the hostname).
+// The handle is cached across tool calls and held until ctx is done: callers must not close it.
+func Connect(ctx context.Context, instanceID string) (*sql.DB, error) {
+	return dbs.Get(ctx, instanceID+"/")
 }
 
-// ConnectDB opens a PostgreSQL connection to a specific database on the given instance.
+// ConnectDB returns the shared PostgreSQL connection pool for a specific database on the given instance.
 // Useful when querying objects that require a direct connection to the target database.
-func ConnectDB(instanceID, database string) (*sql.DB, error) {
-	creds, err := psqlconfig.Load(instanceID)
-	if err != nil {
-		return nil, err
-	}
-	return open(creds.Host, creds.Port, creds.User, creds.Password, database, instanceID)
+func ConnectDB(ctx context.Context, instanceID, database string) (*sql.DB, error) {
+	return dbs.Get(ctx, instanceID+"/"+database)
+}
+
+// Use makes Connect (with an empty database) or ConnectDB return db until
+// restore is called. It lets tests run the tools against a stand-in database.
+func Use(instanceID, database string, db *sql.DB) (restore func()) {
+	return dbs.Use(instanceID+"/"+database, db)
 }
 
-func open(host string, port int, user, password, database, instanceID string) (*sql.DB, error) {
-	dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=require",
-		host, port, user, password, database)
+func open(ctx context.Context, creds *psqlconfig.Credentials, database, instanceID string) (*sql.DB, error) {
+	password := func(context.Context) (string, error) { return creds.Password, nil }
+	if creds.Auth == credentials.AuthIAM {
+		password = awsconfig.NewAuthTokenSource(creds.Host, creds.Port, creds.User, creds.Region).Token
+	}
 
-	db, err := sql.Open("postgres", dsn)
-	if err != nil {
-		return nil, fmt.Errorf("opening postgresql connection: %w", err)
+	c := &connector{
+		dsn: func(ctx context.Context) (string, error) {
+			pass, err := password(ctx)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=require default_transaction_read_only=on",
+				quote(creds.Host), creds.Port, quote(creds.User), quote(pass), quote(database)), nil
+		},
 	}
 
-	if err := db.Ping(); err != nil {
+	if creds.SSH != nil {
+		dialer, err := tunnel.New(creds.SSH)
+		if err != nil {
+			return nil, err
+		}
+		c.dialer = dialer
+	}
+
+	db := sql.OpenDB(session.NewConnector(c, hooks))
+
+	db.SetMaxOpenConns(pool.MaxOpen())
+	db.SetMaxIdleConns(pool.MaxOpen())
+	db.SetConnMaxIdleTime(pool.IdleTimeout())
+
+	if err := db.PingContext(ctx); err != nil {
 		db.Close()
 		return nil, fmt.Errorf("connecting to %s: %w", instanceID, err)
 	}
 
 	return db, nil
 }
+
+// hooks bound every statement with statement_timeout according to the
+// context deadline, and lock waits with lock_timeout.
+var hooks = session.Hooks{
+	Limit: func(ctx context.Context, conn driver.ExecerContext, d time.Duration) error {
+		lock := timeoutconfig.Lock()
+		if d > 0 && d < lock {
+			lock = d
+		}
+		_, err := conn.ExecContext(ctx, fmt.Sprintf("SET statement_timeout = %d; SET lock_timeout = %d",
+			d.Milliseconds(), lock.Milliseconds()), nil)
+		return err
+	},
+	IsTimeout: func(err error) bool {
+		var pqErr *pq.Error
+		// 57014: query_canceled (statement_timeout), 55P03: lock_not_available (lock_timeout).
+		return errors.As(err, &pqErr) && (pqErr.Code == "57014" || pqErr.Code == "55P03")
+	},
+}
+
+// connector builds the DSN for every new connection so that IAM auth tokens
+// are refreshed before they expire while the pool keeps growing. Sessions are
+// started with default_transaction_read_only=on. When dialer
+// is set connections go through an SSH tunnel.
+type connector struct {
+	dsn    func(ctx context.Context) (string, error)
+	dialer *tunnel.Dialer
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	dsn, err := c.dsn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	pc, err := pq.NewConnector(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("opening postgresql connection: %w", err)
+	}
+	if c.dialer != nil {
+		pc.Dialer(c.dialer)
+	}
+
+	return pc.Connect(ctx)
+}
+
+func (c *connector) Driver() driver.Driver {
+	return &pq.Driver{}
+}
+
+// quote escapes a value for the key=value connection string format.
+func quote(v string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(v) + "'"
+}
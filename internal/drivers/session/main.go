@@ -0,0 +1,148 @@
+package session
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// ErrTimeout is wrapped by query errors caused by the context deadline or by
+// a server-side statement time limit.
+var ErrTimeout = errors.New("query timed out")
+
+// Hooks customise the sessions opened by a Connector.
+type Hooks struct {
+	// Init runs once on every new connection (e.g. to make it read-only).
+	Init func(ctx context.Context, conn driver.ExecerContext) error
+	// Limit sets the server-side statement time limit of the session.
+	// A zero duration removes the limit.
+	Limit func(ctx context.Context, conn driver.ExecerContext, d time.Duration) error
+	// IsTimeout reports whether err is a server-side timeout error.
+	IsTimeout func(err error) bool
+}
+
+// conn is the set of driver interfaces implemented by both the MySQL and the
+// PostgreSQL drivers, all of which database/sql uses when present.
+type conn interface {
+	driver.Conn
+	driver.QueryerContext
+	driver.ExecerContext
+	driver.ConnPrepareContext
+	driver.ConnBeginTx
+	driver.Pinger
+	driver.NamedValueChecker
+	driver.SessionResetter
+	driver.Validator
+}
+
+// NewConnector wraps c so that every session runs hooks.Init once, and every
+// query runs with a server-side time limit matching the context deadline.
+func NewConnector(c driver.Connector, hooks Hooks) driver.Connector {
+	return &connector{Connector: c, hooks: hooks}
+}
+
+type connector struct {
+	driver.Connector
+	hooks Hooks
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	dc, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	full, ok := dc.(conn)
+	if !ok {
+		dc.Close()
+		return nil, fmt.Errorf("driver connection %T does not support contexts", dc)
+	}
+
+	if c.hooks.Init != nil {
+		if err := c.hooks.Init(ctx, full); err != nil {
+			full.Close()
+			return nil, err
+		}
+	}
+
+	return &limitedConn{conn: full, hooks: c.hooks}, nil
+}
+
+type limitedConn struct {
+	conn
+	hooks    Hooks
+	limit    time.Duration
+	disabled bool
+}
+
+func (c *limitedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if err := c.applyLimit(ctx); err != nil {
+		return nil, err
+	}
+	rows, err := c.conn.QueryContext(ctx, query, args)
+	return rows, c.wrap(ctx, err)
+}
+
+func (c *limitedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if err := c.applyLimit(ctx); err != nil {
+		return nil, err
+	}
+	res, err := c.conn.ExecContext(ctx, query, args)
+	return res, c.wrap(ctx, err)
+}
+
+// applyLimit sets the server-side limit to the time left before the context
+// deadline, rounded up to the second so that it only changes between calls.
+// Servers that do not support the limit keep working with the context
+// deadline alone.
+func (c *limitedConn) applyLimit(ctx context.Context) error {
+	if c.hooks.Limit == nil || c.disabled {
+		return nil
+	}
+
+	var d time.Duration
+	if deadline, ok := ctx.Deadline(); ok {
+		d = time.Until(deadline)
+		if d <= 0 {
+			return fmt.Errorf("%w: %v", ErrTimeout, context.DeadlineExceeded)
+		}
+		d = d.Truncate(time.Second) + time.Second
+	}
+
+	if d == c.limit {
+		return nil
+	}
+
+	if err := c.hooks.Limit(ctx, c.conn, d); err != nil {
+		if errors.Is(err, driver.ErrBadConn) {
+			return err
+		}
+		log.Printf("disabling server-side statement timeout: %v", err)
+		c.disabled = true
+		return nil
+	}
+	c.limit = d
+
+	return nil
+}
+
+func (c *limitedConn) wrap(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) || (c.hooks.IsTimeout != nil && c.hooks.IsTimeout(err)) {
+		return &timeoutError{err: err}
+	}
+	return err
+}
+
+type timeoutError struct {
+	err error
+}
+
+func (e *timeoutError) Error() string        { return ErrTimeout.Error() + ": " + e.err.Error() }
+func (e *timeoutError) Unwrap() error        { return e.err }
+func (e *timeoutError) Is(target error) bool { return target == ErrTimeout }
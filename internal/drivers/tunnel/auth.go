@@ -0,0 +1,122 @@
+package tunnel
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	sshconfig "github.com/nicola-strappazzon/argos/internal/config/ssh"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// authMethods returns the private key (if ssh_key is set) and the ssh-agent
+// (if SSH_AUTH_SOCK is set) auth methods. The returned func closes the agent
+// connection.
+func authMethods(cfg *sshconfig.Config) ([]ssh.AuthMethod, func() error, error) {
+	methods := []ssh.AuthMethod{}
+	closeAgent := func() error { return nil }
+
+	if cfg.Key != "" {
+		signer, err := loadKey(cfg)
+		if err != nil {
+			return nil, nil, err
+		}
+		methods = append(methods, ssh.PublicKeys(signer))
+	}
+
+	if sock := os.Getenv("SSH_AUTH_SOCK"); sock != "" {
+		conn, err := net.Dial("unix", sock)
+		if err != nil {
+			log.Printf("ssh tunnel %s: ignoring ssh-agent: %v", cfg.ID(), err)
+		} else {
+			methods = append(methods, ssh.PublicKeysCallback(agent.NewClient(conn).Signers))
+			closeAgent = conn.Close
+		}
+	}
+
+	if len(methods) == 0 {
+		return nil, nil, fmt.Errorf("no ssh credentials for %s: set ssh_key or run an ssh-agent", cfg.Addr())
+	}
+
+	return methods, closeAgent, nil
+}
+
+func loadKey(cfg *sshconfig.Config) (ssh.Signer, error) {
+	keyPath, err := expandHome(cfg.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	keyData, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading ssh key %s: %w", keyPath, err)
+	}
+
+	signer, err := ssh.ParsePrivateKey(keyData)
+	var missing *ssh.PassphraseMissingError
+	if errors.As(err, &missing) {
+		if cfg.KeyPassphrase == "" {
+			return nil, fmt.Errorf("ssh key %s is encrypted: set ssh_key_passphrase or load it into ssh-agent", keyPath)
+		}
+		signer, err = ssh.ParsePrivateKeyWithPassphrase(keyData, []byte(cfg.KeyPassphrase))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing ssh key %s: %w", keyPath, err)
+	}
+
+	return signer, nil
+}
+
+// hostKeyCallback verifies host keys against known_hosts, unless strict host
+// key checking has been turned off for the section.
+func hostKeyCallback(cfg *sshconfig.Config) (ssh.HostKeyCallback, error) {
+	if !cfg.StrictHostKeyChecking {
+		log.Printf("ssh tunnel %s: host key verification disabled", cfg.ID())
+		return ssh.InsecureIgnoreHostKey(), nil
+	}
+
+	path := cfg.KnownHosts
+	if path == "" {
+		path = "~/.ssh/known_hosts"
+	}
+	path, err := expandHome(path)
+	if err != nil {
+		return nil, err
+	}
+
+	callback, err := knownhosts.New(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading known hosts %s: %w", path, err)
+	}
+
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		err := callback(hostname, remote, key)
+		var keyErr *knownhosts.KeyError
+		if errors.As(err, &keyErr) && len(keyErr.Want) == 0 {
+			return fmt.Errorf("ssh host %s is not in %s: add it with ssh-keyscan or connect once with ssh", hostname, path)
+		}
+		if errors.As(err, &keyErr) {
+			return fmt.Errorf("ssh host key for %s does not match %s: possible man-in-the-middle attack", hostname, path)
+		}
+		return err
+	}, nil
+}
+
+func expandHome(path string) (string, error) {
+	if !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("getting home directory: %w", err)
+	}
+
+	return filepath.Join(home, path[2:]), nil
+}
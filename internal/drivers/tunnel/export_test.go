@@ -0,0 +1,17 @@
+package tunnel
+
+import sshconfig "github.com/nicola-strappazzon/argos/internal/config/ssh"
+
+var (
+	HostKeyCallback = hostKeyCallback
+	LoadKey         = loadKey
+)
+
+// Route returns the user@addr of each hop of the route, in dialing order.
+func Route(cfg *sshconfig.Config) []string {
+	var hops []string
+	for _, h := range route(cfg) {
+		hops = append(hops, h.user+"@"+h.addr)
+	}
+	return hops
+}
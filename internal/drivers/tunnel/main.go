@@ -0,0 +1,251 @@
+package tunnel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	sshconfig "github.com/nicola-strappazzon/argos/internal/config/ssh"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/sync/singleflight"
+)
+
+const keepAliveTimeout = 15 * time.Second
+
+var (
+	mu      sync.Mutex
+	tunnels = map[string]*tunnel{}
+	dials   singleflight.Group
+)
+
+// tunnel is an SSH connection to a bastion, possibly through ProxyJump hops.
+type tunnel struct {
+	client  *ssh.Client
+	closers []func() error
+}
+
+func (t *tunnel) close() {
+	for i := len(t.closers) - 1; i >= 0; i-- {
+		t.closers[i]()
+	}
+}
+
+// Dialer opens TCP connections through the SSH bastion described by its
+// config. It satisfies the dialer interfaces of the MySQL, PostgreSQL and
+// MongoDB drivers, and all instances behind the same route share one SSH
+// connection. Dead connections are dropped and re-established on the next dial.
+type Dialer struct {
+	Config *sshconfig.Config
+}
+
+// New returns a dialer for cfg and makes sure the bastion is reachable.
+func New(cfg *sshconfig.Config) (*Dialer, error) {
+	if _, err := get(cfg); err != nil {
+		return nil, err
+	}
+	return &Dialer{Config: cfg}, nil
+}
+
+func (d *Dialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	for attempt := 0; ; attempt++ {
+		t, err := get(d.Config)
+		if err != nil {
+			return nil, err
+		}
+
+		conn, err := t.client.DialContext(ctx, network, addr)
+		if err == nil {
+			return conn, nil
+		}
+
+		// The bastion answered but refused the forward: the tunnel is fine.
+		var openErr *ssh.OpenChannelError
+		if errors.As(err, &openErr) || ctx.Err() != nil || attempt > 0 {
+			return nil, fmt.Errorf("dialing %s through ssh host %s: %w", addr, d.Config.Addr(), err)
+		}
+
+		log.Printf("ssh tunnel %s: dial failed, reconnecting: %v", d.Config.ID(), err)
+		drop(d.Config.ID(), t)
+	}
+}
+
+func (d *Dialer) Dial(network, addr string) (net.Conn, error) {
+	return d.DialContext(context.Background(), network, addr)
+}
+
+func (d *Dialer) DialTimeout(network, addr string, timeout time.Duration) (net.Conn, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return d.DialContext(ctx, network, addr)
+}
+
+// get returns the cached tunnel for the route, opening it on first use. The
+// hops are dialed outside mu, once per route however many callers wait.
+func get(cfg *sshconfig.Config) (*tunnel, error) {
+	id := cfg.ID()
+
+	mu.Lock()
+	t, ok := tunnels[id]
+	mu.Unlock()
+	if ok {
+		return t, nil
+	}
+
+	v, err, _ := dials.Do(id, func() (any, error) {
+		mu.Lock()
+		t, ok := tunnels[id]
+		mu.Unlock()
+		if ok {
+			return t, nil
+		}
+
+		t, err := open(cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		mu.Lock()
+		tunnels[id] = t
+		mu.Unlock()
+		go watch(cfg, t)
+
+		return t, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v.(*tunnel), nil
+}
+
+// drop closes the tunnel and removes it from the cache if it is still the
+// cached one for the route.
+func drop(id string, t *tunnel) {
+	mu.Lock()
+	if tunnels[id] == t {
+		delete(tunnels, id)
+	}
+	mu.Unlock()
+
+	t.close()
+}
+
+// watch sends keepalives until the connection dies, then drops the tunnel so
+// the next dial reconnects.
+func watch(cfg *sshconfig.Config, t *tunnel) {
+	done := make(chan struct{})
+	go func() {
+		t.client.Wait()
+		close(done)
+	}()
+
+	var tick <-chan time.Time
+	if cfg.KeepAlive > 0 {
+		ticker := time.NewTicker(cfg.KeepAlive)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-done:
+			log.Printf("ssh tunnel %s: connection closed", cfg.ID())
+			drop(cfg.ID(), t)
+			return
+		case <-tick:
+			if err := keepAlive(t.client); err != nil {
+				log.Printf("ssh tunnel %s: keepalive failed: %v", cfg.ID(), err)
+				drop(cfg.ID(), t)
+				return
+			}
+		}
+	}
+}
+
+func keepAlive(client *ssh.Client) error {
+	errc := make(chan error, 1)
+	go func() {
+		_, _, err := client.SendRequest("keepalive@openssh.com", true, nil)
+		errc <- err
+	}()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-time.After(keepAliveTimeout):
+		return fmt.Errorf("no reply after %s", keepAliveTimeout)
+	}
+}
+
+// open connects to every ProxyJump hop in order and then to the bastion,
+// each hop being dialed through the previous one.
+func open(cfg *sshconfig.Config) (*tunnel, error) {
+	auth, closeAuth, err := authMethods(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	hostKey, err := hostKeyCallback(cfg)
+	if err != nil {
+		closeAuth()
+		return nil, err
+	}
+
+	t := &tunnel{closers: []func() error{closeAuth}}
+
+	for _, h := range route(cfg) {
+		config := &ssh.ClientConfig{
+			User:            h.user,
+			Auth:            auth,
+			HostKeyCallback: hostKey,
+			Timeout:         30 * time.Second,
+		}
+
+		var client *ssh.Client
+		if t.client == nil {
+			client, err = ssh.Dial("tcp", h.addr, config)
+		} else {
+			client, err = dialThrough(t.client, h.addr, config)
+		}
+		if err != nil {
+			t.close()
+			return nil, fmt.Errorf("connecting to ssh host %s: %w", h.addr, err)
+		}
+
+		t.client = client
+		t.closers = append(t.closers, client.Close)
+	}
+
+	return t, nil
+}
+
+type hop struct{ user, addr string }
+
+// route lists the ProxyJump hops and then the bastion, in dialing order.
+func route(cfg *sshconfig.Config) []hop {
+	hops := []hop{}
+	for _, h := range cfg.ProxyJump {
+		user, addr := cfg.Hop(h)
+		hops = append(hops, hop{user, addr})
+	}
+	return append(hops, hop{cfg.User, cfg.Addr()})
+}
+
+func dialThrough(via *ssh.Client, addr string, config *ssh.ClientConfig) (*ssh.Client, error) {
+	conn, err := via.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	c, chans, reqs, err := ssh.NewClientConn(conn, addr, config)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return ssh.NewClient(c, chans, reqs), nil
+}
@@ -0,0 +1,139 @@
+package tunnel_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	sshconfig "github.com/nicola-strappazzon/argos/internal/config/ssh"
+	"github.com/nicola-strappazzon/argos/internal/drivers/tunnel"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+func newKey(t *testing.T) (ed25519.PrivateKey, ssh.PublicKey) {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := ssh.NewPublicKey(priv.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return priv, pub
+}
+
+func TestHostKeyCallback(t *testing.T) {
+	_, known := newKey(t)
+	_, other := newKey(t)
+
+	path := filepath.Join(t.TempDir(), "known_hosts")
+	line := knownhosts.Line([]string{knownhosts.Normalize("bastion.example.com:22")}, known)
+	if err := os.WriteFile(path, []byte(line+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	addr := &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 22}
+
+	strict, err := tunnel.HostKeyCallback(&sshconfig.Config{StrictHostKeyChecking: true, KnownHosts: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := strict("bastion.example.com:22", addr, known); err != nil {
+		t.Errorf("known host rejected: %v", err)
+	}
+	if err := strict("bastion.example.com:22", addr, other); err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Errorf("changed host key accepted: %v", err)
+	}
+	if err := strict("jump.example.com:22", addr, known); err == nil || !strings.Contains(err.Error(), "is not in") {
+		t.Errorf("unknown host accepted: %v", err)
+	}
+
+	insecure, err := tunnel.HostKeyCallback(&sshconfig.Config{Host: "bastion.example.com", KnownHosts: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := insecure("jump.example.com:22", addr, other); err != nil {
+		t.Errorf("strict checking off still verified the key: %v", err)
+	}
+
+	if _, err := tunnel.HostKeyCallback(&sshconfig.Config{StrictHostKeyChecking: true, KnownHosts: path + ".missing"}); err == nil {
+		t.Error("a missing known_hosts file was accepted")
+	}
+}
+
+func TestLoadKey(t *testing.T) {
+	priv, pub := newKey(t)
+	dir := t.TempDir()
+
+	write := func(name string, block *pem.Block) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, pem.EncodeToMemory(block), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	plain, err := ssh.MarshalPrivateKey(priv, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted, err := ssh.MarshalPrivateKeyWithPassphrase(priv, "", []byte("s3cret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	plainPath := write("id_plain", plain)
+	encryptedPath := write("id_encrypted", encrypted)
+
+	for _, tc := range []struct {
+		name, path, passphrase, err string
+	}{
+		{name: "plain", path: plainPath},
+		{name: "passphrase", path: encryptedPath, passphrase: "s3cret"},
+		{name: "missing passphrase", path: encryptedPath, err: "set ssh_key_passphrase"},
+		{name: "wrong passphrase", path: encryptedPath, passphrase: "wrong", err: "parsing ssh key"},
+		{name: "missing file", path: filepath.Join(dir, "id_missing"), err: "reading ssh key"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			signer, err := tunnel.LoadKey(&sshconfig.Config{Key: tc.path, KeyPassphrase: tc.passphrase})
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("expected %q, got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(signer.PublicKey().Marshal()) != string(pub.Marshal()) {
+				t.Error("loaded the wrong key")
+			}
+		})
+	}
+}
+
+func TestRoute(t *testing.T) {
+	cfg := sshconfig.FromKeys(map[string]string{
+		"ssh_host":       "bastion.internal",
+		"ssh_port":       "2222",
+		"ssh_user":       "ec2-user",
+		"ssh_proxy_jump": "jump.example.com, ops@edge.example.com:2200,",
+	})
+
+	got := fmt.Sprint(tunnel.Route(cfg))
+	want := "[ec2-user@jump.example.com:22 ops@edge.example.com:2200 ec2-user@bastion.internal:2222]"
+	if got != want {
+		t.Errorf("route %s, want %s", got, want)
+	}
+	if id := cfg.ID(); id != "jump.example.com,ops@edge.example.com:2200,ec2-user@bastion.internal:2222" {
+		t.Errorf("unexpected route id %s", id)
+	}
+	if !cfg.StrictHostKeyChecking {
+		t.Error("strict host key checking is off by default")
+	}
+}
@@ -0,0 +1,130 @@
+package httpserver
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/nicola-strappazzon/argos/internal/config/http"
+
+	"github.com/modelcontextprotocol/go-sdk/auth"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	// StreamablePath serves the streamable HTTP transport.
+	StreamablePath = "/mcp"
+	// SSEPath serves the legacy HTTP+SSE transport.
+	SSEPath = "/sse"
+)
+
+// Handler serves the MCP server over streamable HTTP and SSE, behind
+// bearer-token authentication when tokens are configured.
+func Handler(server *mcp.Server, cfg *httpconfig.Config) http.Handler {
+	getServer := func(*http.Request) *mcp.Server { return server }
+
+	mux := http.NewServeMux()
+	mux.Handle(StreamablePath, mcp.NewStreamableHTTPHandler(getServer, nil))
+	mux.Handle(SSEPath, mcp.NewSSEHandler(getServer, nil))
+
+	handler := logRequests(mux)
+	if len(cfg.Tokens) > 0 {
+		handler = auth.RequireBearerToken(verifier(cfg.Tokens), nil)(handler)
+	}
+
+	return handler
+}
+
+// Serve listens on addr until ctx is done, then shuts down gracefully.
+// Without any authentication it only accepts a loopback address, and bearer
+// tokens are only sent in clear text to a loopback address.
+func Serve(ctx context.Context, addr string, server *mcp.Server, cfg *httpconfig.Config) error {
+	if !loopback(addr) {
+		if !cfg.Authenticated() {
+			return fmt.Errorf("refusing to listen on %s without authentication: set ARGOS_HTTP_TOKENS or ARGOS_TLS_CLIENT_CA", addr)
+		}
+		if len(cfg.Tokens) > 0 && cfg.TLS == nil {
+			return fmt.Errorf("refusing to accept bearer tokens over plain HTTP on %s: set ARGOS_TLS_CERT and ARGOS_TLS_KEY", addr)
+		}
+	}
+
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           Handler(server, cfg),
+		TLSConfig:         cfg.TLS,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		log.Printf("Listening on %s (streamable HTTP at %s, SSE at %s, tls=%t, mtls=%t, tokens=%d)",
+			addr, StreamablePath, SSEPath, cfg.TLS != nil, cfg.MutualTLS(), len(cfg.Tokens))
+		if cfg.TLS != nil {
+			errs <- srv.ListenAndServeTLS("", "")
+		} else {
+			errs <- srv.ListenAndServe()
+		}
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdown, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdown); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// verifier accepts the configured tokens. The holder's name becomes the
+// session's user, so a session cannot be reused with another token.
+func verifier(tokens map[string]string) auth.TokenVerifier {
+	return func(_ context.Context, token string, _ *http.Request) (*auth.TokenInfo, error) {
+		for known, name := range tokens {
+			if subtle.ConstantTimeCompare([]byte(token), []byte(known)) == 1 {
+				return &auth.TokenInfo{
+					UserID:     name,
+					Expiration: time.Now().Add(24 * time.Hour),
+				}, nil
+			}
+		}
+		return nil, auth.ErrInvalidToken
+	}
+}
+
+// logRequests logs authenticated calls and who made them, identified by token holder or client
+// certificate subject.
+func logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		client := r.RemoteAddr
+		if info := auth.TokenInfoFromContext(r.Context()); info != nil {
+			client = info.UserID + "@" + client
+		} else if r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
+			client = r.TLS.PeerCertificates[0].Subject.CommonName + "@" + client
+		}
+		log.Printf("HTTP %s %s from %s", r.Method, r.URL.Path, client)
+		next.ServeHTTP(w, r)
+	})
+}
+
+func loopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,244 @@
+package httpserver_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nicola-strappazzon/argos/internal/config/http"
+	"github.com/nicola-strappazzon/argos/internal/httpserver"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func newServer() *mcp.Server {
+	return mcp.NewServer(&mcp.Implementation{Name: "test"}, nil)
+}
+
+func TestTokens(t *testing.T) {
+	t.Setenv("ARGOS_HTTP_TOKENS", "alice:right, bob : other ,bare")
+	t.Setenv("ARGOS_TLS_CERT", "")
+	t.Setenv("ARGOS_TLS_KEY", "")
+	t.Setenv("ARGOS_TLS_CLIENT_CA", "")
+	cfg, err := httpconfig.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Tokens["right"] != "alice" || cfg.Tokens["other"] != "bob" || cfg.Tokens["bare"] != "token3" {
+		t.Fatalf("unexpected tokens: %v", cfg.Tokens)
+	}
+
+	srv := httptest.NewServer(httpserver.Handler(newServer(), cfg))
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		name, header string
+		status       int
+	}{
+		{"missing", "", http.StatusUnauthorized},
+		{"wrong", "Bearer wrong", http.StatusUnauthorized},
+		{"not bearer", "Basic right", http.StatusUnauthorized},
+		{"right", "Bearer right", 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, srv.URL+httpserver.StreamablePath, nil)
+			if tc.header != "" {
+				req.Header.Set("Authorization", tc.header)
+			}
+			res, err := srv.Client().Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res.Body.Close()
+
+			if tc.status != 0 && res.StatusCode != tc.status {
+				t.Errorf("status %d, want %d", res.StatusCode, tc.status)
+			}
+			if tc.status == 0 && res.StatusCode == http.StatusUnauthorized {
+				t.Error("a valid token was rejected")
+			}
+		})
+	}
+}
+
+// pki holds the PEM files of a CA and of a server certificate for
+// 127.0.0.1 it signed, and a client certificate it signed too.
+type pki struct {
+	ca, cert, key string
+	client        tls.Certificate
+	roots         *x509.CertPool
+}
+
+func newPKI(t *testing.T) pki {
+	t.Helper()
+	dir := t.TempDir()
+
+	write := func(name, kind string, der []byte) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: kind, Bytes: der}), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	key := func() *ecdsa.PrivateKey {
+		k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return k
+	}
+	sign := func(tmpl, parent *x509.Certificate, pub any, priv *ecdsa.PrivateKey) *x509.Certificate {
+		der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, pub, priv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cert, _ := x509.ParseCertificate(der)
+		return cert
+	}
+
+	caKey := key()
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "argos test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	ca := sign(caTemplate, caTemplate, caKey.Public(), caKey)
+
+	serverKey := key()
+	server := sign(&x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "argos"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, ca, serverKey.Public(), caKey)
+	serverDER, _ := x509.MarshalECPrivateKey(serverKey)
+
+	clientKey := key()
+	client := sign(&x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "alice"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca, clientKey.Public(), caKey)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+
+	return pki{
+		ca:     write("ca.pem", "CERTIFICATE", ca.Raw),
+		cert:   write("server.pem", "CERTIFICATE", server.Raw),
+		key:    write("server.key", "EC PRIVATE KEY", serverDER),
+		client: tls.Certificate{Certificate: [][]byte{client.Raw}, PrivateKey: clientKey},
+		roots:  roots,
+	}
+}
+
+func TestTokenWithColon(t *testing.T) {
+	t.Setenv("ARGOS_HTTP_TOKENS", "alice:right:wrong")
+	t.Setenv("ARGOS_TLS_CERT", "")
+	t.Setenv("ARGOS_TLS_KEY", "")
+	t.Setenv("ARGOS_TLS_CLIENT_CA", "")
+	if _, err := httpconfig.Load(); err == nil || !strings.Contains(err.Error(), `"alice"`) {
+		t.Fatalf("expected a token with ':' to be rejected, got %v", err)
+	}
+}
+
+func TestMutualTLS(t *testing.T) {
+	p := newPKI(t)
+	t.Setenv("ARGOS_HTTP_TOKENS", "")
+	t.Setenv("ARGOS_TLS_CERT", p.cert)
+	t.Setenv("ARGOS_TLS_KEY", p.key)
+	t.Setenv("ARGOS_TLS_CLIENT_CA", p.ca)
+	cfg, err := httpconfig.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.MutualTLS() || !cfg.Authenticated() {
+		t.Fatal("expected mTLS to be required")
+	}
+
+	srv := httptest.NewUnstartedServer(httpserver.Handler(newServer(), cfg))
+	srv.TLS = cfg.TLS
+	srv.StartTLS()
+	defer srv.Close()
+
+	get := func(certs ...tls.Certificate) error {
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			RootCAs:      p.roots,
+			Certificates: certs,
+		}}}
+		res, err := client.Get(srv.URL + httpserver.StreamablePath)
+		if err == nil {
+			res.Body.Close()
+		}
+		return err
+	}
+
+	if err := get(); err == nil {
+		t.Error("a client without a certificate was accepted")
+	}
+	if err := get(p.client); err != nil {
+		t.Errorf("a client with a certificate was rejected: %v", err)
+	}
+}
+
+func TestServeGuards(t *testing.T) {
+	p := newPKI(t)
+	tokens := &httpconfig.Config{Tokens: map[string]string{"right": "alice"}}
+
+	t.Setenv("ARGOS_HTTP_TOKENS", "alice:right")
+	t.Setenv("ARGOS_TLS_CERT", p.cert)
+	t.Setenv("ARGOS_TLS_KEY", p.key)
+	t.Setenv("ARGOS_TLS_CLIENT_CA", "")
+	withTLS, err := httpconfig.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name string
+		addr string
+		cfg  *httpconfig.Config
+		err  string
+	}{
+		{"no auth", "0.0.0.0:0", &httpconfig.Config{}, "without authentication"},
+		{"tokens without tls", "0.0.0.0:0", tokens, "over plain HTTP"},
+		{"no auth on loopback", "127.0.0.1:0", &httpconfig.Config{}, ""},
+		{"tokens on loopback", "localhost:0", tokens, ""},
+		{"tokens with tls", ":0", withTLS, ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			err := httpserver.Serve(ctx, tc.addr, newServer(), tc.cfg)
+			if tc.err == "" && err != nil {
+				t.Errorf("refused to start: %v", err)
+			}
+			if tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)) {
+				t.Errorf("expected %q, got %v", tc.err, err)
+			}
+		})
+	}
+}
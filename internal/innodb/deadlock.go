@@ -0,0 +1,440 @@
+package innodb
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/nicola-strappazzon/argos/internal/display"
+)
+
+type Deadlock struct {
+	Timestamp    string       `json:"timestamp"`
+	Transactions []DeadlockTx `json:"transactions"`
+	Victim       int          `json:"victim_transaction"`
+	// WaitsFor is the lock-wait graph: each transaction points to the one
+	// holding the lock it waits for.
+	WaitsFor []WaitEdge `json:"waits_for"`
+	// TooComplex is set when InnoDB gave up walking the wait-for graph
+	// (more than 200 transactions or 1,000,000 locks) and rolled back the
+	// requesting transaction without a proper cycle.
+	TooComplex  bool   `json:"too_complex,omitempty"`
+	Explanation string `json:"explanation"`
+}
+
+// DeadlockTx is one transaction of the deadlock, numbered as InnoDB prints
+// it: (1), (2)...
+type DeadlockTx struct {
+	Number         int    `json:"number"`
+	ID             int64  `json:"trx_id"`
+	ActiveSeconds  int64  `json:"active_seconds"`
+	State          string `json:"state,omitempty"`
+	TablesInUse    int64  `json:"tables_in_use"`
+	TablesLocked   int64  `json:"tables_locked"`
+	LockStructs    int64  `json:"lock_structs"`
+	RowLocks       int64  `json:"row_locks"`
+	UndoLogEntries int64  `json:"undo_log_entries"`
+	ThreadID       int64  `json:"thread_id"`
+	QueryID        int64  `json:"query_id"`
+	Host           string `json:"host,omitempty"`
+	User           string `json:"user,omitempty"`
+	ThreadState    string `json:"thread_state,omitempty"`
+	Query          string `json:"query"`
+	// IsolationHint is inferred from the locks, since InnoDB does not print
+	// the isolation level: gap and next-key locks are only taken under
+	// REPEATABLE READ and SERIALIZABLE.
+	IsolationHint string `json:"isolation_hint,omitempty"`
+	// Holds is empty on servers that only print the locks held by the
+	// last transaction (5.7 and earlier).
+	Holds      []Lock `json:"holds,omitempty"`
+	WaitingFor *Lock  `json:"waiting_for,omitempty"`
+}
+
+// Lock is a record or table lock.
+type Lock struct {
+	// Kind is "record" or "table".
+	Kind  string `json:"kind"`
+	Table string `json:"table"`
+	Index string `json:"index,omitempty"`
+	// Mode is X, S, IX, IS or AUTO-INC.
+	Mode string `json:"mode"`
+	// Type is "next-key" (the record and the gap before it), "record",
+	// "gap", "insert-intention" or "table".
+	Type    string `json:"type"`
+	Space   int64  `json:"space_id,omitempty"`
+	Page    int64  `json:"page_no,omitempty"`
+	HeapNos []int  `json:"heap_nos,omitempty"`
+	Waiting bool   `json:"waiting,omitempty"`
+}
+
+// WaitEdge says transaction Waiter waits for a lock held by Holder. Holder is
+// 0 when the output does not tell which transaction holds it.
+type WaitEdge struct {
+	Waiter int    `json:"waiter"`
+	Holder int    `json:"holder,omitempty"`
+	Lock   string `json:"lock"`
+}
+
+var (
+	reDeadlockTime   = regexp.MustCompile(`(\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}) \S+\n\*\*\*`)
+	reDeadlockMarker = regexp.MustCompile(`(?m)^\*\*\* \((\d+)\) (TRANSACTION|HOLDS THE LOCK\(S\)|WAITING FOR THIS LOCK TO BE GRANTED):$`)
+	reDeadlockVictim = regexp.MustCompile(`WE ROLL BACK TRANSACTION \((\d+)\)`)
+	reTooDeep        = regexp.MustCompile(`TOO DEEP OR LONG SEARCH IN THE LOCK TABLE WAITS-FOR GRAPH`)
+
+	reTxHeader  = regexp.MustCompile(`(?m)^TRANSACTION (\d+), ACTIVE (\d+) sec ?(.*)$`)
+	reTxTables  = regexp.MustCompile(`mysql tables in use (\d+), locked (\d+)`)
+	reTxLocks   = regexp.MustCompile(`(\d+) lock struct\(s\), heap size \d+, (\d+) row lock\(s\)(?:, undo log entries (\d+))?`)
+	reTxThread  = regexp.MustCompile(`(?m)^MySQL thread id (\d+), OS thread handle \S+, query id (\d+)(.*)$`)
+	reRecLock   = regexp.MustCompile(`(?m)^RECORD LOCKS space id (\d+) page no (\d+) n bits \d+ index (\S+) of table (\S+) trx id \d+ lock[_ ]mode (\S+)(.*)$`)
+	reTableLock = regexp.MustCompile(`(?m)^TABLE LOCK table (\S+) trx id \d+ lock mode (\S+)(.*)$`)
+	reHeapNo    = regexp.MustCompile(`(?m)^Record lock, heap no (\d+)`)
+)
+
+func parseDeadlock(s string) *Deadlock {
+	dl := &Deadlock{WaitsFor: []WaitEdge{}}
+	if m := reDeadlockTime.FindStringSubmatch(s); m != nil {
+		dl.Timestamp = m[1]
+	}
+	if m := reDeadlockVictim.FindStringSubmatch(s); m != nil {
+		dl.Victim, _ = strconv.Atoi(m[1])
+	}
+	dl.TooComplex = reTooDeep.MatchString(s)
+
+	// Each "*** (n) ..." marker starts a block that runs to the next one.
+	markers := reDeadlockMarker.FindAllStringSubmatchIndex(s, -1)
+	index := map[int]int{}
+	for i, mk := range markers {
+		end := len(s)
+		if i+1 < len(markers) {
+			end = markers[i+1][0]
+		}
+		if next := strings.Index(s[mk[1]:end], "\n*** WE ROLL BACK"); next != -1 {
+			end = mk[1] + next
+		}
+		block := s[mk[1]:end]
+
+		n, _ := strconv.Atoi(s[mk[2]:mk[3]])
+		if _, ok := index[n]; !ok {
+			index[n] = len(dl.Transactions)
+			dl.Transactions = append(dl.Transactions, DeadlockTx{Number: n})
+		}
+		tx := &dl.Transactions[index[n]]
+
+		switch s[mk[4]:mk[5]] {
+		case "TRANSACTION":
+			parseDeadlockTx(tx, block)
+		case "HOLDS THE LOCK(S)":
+			tx.Holds = append(tx.Holds, parseLocks(block)...)
+		default:
+			if locks := parseLocks(block); len(locks) > 0 {
+				tx.WaitingFor = &locks[0]
+			}
+		}
+	}
+
+	for i := range dl.Transactions {
+		dl.Transactions[i].IsolationHint = isolationHint(&dl.Transactions[i])
+	}
+	dl.WaitsFor = waitGraph(dl.Transactions)
+	dl.Explanation = explain(dl)
+
+	return dl
+}
+
+func parseDeadlockTx(tx *DeadlockTx, block string) {
+	if m := reTxHeader.FindStringSubmatch(block); m != nil {
+		tx.ID = parseInt64(m[1])
+		tx.ActiveSeconds = parseInt64(m[2])
+		tx.State = strings.TrimSpace(m[3])
+	}
+	if m := reTxTables.FindStringSubmatch(block); m != nil {
+		tx.TablesInUse = parseInt64(m[1])
+		tx.TablesLocked = parseInt64(m[2])
+	}
+	if m := reTxLocks.FindStringSubmatch(block); m != nil {
+		tx.LockStructs = parseInt64(m[1])
+		tx.RowLocks = parseInt64(m[2])
+		tx.UndoLogEntries = parseInt64(m[3])
+	}
+
+	loc := reTxThread.FindStringSubmatchIndex(block)
+	if loc == nil {
+		return
+	}
+	tx.ThreadID = parseInt64(block[loc[2]:loc[3]])
+	tx.QueryID = parseInt64(block[loc[4]:loc[5]])
+
+	// "10.0.3.17 app updating": host, user and thread state; internal
+	// threads print fewer fields.
+	fields := strings.Fields(block[loc[6]:loc[7]])
+	if len(fields) > 0 {
+		tx.Host = fields[0]
+	}
+	if len(fields) > 1 {
+		tx.User = fields[1]
+	}
+	if len(fields) > 2 {
+		tx.ThreadState = strings.Join(fields[2:], " ")
+	}
+
+	// The statement follows, possibly over several lines, up to a blank line.
+	query, _, _ := strings.Cut(strings.TrimLeft(block[loc[1]:], "\n"), "\n\n")
+	tx.Query = strings.TrimSpace(query)
+}
+
+func parseLocks(block string) []Lock {
+	var locks []Lock
+
+	recs := reRecLock.FindAllStringSubmatchIndex(block, -1)
+	for i, m := range recs {
+		end := len(block)
+		if i+1 < len(recs) {
+			end = recs[i+1][0]
+		}
+		rest := block[m[12]:m[13]]
+		l := Lock{
+			Kind:    "record",
+			Space:   parseInt64(block[m[2]:m[3]]),
+			Page:    parseInt64(block[m[4]:m[5]]),
+			Index:   block[m[6]:m[7]],
+			Table:   block[m[8]:m[9]],
+			Mode:    block[m[10]:m[11]],
+			Type:    recordLockType(rest),
+			Waiting: strings.Contains(rest, "waiting"),
+		}
+		// Table locks of the block come after its record locks, if any.
+		if t := reTableLock.FindStringIndex(block[m[1]:end]); t != nil {
+			end = m[1] + t[0]
+		}
+		for _, h := range reHeapNo.FindAllStringSubmatch(block[m[1]:end], -1) {
+			n, _ := strconv.Atoi(h[1])
+			l.HeapNos = append(l.HeapNos, n)
+		}
+		locks = append(locks, l)
+	}
+
+	for _, m := range reTableLock.FindAllStringSubmatch(block, -1) {
+		locks = append(locks, Lock{
+			Kind:    "table",
+			Table:   m[1],
+			Mode:    m[2],
+			Type:    "table",
+			Waiting: strings.Contains(m[3], "waiting"),
+		})
+	}
+
+	return locks
+}
+
+func recordLockType(s string) string {
+	switch {
+	case strings.Contains(s, "insert intention"):
+		return "insert-intention"
+	case strings.Contains(s, "locks gap before rec"):
+		return "gap"
+	case strings.Contains(s, "locks rec but not gap"):
+		return "record"
+	default:
+		return "next-key"
+	}
+}
+
+func isolationHint(tx *DeadlockTx) string {
+	locks := slices.Clone(tx.Holds)
+	if tx.WaitingFor != nil {
+		locks = append(locks, *tx.WaitingFor)
+	}
+	for _, l := range locks {
+		if l.Type == "next-key" || l.Type == "gap" || l.Type == "insert-intention" {
+			return "REPEATABLE READ or SERIALIZABLE"
+		}
+	}
+	return ""
+}
+
+// waitGraph links each waiting transaction to the one holding a lock on the
+// same records, or on the same index or table when no records are printed.
+// When the holders are not printed and there are two transactions, each
+// waits for the other.
+func waitGraph(txs []DeadlockTx) []WaitEdge {
+	edges := []WaitEdge{}
+	for _, tx := range txs {
+		if tx.WaitingFor == nil {
+			continue
+		}
+		edge := WaitEdge{Waiter: tx.Number, Lock: describe(*tx.WaitingFor)}
+
+		best := 0
+		for _, other := range txs {
+			if other.Number == tx.Number {
+				continue
+			}
+			for _, held := range other.Holds {
+				if score := overlap(*tx.WaitingFor, held); score > best {
+					best = score
+					edge.Holder = other.Number
+				}
+			}
+		}
+		if edge.Holder == 0 && len(txs) == 2 {
+			edge.Holder = txs[0].Number
+			if edge.Holder == tx.Number {
+				edge.Holder = txs[1].Number
+			}
+		}
+
+		edges = append(edges, edge)
+	}
+	return edges
+}
+
+// overlap scores how closely a held lock matches a wanted one: 3 for a
+// shared record, 2 for the same page, 1 for the same table (and index).
+func overlap(want, held Lock) int {
+	if want.Table != held.Table || want.Index != held.Index {
+		return 0
+	}
+	if want.Kind == "record" && held.Kind == "record" && want.Space == held.Space && want.Page == held.Page {
+		for _, h := range want.HeapNos {
+			if slices.Contains(held.HeapNos, h) {
+				return 3
+			}
+		}
+		return 2
+	}
+	return 1
+}
+
+var modeNames = map[string]string{
+	"X":        "exclusive",
+	"S":        "shared",
+	"IX":       "intention exclusive",
+	"IS":       "intention shared",
+	"AUTO-INC": "auto-increment",
+}
+
+// describe renders a lock as e.g. "an exclusive record lock on index
+// PRIMARY of `bank`.`accounts`".
+func describe(l Lock) string {
+	mode := modeNames[l.Mode]
+	if mode == "" {
+		mode = l.Mode
+	}
+
+	if l.Kind == "table" {
+		return fmt.Sprintf("%s %s table lock on %s", article(mode), mode, l.Table)
+	}
+
+	kind := l.Type + " lock"
+	if l.Type == "insert-intention" {
+		kind = "insert intention gap lock"
+	}
+	return fmt.Sprintf("%s %s %s on index %s of %s", article(mode), mode, kind, l.Index, l.Table)
+}
+
+func article(word string) string {
+	if word != "" && strings.ContainsRune("aeiou", rune(word[0])) {
+		return "an"
+	}
+	return "a"
+}
+
+// explain describes the cycle in plain language, with a hint on how to
+// avoid it.
+func explain(dl *Deadlock) string {
+	if len(dl.Transactions) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	holders := map[int]int{}
+	for _, e := range dl.WaitsFor {
+		holders[e.Waiter] = e.Holder
+	}
+
+	for _, tx := range dl.Transactions {
+		fmt.Fprintf(&sb, "Transaction (%d)", tx.Number)
+		if tx.ThreadID != 0 {
+			fmt.Fprintf(&sb, " (thread %d", tx.ThreadID)
+			if tx.User != "" {
+				fmt.Fprintf(&sb, ", %s@%s", tx.User, tx.Host)
+			}
+			sb.WriteString(")")
+		}
+		if tx.Query != "" {
+			fmt.Fprintf(&sb, " running %q", oneLine(tx.Query, 120))
+		}
+		if len(tx.Holds) > 0 {
+			descs := make([]string, 0, len(tx.Holds))
+			for _, l := range tx.Holds {
+				descs = append(descs, describe(l))
+			}
+			fmt.Fprintf(&sb, " holds %s", strings.Join(descs, " and "))
+			if tx.WaitingFor != nil {
+				sb.WriteString(" and")
+			}
+		}
+		if tx.WaitingFor != nil {
+			fmt.Fprintf(&sb, " waits for %s", describe(*tx.WaitingFor))
+			if h := holders[tx.Number]; h != 0 {
+				fmt.Fprintf(&sb, " held by transaction (%d)", h)
+			}
+		}
+		sb.WriteString(". ")
+	}
+
+	if dl.TooComplex {
+		sb.WriteString("The wait-for graph was too deep or large to search, so InnoDB rolled back the requesting transaction without finding a cycle. ")
+	}
+	if dl.Victim != 0 {
+		fmt.Fprintf(&sb, "InnoDB rolled back transaction (%d). ", dl.Victim)
+	}
+	sb.WriteString(advice(dl))
+
+	return strings.TrimSpace(sb.String())
+}
+
+func advice(dl *Deadlock) string {
+	var gap, sameIndex bool
+	indexes := map[string]bool{}
+	for _, tx := range dl.Transactions {
+		for _, l := range append(slices.Clone(tx.Holds), derefLocks(tx.WaitingFor)...) {
+			if l.Type == "gap" || l.Type == "insert-intention" || l.Type == "next-key" {
+				gap = true
+			}
+			if l.Kind == "record" {
+				key := l.Table + "." + l.Index
+				if indexes[key] {
+					sameIndex = true
+				}
+				indexes[key] = true
+			}
+		}
+	}
+
+	switch {
+	case gap:
+		return "Gap or next-key locks are involved: they protect ranges under REPEATABLE READ, so concurrent inserts into or locking reads of the same range conflict. READ COMMITTED avoids most gap locks; otherwise lock the range in a consistent order or insert without a prior locking read."
+	case sameIndex:
+		return "The transactions lock the same rows in a different order. Access rows in a consistent order (e.g. by primary key) and keep transactions short."
+	default:
+		return "The transactions lock different indexes or tables in a different order. Access them in a consistent order and keep transactions short."
+	}
+}
+
+func derefLocks(l *Lock) []Lock {
+	if l == nil {
+		return nil
+	}
+	return []Lock{*l}
+}
+
+func oneLine(s string, max int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if len(s) > max {
+		s = display.Cut(s, max) + "..."
+	}
+	return s
+}
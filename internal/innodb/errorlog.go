@@ -0,0 +1,75 @@
+package innodb
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// LoggedDeadlock is a deadlock written to the error log, which the server does
+// for every deadlock when innodb_print_all_deadlocks is ON.
+type LoggedDeadlock struct {
+	Time time.Time `json:"time"`
+	Deadlock
+}
+
+var (
+	// "2024-05-14T09:58:41.123456Z 813 [Note] [MY-012469] [InnoDB] *** (1) TRANSACTION:"
+	// on 8.0 and later, "... [Note] InnoDB: *** (1) TRANSACTION:" on 5.7.
+	reLogLine         = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(?:\.\d+)?(?:Z|[+-]\d{2}:?\d{2})?) +\d+ \[\w+\] (?:\[MY-\d+\] \[InnoDB\] |InnoDB: )?(.*)$`)
+	reDeadlockStarted = regexp.MustCompile(`Transactions deadlock detected, dumping detailed information`)
+)
+
+// ParseErrorLog returns the deadlocks found in a MySQL error log read from r,
+// in the order they were logged. A deadlock cut by the end of the log is
+// dropped. The log is read a line at a time, never held in memory whole.
+func ParseErrorLog(r io.Reader) ([]LoggedDeadlock, error) {
+	var (
+		deadlocks []LoggedDeadlock
+		current   *strings.Builder
+		started   time.Time
+	)
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), "\r")
+
+		m := reLogLine.FindStringSubmatch(line)
+		if m != nil && reDeadlockStarted.MatchString(m[2]) {
+			current = &strings.Builder{}
+			started = parseLogTime(m[1])
+			continue
+		}
+		if current == nil {
+			continue
+		}
+
+		// Lines without the log prefix continue the current message.
+		if m != nil {
+			line = m[2]
+		}
+		current.WriteString(line)
+		current.WriteByte('\n')
+
+		if m != nil && reDeadlockVictim.MatchString(line) {
+			dl := parseDeadlock(current.String())
+			dl.Timestamp = started.Format(time.DateTime)
+			deadlocks = append(deadlocks, LoggedDeadlock{Time: started, Deadlock: *dl})
+			current = nil
+		}
+	}
+
+	return deadlocks, sc.Err()
+}
+
+func parseLogTime(s string) time.Time {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC()
+		}
+	}
+	return time.Time{}
+}
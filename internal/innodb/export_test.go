@@ -0,0 +1,3 @@
+package innodb
+
+var OneLine = oneLine
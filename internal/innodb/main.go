@@ -0,0 +1,294 @@
+// Package innodb parses the InnoDB monitor output returned by SHOW ENGINE
+// INNODB STATUS on MySQL 5.7 to 8.4.
+//
+// The output is split into its sections first and each section is parsed on
+// its own, so a line of one section is never mistaken for another. Expected
+// sections that are absent are listed in Status.Missing, and values of a
+// present section whose line was not recognised in Status.Unparsed, instead
+// of being silently reported as zero.
+package innodb
+
+import (
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Section titles as printed by InnoDB.
+const (
+	SectionBackgroundThread   = "BACKGROUND THREAD"
+	SectionSemaphores         = "SEMAPHORES"
+	SectionForeignKeyError    = "LATEST FOREIGN KEY ERROR"
+	SectionDeadlock           = "LATEST DETECTED DEADLOCK"
+	SectionTransactions       = "TRANSACTIONS"
+	SectionFileIO             = "FILE I/O"
+	SectionInsertBuffer       = "INSERT BUFFER AND ADAPTIVE HASH INDEX"
+	SectionLog                = "LOG"
+	SectionBufferPool         = "BUFFER POOL AND MEMORY"
+	SectionBufferPoolInstance = "INDIVIDUAL BUFFER POOL INFO"
+	SectionRowOperations      = "ROW OPERATIONS"
+
+	endOfOutput = "END OF INNODB MONITOR OUTPUT"
+)
+
+// expected are the sections every server prints; the others only appear
+// when there is something to report or more than one buffer pool instance.
+var expected = []string{
+	SectionBackgroundThread,
+	SectionSemaphores,
+	SectionTransactions,
+	SectionFileIO,
+	SectionInsertBuffer,
+	SectionLog,
+	SectionBufferPool,
+	SectionRowOperations,
+}
+
+type Status struct {
+	Timestamp string `json:"timestamp"`
+	// AveragedOverSeconds is the interval the per second rates are computed over.
+	AveragedOverSeconds int64            `json:"averaged_over_seconds"`
+	Semaphores          Semaphores       `json:"semaphores"`
+	ForeignKeyError     *ForeignKeyError `json:"latest_foreign_key_error,omitempty"`
+	Deadlock            *Deadlock        `json:"latest_deadlock,omitempty"`
+	Transactions        Transactions     `json:"transactions"`
+	FileIO              FileIO           `json:"file_io"`
+	Log                 Log              `json:"log"`
+	BufferPool          BufferPool       `json:"buffer_pool"`
+	RowOps              RowOperations    `json:"row_operations"`
+	// Missing lists the expected sections absent from the output.
+	Missing []string `json:"missing_sections,omitempty"`
+	// Unparsed lists the values whose line was not found in their section.
+	Unparsed []string `json:"unparsed_fields,omitempty"`
+	// Truncated is set when the output does not end with the end marker,
+	// e.g. because it exceeded the 1 MB limit of SHOW ENGINE INNODB STATUS.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+type Semaphores struct {
+	ReservationCount int64           `json:"reservation_count"`
+	SignalCount      int64           `json:"signal_count"`
+	RWSharedSpins    int64           `json:"rw_shared_spins"`
+	RWSharedOSWaits  int64           `json:"rw_shared_os_waits"`
+	RWExclSpins      int64           `json:"rw_excl_spins"`
+	RWExclOSWaits    int64           `json:"rw_excl_os_waits"`
+	RWSXSpins        int64           `json:"rw_sx_spins"`
+	RWSXOSWaits      int64           `json:"rw_sx_os_waits"`
+	Waits            []SemaphoreWait `json:"waits,omitempty"`
+}
+
+// SemaphoreWait is a thread waiting on a mutex or rw-latch. Long waits point
+// to internal contention; InnoDB crashes the server after 600 seconds.
+type SemaphoreWait struct {
+	Thread    string  `json:"thread"`
+	WaitingAt string  `json:"waiting_at"`
+	Seconds   float64 `json:"seconds"`
+	Latch     string  `json:"latch"`
+	CreatedAt string  `json:"created_at,omitempty"`
+}
+
+type ForeignKeyError struct {
+	Timestamp  string `json:"timestamp"`
+	Table      string `json:"table,omitempty"`
+	Constraint string `json:"constraint,omitempty"`
+	Query      string `json:"query,omitempty"`
+	Detail     string `json:"detail"`
+}
+
+type Transactions struct {
+	TrxIDCounter  int64 `json:"trx_id_counter"`
+	HistoryLength int64 `json:"history_list_length"`
+	Active        int   `json:"active"`
+	LockWaits     int   `json:"lock_waits"`
+}
+
+type FileIO struct {
+	ReadsPerSec     float64 `json:"reads_per_sec"`
+	WritesPerSec    float64 `json:"writes_per_sec"`
+	FsyncsPerSec    float64 `json:"fsyncs_per_sec"`
+	LogWritesPerSec float64 `json:"log_writes_per_sec"`
+}
+
+type Log struct {
+	SequenceNumber int64 `json:"sequence_number"`
+	FlushedUpTo    int64 `json:"flushed_up_to"`
+	LastCheckpoint int64 `json:"last_checkpoint"`
+}
+
+// BufferPoolStats are the counters printed for the whole buffer pool and
+// again for each instance.
+type BufferPoolStats struct {
+	SizePages     int64   `json:"size_pages"`
+	FreePages     int64   `json:"free_pages"`
+	DatabasePages int64   `json:"database_pages"`
+	ModifiedPages int64   `json:"modified_pages"`
+	HitRatePct    float64 `json:"hit_rate_pct"`
+	ReadsPerSec   float64 `json:"reads_per_sec"`
+	WritesPerSec  float64 `json:"writes_per_sec"`
+}
+
+type BufferPool struct {
+	BufferPoolStats
+	Instances []BufferPoolInstance `json:"instances,omitempty"`
+}
+
+type BufferPoolInstance struct {
+	ID int `json:"id"`
+	BufferPoolStats
+}
+
+type RowOperations struct {
+	QueriesInsideInnoDB int64   `json:"queries_inside_innodb"`
+	QueriesInQueue      int64   `json:"queries_in_queue"`
+	InsertsPerSec       float64 `json:"inserts_per_sec"`
+	UpdatesPerSec       float64 `json:"updates_per_sec"`
+	DeletesPerSec       float64 `json:"deletes_per_sec"`
+	ReadsPerSec         float64 `json:"reads_per_sec"`
+}
+
+var (
+	reRule      = regexp.MustCompile(`^(-{3,}|={3,})$`)
+	reTitle     = regexp.MustCompile(`^[A-Z][A-Z /]*[A-Z]$`)
+	reTimestamp = regexp.MustCompile(`(\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}) \S+ INNODB MONITOR OUTPUT`)
+	reAveraged  = regexp.MustCompile(`Per second averages calculated from the last (\d+) seconds`)
+)
+
+// Parse parses the Status column of SHOW ENGINE INNODB STATUS.
+func Parse(raw string) Status {
+	raw = strings.ReplaceAll(raw, "\r\n", "\n")
+
+	out := Status{}
+	if m := reTimestamp.FindStringSubmatch(raw); m != nil {
+		out.Timestamp = m[1]
+	}
+	if m := reAveraged.FindStringSubmatch(raw); m != nil {
+		out.AveragedOverSeconds = parseInt64(m[1])
+	}
+
+	sections, ended := split(raw)
+	out.Truncated = !ended
+	for _, name := range expected {
+		if _, ok := sections[name]; !ok {
+			out.Missing = append(out.Missing, name)
+		}
+	}
+
+	p := &parser{out: &out}
+	if s, ok := sections[SectionSemaphores]; ok {
+		p.semaphores(s)
+	}
+	if s, ok := sections[SectionForeignKeyError]; ok {
+		out.ForeignKeyError = parseForeignKeyError(s)
+	}
+	if s, ok := sections[SectionDeadlock]; ok {
+		out.Deadlock = parseDeadlock(s)
+	}
+	if s, ok := sections[SectionTransactions]; ok {
+		p.transactions(s)
+	}
+	if s, ok := sections[SectionFileIO]; ok {
+		p.fileIO(s)
+	}
+	if s, ok := sections[SectionLog]; ok {
+		p.log(s)
+	}
+	if s, ok := sections[SectionBufferPool]; ok {
+		out.BufferPool.BufferPoolStats = p.bufferPool("buffer_pool", s)
+	}
+	if s, ok := sections[SectionBufferPoolInstance]; ok {
+		out.BufferPool.Instances = parseBufferPoolInstances(s)
+	}
+	if s, ok := sections[SectionRowOperations]; ok {
+		p.rowOperations(s)
+	}
+
+	return out
+}
+
+// fieldSections maps the prefix of a field name to the section it is read
+// from.
+var fieldSections = map[string]string{
+	"semaphores":                 SectionSemaphores,
+	"transactions":               SectionTransactions,
+	"file_io":                    SectionFileIO,
+	"file_io.log_writes_per_sec": SectionLog,
+	"log":                        SectionLog,
+	"buffer_pool":                SectionBufferPool,
+	"row_operations":             SectionRowOperations,
+}
+
+// Found reports whether a field, named as in Unparsed (e.g.
+// "transactions.history_list_length"), was read from the output. A zero
+// value of a field not found is not a measurement.
+func (s Status) Found(field string) bool {
+	if slices.Contains(s.Unparsed, field) {
+		return false
+	}
+	section, ok := fieldSections[field]
+	if !ok {
+		prefix, _, _ := strings.Cut(field, ".")
+		section = fieldSections[prefix]
+	}
+	return !slices.Contains(s.Missing, section)
+}
+
+// split returns the body of each section by title, and whether the end
+// marker was found. A section header is a title line between two rules.
+func split(raw string) (map[string]string, bool) {
+	lines := strings.Split(raw, "\n")
+	sections := map[string]string{}
+
+	title := ""
+	start := 0
+	flush := func(end int) {
+		if title != "" {
+			sections[title] = strings.Join(lines[start:end], "\n")
+		}
+	}
+
+	for i := 0; i+2 < len(lines); i++ {
+		if !reRule.MatchString(lines[i]) || !reRule.MatchString(lines[i+2]) {
+			continue
+		}
+		name := strings.TrimSpace(lines[i+1])
+		if !reTitle.MatchString(name) {
+			continue
+		}
+
+		flush(i)
+		if name == endOfOutput {
+			return sections, true
+		}
+		title = name
+		start = i + 3
+		i += 2
+	}
+	flush(len(lines))
+
+	return sections, false
+}
+
+// parser keeps track of the values that could not be found.
+type parser struct {
+	out *Status
+}
+
+// find matches re in text and records field as unparsed when it does not.
+func (p *parser) find(field string, re *regexp.Regexp, text string) []string {
+	m := re.FindStringSubmatch(text)
+	if m == nil {
+		p.out.Unparsed = append(p.out.Unparsed, field)
+	}
+	return m
+}
+
+func parseInt64(s string) int64 {
+	n, _ := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	return n
+}
+
+func parseFloat64(s string) float64 {
+	f, _ := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	return f
+}
@@ -0,0 +1,187 @@
+package innodb_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/nicola-strappazzon/argos/internal/innodb"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files")
+
+// TestGolden parses every testdata/*.txt and compares the result with the
+// .golden.json next to it. Run with -update after a deliberate change.
+func TestGolden(t *testing.T) {
+	inputs, err := filepath.Glob("testdata/*.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) == 0 {
+		t.Fatal("no testdata")
+	}
+
+	for _, input := range inputs {
+		name := strings.TrimSuffix(filepath.Base(input), ".txt")
+		t.Run(name, func(t *testing.T) {
+			raw, err := os.ReadFile(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := json.MarshalIndent(innodb.Parse(string(raw)), "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, '\n')
+
+			golden := strings.TrimSuffix(input, ".txt") + ".golden.json"
+			if *update {
+				if err := os.WriteFile(golden, got, 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v (run go test -update to create it)", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("%s differs from the parsed output:\n%s", golden, got)
+			}
+		})
+	}
+}
+
+func TestMissingSections(t *testing.T) {
+	got := innodb.Parse("")
+
+	if !got.Truncated {
+		t.Error("empty output should be reported as truncated")
+	}
+	if len(got.Missing) != 8 {
+		t.Errorf("expected every section missing, got %v", got.Missing)
+	}
+	if len(got.Unparsed) != 0 {
+		t.Errorf("missing sections should not report their fields, got %v", got.Unparsed)
+	}
+}
+
+func TestUnparsedFields(t *testing.T) {
+	raw := "------------\nTRANSACTIONS\n------------\nHistory list length 12\n" +
+		"----------------------------\nEND OF INNODB MONITOR OUTPUT\n============================\n"
+	got := innodb.Parse(raw)
+
+	if got.Truncated {
+		t.Error("output with the end marker is not truncated")
+	}
+	if got.Transactions.HistoryLength != 12 {
+		t.Errorf("history list length: got %d", got.Transactions.HistoryLength)
+	}
+	if len(got.Unparsed) != 1 || got.Unparsed[0] != "transactions.trx_id_counter" {
+		t.Errorf("expected the trx id counter unparsed, got %v", got.Unparsed)
+	}
+}
+
+func TestFound(t *testing.T) {
+	raw := "------------\nTRANSACTIONS\n------------\nHistory list length 12\n" +
+		"---\nLOG\n---\n0.00 log i/o's/second\n"
+	got := innodb.Parse(raw)
+
+	for field, want := range map[string]bool{
+		"transactions.history_list_length": true,
+		"transactions.trx_id_counter":      false,
+		"file_io.log_writes_per_sec":       true,
+		"file_io.rates":                    false,
+		"buffer_pool.size_pages":           false,
+	} {
+		if got.Found(field) != want {
+			t.Errorf("Found(%q) = %v, want %v", field, !want, want)
+		}
+	}
+}
+
+// TestLogSequenceNumber checks both layouts of the LOG section: MySQL 5.7
+// separates the label from the value with one space, 8.0 pads it.
+func TestLogSequenceNumber(t *testing.T) {
+	for _, line := range []string{
+		"Log sequence number 412998317",
+		"Log sequence number          412998317",
+	} {
+		got := innodb.Parse("---\nLOG\n---\n" + line + "\n")
+		if got.Log.SequenceNumber != 412998317 || !got.Found("log.sequence_number") {
+			t.Errorf("%q: got %d", line, got.Log.SequenceNumber)
+		}
+	}
+}
+
+func TestOneLine(t *testing.T) {
+	for _, tt := range []struct {
+		in   string
+		max  int
+		want string
+	}{
+		{"SELECT *\n  FROM t", 20, "SELECT * FROM t"},
+		{"SELECT 'abcdef'", 10, "SELECT 'ab..."},
+		// "é" takes bytes 8 and 9: it is dropped rather than split.
+		{"SELECT 'é'", 9, "SELECT '..."},
+		{"UPDATE t SET n = '日本語'", 21, "UPDATE t SET n = '日..."},
+	} {
+		got := innodb.OneLine(tt.in, tt.max)
+		if got != tt.want || !utf8.ValidString(got) {
+			t.Errorf("OneLine(%q, %d) = %q, want %q", tt.in, tt.max, got, tt.want)
+		}
+	}
+}
+
+// TestParseErrorLog checks that a deadlock read from the error log parses the
+// same as the one in SHOW ENGINE INNODB STATUS it was taken from.
+func TestParseErrorLog(t *testing.T) {
+	tests := []struct {
+		log, status string
+		count       int
+		first       string
+	}{
+		// The third deadlock of error-8.0.log is cut by the end of the log.
+		{"error-8.0.log", "mysql-8.0.txt", 2, "2024-05-14T09:58:41.123456Z"},
+		{"error-5.7.log", "mysql-5.7.txt", 1, "2024-05-14T10:19:52.004521Z"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.log, func(t *testing.T) {
+			log, err := os.ReadFile(filepath.Join("testdata", tt.log))
+			if err != nil {
+				t.Fatal(err)
+			}
+			status, err := os.ReadFile(filepath.Join("testdata", tt.status))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := innodb.ParseErrorLog(bytes.NewReader(log))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != tt.count {
+				t.Fatalf("expected %d deadlocks, got %d", tt.count, len(got))
+			}
+			if ts := got[0].Time.Format(time.RFC3339Nano); ts != tt.first {
+				t.Errorf("expected the first deadlock at %s, got %s", tt.first, ts)
+			}
+
+			want := *innodb.Parse(string(status)).Deadlock
+			want.Timestamp = got[0].Timestamp
+			if !reflect.DeepEqual(got[0].Deadlock, want) {
+				t.Errorf("logged deadlock differs from the status one:\n got %+v\nwant %+v", got[0].Deadlock, want)
+			}
+		})
+	}
+}
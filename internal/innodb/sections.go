@@ -0,0 +1,221 @@
+package innodb
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	reReservation   = regexp.MustCompile(`OS WAIT ARRAY INFO: reservation count (\d+)`)
+	reSignal        = regexp.MustCompile(`OS WAIT ARRAY INFO: signal count (\d+)`)
+	reRWShared      = regexp.MustCompile(`RW-shared spins (\d+), rounds \d+, OS waits (\d+)`)
+	reRWExcl        = regexp.MustCompile(`RW-excl spins (\d+), rounds \d+, OS waits (\d+)`)
+	reRWSX          = regexp.MustCompile(`RW-sx spins (\d+), rounds \d+, OS waits (\d+)`)
+	reSemaphoreWait = regexp.MustCompile(`--Thread (\d+) has waited at (\S+) line (\d+) for ([\d.]+) seconds the semaphore:\n([^\n]*)`)
+	reLatch         = regexp.MustCompile(`^(.+?) at 0x[0-9a-fA-F]+ created (?:in )?file (\S+) line (\d+)`)
+	reMutex         = regexp.MustCompile(`^Mutex at 0x[0-9a-fA-F]+, Mutex (\S+) created (\S+?),`)
+
+	reFKTimestamp  = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2})`)
+	reFKTable      = regexp.MustCompile(`(?:Foreign key constraint fails for table|Error in foreign key constraint of table) (\S+?):?\n`)
+	reFKConstraint = regexp.MustCompile("CONSTRAINT `([^`]+)`")
+
+	reTxQuery = regexp.MustCompile(`MySQL thread id \d+[^\n]*\n([^\n]+)`)
+
+	reTrxIDCounter  = regexp.MustCompile(`Trx id counter (\d+)`)
+	reHistoryLength = regexp.MustCompile(`History list length (\d+)`)
+	reActiveTrx     = regexp.MustCompile(`(?m)^---TRANSACTION \d+, ACTIVE`)
+	reLockWait      = regexp.MustCompile(`(?m)^LOCK WAIT `)
+
+	reFileIORate = regexp.MustCompile(`([\d.]+) reads/s, [\d.]+ avg bytes/read, ([\d.]+) writes/s, ([\d.]+) fsyncs/s`)
+
+	reLogSeq     = regexp.MustCompile(`Log sequence number\s+(\d+)`)
+	reLogFlushed = regexp.MustCompile(`Log flushed up to\s+(\d+)`)
+	reCheckpoint = regexp.MustCompile(`Last checkpoint at\s+(\d+)`)
+	reLogIORate  = regexp.MustCompile(`([\d.]+) log i/o's/second`)
+
+	reBPSize     = regexp.MustCompile(`(?m)^Buffer pool size\s+(\d+)`)
+	reBPFree     = regexp.MustCompile(`(?m)^Free buffers\s+(\d+)`)
+	reBPDatabase = regexp.MustCompile(`(?m)^Database pages\s+(\d+)`)
+	reBPModified = regexp.MustCompile(`(?m)^Modified db pages\s+(\d+)`)
+	reBPHitRate  = regexp.MustCompile(`Buffer pool hit rate (\d+) / (\d+)`)
+	reBPRWRate   = regexp.MustCompile(`([\d.]+) reads/s, ([\d.]+) creates/s, ([\d.]+) writes/s`)
+	reBPInstance = regexp.MustCompile(`(?m)^---BUFFER POOL (\d+)$`)
+
+	reQueriesInside = regexp.MustCompile(`(\d+) queries inside InnoDB, (\d+) queries in queue`)
+	reRowOpsRate    = regexp.MustCompile(`([\d.]+) inserts/s, ([\d.]+) updates/s, ([\d.]+) deletes/s, ([\d.]+) reads/s`)
+)
+
+// noPageGets replaces the hit rate line when the buffer pool was idle over
+// the averaging interval.
+const noPageGets = "No buffer pool page gets since the last printout"
+
+func (p *parser) semaphores(s string) {
+	sem := &p.out.Semaphores
+	if m := p.find("semaphores.reservation_count", reReservation, s); m != nil {
+		sem.ReservationCount = parseInt64(m[1])
+	}
+	if m := p.find("semaphores.signal_count", reSignal, s); m != nil {
+		sem.SignalCount = parseInt64(m[1])
+	}
+	if m := p.find("semaphores.rw_shared", reRWShared, s); m != nil {
+		sem.RWSharedSpins = parseInt64(m[1])
+		sem.RWSharedOSWaits = parseInt64(m[2])
+	}
+	if m := p.find("semaphores.rw_excl", reRWExcl, s); m != nil {
+		sem.RWExclSpins = parseInt64(m[1])
+		sem.RWExclOSWaits = parseInt64(m[2])
+	}
+	// Older servers have no RW-sx latches, so the line is optional.
+	if m := reRWSX.FindStringSubmatch(s); m != nil {
+		sem.RWSXSpins = parseInt64(m[1])
+		sem.RWSXOSWaits = parseInt64(m[2])
+	}
+
+	for _, m := range reSemaphoreWait.FindAllStringSubmatch(s, -1) {
+		w := SemaphoreWait{
+			Thread:    m[1],
+			WaitingAt: m[2] + ":" + m[3],
+			Seconds:   parseFloat64(m[4]),
+			Latch:     strings.TrimSpace(m[5]),
+		}
+		// "S-lock on RW-latch at 0x... created in file dict0dict.cc line 1183"
+		// or "Mutex at 0x..., Mutex FLUSH_LIST created buf0buf.cc:1460, lock var 1".
+		if l := reLatch.FindStringSubmatch(m[5]); l != nil {
+			w.Latch = l[1]
+			w.CreatedAt = l[2] + ":" + l[3]
+		} else if l := reMutex.FindStringSubmatch(m[5]); l != nil {
+			w.Latch = "Mutex " + l[1]
+			w.CreatedAt = l[2]
+		}
+		sem.Waits = append(sem.Waits, w)
+	}
+}
+
+func parseForeignKeyError(s string) *ForeignKeyError {
+	fk := &ForeignKeyError{Detail: strings.TrimSpace(s)}
+	if m := reFKTimestamp.FindStringSubmatch(fk.Detail); m != nil {
+		fk.Timestamp = m[1]
+	}
+	if m := reFKTable.FindStringSubmatch(s); m != nil {
+		fk.Table = m[1]
+	}
+	if m := reFKConstraint.FindStringSubmatch(s); m != nil {
+		fk.Constraint = m[1]
+	}
+	if m := reTxQuery.FindStringSubmatch(s); m != nil {
+		fk.Query = strings.TrimSpace(m[1])
+	}
+	return fk
+}
+
+func (p *parser) transactions(s string) {
+	trx := &p.out.Transactions
+	if m := p.find("transactions.trx_id_counter", reTrxIDCounter, s); m != nil {
+		trx.TrxIDCounter = parseInt64(m[1])
+	}
+	if m := p.find("transactions.history_list_length", reHistoryLength, s); m != nil {
+		trx.HistoryLength = parseInt64(m[1])
+	}
+	trx.Active = len(reActiveTrx.FindAllStringIndex(s, -1))
+	trx.LockWaits = len(reLockWait.FindAllStringIndex(s, -1))
+}
+
+func (p *parser) fileIO(s string) {
+	if m := p.find("file_io.rates", reFileIORate, s); m != nil {
+		p.out.FileIO.ReadsPerSec = parseFloat64(m[1])
+		p.out.FileIO.WritesPerSec = parseFloat64(m[2])
+		p.out.FileIO.FsyncsPerSec = parseFloat64(m[3])
+	}
+}
+
+func (p *parser) log(s string) {
+	l := &p.out.Log
+	if m := p.find("log.sequence_number", reLogSeq, s); m != nil {
+		l.SequenceNumber = parseInt64(m[1])
+	}
+	if m := p.find("log.flushed_up_to", reLogFlushed, s); m != nil {
+		l.FlushedUpTo = parseInt64(m[1])
+	}
+	if m := p.find("log.last_checkpoint", reCheckpoint, s); m != nil {
+		l.LastCheckpoint = parseInt64(m[1])
+	}
+	// The log write rate is printed in the LOG section, not in FILE I/O.
+	if m := p.find("file_io.log_writes_per_sec", reLogIORate, s); m != nil {
+		p.out.FileIO.LogWritesPerSec = parseFloat64(m[1])
+	}
+}
+
+// bufferPool parses the counters of the whole buffer pool. Values not found
+// are recorded under prefix.
+func (p *parser) bufferPool(prefix, s string) BufferPoolStats {
+	var bp BufferPoolStats
+	if m := p.find(prefix+".size_pages", reBPSize, s); m != nil {
+		bp.SizePages = parseInt64(m[1])
+	}
+	if m := p.find(prefix+".free_pages", reBPFree, s); m != nil {
+		bp.FreePages = parseInt64(m[1])
+	}
+	if m := p.find(prefix+".database_pages", reBPDatabase, s); m != nil {
+		bp.DatabasePages = parseInt64(m[1])
+	}
+	if m := p.find(prefix+".modified_pages", reBPModified, s); m != nil {
+		bp.ModifiedPages = parseInt64(m[1])
+	}
+	if !strings.Contains(s, noPageGets) {
+		if m := p.find(prefix+".hit_rate_pct", reBPHitRate, s); m != nil {
+			bp.HitRatePct = hitRate(m)
+		}
+	}
+	if m := p.find(prefix+".rates", reBPRWRate, s); m != nil {
+		bp.ReadsPerSec = parseFloat64(m[1])
+		bp.WritesPerSec = parseFloat64(m[3])
+	}
+	return bp
+}
+
+// parseBufferPoolInstances parses the INDIVIDUAL BUFFER POOL INFO section,
+// printed when innodb_buffer_pool_instances is greater than one.
+func parseBufferPoolInstances(s string) []BufferPoolInstance {
+	headers := reBPInstance.FindAllStringSubmatchIndex(s, -1)
+
+	instances := make([]BufferPoolInstance, 0, len(headers))
+	for i, h := range headers {
+		end := len(s)
+		if i+1 < len(headers) {
+			end = headers[i+1][0]
+		}
+		id, _ := strconv.Atoi(s[h[2]:h[3]])
+
+		// Instances are not reported as unparsed: the totals already are.
+		p := &parser{out: &Status{}}
+		instances = append(instances, BufferPoolInstance{
+			ID:              id,
+			BufferPoolStats: p.bufferPool("", s[h[1]:end]),
+		})
+	}
+	return instances
+}
+
+func hitRate(m []string) float64 {
+	total := parseInt64(m[2])
+	if total == 0 {
+		return 0
+	}
+	return float64(parseInt64(m[1])) / float64(total) * 100
+}
+
+func (p *parser) rowOperations(s string) {
+	r := &p.out.RowOps
+	if m := p.find("row_operations.queries", reQueriesInside, s); m != nil {
+		r.QueriesInsideInnoDB = parseInt64(m[1])
+		r.QueriesInQueue = parseInt64(m[2])
+	}
+	// The first rates line is for user tables; 8.0 adds one for system tables.
+	if m := p.find("row_operations.rates", reRowOpsRate, s); m != nil {
+		r.InsertsPerSec = parseFloat64(m[1])
+		r.UpdatesPerSec = parseFloat64(m[2])
+		r.DeletesPerSec = parseFloat64(m[3])
+		r.ReadsPerSec = parseFloat64(m[4])
+	}
+}
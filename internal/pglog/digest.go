@@ -0,0 +1,534 @@
+package pglog
+
+import (
+	"cmp"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nicola-strappazzon/argos/internal/digest"
+	"github.com/nicola-strappazzon/argos/internal/display"
+	"github.com/nicola-strappazzon/argos/internal/sqlguard"
+)
+
+// decimals is the precision of the durations, in milliseconds:
+// microseconds.
+const decimals = 3
+
+// maxBuckets is the most buckets a timeline has: past it, the interval is
+// made coarser.
+const maxBuckets = 1000
+
+// Filter selects the messages to analyze. Zero values select everything.
+// Database only excludes messages of another database: those of background
+// processes, such as checkpoints, have none and are kept.
+type Filter = digest.Filter
+
+func match(f Filter, e Entry) bool {
+	if !f.InRange(e.Time) {
+		return false
+	}
+	if f.Database != "" && e.Database != "" && e.Database != f.Database {
+		return false
+	}
+	return true
+}
+
+// Stats summarizes the durations of a class, in milliseconds.
+type Stats = digest.Stats
+
+// Class is the digest of the statements sharing a fingerprint.
+type Class struct {
+	digest.Class
+	Duration Stats `json:"duration_ms"`
+	// SampleDuration is the duration of Sample.
+	SampleDuration float64 `json:"sample_duration_ms"`
+
+	durations []float64
+}
+
+// ErrorClass gathers the errors with the same message, numbers and quoted
+// values aside.
+type ErrorClass struct {
+	Level     string `json:"level"`
+	SQLState  string `json:"sqlstate,omitempty"`
+	Message   string `json:"message"`
+	Count     int    `json:"count"`
+	FirstSeen string `json:"first_seen"`
+	LastSeen  string `json:"last_seen"`
+	// Sample is the first message of the class, with its statement.
+	Sample          string `json:"sample"`
+	SampleStatement string `json:"sample_statement,omitempty"`
+
+	first, last time.Time
+}
+
+// Vacuum is an automatic vacuum or analyze logged by
+// log_autovacuum_min_duration.
+type Vacuum struct {
+	Time string `json:"time"`
+	// Kind is "vacuum" or "analyze".
+	Kind          string  `json:"kind"`
+	Table         string  `json:"table"`
+	Wraparound    bool    `json:"to_prevent_wraparound,omitempty"`
+	ElapsedSec    float64 `json:"elapsed_sec"`
+	TuplesRemoved int64   `json:"tuples_removed,omitempty"`
+}
+
+// Checkpoint is a completed checkpoint, or restartpoint on a replica,
+// logged by log_checkpoints.
+type Checkpoint struct {
+	Time string `json:"time"`
+	// Reason is what started it: time, wal, immediate force wait...
+	Reason     string  `json:"reason,omitempty"`
+	Buffers    int64   `json:"buffers"`
+	WriteSec   float64 `json:"write_sec"`
+	SyncSec    float64 `json:"sync_sec"`
+	TotalSec   float64 `json:"total_sec"`
+	DistanceKB int64   `json:"distance_kb,omitempty"`
+}
+
+// LockWait is a wait logged by log_lock_waits, updated when the lock is
+// acquired.
+type LockWait struct {
+	Time   string  `json:"time"`
+	PID    int     `json:"pid"`
+	Mode   string  `json:"mode"`
+	Object string  `json:"object"`
+	WaitMs float64 `json:"wait_ms"`
+	// Acquired is set once the lock was granted; WaitMs is then the whole
+	// wait.
+	Acquired  bool   `json:"acquired"`
+	Blockers  []int  `json:"blockers,omitempty"`
+	Database  string `json:"database,omitempty"`
+	Statement string `json:"statement,omitempty"`
+}
+
+// CheckpointSummary sums up the checkpoints of the log.
+type CheckpointSummary struct {
+	Count       int            `json:"count"`
+	Reasons     map[string]int `json:"reasons"`
+	Buffers     int64          `json:"buffers"`
+	AvgTotalSec float64        `json:"avg_total_sec"`
+	MaxTotalSec float64        `json:"max_total_sec"`
+	// Warnings counts "checkpoints are occurring too frequently".
+	Warnings int `json:"too_frequent_warnings"`
+}
+
+// Bucket counts the events of a minute, an hour or a day.
+type Bucket struct {
+	Start       string  `json:"start"`
+	Queries     int     `json:"queries"`
+	DurationMs  float64 `json:"duration_ms"`
+	Errors      int     `json:"errors"`
+	LockWaits   int     `json:"lock_waits"`
+	Autovacuums int     `json:"autovacuums"`
+	Checkpoints int     `json:"checkpoints"`
+}
+
+var (
+	reDuration   = regexp.MustCompile(`(?s)^duration: ([\d.]+) ms\s+(?:statement|execute [^:]*):\s*(.*)$`)
+	reVacuum     = regexp.MustCompile(`^automatic (?:aggressive )?(vacuum|analyze)( to prevent wraparound)? of table "([^"]+)"`)
+	reElapsed    = regexp.MustCompile(`elapsed:? ([\d.]+) s`)
+	reTuples     = regexp.MustCompile(`tuples: (\d+) removed`)
+	reCkptStart  = regexp.MustCompile(`^(?:checkpoint|restartpoint) starting: (.*)$`)
+	reCkptDone   = regexp.MustCompile(`^(?:checkpoint|restartpoint) complete: wrote (\d+) buffers.*?write=([\d.]+) s, sync=([\d.]+) s, total=([\d.]+) s`)
+	reDistance   = regexp.MustCompile(`distance=(\d+) kB`)
+	reLockWait   = regexp.MustCompile(`^process (\d+) (still waiting for|acquired) (\S+) on (.+?) after ([\d.]+) ms`)
+	reBlockers   = regexp.MustCompile(`Process(?:es)? holding the lock: ([\d, ]+)\.`)
+	reSingleQuot = regexp.MustCompile(`'(?:[^']|'')*'`)
+	reNumber     = regexp.MustCompile(`\b\d+(?:\.\d+)?\b`)
+)
+
+type event struct {
+	time     time.Time
+	kind     string
+	duration float64
+}
+
+// Digest analyzes the messages of a log.
+type Digest struct {
+	Filter Filter
+
+	// Entries counts the messages analyzed, Skipped those the filter
+	// excluded.
+	Entries  int
+	Skipped  int
+	Queries  int
+	Duration float64
+	First    time.Time
+	Last     time.Time
+
+	Deadlocks   int
+	Errors      int
+	Vacuums     []Vacuum
+	Checkpoints []Checkpoint
+	LockWaits   []LockWait
+	Warnings    int
+
+	classes map[string]*Class
+	errors  map[string]*ErrorClass
+	// reasons is the reason of the checkpoint in progress, per process.
+	reasons map[int]string
+	// waiting indexes the lock waits not acquired yet.
+	waiting map[string]int
+	events  []event
+}
+
+func NewDigest(f Filter) *Digest {
+	return &Digest{
+		Filter:  f,
+		classes: map[string]*Class{},
+		errors:  map[string]*ErrorClass{},
+		reasons: map[int]string{},
+		waiting: map[string]int{},
+	}
+}
+
+// Add analyzes a message, unless the filter excludes it.
+func (d *Digest) Add(e Entry) {
+	if !match(d.Filter, e) {
+		d.Skipped++
+		return
+	}
+	d.Entries++
+	if !e.Time.IsZero() {
+		if d.First.IsZero() || e.Time.Before(d.First) {
+			d.First = e.Time
+		}
+		if e.Time.After(d.Last) {
+			d.Last = e.Time
+		}
+	}
+
+	switch e.Level {
+	case "ERROR", "FATAL", "PANIC":
+		d.addError(e)
+		return
+	case "LOG":
+	default:
+		return
+	}
+
+	if m := reDuration.FindStringSubmatch(e.Message); m != nil {
+		ms, _ := strconv.ParseFloat(m[1], 64)
+		d.addQuery(e, ms, strings.TrimSpace(m[2]))
+		return
+	}
+
+	if m := reVacuum.FindStringSubmatch(e.Message); m != nil {
+		if d.Filter.Database != "" && !strings.HasPrefix(m[3], d.Filter.Database+".") {
+			d.Entries--
+			d.Skipped++
+			return
+		}
+		v := Vacuum{Time: digest.Format(e.Time), Kind: m[1], Table: m[3], Wraparound: m[2] != ""}
+		if m := reElapsed.FindStringSubmatch(e.Message); m != nil {
+			v.ElapsedSec, _ = strconv.ParseFloat(m[1], 64)
+		}
+		if m := reTuples.FindStringSubmatch(e.Message); m != nil {
+			v.TuplesRemoved, _ = strconv.ParseInt(m[1], 10, 64)
+		}
+		d.Vacuums = append(d.Vacuums, v)
+		d.event(e.Time, "autovacuum", 0)
+		return
+	}
+
+	if m := reCkptStart.FindStringSubmatch(e.Message); m != nil {
+		d.reasons[e.PID] = strings.TrimSpace(m[1])
+		return
+	}
+	if m := reCkptDone.FindStringSubmatch(e.Message); m != nil {
+		c := Checkpoint{Time: digest.Format(e.Time), Reason: d.reasons[e.PID]}
+		c.Buffers, _ = strconv.ParseInt(m[1], 10, 64)
+		c.WriteSec, _ = strconv.ParseFloat(m[2], 64)
+		c.SyncSec, _ = strconv.ParseFloat(m[3], 64)
+		c.TotalSec, _ = strconv.ParseFloat(m[4], 64)
+		if m := reDistance.FindStringSubmatch(e.Message); m != nil {
+			c.DistanceKB, _ = strconv.ParseInt(m[1], 10, 64)
+		}
+		delete(d.reasons, e.PID)
+		d.Checkpoints = append(d.Checkpoints, c)
+		d.event(e.Time, "checkpoint", 0)
+		return
+	}
+	if strings.HasPrefix(e.Message, "checkpoints are occurring too frequently") {
+		d.Warnings++
+		return
+	}
+
+	if m := reLockWait.FindStringSubmatch(e.Message); m != nil {
+		d.addLockWait(e, m)
+	}
+}
+
+func (d *Digest) addQuery(e Entry, ms float64, query string) {
+	fp := sqlguard.Fingerprint(sqlguard.PostgreSQL, query)
+	c, ok := d.classes[fp]
+	if !ok {
+		c = &Class{Class: digest.Class{Fingerprint: fp}}
+		d.classes[fp] = c
+	}
+
+	c.Add(e.Time, e.Database, e.User, query, ms)
+	c.durations = append(c.durations, ms)
+
+	d.Queries++
+	d.Duration += ms
+	d.event(e.Time, "query", ms)
+}
+
+func (d *Digest) addError(e Entry) {
+	d.Errors++
+	if strings.HasPrefix(e.Message, "deadlock detected") {
+		d.Deadlocks++
+	}
+
+	message := reNumber.ReplaceAllString(reSingleQuot.ReplaceAllString(e.Message, "?"), "?")
+	if i := strings.IndexByte(message, '\n'); i >= 0 {
+		message = message[:i]
+	}
+	key := e.Level + "\x00" + e.SQLState + "\x00" + message
+
+	c, ok := d.errors[key]
+	if !ok {
+		c = &ErrorClass{Level: e.Level, SQLState: e.SQLState, Message: message, Sample: e.Message, SampleStatement: e.Statement, first: e.Time}
+		d.errors[key] = c
+	}
+	c.Count++
+	if e.Time.Before(c.first) {
+		c.first = e.Time
+	}
+	if e.Time.After(c.last) {
+		c.last = e.Time
+	}
+
+	d.event(e.Time, "error", 0)
+}
+
+func (d *Digest) addLockWait(e Entry, m []string) {
+	pid, _ := strconv.Atoi(m[1])
+	ms, _ := strconv.ParseFloat(m[5], 64)
+	key := m[1] + "\x00" + m[3] + "\x00" + m[4]
+
+	if m[2] == "acquired" {
+		if i, ok := d.waiting[key]; ok {
+			d.LockWaits[i].Acquired = true
+			d.LockWaits[i].WaitMs = ms
+			delete(d.waiting, key)
+		}
+		return
+	}
+
+	w := LockWait{
+		Time:      digest.Format(e.Time),
+		PID:       pid,
+		Mode:      m[3],
+		Object:    m[4],
+		WaitMs:    ms,
+		Database:  e.Database,
+		Statement: e.Statement,
+	}
+	if b := reBlockers.FindStringSubmatch(e.Detail); b != nil {
+		for _, s := range strings.Split(b[1], ",") {
+			if n, err := strconv.Atoi(strings.TrimSpace(s)); err == nil {
+				w.Blockers = append(w.Blockers, n)
+			}
+		}
+	}
+
+	d.waiting[key] = len(d.LockWaits)
+	d.LockWaits = append(d.LockWaits, w)
+	d.event(e.Time, "lock_wait", 0)
+}
+
+func (d *Digest) event(t time.Time, kind string, duration float64) {
+	if !t.IsZero() {
+		d.events = append(d.events, event{t, kind, duration})
+	}
+}
+
+// Order names the metric classes are ranked by.
+const (
+	ByTotalTime = digest.ByTotalTime
+	ByAvgTime   = digest.ByAvgTime
+	ByMaxTime   = "max_time"
+	ByCount     = digest.ByCount
+)
+
+var orders = digest.Orders[*Class]{
+	ByTotalTime: func(c *Class) float64 { return c.Duration.Total },
+	ByAvgTime:   func(c *Class) float64 { return c.Duration.Avg },
+	ByMaxTime:   func(c *Class) float64 { return c.Duration.Max },
+	ByCount:     func(c *Class) float64 { return float64(c.Count) },
+}
+
+// Orders returns the valid orders, for input schemas.
+func Orders() []string {
+	return []string{ByTotalTime, ByAvgTime, ByMaxTime, ByCount}
+}
+
+// Unique returns the number of fingerprints.
+func (d *Digest) Unique() int {
+	return len(d.classes)
+}
+
+// Classes returns the classes ranked by order (total_time when unknown),
+// at most limit of them when limit is positive.
+func (d *Digest) Classes(order string, limit int) []Class {
+	all := make([]*Class, 0, len(d.classes))
+	for _, c := range d.classes {
+		c.Duration = digest.Summarize(c.durations, decimals)
+		c.SampleDuration = c.Longest()
+		c.Finish(c.Duration.Total, d.Duration, decimals)
+		all = append(all, c)
+	}
+
+	out := []Class{}
+	for _, c := range orders.Rank(all, order, limit) {
+		out = append(out, *c)
+	}
+	return out
+}
+
+// ErrorClasses returns the most frequent errors, at most limit of them
+// when limit is positive.
+func (d *Digest) ErrorClasses(limit int) []ErrorClass {
+	all := make([]ErrorClass, 0, len(d.errors))
+	for _, c := range d.errors {
+		c.FirstSeen = digest.Format(c.first)
+		c.LastSeen = digest.Format(c.last)
+		all = append(all, *c)
+	}
+
+	slices.SortFunc(all, func(a, b ErrorClass) int {
+		if a.Count != b.Count {
+			return b.Count - a.Count
+		}
+		return strings.Compare(a.Message, b.Message)
+	})
+	return digest.Top(all, limit)
+}
+
+// CheckpointSummary sums up the checkpoints.
+func (d *Digest) CheckpointSummary() CheckpointSummary {
+	s := CheckpointSummary{Count: len(d.Checkpoints), Reasons: map[string]int{}, Warnings: d.Warnings}
+
+	var total float64
+	for _, c := range d.Checkpoints {
+		if c.Reason != "" {
+			s.Reasons[c.Reason]++
+		}
+		s.Buffers += c.Buffers
+		total += c.TotalSec
+		s.MaxTotalSec = max(s.MaxTotalSec, c.TotalSec)
+	}
+	if s.Count > 0 {
+		s.AvgTotalSec = display.Round(total/float64(s.Count), decimals)
+	}
+
+	return s
+}
+
+// SlowestVacuums returns the longest autovacuum runs, at most limit of them
+// when limit is positive.
+func (d *Digest) SlowestVacuums(limit int) []Vacuum {
+	out := append([]Vacuum{}, d.Vacuums...)
+	slices.SortStableFunc(out, func(a, b Vacuum) int { return cmp.Compare(b.ElapsedSec, a.ElapsedSec) })
+	return digest.Top(out, limit)
+}
+
+// SlowestCheckpoints returns the longest checkpoints, at most limit of them
+// when limit is positive.
+func (d *Digest) SlowestCheckpoints(limit int) []Checkpoint {
+	out := append([]Checkpoint{}, d.Checkpoints...)
+	slices.SortStableFunc(out, func(a, b Checkpoint) int { return cmp.Compare(b.TotalSec, a.TotalSec) })
+	return digest.Top(out, limit)
+}
+
+// LongestLockWaits returns the longest lock waits, at most limit of them
+// when limit is positive.
+func (d *Digest) LongestLockWaits(limit int) []LockWait {
+	out := append([]LockWait{}, d.LockWaits...)
+	slices.SortStableFunc(out, func(a, b LockWait) int { return cmp.Compare(b.WaitMs, a.WaitMs) })
+	return digest.Top(out, limit)
+}
+
+// Intervals are the bucket sizes of the timeline.
+var Intervals = map[string]time.Duration{
+	"minute": time.Minute,
+	"hour":   time.Hour,
+	"day":    24 * time.Hour,
+}
+
+// Interval returns the bucket size suited to the time span of the log:
+// minute up to 2 hours, hour up to 3 days, day beyond.
+func (d *Digest) Interval() string {
+	switch span := d.Last.Sub(d.First); {
+	case span <= 2*time.Hour:
+		return "minute"
+	case span <= 72*time.Hour:
+		return "hour"
+	default:
+		return "day"
+	}
+}
+
+// coarser lists the intervals from the finest.
+var coarser = []string{"minute", "hour", "day"}
+
+// Timeline counts the events per interval from the first message to the
+// last, empty buckets included, and returns the interval used: a coarser
+// one when the log spans more than maxBuckets of it. Past maxBuckets days,
+// only the last ones are counted.
+func (d *Digest) Timeline(interval string) (string, []Bucket) {
+	step, ok := Intervals[interval]
+	if !ok || d.First.IsZero() {
+		return interval, []Bucket{}
+	}
+
+	buckets := func(step time.Duration) int64 {
+		return int64(d.Last.Truncate(step).Sub(d.First.Truncate(step))/step) + 1
+	}
+	for i := slices.Index(coarser, interval); i+1 < len(coarser) && buckets(step) > maxBuckets; i++ {
+		interval = coarser[i+1]
+		step = Intervals[interval]
+	}
+
+	first := d.First.Truncate(step)
+	if buckets(step) > maxBuckets {
+		first = d.Last.Truncate(step).Add(-(maxBuckets - 1) * step)
+	}
+
+	out := []Bucket{}
+	index := map[int64]int{}
+	for t := first; !t.After(d.Last); t = t.Add(step) {
+		index[t.Unix()] = len(out)
+		out = append(out, Bucket{Start: digest.Format(t)})
+	}
+
+	for _, e := range d.events {
+		i, ok := index[e.time.Truncate(step).Unix()]
+		if !ok {
+			continue
+		}
+		b := &out[i]
+		switch e.kind {
+		case "query":
+			b.Queries++
+			b.DurationMs = display.Round(b.DurationMs+e.duration, decimals)
+		case "error":
+			b.Errors++
+		case "lock_wait":
+			b.LockWaits++
+		case "autovacuum":
+			b.Autovacuums++
+		case "checkpoint":
+			b.Checkpoints++
+		}
+	}
+
+	return interval, out
+}
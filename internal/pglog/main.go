@@ -0,0 +1,237 @@
+// Package pglog parses PostgreSQL server logs written with any
+// log_line_prefix and analyzes them like pgBadger: statements logged by
+// log_min_duration_statement digested by fingerprint, autovacuum runs,
+// checkpoints, lock waits and errors.
+package pglog
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultPrefix is the log_line_prefix of RDS and Aurora PostgreSQL.
+const DefaultPrefix = "%t:%r:%u@%d:[%p]:"
+
+// Entry is a message of the log with the DETAIL, HINT, CONTEXT and
+// STATEMENT lines that follow it.
+type Entry struct {
+	Time        time.Time
+	PID         int
+	User        string
+	Database    string
+	Host        string
+	Application string
+	SQLState    string
+	// Level is the severity: LOG, WARNING, ERROR, FATAL, PANIC...
+	Level     string
+	Message   string
+	Detail    string
+	Hint      string
+	Context   string
+	Statement string
+}
+
+// escapes are the regular expressions of the log_line_prefix escapes.
+// Escapes that can be empty or contain separators match lazily, so the
+// literal text around them decides where they end.
+var escapes = map[byte]string{
+	'a': `.*?`,
+	'u': `.*?`,
+	'd': `.*?`,
+	'r': `.*?`,
+	'h': `.*?`,
+	'b': `.*?`,
+	'i': `.*?`,
+	'v': `.*?`,
+	'p': `\d+`,
+	'P': `\d*`,
+	'l': `\d+`,
+	'x': `\d+`,
+	'Q': `-?\d+`,
+	'e': `[0-9A-Z]{5}`,
+	'c': `[0-9a-f]+\.[0-9a-f]+`,
+	't': `\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}(?: [A-Za-z]{1,6}| ?[+-]\d{2}(?::?\d{2})?)?`,
+	'm': `\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}\.\d{3}(?: [A-Za-z]{1,6}| ?[+-]\d{2}(?::?\d{2})?)?`,
+	's': `\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}(?: [A-Za-z]{1,6}| ?[+-]\d{2}(?::?\d{2})?)?`,
+	'n': `\d+\.\d+`,
+	'q': ``,
+}
+
+// Parser reads logs written with a log_line_prefix.
+type Parser struct {
+	re *regexp.Regexp
+}
+
+// NewParser compiles a log_line_prefix, e.g. "%m [%p] " or DefaultPrefix.
+func NewParser(prefix string) (*Parser, error) {
+	var sb strings.Builder
+	sb.WriteString(`^`)
+
+	seen := map[byte]bool{}
+	trimmed := strings.TrimRight(prefix, " ")
+	for i := 0; i < len(trimmed); i++ {
+		c := trimmed[i]
+		if c != '%' {
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+			continue
+		}
+
+		// Skip the padding of escapes such as %-10u.
+		i++
+		for i < len(trimmed) && (trimmed[i] == '-' || trimmed[i] >= '0' && trimmed[i] <= '9') {
+			i++
+		}
+		if i == len(trimmed) {
+			return nil, fmt.Errorf("log_line_prefix %q ends with an incomplete escape", prefix)
+		}
+
+		c = trimmed[i]
+		if c == '%' {
+			sb.WriteString(`%`)
+			continue
+		}
+		expr, ok := escapes[c]
+		if !ok {
+			return nil, fmt.Errorf("log_line_prefix %q: unknown escape %%%c", prefix, c)
+		}
+		if seen[c] || expr == "" {
+			sb.WriteString(`(?:` + expr + `)`)
+		} else {
+			sb.WriteString(`(?P<` + string(c) + `>` + expr + `)`)
+		}
+		seen[c] = true
+	}
+	sb.WriteString(`\s*(?P<level>[A-Z]+[0-9]?):\s+(?P<message>.*)$`)
+
+	re, err := regexp.Compile(sb.String())
+	if err != nil {
+		return nil, fmt.Errorf("log_line_prefix %q: %w", prefix, err)
+	}
+
+	return &Parser{re: re}, nil
+}
+
+// Parse reads a log and calls fn for each message, in order. Lines that
+// continue a message, e.g. the next lines of a multi-line statement, are
+// joined to it; lines before the first message are skipped.
+func (p *Parser) Parse(r io.Reader, fn func(Entry)) error {
+	var current *Entry
+	// field is where continuation lines go: the message or the last
+	// DETAIL, STATEMENT... line.
+	var field *string
+
+	flush := func() {
+		if current != nil {
+			fn(*current)
+			current, field = nil, nil
+		}
+	}
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), "\r")
+
+		m := p.re.FindStringSubmatch(line)
+		if m == nil {
+			if field != nil {
+				*field += "\n" + strings.TrimPrefix(line, "\t")
+			}
+			continue
+		}
+
+		e := p.entry(m)
+		if current != nil && (current.PID == e.PID || e.PID == 0) {
+			var f *string
+			switch e.Level {
+			case "DETAIL":
+				f = &current.Detail
+			case "HINT":
+				f = &current.Hint
+			case "CONTEXT":
+				f = &current.Context
+			case "STATEMENT", "QUERY":
+				f = &current.Statement
+			}
+			if f != nil {
+				*f = e.Message
+				field = f
+				continue
+			}
+		}
+
+		flush()
+		current = &e
+		field = &current.Message
+	}
+	flush()
+
+	return sc.Err()
+}
+
+func (p *Parser) entry(m []string) Entry {
+	get := func(name string) string {
+		if i := p.re.SubexpIndex(name); i > 0 {
+			return m[i]
+		}
+		return ""
+	}
+
+	e := Entry{
+		User:        get("u"),
+		Database:    get("d"),
+		Host:        get("h"),
+		Application: get("a"),
+		SQLState:    get("e"),
+		Level:       get("level"),
+		Message:     get("message"),
+	}
+	if e.Host == "" {
+		// %r is the host followed by the port in parentheses.
+		e.Host, _, _ = strings.Cut(get("r"), "(")
+	}
+	e.PID, _ = strconv.Atoi(get("p"))
+
+	switch {
+	case get("m") != "":
+		e.Time = parseTime(get("m"))
+	case get("t") != "":
+		e.Time = parseTime(get("t"))
+	case get("n") != "":
+		if f, err := strconv.ParseFloat(get("n"), 64); err == nil {
+			e.Time = time.UnixMilli(int64(f * 1000)).UTC()
+		}
+	}
+
+	return e
+}
+
+var timeLayouts = []string{
+	"2006-01-02 15:04:05.000 MST",
+	"2006-01-02 15:04:05 MST",
+	"2006-01-02 15:04:05.000-07:00",
+	"2006-01-02 15:04:05.000-0700",
+	"2006-01-02 15:04:05.000-07",
+	"2006-01-02 15:04:05-07:00",
+	"2006-01-02 15:04:05-0700",
+	"2006-01-02 15:04:05-07",
+	"2006-01-02 15:04:05.000",
+	"2006-01-02 15:04:05",
+}
+
+// parseTime reads the time of a prefix. Zone abbreviations other than UTC
+// and GMT are read as UTC, as Go cannot resolve them.
+func parseTime(s string) time.Time {
+	s = strings.Replace(strings.Replace(s, " +", "+", 1), " -", "-", 1)
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC()
+		}
+	}
+	return time.Time{}
+}
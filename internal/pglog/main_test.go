@@ -0,0 +1,141 @@
+package pglog_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nicola-strappazzon/argos/internal/pglog"
+)
+
+func digest(t *testing.T, f pglog.Filter) *pglog.Digest {
+	t.Helper()
+
+	file, err := os.Open("testdata/postgresql.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	p, err := pglog.NewParser(pglog.DefaultPrefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := pglog.NewDigest(f)
+	if err := p.Parse(file, d.Add); err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestParsePrefixes(t *testing.T) {
+	tests := []struct {
+		prefix, line string
+		want         pglog.Entry
+	}{
+		{
+			pglog.DefaultPrefix,
+			"2024-05-14 09:00:05 UTC:10.0.1.12(51234):app@shop:[1001]:LOG:  duration: 1.5 ms  statement: SELECT 1",
+			pglog.Entry{Time: time.Date(2024, 5, 14, 9, 0, 5, 0, time.UTC), PID: 1001, User: "app", Database: "shop", Host: "10.0.1.12", Level: "LOG", Message: "duration: 1.5 ms  statement: SELECT 1"},
+		},
+		{
+			"%m [%p] %q%u@%d ",
+			"2024-05-14 09:00:05.250 CEST [77] postgres@app ERROR:  relation \"x\" does not exist",
+			pglog.Entry{Time: time.Date(2024, 5, 14, 9, 0, 5, 250e6, time.UTC), PID: 77, User: "postgres", Database: "app", Level: "ERROR", Message: "relation \"x\" does not exist"},
+		},
+		{
+			"%t [%p]: [%l-1] user=%u,db=%d,app=%a,client=%h,sqlstate=%e ",
+			"2024-05-14 09:00:05 +02 [9]: [3-1] user=u,db=d,app=psql,client=[local],sqlstate=57014 ERROR:  canceling statement due to statement timeout",
+			pglog.Entry{Time: time.Date(2024, 5, 14, 7, 0, 5, 0, time.UTC), PID: 9, User: "u", Database: "d", Application: "psql", Host: "[local]", SQLState: "57014", Level: "ERROR", Message: "canceling statement due to statement timeout"},
+		},
+	}
+
+	for _, tt := range tests {
+		p, err := pglog.NewParser(tt.prefix)
+		if err != nil {
+			t.Fatalf("%q: %v", tt.prefix, err)
+		}
+		var got []pglog.Entry
+		if err := p.Parse(strings.NewReader(tt.line+"\n"), func(e pglog.Entry) { got = append(got, e) }); err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 1 || got[0] != tt.want {
+			t.Errorf("%q: got %+v, want %+v", tt.prefix, got, tt.want)
+		}
+	}
+
+	if _, err := pglog.NewParser("%t %Z"); err == nil {
+		t.Error("expected an unknown escape to be refused")
+	}
+}
+
+func TestDigest(t *testing.T) {
+	d := digest(t, pglog.Filter{})
+
+	if d.Queries != 3 || d.Unique() != 2 || d.Duration != 2700.75 {
+		t.Fatalf("expected 3 statements in 2 fingerprints, got %d in %d (%v ms)", d.Queries, d.Unique(), d.Duration)
+	}
+
+	classes := d.Classes(pglog.ByCount, 0)
+	if classes[0].Fingerprint != "select * from orders where customer_id = ?" || classes[0].Count != 2 || classes[0].SampleDuration != 120.5 {
+		t.Errorf("unexpected first class: %+v", classes[0])
+	}
+	if classes[1].Fingerprint != "select status, count(*) from orders group by status" || classes[1].LoadPct != 92.567 {
+		t.Errorf("expected the multi-line statement to be joined, got %+v", classes[1])
+	}
+
+	vacuums := d.SlowestVacuums(0)
+	if len(vacuums) != 2 || vacuums[0].Table != "shop.public.orders" || vacuums[0].ElapsedSec != 3.52 || vacuums[0].TuplesRemoved != 1200 || vacuums[1].Kind != "analyze" {
+		t.Errorf("unexpected autovacuum runs: %+v", vacuums)
+	}
+
+	ckpt := d.CheckpointSummary()
+	if ckpt.Count != 2 || ckpt.Reasons["time"] != 1 || ckpt.Reasons["wal"] != 1 || ckpt.Buffers != 1800 || ckpt.MaxTotalSec != 89.95 || ckpt.Warnings != 1 {
+		t.Errorf("unexpected checkpoints: %+v", ckpt)
+	}
+
+	waits := d.LongestLockWaits(0)
+	if len(waits) != 1 || !waits[0].Acquired || waits[0].WaitMs != 4210.4 || len(waits[0].Blockers) != 1 || waits[0].Blockers[0] != 1004 ||
+		waits[0].Statement != "UPDATE orders SET status = 'paid' WHERE id = 12" {
+		t.Errorf("unexpected lock waits: %+v", waits)
+	}
+
+	errs := d.ErrorClasses(0)
+	if d.Errors != 4 || d.Deadlocks != 1 || len(errs) != 3 || errs[0].Count != 2 ||
+		errs[0].Message != `duplicate key value violates unique constraint "orders_pkey"` || errs[0].SampleStatement != "INSERT INTO orders (id) VALUES (12)" {
+		t.Errorf("unexpected errors: %d %+v", d.Errors, errs)
+	}
+
+	interval, timeline := d.Timeline(d.Interval())
+	if interval != "minute" || len(timeline) != 5 || timeline[0].Queries != 2 || timeline[0].Checkpoints != 0 || timeline[1].Checkpoints != 1 || timeline[1].DurationMs != 2500 || timeline[4].Errors != 2 {
+		t.Errorf("unexpected timeline: %+v", timeline)
+	}
+}
+
+func TestTimelineCap(t *testing.T) {
+	d := pglog.NewDigest(pglog.Filter{})
+	start := time.Date(2024, 5, 14, 0, 0, 0, 0, time.UTC)
+	for _, at := range []time.Duration{0, 30 * time.Hour} {
+		d.Add(pglog.Entry{Time: start.Add(at), Level: "LOG", Message: "duration: 1.0 ms  statement: SELECT 1"})
+	}
+
+	// 30 hours are 1801 minutes: too many buckets.
+	if interval, timeline := d.Timeline("minute"); interval != "hour" || len(timeline) != 31 || timeline[30].Queries != 1 {
+		t.Errorf("expected hourly buckets, got %s with %d buckets", interval, len(timeline))
+	}
+
+	d.Add(pglog.Entry{Time: start.Add(5 * 365 * 24 * time.Hour), Level: "LOG", Message: "duration: 1.0 ms  statement: SELECT 1"})
+	if interval, timeline := d.Timeline("minute"); interval != "day" || len(timeline) != 1000 || timeline[999].Queries != 1 {
+		t.Errorf("expected the last 1000 days, got %s with %d buckets", interval, len(timeline))
+	}
+}
+
+func TestDigestFilter(t *testing.T) {
+	d := digest(t, pglog.Filter{Database: "crm", Since: time.Date(2024, 5, 14, 9, 1, 0, 0, time.UTC)})
+
+	if d.Queries != 0 || len(d.Vacuums) != 1 || d.Vacuums[0].Table != "crm.public.leads" || d.Errors != 2 || len(d.Checkpoints) != 2 {
+		t.Errorf("expected only the crm messages and background processes since 09:01, got %d queries, %+v, %d errors, %d checkpoints",
+			d.Queries, d.Vacuums, d.Errors, len(d.Checkpoints))
+	}
+}
@@ -0,0 +1,226 @@
+// Package rdslog lists and downloads the log files of an RDS instance
+// through the RDS API, for the tools that analyze them.
+package rdslog
+
+import (
+	"context"
+	"io"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/nicola-strappazzon/argos/internal/awsclient"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+)
+
+const (
+	// linesPerPage is the number of lines asked for per
+	// DownloadDBLogFilePortion call.
+	linesPerPage = 2000
+	// truncatedMark ends a portion larger than the 1 MB the API returns;
+	// the lines past it are lost unless asked for again in smaller pages.
+	truncatedMark = "[Your log message was truncated]"
+	// start is the marker of the beginning of a file. Without a marker the
+	// API returns the end of the file.
+	start = "0"
+)
+
+type File struct {
+	Name        string
+	Size        int64
+	LastWritten time.Time
+}
+
+// List returns the log files whose name contains filter (all when empty)
+// written since the given time (all when zero).
+func List(ctx context.Context, svc awsclient.RDSAPI, instanceID, filter string, since time.Time) ([]File, error) {
+	in := &rds.DescribeDBLogFilesInput{
+		DBInstanceIdentifier: aws.String(instanceID),
+	}
+	if filter != "" {
+		in.FilenameContains = aws.String(filter)
+	}
+	if !since.IsZero() {
+		in.FileLastWritten = aws.Int64(since.UnixMilli())
+	}
+
+	var files []File
+	pages := rds.NewDescribeDBLogFilesPaginator(svc, in)
+	for pages.HasMorePages() {
+		page, err := pages.NextPage(ctx)
+		if err != nil {
+			return files, err
+		}
+		for _, f := range page.DescribeDBLogFiles {
+			file := File{
+				Name: aws.ToString(f.LogFileName),
+				Size: aws.ToInt64(f.Size),
+			}
+			if f.LastWritten != nil {
+				file.LastWritten = time.UnixMilli(aws.ToInt64(f.LastWritten)).UTC()
+			}
+			files = append(files, file)
+		}
+	}
+
+	return files, nil
+}
+
+// Options select the part of a file to download. The zero value downloads
+// the whole file from the beginning.
+type Options struct {
+	// Marker resumes a download where a previous one stopped.
+	Marker string
+	// Tail downloads only the last Tail lines.
+	Tail int64
+	// Since and Until keep the lines logged in the time range, see Window.
+	Since time.Time
+	Until time.Time
+	// Window resumes the time range filter where a previous download
+	// stopped, from the Position of its last checkpoint.
+	Window *WindowState
+	// Checkpoint is called after each page is written with the position to
+	// resume from. An error stops the download.
+	Checkpoint func(pos Position) error
+}
+
+// Position is where a download can resume: Marker and Window go in Options,
+// and the output is cut back to the Bytes written when it was taken.
+type Position struct {
+	Marker string
+	Bytes  int64
+	// Window is the state of the time range filter, nil without one.
+	Window *WindowState
+}
+
+// Stats describe a download.
+type Stats struct {
+	Bytes int64
+	Pages int
+	// Marker is where the download stopped; pass it as Options.Marker to
+	// resume after a failure.
+	Marker string
+	// Complete is set when the end of the file, or of the time range, was
+	// reached.
+	Complete bool
+	// Truncated counts the portions still larger than 1 MB with a single
+	// line, whose end was lost.
+	Truncated int
+	// Lines is the number of lines of a Tail download, and Shortened is set
+	// when the last Tail lines were over 1 MB and only their end was kept.
+	Lines     int64
+	Shortened bool
+}
+
+// Download writes the content of a log file to w and returns the number of
+// bytes written.
+func Download(ctx context.Context, svc awsclient.RDSAPI, instanceID, logFileName string, w io.Writer) (int64, error) {
+	stats, err := Stream(ctx, svc, instanceID, logFileName, w, Options{})
+	return stats.Bytes, err
+}
+
+// Stream writes a log file to w a page at a time, so the file is never held
+// in memory. A page cut at 1 MB by the API is asked for again with fewer
+// lines, so that no line is lost.
+func Stream(ctx context.Context, svc awsclient.RDSAPI, instanceID, logFileName string, w io.Writer, opt Options) (Stats, error) {
+	stats := Stats{Marker: opt.Marker}
+	if stats.Marker == "" && opt.Tail == 0 {
+		stats.Marker = start
+	}
+
+	cw := &countingWriter{w: w}
+	var out io.Writer = cw
+
+	var window *Window
+	if !opt.Since.IsZero() || !opt.Until.IsZero() {
+		window = NewWindow(cw, opt.Since, opt.Until)
+		if opt.Window != nil {
+			window.Restore(*opt.Window)
+		}
+		out = window
+	}
+
+	lines := int32(linesPerPage)
+	if opt.Tail > 0 {
+		lines = int32(min(opt.Tail, math.MaxInt32))
+	}
+
+	for {
+		in := &rds.DownloadDBLogFilePortionInput{
+			DBInstanceIdentifier: aws.String(instanceID),
+			LogFileName:          aws.String(logFileName),
+			NumberOfLines:        aws.Int32(lines),
+		}
+		if stats.Marker != "" {
+			in.Marker = aws.String(stats.Marker)
+		}
+
+		page, err := svc.DownloadDBLogFilePortion(ctx, in)
+		if err != nil {
+			return stats, err
+		}
+
+		data := aws.ToString(page.LogFileData)
+		if strings.Contains(data, truncatedMark) {
+			if lines > 1 {
+				lines = max(lines/2, 1)
+				continue
+			}
+			stats.Truncated++
+		}
+
+		if _, err := io.WriteString(out, data); err != nil {
+			return stats, err
+		}
+		stats.Bytes = cw.n
+		stats.Pages++
+		stats.Marker = aws.ToString(page.Marker)
+
+		if opt.Tail > 0 {
+			stats.Lines = int64(strings.Count(data, "\n"))
+			if data != "" && !strings.HasSuffix(data, "\n") {
+				stats.Lines++
+			}
+			stats.Shortened = int64(lines) < opt.Tail
+		}
+
+		if opt.Checkpoint != nil {
+			pos := Position{Marker: stats.Marker, Bytes: cw.n}
+			if window != nil {
+				state := window.State()
+				pos.Window = &state
+			}
+			if err := opt.Checkpoint(pos); err != nil {
+				return stats, err
+			}
+		}
+
+		if opt.Tail > 0 || !aws.ToBool(page.AdditionalDataPending) || (window != nil && window.Done()) {
+			break
+		}
+		lines = linesPerPage
+	}
+
+	if window != nil {
+		if err := window.Flush(); err != nil {
+			return stats, err
+		}
+		stats.Bytes = cw.n
+	}
+	stats.Complete = true
+
+	return stats, nil
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
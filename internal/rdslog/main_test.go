@@ -0,0 +1,221 @@
+package rdslog_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nicola-strappazzon/argos/internal/awsclient"
+	"github.com/nicola-strappazzon/argos/internal/rdslog"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+)
+
+// fakeRDS serves lines with the marker semantics of the API: "0" is the
+// beginning, no marker the end, and the marker of a page is the index of the
+// next line. Pages over limit bytes are cut with the truncation mark.
+type fakeRDS struct {
+	awsclient.RDSAPI
+	lines  []string
+	limit  int
+	failAt int
+	calls  []string
+}
+
+func (f *fakeRDS) DownloadDBLogFilePortion(_ context.Context, in *rds.DownloadDBLogFilePortionInput, _ ...func(*rds.Options)) (*rds.DownloadDBLogFilePortionOutput, error) {
+	n := int(aws.ToInt32(in.NumberOfLines))
+	f.calls = append(f.calls, aws.ToString(in.Marker)+"/"+strconv.Itoa(n))
+
+	if f.failAt > 0 && len(f.calls) == f.failAt {
+		return nil, errors.New("connection reset")
+	}
+
+	from := len(f.lines) - n
+	if in.Marker != nil {
+		from, _ = strconv.Atoi(*in.Marker)
+	}
+	from = max(from, 0)
+	to := min(from+n, len(f.lines))
+
+	data := strings.Join(f.lines[from:to], "")
+	if f.limit > 0 && len(data) > f.limit {
+		data = data[:f.limit] + "\n [Your log message was truncated]\n"
+	}
+
+	return &rds.DownloadDBLogFilePortionOutput{
+		LogFileData:           aws.String(data),
+		Marker:                aws.String(strconv.Itoa(to)),
+		AdditionalDataPending: aws.Bool(to < len(f.lines)),
+	}, nil
+}
+
+func lines(n int) []string {
+	out := make([]string, n)
+	for i := range out {
+		out[i] = "line " + strconv.Itoa(i) + "\n"
+	}
+	return out
+}
+
+func TestStream(t *testing.T) {
+	fake := &fakeRDS{lines: lines(4500)}
+
+	var sb strings.Builder
+	var checkpoints []string
+	stats, err := rdslog.Stream(context.Background(), fake, "db1", "error/mysql-error.log", &sb, rdslog.Options{
+		Checkpoint: func(pos rdslog.Position) error {
+			checkpoints = append(checkpoints, pos.Marker)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sb.String() != strings.Join(fake.lines, "") {
+		t.Errorf("expected the whole file in order, got %d bytes", sb.Len())
+	}
+	if !stats.Complete || stats.Pages != 3 || stats.Bytes != int64(sb.Len()) {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	if !slices.Equal(fake.calls, []string{"0/2000", "2000/2000", "4000/2000"}) {
+		t.Errorf("expected the download to start at marker 0, got %v", fake.calls)
+	}
+	if !slices.Equal(checkpoints, []string{"2000", "4000", "4500"}) {
+		t.Errorf("unexpected checkpoints: %v", checkpoints)
+	}
+}
+
+func TestStreamResume(t *testing.T) {
+	fake := &fakeRDS{lines: lines(4500), failAt: 2}
+
+	var sb strings.Builder
+	stats, err := rdslog.Stream(context.Background(), fake, "db1", "f", &sb, rdslog.Options{})
+	if err == nil || stats.Complete || stats.Marker != "2000" {
+		t.Fatalf("expected a failure after the first page, got %+v, %v", stats, err)
+	}
+
+	fake.failAt = 0
+	if _, err := rdslog.Stream(context.Background(), fake, "db1", "f", &sb, rdslog.Options{Marker: stats.Marker}); err != nil {
+		t.Fatal(err)
+	}
+	if sb.String() != strings.Join(fake.lines, "") {
+		t.Errorf("expected the resumed download to complete the file, got %d bytes", sb.Len())
+	}
+}
+
+func TestStreamTruncatedPortion(t *testing.T) {
+	// 300 lines of 9 or 10 bytes fit in the limit: the 2000 line page does not.
+	fake := &fakeRDS{lines: lines(1000), limit: 3000}
+
+	var sb strings.Builder
+	stats, err := rdslog.Stream(context.Background(), fake, "db1", "f", &sb, rdslog.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sb.String() != strings.Join(fake.lines, "") || stats.Truncated != 0 {
+		t.Errorf("expected no line lost, got %d bytes, %+v", sb.Len(), stats)
+	}
+	if fake.calls[0] != "0/2000" || fake.calls[1] != "0/1000" || fake.calls[2] != "0/500" || fake.calls[3] != "0/250" {
+		t.Errorf("expected the page to be asked again with fewer lines, got %v", fake.calls)
+	}
+}
+
+func TestStreamTail(t *testing.T) {
+	fake := &fakeRDS{lines: lines(4500)}
+
+	var sb strings.Builder
+	if _, err := rdslog.Stream(context.Background(), fake, "db1", "f", &sb, rdslog.Options{Tail: 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	if sb.String() != "line 4498\nline 4499\n" || !slices.Equal(fake.calls, []string{"/2"}) {
+		t.Errorf("expected the last 2 lines in one call, got %q after %v", sb.String(), fake.calls)
+	}
+}
+
+func TestStreamTailShortened(t *testing.T) {
+	// The last 1000 lines are over the limit: only their end comes back.
+	fake := &fakeRDS{lines: lines(4500), limit: 3000}
+
+	var sb strings.Builder
+	stats, err := rdslog.Stream(context.Background(), fake, "db1", "f", &sb, rdslog.Options{Tail: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !stats.Shortened || stats.Lines != 250 || strings.Count(sb.String(), "\n") != 250 {
+		t.Errorf("expected the last 250 lines reported as shortened, got %+v", stats)
+	}
+}
+
+func TestStreamWindowResume(t *testing.T) {
+	// The entry of 09:00 spans the pages: its untimed lines must be kept
+	// after a resume too.
+	log := []string{"# Time: 2024-05-14T08:00:00.000000Z\n", "SELECT 8;\n", "# Time: 2024-05-14T09:00:00.000000Z\n"}
+	for i := 0; i < 2500; i++ {
+		log = append(log, "SELECT 9;\n")
+	}
+	fake := &fakeRDS{lines: log, failAt: 2}
+	opt := rdslog.Options{Since: time.Date(2024, 5, 14, 9, 0, 0, 0, time.UTC)}
+
+	var last rdslog.Position
+	opt.Checkpoint = func(pos rdslog.Position) error {
+		last = pos
+		return nil
+	}
+
+	var sb strings.Builder
+	if _, err := rdslog.Stream(context.Background(), fake, "db1", "f", &sb, opt); err == nil {
+		t.Fatal("expected a failure after the first page")
+	}
+	if last.Window == nil || !last.Window.Keep || last.Bytes != int64(sb.Len()) {
+		t.Fatalf("expected the checkpoint to keep the current entry, got %+v", last)
+	}
+
+	fake.failAt = 0
+	opt.Marker, opt.Window = last.Marker, last.Window
+	if _, err := rdslog.Stream(context.Background(), fake, "db1", "f", &sb, opt); err != nil {
+		t.Fatal(err)
+	}
+	if sb.String() != strings.Join(log[2:], "") {
+		t.Errorf("expected the resumed window to keep the whole entry, got %d bytes", sb.Len())
+	}
+}
+
+func TestStreamWindow(t *testing.T) {
+	var log []string
+	for h := 8; h <= 11; h++ {
+		log = append(log,
+			"# Time: 2024-05-14T"+strconv.Itoa(100 + h)[1:]+":00:00.000000Z\n",
+			"SELECT "+strconv.Itoa(h)+";\n",
+		)
+	}
+	for i := 0; i < 3000; i++ {
+		log = append(log, "2024-05-14T12:00:00.000000Z 0 [Note] later\n")
+	}
+	fake := &fakeRDS{lines: log}
+
+	var sb strings.Builder
+	stats, err := rdslog.Stream(context.Background(), fake, "db1", "f", &sb, rdslog.Options{
+		Since: time.Date(2024, 5, 14, 9, 0, 0, 0, time.UTC),
+		Until: time.Date(2024, 5, 14, 10, 30, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "# Time: 2024-05-14T09:00:00.000000Z\nSELECT 9;\n# Time: 2024-05-14T10:00:00.000000Z\nSELECT 10;\n"
+	if sb.String() != want || stats.Bytes != int64(len(want)) {
+		t.Errorf("unexpected window: %q (%d bytes)", sb.String(), stats.Bytes)
+	}
+	if len(fake.calls) != 1 || !stats.Complete {
+		t.Errorf("expected the download to stop past the window, got %v", fake.calls)
+	}
+}
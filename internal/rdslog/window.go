@@ -0,0 +1,101 @@
+package rdslog
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// reLineTime matches the timestamp starting an entry of the MySQL slow log
+// ("# Time: 2024-05-14T09:58:41.123456Z") and error log, and of the
+// PostgreSQL log with the RDS log_line_prefix ("2024-05-14 09:58:41 UTC:").
+var reLineTime = regexp.MustCompile(`^(?:# Time: )?(\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2})`)
+
+// Window is a writer keeping the lines logged between since and until (either
+// may be zero). Lines without a timestamp belong to the entry of the last
+// line with one, and are dropped before the first. Logs are in time order,
+// so once a line past until is seen the window is done.
+type Window struct {
+	w            io.Writer
+	since, until time.Time
+
+	partial []byte
+	keep    bool
+	done    bool
+}
+
+func NewWindow(w io.Writer, since, until time.Time) *Window {
+	return &Window{w: w, since: since, until: until, keep: since.IsZero()}
+}
+
+// WindowState is what a Window needs to continue on a resumed download: the
+// keep decision of the current entry and the line not ended yet.
+type WindowState struct {
+	Keep    bool   `json:"keep"`
+	Partial string `json:"partial,omitempty"`
+}
+
+// State returns the state to save with the marker of the last page written.
+func (x *Window) State() WindowState {
+	return WindowState{Keep: x.keep, Partial: string(x.partial)}
+}
+
+// Restore continues from a state returned by State.
+func (x *Window) Restore(s WindowState) {
+	x.keep = s.Keep
+	x.partial = []byte(s.Partial)
+}
+
+// Done reports whether a line past the end of the window was seen.
+func (x *Window) Done() bool {
+	return x.done
+}
+
+func (x *Window) Write(p []byte) (int, error) {
+	x.partial = append(x.partial, p...)
+	for {
+		i := bytes.IndexByte(x.partial, '\n')
+		if i == -1 {
+			return len(p), nil
+		}
+		if err := x.line(x.partial[:i+1]); err != nil {
+			return 0, err
+		}
+		x.partial = x.partial[i+1:]
+	}
+}
+
+// Flush writes the last line when it has no newline.
+func (x *Window) Flush() error {
+	if len(x.partial) == 0 {
+		return nil
+	}
+	err := x.line(x.partial)
+	x.partial = nil
+	return err
+}
+
+func (x *Window) line(line []byte) error {
+	if x.done {
+		return nil
+	}
+
+	if m := reLineTime.FindSubmatch(line); m != nil {
+		t, err := time.Parse("2006-01-02 15:04:05", strings.Replace(string(m[1]), "T", " ", 1))
+		if err == nil {
+			if !x.until.IsZero() && t.After(x.until) {
+				x.done = true
+				return nil
+			}
+			x.keep = x.since.IsZero() || !t.Before(x.since)
+		}
+	}
+
+	if !x.keep {
+		return nil
+	}
+	_, err := x.w.Write(line)
+	return err
+}
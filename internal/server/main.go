@@ -0,0 +1,76 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+
+	"github.com/nicola-strappazzon/argos/internal/config/tools"
+	"github.com/nicola-strappazzon/argos/tools/registry"
+
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// New returns an MCP server exposing the registered tools that filter
+// allows, each one bounded by its timeout and reporting failures as error
+// results. A nil filter exposes every tool. When artifacts_read is allowed,
+// the files of the workspace are also exposed as resources.
+func New(filter *toolsconfig.Filter) (*mcp.Server, error) {
+	if filter == nil {
+		filter = &toolsconfig.Filter{}
+	}
+
+	server := mcp.NewServer(&mcp.Implementation{Name: "My personal assistant"}, nil)
+
+	names := make([]string, 0, len(registry.Tools))
+	for name := range registry.Tools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var files *artifacts
+	if read, ok := registry.Tools["artifacts_read"]; ok && filter.Allowed(read.Name, read.Group()) {
+		files = newArtifacts(server)
+	}
+
+	for _, key := range names {
+		if tool, ok := registry.Tools[key]; ok {
+			if !filter.Allowed(tool.Name, tool.Group()) {
+				log.Printf("Skip disabled tool: %s", tool.Name)
+				continue
+			}
+
+			log.Printf("Load tool: %s", tool.Name)
+
+			inputSchema := tool.InputSchema
+			if inputSchema == nil {
+				inputSchema = map[string]any{"type": "object"}
+			}
+
+			var outputSchema any
+			if tool.Output != nil {
+				schema, err := jsonschema.ForType(reflect.TypeOf(tool.Output), &jsonschema.ForOptions{})
+				if err != nil {
+					return nil, fmt.Errorf("output schema of %s: %w", tool.Name, err)
+				}
+				outputSchema = schema
+			}
+
+			h := withErrors(tool, withText(withTimeout(tool)))
+			if files != nil && tool.Writes {
+				h = withArtifacts(files, h)
+			}
+
+			mcp.AddTool(server, &mcp.Tool{
+				Name:         tool.Name,
+				Description:  tool.Description,
+				InputSchema:  inputSchema,
+				OutputSchema: outputSchema,
+			}, h)
+		}
+	}
+
+	return server, nil
+}
@@ -0,0 +1,124 @@
+package server_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nicola-strappazzon/argos/internal/config/tools"
+	"github.com/nicola-strappazzon/argos/internal/server"
+	"github.com/nicola-strappazzon/argos/internal/testharness"
+	_ "github.com/nicola-strappazzon/argos/tools/plugins"
+	"github.com/nicola-strappazzon/argos/tools/registry"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestListTools(t *testing.T) {
+	res, err := testharness.Session(t).ListTools(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	listed := map[string]*mcp.Tool{}
+	for _, tool := range res.Tools {
+		listed[tool.Name] = tool
+	}
+
+	for name := range registry.Tools {
+		tool, ok := listed[name]
+		if !ok {
+			t.Errorf("%s: not listed", name)
+			continue
+		}
+		if tool.Description == "" || tool.InputSchema == nil || tool.OutputSchema == nil {
+			t.Errorf("%s: missing description or schema", name)
+		}
+	}
+	if len(listed) != len(registry.Tools) {
+		t.Errorf("listed %d tools, registered %d", len(listed), len(registry.Tools))
+	}
+}
+
+func TestNewFilter(t *testing.T) {
+	srv, err := server.New(&toolsconfig.Filter{Enable: []string{"mysql"}, Disable: []string{"mysql_explain"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	if _, err := srv.Connect(ctx, serverTransport, nil); err != nil {
+		t.Fatal(err)
+	}
+	cs, err := mcp.NewClient(&mcp.Implementation{Name: "test"}, nil).Connect(ctx, clientTransport, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cs.Close()
+
+	res, err := cs.ListTools(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tool := range res.Tools {
+		if registry.Tools[tool.Name].Group() != "mysql" || tool.Name == "mysql_explain" {
+			t.Errorf("%s: should be filtered out", tool.Name)
+		}
+	}
+	if len(res.Tools) == 0 {
+		t.Error("expected the mysql tools")
+	}
+}
+
+func TestArtifactResources(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("ARGOS_WORKSPACE", dir)
+	path := filepath.Join(dir, "db1", "pt_index_usage", "run", "report.txt")
+	os.MkdirAll(filepath.Dir(path), 0755)
+	if err := os.WriteFile(path, []byte("report"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	cs := testharness.Session(t)
+	uri := "argos://artifacts/db1/pt_index_usage/run/report.txt"
+
+	list, err := cs.ListResources(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Resources) != 1 || list.Resources[0].URI != uri {
+		t.Fatalf("expected the report to be listed, got %+v", list.Resources)
+	}
+
+	read, err := cs.ReadResource(ctx, &mcp.ReadResourceParams{URI: uri})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(read.Contents) != 1 || read.Contents[0].Text != "report" {
+		t.Errorf("unexpected contents: %+v", read.Contents)
+	}
+
+	// Only the tools writing to the workspace refresh the list.
+	if err := os.WriteFile(filepath.Join(filepath.Dir(path), "other.txt"), []byte("other"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cs.CallTool(ctx, &mcp.CallToolParams{Name: "artifacts_list", Arguments: map[string]any{}}); err != nil {
+		t.Fatal(err)
+	}
+	if list, _ := cs.ListResources(ctx, nil); len(list.Resources) != 1 {
+		t.Errorf("expected a read-only tool to leave the list as is, got %+v", list.Resources)
+	}
+
+	if _, err := cs.CallTool(ctx, &mcp.CallToolParams{Name: "artifacts_delete", Arguments: map[string]any{"path": "db1"}}); err != nil {
+		t.Fatal(err)
+	}
+	if list, _ := cs.ListResources(ctx, nil); len(list.Resources) != 0 {
+		t.Errorf("expected the deleted report to be unlisted, got %+v", list.Resources)
+	}
+	if _, err := cs.ReadResource(ctx, &mcp.ReadResourceParams{URI: uri}); err == nil {
+		t.Error("expected reading the deleted report to fail")
+	}
+}
@@ -0,0 +1,90 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/nicola-strappazzon/argos/internal/config/timeout"
+	"github.com/nicola-strappazzon/argos/internal/drivers/session"
+	"github.com/nicola-strappazzon/argos/internal/toolerr"
+	"github.com/nicola-strappazzon/argos/tools/registry"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type handler = func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error)
+
+// withText adds a compact text rendering of the result next to the
+// structured content, for clients that only read text content.
+func withText(next handler) handler {
+	return func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
+		result, out, err := next(ctx, req, args)
+		if err != nil || out == nil {
+			return result, out, err
+		}
+
+		if result == nil {
+			result = &mcp.CallToolResult{}
+		}
+		if result.Content == nil {
+			result.Content = []mcp.Content{&mcp.TextContent{Text: registry.Text(out)}}
+		}
+
+		return result, out, nil
+	}
+}
+
+// withErrors reports tool failures as IsError results carrying the error
+// kind and a remediation hint, as text and as a toolerr.Report in the
+// structured content, so the model can fix the call or the
+// configuration instead of seeing a failed request. Protocol errors pass
+// through unchanged.
+func withErrors(tool registry.Property, next handler) handler {
+	return func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
+		result, out, err := next(ctx, req, args)
+		if err == nil {
+			return result, out, nil
+		}
+
+		var wireErr *jsonrpc.Error
+		if errors.As(err, &wireErr) {
+			return result, out, err
+		}
+
+		e := toolerr.Classify(err)
+		log.Printf("Tool %s failed (%s): %v", tool.Name, e.Kind, err)
+
+		text := fmt.Sprintf("%s failed (%s): %v", tool.Name, e.Kind, err)
+		if e.Hint != "" {
+			text += "\nHint: " + e.Hint
+		}
+
+		return &mcp.CallToolResult{
+			IsError:           true,
+			Content:           []mcp.Content{&mcp.TextContent{Text: text}},
+			StructuredContent: e.Report(),
+		}, nil, nil
+	}
+}
+
+// withTimeout runs the tool under its deadline and turns a missed deadline
+// into an explicit timeout error for the model.
+func withTimeout(tool registry.Property) handler {
+	return func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
+		timeout := timeoutconfig.For(tool.Name, tool.Timeout)
+
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		result, out, err := tool.Function(ctx, req, args)
+		if err != nil && (errors.Is(err, session.ErrTimeout) || errors.Is(ctx.Err(), context.DeadlineExceeded)) {
+			hint := fmt.Sprintf("retry with a narrower request, or raise the limit with %s", timeoutconfig.EnvName(tool.Name))
+			return result, nil, toolerr.New(toolerr.Timeout, hint, fmt.Errorf("%s timed out after %s: %w", tool.Name, timeout, err))
+		}
+
+		return result, out, err
+	}
+}
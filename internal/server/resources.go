@@ -0,0 +1,107 @@
+package server
+
+import (
+	"context"
+	"log"
+	"path"
+	"sync"
+
+	"github.com/nicola-strappazzon/argos/internal/workspace"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// maxResource is the largest part of an artifact returned as a resource;
+// artifacts_read pages through larger files.
+const maxResource = 1 << 20
+
+// artifacts exposes the files of the workspace as MCP resources. Any
+// argos://artifacts/ URI can be read through a template, and the files are
+// listed as resources, refreshed after the calls of tools writing files.
+type artifacts struct {
+	server *mcp.Server
+
+	mu     sync.Mutex
+	listed map[string]bool
+}
+
+func newArtifacts(server *mcp.Server) *artifacts {
+	a := &artifacts{server: server, listed: map[string]bool{}}
+
+	server.AddResourceTemplate(&mcp.ResourceTemplate{
+		Name:        "artifact",
+		Description: "A file written by a tool: downloaded log or report. Files larger than 1 MB are truncated, use artifacts_read to page through them.",
+		URITemplate: workspace.URIPrefix + "{+path}",
+	}, a.read)
+	a.sync()
+
+	return a
+}
+
+// sync adds the resources of new files and removes those of deleted ones.
+func (a *artifacts) sync() {
+	list, err := workspace.List("", "")
+	if err != nil {
+		log.Printf("Listing artifacts failed: %v", err)
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	current := make(map[string]bool, len(list))
+	for _, f := range list {
+		current[f.URI] = true
+		if a.listed[f.URI] {
+			continue
+		}
+		a.server.AddResource(&mcp.Resource{
+			Name:     f.Path,
+			URI:      f.URI,
+			MIMEType: mimeType(f.Path),
+			Size:     f.Size,
+		}, a.read)
+	}
+
+	var gone []string
+	for uri := range a.listed {
+		if !current[uri] {
+			gone = append(gone, uri)
+		}
+	}
+	if len(gone) > 0 {
+		a.server.RemoveResources(gone...)
+	}
+
+	a.listed = current
+}
+
+func (a *artifacts) read(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+	uri := req.Params.URI
+
+	data, _, err := workspace.Read(uri, 0, maxResource)
+	if err != nil {
+		return nil, mcp.ResourceNotFoundError(uri)
+	}
+
+	return &mcp.ReadResourceResult{
+		Contents: []*mcp.ResourceContents{{URI: uri, MIMEType: mimeType(uri), Text: string(data)}},
+	}, nil
+}
+
+// withArtifacts refreshes the listed resources once a tool marked as Writes
+// has run, as it may have written or deleted files.
+func withArtifacts(a *artifacts, next handler) handler {
+	return func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
+		result, out, err := next(ctx, req, args)
+		a.sync()
+		return result, out, err
+	}
+}
+
+func mimeType(name string) string {
+	if path.Ext(name) == ".json" {
+		return "application/json"
+	}
+	return "text/plain"
+}
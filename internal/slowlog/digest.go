@@ -0,0 +1,146 @@
+package slowlog
+
+import (
+	"strings"
+	"time"
+
+	"github.com/nicola-strappazzon/argos/internal/digest"
+	"github.com/nicola-strappazzon/argos/internal/sqlguard"
+)
+
+// decimals is the precision of the times, in seconds: microseconds.
+const decimals = 6
+
+// Filter selects the entries to digest. Zero values select everything.
+type Filter = digest.Filter
+
+func match(f Filter, e Entry) bool {
+	if !f.InRange(e.Time) {
+		return false
+	}
+	if f.Database != "" && !strings.EqualFold(f.Database, e.Database) {
+		return false
+	}
+	return true
+}
+
+// Stats summarizes a metric over the queries of a class.
+type Stats = digest.Stats
+
+// Class is the digest of the queries sharing a fingerprint.
+type Class struct {
+	digest.Class
+	QueryTime    Stats `json:"query_time_sec"`
+	LockTime     Stats `json:"lock_time_sec"`
+	RowsSent     Stats `json:"rows_sent"`
+	RowsExamined Stats `json:"rows_examined"`
+	// SampleTime is the query time of Sample, as pt-query-digest shows.
+	SampleTime float64 `json:"sample_query_time_sec"`
+
+	queryTimes, lockTimes, rowsSent, rowsExamined []float64
+}
+
+// Digest aggregates entries by fingerprint.
+type Digest struct {
+	Filter Filter
+
+	Queries   int
+	Skipped   int
+	QueryTime float64
+	First     time.Time
+	Last      time.Time
+
+	classes map[string]*Class
+}
+
+func NewDigest(f Filter) *Digest {
+	return &Digest{Filter: f, classes: map[string]*Class{}}
+}
+
+// Add adds an entry, unless the filter excludes it.
+func (d *Digest) Add(e Entry) {
+	if !match(d.Filter, e) {
+		d.Skipped++
+		return
+	}
+
+	fp := fingerprint(e.Query)
+	c, ok := d.classes[fp]
+	if !ok {
+		c = &Class{Class: digest.Class{Fingerprint: fp}}
+		d.classes[fp] = c
+	}
+
+	c.Add(e.Time, e.Database, e.User, e.Query, e.QueryTime)
+	c.queryTimes = append(c.queryTimes, e.QueryTime)
+	c.lockTimes = append(c.lockTimes, e.LockTime)
+	c.rowsSent = append(c.rowsSent, float64(e.RowsSent))
+	c.rowsExamined = append(c.rowsExamined, float64(e.RowsExamined))
+
+	d.Queries++
+	d.QueryTime += e.QueryTime
+	if d.First.IsZero() || e.Time.Before(d.First) {
+		d.First = e.Time
+	}
+	if e.Time.After(d.Last) {
+		d.Last = e.Time
+	}
+}
+
+// fingerprint is the sqlguard fingerprint of a query, except for the
+// "# administrator command: Quit;" lines the server logs for commands that
+// are not statements.
+func fingerprint(query string) string {
+	if cmd, ok := strings.CutPrefix(query, "# administrator command: "); ok {
+		return "administrator command: " + strings.ToLower(strings.TrimSuffix(cmd, ";"))
+	}
+	return sqlguard.Fingerprint(sqlguard.MySQL, query)
+}
+
+// Order names the metric classes are ranked by.
+const (
+	ByTotalTime    = digest.ByTotalTime
+	ByAvgTime      = digest.ByAvgTime
+	ByCount        = digest.ByCount
+	ByRowsExamined = "rows_examined"
+	ByLockTime     = "lock_time"
+)
+
+var orders = digest.Orders[*Class]{
+	ByTotalTime:    func(c *Class) float64 { return c.QueryTime.Total },
+	ByAvgTime:      func(c *Class) float64 { return c.QueryTime.Avg },
+	ByCount:        func(c *Class) float64 { return float64(c.Count) },
+	ByRowsExamined: func(c *Class) float64 { return c.RowsExamined.Total },
+	ByLockTime:     func(c *Class) float64 { return c.LockTime.Total },
+}
+
+// Orders returns the valid orders, for input schemas.
+func Orders() []string {
+	return []string{ByTotalTime, ByAvgTime, ByCount, ByRowsExamined, ByLockTime}
+}
+
+// Unique returns the number of fingerprints.
+func (d *Digest) Unique() int {
+	return len(d.classes)
+}
+
+// Classes returns the classes ranked by order (total_time when unknown),
+// at most limit of them when limit is positive.
+func (d *Digest) Classes(order string, limit int) []Class {
+	all := make([]*Class, 0, len(d.classes))
+	for _, c := range d.classes {
+		c.QueryTime = digest.Summarize(c.queryTimes, decimals)
+		c.LockTime = digest.Summarize(c.lockTimes, decimals)
+		c.RowsSent = digest.Summarize(c.rowsSent, decimals)
+		c.RowsExamined = digest.Summarize(c.rowsExamined, decimals)
+		c.SampleTime = c.Longest()
+		c.Finish(c.QueryTime.Total, d.QueryTime, decimals)
+		all = append(all, c)
+	}
+
+	out := []Class{}
+	for _, c := range orders.Rank(all, order, limit) {
+		out = append(out, *c)
+	}
+	return out
+}
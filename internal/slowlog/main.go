@@ -0,0 +1,189 @@
+// Package slowlog parses MySQL slow query logs, as written by MySQL 5.6 to
+// 8.4, Percona Server and MariaDB, and digests them by query fingerprint
+// like pt-query-digest.
+package slowlog
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Entry is one query of the log.
+type Entry struct {
+	Time         time.Time
+	User         string
+	Host         string
+	ThreadID     int64
+	Database     string
+	QueryTime    float64
+	LockTime     float64
+	RowsSent     int64
+	RowsExamined int64
+	Query        string
+}
+
+var (
+	reTime     = regexp.MustCompile(`^# Time: (.+)$`)
+	reUserHost = regexp.MustCompile(`^# User@Host: (\S*?)\[[^\]]*\] @ (\S*) ?\[([^\]]*)\](?:\s+Id:\s*(\d+))?`)
+	reSchema   = regexp.MustCompile(`^# Schema: (\S+)`)
+	reThreadID = regexp.MustCompile(`# Thread_id: (\d+)`)
+	reMetric   = regexp.MustCompile(`(Query_time|Lock_time|Rows_sent|Rows_examined): ([\d.]+)`)
+	reUse      = regexp.MustCompile("(?i)^use `?([^`;\\s]+)`?;$")
+	reSetTime  = regexp.MustCompile(`^SET timestamp=(\d+);$`)
+	// The header the server writes when it opens the log.
+	reHeader = regexp.MustCompile(`^(\S+, Version: .* started with:|Tcp port: \d+|Time\s+Id\s+Command\s+Argument)`)
+)
+
+// Parse reads a slow log and calls fn for each entry, in order. Lines before
+// the first entry and server headers are skipped.
+func Parse(r io.Reader, fn func(Entry)) error {
+	p := &parser{fn: fn, databases: map[int64]string{}}
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for sc.Scan() {
+		p.line(strings.TrimRight(sc.Text(), "\r"))
+	}
+	p.flush()
+
+	return sc.Err()
+}
+
+type parser struct {
+	fn func(Entry)
+
+	current  *Entry
+	query    []string
+	inHeader bool
+	// last is the time of the previous entry: MySQL 5.6 only writes
+	// "# Time:" when the second changes.
+	last time.Time
+	// databases is the current database of each connection: "use db;" is
+	// only logged when it changes.
+	databases map[int64]string
+}
+
+func (p *parser) line(line string) {
+	if reHeader.MatchString(line) {
+		p.flush()
+		return
+	}
+
+	if m := reTime.FindStringSubmatch(line); m != nil {
+		p.flush()
+		p.start()
+		if t, ok := parseTime(m[1]); ok {
+			p.current.Time = t
+		}
+		return
+	}
+
+	if m := reUserHost.FindStringSubmatch(line); m != nil {
+		// "# Time:" starts the entry when present; otherwise it is this line.
+		if p.current == nil || !p.inHeader || p.current.User != "" {
+			p.flush()
+			p.start()
+		}
+		p.current.User = m[1]
+		p.current.Host = m[3]
+		if p.current.Host == "" {
+			p.current.Host = m[2]
+		}
+		if m[4] != "" {
+			p.current.ThreadID, _ = strconv.ParseInt(m[4], 10, 64)
+			p.current.Database = p.databases[p.current.ThreadID]
+		}
+		return
+	}
+
+	if p.current == nil {
+		return
+	}
+
+	if p.inHeader && strings.HasPrefix(line, "# ") {
+		if m := reSchema.FindStringSubmatch(line); m != nil {
+			p.current.Database = m[1]
+		}
+		if m := reThreadID.FindStringSubmatch(line); m != nil && p.current.ThreadID == 0 {
+			p.current.ThreadID, _ = strconv.ParseInt(m[1], 10, 64)
+		}
+		for _, m := range reMetric.FindAllStringSubmatch(line, -1) {
+			switch m[1] {
+			case "Query_time":
+				p.current.QueryTime, _ = strconv.ParseFloat(m[2], 64)
+			case "Lock_time":
+				p.current.LockTime, _ = strconv.ParseFloat(m[2], 64)
+			case "Rows_sent":
+				p.current.RowsSent, _ = strconv.ParseInt(m[2], 10, 64)
+			case "Rows_examined":
+				p.current.RowsExamined, _ = strconv.ParseInt(m[2], 10, 64)
+			}
+		}
+		return
+	}
+	p.inHeader = false
+
+	if len(p.query) == 0 {
+		if m := reUse.FindStringSubmatch(line); m != nil {
+			p.current.Database = m[1]
+			return
+		}
+		if m := reSetTime.FindStringSubmatch(line); m != nil {
+			if p.current.Time.IsZero() {
+				sec, _ := strconv.ParseInt(m[1], 10, 64)
+				p.current.Time = time.Unix(sec, 0).UTC()
+			}
+			return
+		}
+	}
+
+	p.query = append(p.query, line)
+}
+
+func (p *parser) start() {
+	p.current = &Entry{}
+	p.query = nil
+	p.inHeader = true
+}
+
+func (p *parser) flush() {
+	e := p.current
+	p.current = nil
+	p.inHeader = false
+	if e == nil {
+		return
+	}
+
+	e.Query = strings.TrimSpace(strings.Join(p.query, "\n"))
+	p.query = nil
+	if e.Query == "" {
+		return
+	}
+
+	if e.Time.IsZero() {
+		e.Time = p.last
+	}
+	p.last = e.Time
+	if e.ThreadID != 0 && e.Database != "" {
+		p.databases[e.ThreadID] = e.Database
+	}
+
+	p.fn(*e)
+}
+
+// parseTime reads "2024-05-14T09:58:41.123456Z" (5.7 and later) or
+// "240514  9:58:41" (5.6 and MariaDB).
+func parseTime(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t.UTC(), true
+	}
+	if t, err := time.Parse("060102 15:04:05", strings.Join(strings.Fields(s), " ")); err == nil {
+		return t, true
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,141 @@
+package slowlog_test
+
+import (
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/nicola-strappazzon/argos/internal/slowlog"
+)
+
+func parse(t *testing.T, name string) []slowlog.Entry {
+	t.Helper()
+
+	f, err := os.Open("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var entries []slowlog.Entry
+	if err := slowlog.Parse(f, func(e slowlog.Entry) { entries = append(entries, e) }); err != nil {
+		t.Fatal(err)
+	}
+	return entries
+}
+
+func TestParse(t *testing.T) {
+	entries := parse(t, "slow-8.0.log")
+	if len(entries) != 6 {
+		t.Fatalf("expected 6 entries, got %d", len(entries))
+	}
+
+	first := entries[0]
+	want := slowlog.Entry{
+		Time:         time.Date(2024, 5, 14, 9, 0, 1, 100000, time.UTC),
+		User:         "app",
+		Host:         "10.0.3.17",
+		ThreadID:     812,
+		Database:     "bank",
+		QueryTime:    2.5,
+		LockTime:     0.0001,
+		RowsSent:     1,
+		RowsExamined: 1000000,
+		Query:        "SELECT * FROM accounts WHERE email = 'a@example.com';",
+	}
+	if first != want {
+		t.Errorf("unexpected first entry:\n got %+v\nwant %+v", first, want)
+	}
+
+	// The database of a connection carries over until it logs another "use".
+	var databases []string
+	for _, e := range entries {
+		databases = append(databases, e.Database)
+	}
+	if !slices.Equal(databases, []string{"bank", "bank", "shop", "bank", "shop", "bank"}) {
+		t.Errorf("unexpected databases: %v", databases)
+	}
+
+	if q := entries[2].Query; q != "SELECT c.name, SUM(o.total)\nFROM orders o\nJOIN customers c ON c.id = o.customer_id\nWHERE o.created_at >= '2024-01-01'\nGROUP BY c.name ORDER BY 2 DESC LIMIT 30;" {
+		t.Errorf("expected the multi-line query, got %q", q)
+	}
+	if e := entries[2]; e.Host != "10.0.4.2" || e.QueryTime != 12 || e.RowsExamined != 5000000 {
+		t.Errorf("unexpected entry with extra fields: %+v", e)
+	}
+}
+
+func TestParseMySQL56(t *testing.T) {
+	entries := parse(t, "slow-5.6.log")
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+
+	// The second entry has no "# Time:" line: it is in the same second.
+	for _, e := range entries {
+		if !e.Time.Equal(time.Date(2024, 5, 14, 9, 0, 1, 0, time.UTC)) || e.Host != "localhost" || e.Database != "bank" {
+			t.Errorf("unexpected entry: %+v", e)
+		}
+	}
+	if entries[1].Query != "SELECT 2;" || entries[1].QueryTime != 3 {
+		t.Errorf("unexpected second entry: %+v", entries[1])
+	}
+}
+
+func TestDigest(t *testing.T) {
+	d := slowlog.NewDigest(slowlog.Filter{})
+	for _, e := range parse(t, "slow-8.0.log") {
+		d.Add(e)
+	}
+
+	if d.Queries != 6 || d.Unique() != 4 || d.QueryTime != 21.5 {
+		t.Fatalf("unexpected totals: %d queries, %d unique, %v s", d.Queries, d.Unique(), d.QueryTime)
+	}
+
+	classes := d.Classes(slowlog.ByTotalTime, 0)
+	var fingerprints []string
+	for _, c := range classes {
+		fingerprints = append(fingerprints, c.Fingerprint)
+	}
+	if !slices.Equal(fingerprints, []string{
+		"select c.name, sum(o.total) from orders o join customers c on c.id = o.customer_id where o.created_at >= ? group by c.name order by ? desc limit ?",
+		"select * from accounts where email = ?",
+		"administrator command: quit",
+		"insert into orders (customer_id, total) values (?+)",
+	}) {
+		t.Errorf("unexpected ranking: %q", fingerprints)
+	}
+
+	c := classes[1]
+	if c.Rank != 2 || c.Count != 3 || c.LoadPct != 20.930233 || c.FirstSeen != "2024-05-14T09:00:01Z" || c.LastSeen != "2024-05-14T09:30:00Z" {
+		t.Errorf("unexpected class: %+v", c)
+	}
+	if c.QueryTime != (slowlog.Stats{Total: 4.5, Avg: 1.5, Min: 0.5, Max: 2.5, P95: 2.5}) {
+		t.Errorf("unexpected query time: %+v", c.QueryTime)
+	}
+	if c.RowsExamined.Total != 2700000 || c.RowsSent.Total != 3 {
+		t.Errorf("unexpected rows: %+v %+v", c.RowsExamined, c.RowsSent)
+	}
+	if c.Sample != "SELECT * FROM accounts WHERE email = 'a@example.com';" || c.SampleTime != 2.5 || c.SampleDatabase != "bank" {
+		t.Errorf("expected the slowest query as sample, got %+v", c)
+	}
+
+	if top := d.Classes(slowlog.ByLockTime, 1); len(top) != 1 || top[0].Fingerprint != "insert into orders (customer_id, total) values (?+)" {
+		t.Errorf("unexpected top class by lock time: %+v", top)
+	}
+}
+
+func TestDigestFilter(t *testing.T) {
+	d := slowlog.NewDigest(slowlog.Filter{
+		Since:    time.Date(2024, 5, 14, 9, 5, 0, 0, time.UTC),
+		Until:    time.Date(2024, 5, 14, 10, 0, 0, 0, time.UTC),
+		Database: "BANK",
+	})
+	for _, e := range parse(t, "slow-8.0.log") {
+		d.Add(e)
+	}
+
+	if d.Queries != 2 || d.Skipped != 4 || d.Unique() != 1 {
+		t.Errorf("expected the 2 bank queries of the window, got %d (%d skipped)", d.Queries, d.Skipped)
+	}
+}
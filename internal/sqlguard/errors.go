@@ -0,0 +1,16 @@
+package sqlguard
+
+import (
+	"errors"
+
+	"github.com/nicola-strappazzon/argos/internal/toolerr"
+)
+
+func init() {
+	toolerr.Register(func(err error) (toolerr.Kind, string, bool) {
+		if errors.Is(err, ErrNotReadOnly) {
+			return toolerr.InvalidInput, "send a single read-only SELECT statement", true
+		}
+		return "", "", false
+	})
+}
@@ -0,0 +1,137 @@
+package sqlguard
+
+import (
+	"strings"
+)
+
+// Fingerprint returns sql with its literals replaced by ?, so that statements
+// differing only in their values share the same fingerprint: "SELECT * FROM
+// t WHERE id IN (1, 2, 3)" becomes "select * from t where id in (?+)".
+// Keywords and identifiers are lower-cased, quotes around identifiers and
+// comments are dropped, and lists of values collapse into (?+). Text that
+// cannot be tokenized, e.g. a statement cut in a log, is only lower-cased
+// with its whitespace collapsed.
+func Fingerprint(d Dialect, sql string) string {
+	tokens, err := tokenize(&lexer{d: d, src: sql, executable: true})
+	if err != nil {
+		return strings.ToLower(strings.Join(strings.Fields(sql), " "))
+	}
+
+	for len(tokens) > 0 && tokens[len(tokens)-1].Text == ";" {
+		tokens = tokens[:len(tokens)-1]
+	}
+
+	words := make([]string, 0, len(tokens))
+	for i, t := range tokens {
+		adjacent := i > 0 && tokens[i-1].Pos+len(tokens[i-1].Text) == t.Pos
+		switch {
+		case t.Kind == String || t.Kind == Number:
+			words = append(words, "?")
+		case t.Kind == Variable && d == PostgreSQL:
+			words = append(words, "?")
+		case t.Kind == Symbol && t.Text == "?":
+			words = append(words, "?")
+		case t.Kind == QuotedIdent:
+			words = append(words, strings.ToLower(t.Text[1:len(t.Text)-1]))
+		case t.Kind == Symbol && t.Text == "-" && i+1 < len(tokens) && tokens[i+1].Kind == Number && isOperand(words):
+			// A negative number: the sign goes with the literal.
+			continue
+		case t.Kind == Symbol && adjacent && isOperator(t.Text) && tokens[i-1].Kind == Symbol && isOperator(tokens[i-1].Text):
+			// The lexer splits operators such as >= or <> in characters.
+			words[len(words)-1] += t.Text
+		case t.Kind == Symbol && t.Text == "(" && adjacent && tokens[i-1].Kind == Word && !notCalls[tokens[i-1].Upper()]:
+			// A function call: count(*), not count (*).
+			words = append(words, call)
+		default:
+			words = append(words, strings.ToLower(t.Text))
+		}
+	}
+
+	return join(collapse(words))
+}
+
+// call is the opening parenthesis of a function call, written without a
+// space before it.
+const call = "\x00("
+
+// notCalls are the keywords often written right before a parenthesis that
+// does not open a function call.
+var notCalls = map[string]bool{
+	"IN": true, "VALUES": true, "VALUE": true, "EXISTS": true, "ANY": true, "ALL": true, "SOME": true,
+	"NOT": true, "AND": true, "OR": true, "ON": true, "USING": true, "AS": true, "FROM": true,
+	"JOIN": true, "WHERE": true, "SELECT": true, "INTO": true, "TABLE": true, "OVER": true,
+}
+
+func isOperator(s string) bool {
+	return strings.Contains("<>=!|&:", s)
+}
+
+// isOperand reports whether a minus sign after words starts a number rather
+// than subtracting from the previous operand.
+func isOperand(words []string) bool {
+	if len(words) == 0 {
+		return true
+	}
+	switch words[len(words)-1] {
+	case "(", call, ",", "=", "<", ">", "in", "values", "and", "or", "between", "then", "else", "when":
+		return true
+	}
+	return false
+}
+
+// collapse replaces lists of placeholders, "(?, ?, ?)", by "(?+)", and
+// repeated rows, "(?+), (?+)", by a single one.
+func collapse(words []string) []string {
+	out := make([]string, 0, len(words))
+	for i := 0; i < len(words); i++ {
+		if words[i] == "(" || words[i] == call {
+			j := i + 1
+			for j+1 < len(words) && words[j] == "?" && words[j+1] == "," {
+				j += 2
+			}
+			if j+1 < len(words) && words[j] == "?" && words[j+1] == ")" {
+				out = append(out, words[i], "?+", ")")
+				i = j + 1
+
+				// Drop the following rows of a multi-row VALUES.
+				for i+1 < len(words) && words[i+1] == "," && rowAt(words, i+2) != -1 {
+					i = rowAt(words, i+2)
+				}
+				continue
+			}
+		}
+		out = append(out, words[i])
+	}
+	return out
+}
+
+// rowAt returns the index of the closing parenthesis of a placeholder list
+// starting at i, or -1.
+func rowAt(words []string, i int) int {
+	if i >= len(words) || words[i] != "(" {
+		return -1
+	}
+	for j := i + 1; j < len(words); j += 2 {
+		if words[j] != "?" {
+			return -1
+		}
+		if j+1 < len(words) && words[j+1] == ")" {
+			return j + 1
+		}
+		if j+1 >= len(words) || words[j+1] != "," {
+			return -1
+		}
+	}
+	return -1
+}
+
+func join(words []string) string {
+	var sb strings.Builder
+	for i, w := range words {
+		if i > 0 && w != "," && w != ")" && w != "." && w != call && words[i-1] != "(" && words[i-1] != call && words[i-1] != "." {
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(strings.TrimPrefix(w, "\x00"))
+	}
+	return sb.String()
+}
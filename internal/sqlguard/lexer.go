@@ -0,0 +1,247 @@
+package sqlguard
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Dialect selects the lexical rules (quotes, comments) of the SQL text.
+type Dialect int
+
+const (
+	MySQL Dialect = iota
+	PostgreSQL
+)
+
+type Kind int
+
+const (
+	Word        Kind = iota // keyword or unquoted identifier
+	QuotedIdent             // `ident` (MySQL) or "ident" (PostgreSQL)
+	String                  // '...', "..." (MySQL), E'...', $tag$...$tag$
+	Number
+	Variable // @var, @@var (MySQL), $1 (PostgreSQL)
+	Symbol
+)
+
+// Token is a lexical token. Comments and whitespace are dropped.
+type Token struct {
+	Kind Kind
+	Text string
+	Pos  int
+}
+
+// Upper returns the token text upper-cased, for keyword comparison.
+func (t Token) Upper() string {
+	return strings.ToUpper(t.Text)
+}
+
+// Tokenize splits sql into tokens following the quoting and comment rules
+// of the dialect. MySQL executable comments (/*! ... */) are rejected because
+// the server runs their content.
+func Tokenize(d Dialect, sql string) ([]Token, error) {
+	return tokenize(&lexer{d: d, src: sql})
+}
+
+func tokenize(l *lexer) ([]Token, error) {
+	tokens := []Token{}
+
+	for {
+		if err := l.skipSpaceAndComments(); err != nil {
+			return nil, err
+		}
+		if l.pos >= len(l.src) {
+			return tokens, nil
+		}
+
+		t, err := l.next()
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, t)
+	}
+}
+
+type lexer struct {
+	d   Dialect
+	src string
+	pos int
+	// executable skips MySQL executable comments like any other comment
+	// instead of rejecting them.
+	executable bool
+}
+
+func (l *lexer) peek(offset int) byte {
+	if l.pos+offset < len(l.src) {
+		return l.src[l.pos+offset]
+	}
+	return 0
+}
+
+func (l *lexer) skipSpaceAndComments() error {
+	for l.pos < len(l.src) {
+		c := l.peek(0)
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v':
+			l.pos++
+		case c == '-' && l.peek(1) == '-' && (l.d == PostgreSQL || isSpaceOrEnd(l.peek(2))):
+			l.skipLine()
+		case c == '#' && l.d == MySQL:
+			l.skipLine()
+		case c == '/' && l.peek(1) == '*':
+			if l.d == MySQL && !l.executable && (l.peek(2) == '!' || (l.peek(2) == 'M' && l.peek(3) == '!')) {
+				return fmt.Errorf("executable comment at position %d is not allowed", l.pos)
+			}
+			if err := l.skipBlockComment(); err != nil {
+				return err
+			}
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+func isSpaceOrEnd(c byte) bool {
+	return c == 0 || c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v'
+}
+
+func (l *lexer) skipLine() {
+	for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+		l.pos++
+	}
+}
+
+// skipBlockComment skips /* ... */. PostgreSQL block comments nest.
+func (l *lexer) skipBlockComment() error {
+	start := l.pos
+	depth := 0
+	for l.pos < len(l.src) {
+		switch {
+		case l.peek(0) == '/' && l.peek(1) == '*':
+			if depth == 0 || l.d == PostgreSQL {
+				depth++
+			}
+			l.pos += 2
+		case l.peek(0) == '*' && l.peek(1) == '/':
+			depth--
+			l.pos += 2
+			if depth == 0 {
+				return nil
+			}
+		default:
+			l.pos++
+		}
+	}
+	return fmt.Errorf("unterminated comment at position %d", start)
+}
+
+func (l *lexer) next() (Token, error) {
+	start := l.pos
+	c := l.peek(0)
+
+	switch {
+	case c == '\'':
+		return l.quoted(String, '\'', l.d == MySQL)
+	case (c == 'E' || c == 'e') && l.peek(1) == '\'' && l.d == PostgreSQL:
+		l.pos++
+		t, err := l.quoted(String, '\'', true)
+		t.Pos = start
+		return t, err
+	case c == '"' && l.d == MySQL:
+		return l.quoted(String, '"', true)
+	case c == '"':
+		return l.quoted(QuotedIdent, '"', false)
+	case c == '`' && l.d == MySQL:
+		return l.quoted(QuotedIdent, '`', false)
+	case c == '$' && l.d == PostgreSQL:
+		return l.dollar()
+	case c == '@' && l.d == MySQL:
+		l.pos++
+		if l.peek(0) == '@' {
+			l.pos++
+		}
+		for isWordChar(l.peek(0)) || l.peek(0) == '.' {
+			l.pos++
+		}
+		return Token{Kind: Variable, Text: l.src[start:l.pos], Pos: start}, nil
+	case isDigit(c) || (c == '.' && isDigit(l.peek(1))):
+		for isDigit(l.peek(0)) || l.peek(0) == '.' || isWordChar(l.peek(0)) {
+			l.pos++
+		}
+		return Token{Kind: Number, Text: l.src[start:l.pos], Pos: start}, nil
+	case isWordStart(c):
+		for isWordChar(l.peek(0)) || l.peek(0) == '$' {
+			l.pos++
+		}
+		return Token{Kind: Word, Text: l.src[start:l.pos], Pos: start}, nil
+	}
+
+	l.pos++
+	return Token{Kind: Symbol, Text: string(c), Pos: start}, nil
+}
+
+// quoted reads a token delimited by quote. A doubled quote is an escaped
+// quote; with backslash set, \x escapes x too.
+func (l *lexer) quoted(kind Kind, quote byte, backslash bool) (Token, error) {
+	start := l.pos
+	l.pos++
+	for l.pos < len(l.src) {
+		c := l.peek(0)
+		switch {
+		case backslash && c == '\\':
+			l.pos += 2
+		case c == quote && l.peek(1) == quote:
+			l.pos += 2
+		case c == quote:
+			l.pos++
+			return Token{Kind: kind, Text: l.src[start:l.pos], Pos: start}, nil
+		default:
+			l.pos++
+		}
+	}
+	return Token{}, fmt.Errorf("unterminated quoted text at position %d", start)
+}
+
+// dollar reads a $n parameter or a $tag$ ... $tag$ string.
+func (l *lexer) dollar() (Token, error) {
+	start := l.pos
+	l.pos++
+
+	if isDigit(l.peek(0)) {
+		for isDigit(l.peek(0)) {
+			l.pos++
+		}
+		return Token{Kind: Variable, Text: l.src[start:l.pos], Pos: start}, nil
+	}
+
+	for isWordChar(l.peek(0)) {
+		l.pos++
+	}
+	if l.peek(0) != '$' {
+		l.pos = start + 1
+		return Token{Kind: Symbol, Text: "$", Pos: start}, nil
+	}
+	l.pos++
+
+	tag := l.src[start:l.pos]
+	end := strings.Index(l.src[l.pos:], tag)
+	if end == -1 {
+		return Token{}, fmt.Errorf("unterminated dollar-quoted string at position %d", start)
+	}
+	l.pos += end + len(tag)
+
+	return Token{Kind: String, Text: l.src[start:l.pos], Pos: start}, nil
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isWordStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80
+}
+
+func isWordChar(c byte) bool {
+	return isWordStart(c) || isDigit(c)
+}
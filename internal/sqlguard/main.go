@@ -0,0 +1,203 @@
+package sqlguard
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNotReadOnly is wrapped by every error returned by CheckReadOnly.
+var ErrNotReadOnly = errors.New("query rejected: only read-only statements are allowed")
+
+// statementKeywords are rejected anywhere in a query unless used as a
+// function name (e.g. MySQL's REPLACE() and INSERT() string functions).
+var statementKeywords = map[string]bool{
+	"INSERT": true, "UPDATE": true, "DELETE": true, "REPLACE": true, "MERGE": true,
+	"CREATE": true, "ALTER": true, "DROP": true, "TRUNCATE": true, "RENAME": true,
+	"GRANT": true, "REVOKE": true, "CALL": true, "HANDLER": true, "LOAD": true,
+	"INTO": true, "OUTFILE": true, "DUMPFILE": true, "COPY": true, "VACUUM": true,
+	"REINDEX": true, "CLUSTER": true, "REFRESH": true, "NOTIFY": true, "LISTEN": true,
+	"PREPARE": true, "EXECUTE": true, "DEALLOCATE": true, "COMMIT": true,
+	"ROLLBACK": true, "SAVEPOINT": true, "KILL": true, "SHUTDOWN": true,
+	"FLUSH": true, "PURGE": true, "INSTALL": true, "UNINSTALL": true,
+	"OPTIMIZE": true, "REPAIR": true, "LOCK": true, "UNLOCK": true,
+}
+
+// sideEffectFunctions are functions that block, take locks, touch files,
+// change settings or sequences, or run arbitrary SQL.
+var sideEffectFunctions = map[Dialect]map[string]bool{
+	MySQL: {
+		"sleep": true, "benchmark": true, "get_lock": true, "release_lock": true,
+		"release_all_locks": true, "load_file": true, "master_pos_wait": true,
+		"source_pos_wait": true, "wait_for_executed_gtid_set": true,
+		"wait_until_sql_thread_after_gtids": true,
+	},
+	PostgreSQL: {
+		"pg_sleep": true, "pg_sleep_for": true, "pg_sleep_until": true,
+		"pg_terminate_backend": true, "pg_cancel_backend": true, "pg_reload_conf": true,
+		"pg_rotate_logfile": true, "pg_read_file": true, "pg_read_binary_file": true,
+		"pg_ls_dir": true, "lo_import": true, "lo_export": true, "lo_unlink": true,
+		"lo_create": true, "lo_creat": true, "dblink": true, "dblink_exec": true,
+		"dblink_connect": true, "set_config": true, "nextval": true, "setval": true,
+		"pg_advisory_lock": true, "pg_advisory_lock_shared": true,
+		"pg_advisory_xact_lock": true, "pg_advisory_xact_lock_shared": true,
+		"pg_try_advisory_lock": true, "pg_try_advisory_lock_shared": true,
+		"pg_try_advisory_xact_lock": true, "pg_try_advisory_xact_lock_shared": true,
+		"pg_advisory_unlock": true, "pg_advisory_unlock_all": true,
+		"pg_notify": true, "pg_switch_wal": true, "pg_create_restore_point": true,
+		"pg_start_backup": true, "pg_stop_backup": true, "pg_backup_start": true,
+		"pg_backup_stop": true, "pg_promote": true, "pg_logical_emit_message": true,
+		"pg_create_logical_replication_slot": true, "pg_create_physical_replication_slot": true,
+		"pg_drop_replication_slot": true, "query_to_xml": true,
+		"query_to_xml_and_xmlschema": true, "query_to_xmlschema": true, "cursor_to_xml": true,
+		"pg_stat_reset": true, "pg_stat_statements_reset": true,
+	},
+}
+
+// sideEffectPrefixes name families of side-effecting functions, e.g.
+// pg_stat_reset_shared() and pg_stat_reset_single_table_counters().
+var sideEffectPrefixes = map[Dialect][]string{
+	PostgreSQL: {"pg_stat_reset_"},
+}
+
+// CheckReadOnly returns an error wrapping ErrNotReadOnly unless sql is a
+// single SELECT, WITH, TABLE or VALUES query, or a SHOW, DESCRIBE or EXPLAIN
+// statement on such a query, with no data-modifying clause and no call to a
+// side-effecting function.
+func CheckReadOnly(d Dialect, sql string) error {
+	tokens, err := Tokenize(d, sql)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrNotReadOnly, err)
+	}
+
+	for len(tokens) > 0 && tokens[len(tokens)-1].Text == ";" {
+		tokens = tokens[:len(tokens)-1]
+	}
+	if len(tokens) == 0 {
+		return fmt.Errorf("%w: empty statement", ErrNotReadOnly)
+	}
+	for _, t := range tokens {
+		if t.Kind == Symbol && t.Text == ";" {
+			return fmt.Errorf("%w: multiple statements are not allowed", ErrNotReadOnly)
+		}
+	}
+
+	switch first := tokens[0].Upper(); {
+	case first == "SHOW" && tokens[0].Kind == Word:
+		return nil
+	case (first == "DESCRIBE" || first == "DESC") && tokens[0].Kind == Word:
+		return checkQuery(d, tokens[1:])
+	case first == "EXPLAIN" && tokens[0].Kind == Word:
+		return checkExplain(d, tokens[1:])
+	}
+
+	if !isQueryStart(tokens[0]) {
+		return fmt.Errorf("%w: %s statements are not allowed", ErrNotReadOnly, tokens[0].Upper())
+	}
+
+	return checkQuery(d, tokens)
+}
+
+func isQueryStart(t Token) bool {
+	if t.Kind == Symbol {
+		return t.Text == "("
+	}
+	if t.Kind != Word {
+		return false
+	}
+	switch t.Upper() {
+	case "SELECT", "WITH", "TABLE", "VALUES":
+		return true
+	}
+	return false
+}
+
+// checkExplain skips the EXPLAIN options of both dialects, e.g.
+// "ANALYZE FORMAT=TREE" or "(ANALYZE, BUFFERS)", then checks the statement.
+// "EXPLAIN table" (a DESCRIBE synonym) and "EXPLAIN FOR CONNECTION n" are allowed.
+func checkExplain(d Dialect, tokens []Token) error {
+	i := 0
+	if i < len(tokens) && tokens[i].Text == "(" {
+		depth := 0
+		for ; i < len(tokens); i++ {
+			if tokens[i].Text == "(" {
+				depth++
+			}
+			if tokens[i].Text == ")" {
+				depth--
+				if depth == 0 {
+					i++
+					break
+				}
+			}
+		}
+	}
+
+options:
+	for i < len(tokens) && tokens[i].Kind == Word && !isQueryStart(tokens[i]) {
+		switch tokens[i].Upper() {
+		case "ANALYZE", "VERBOSE", "EXTENDED", "PARTITIONS", "FORMAT":
+			i++
+			if i+1 < len(tokens) && tokens[i].Text == "=" {
+				i += 2
+			}
+			continue
+		case "FOR":
+			if i+1 < len(tokens) && tokens[i+1].Upper() == "CONNECTION" {
+				return checkQuery(d, tokens[i+2:])
+			}
+		}
+		break options
+	}
+
+	if i >= len(tokens) {
+		return fmt.Errorf("%w: EXPLAIN without a statement", ErrNotReadOnly)
+	}
+	if isQueryStart(tokens[i]) {
+		return checkQuery(d, tokens[i:])
+	}
+
+	// EXPLAIN table [column], a synonym of DESCRIBE.
+	for _, t := range tokens[i:] {
+		if t.Kind != Word && t.Kind != QuotedIdent && t.Text != "." {
+			return fmt.Errorf("%w: EXPLAIN %s is not allowed", ErrNotReadOnly, tokens[i].Upper())
+		}
+	}
+
+	return checkQuery(d, tokens[i:])
+}
+
+// checkQuery rejects data-modifying keywords, locking reads and calls to
+// side-effecting functions anywhere in the statement.
+func checkQuery(d Dialect, tokens []Token) error {
+	for i, t := range tokens {
+		isCall := i+1 < len(tokens) && tokens[i+1].Text == "("
+
+		if t.Kind == Word && !isCall && statementKeywords[t.Upper()] {
+			return fmt.Errorf("%w: %s is not allowed", ErrNotReadOnly, t.Upper())
+		}
+
+		// Locking reads: FOR SHARE, FOR KEY SHARE, FOR NO KEY UPDATE
+		// (FOR UPDATE and LOCK IN SHARE MODE are caught above).
+		if t.Kind == Word && t.Upper() == "FOR" && i+1 < len(tokens) {
+			switch tokens[i+1].Upper() {
+			case "SHARE", "KEY", "NO":
+				return fmt.Errorf("%w: locking reads (FOR %s) are not allowed", ErrNotReadOnly, tokens[i+1].Upper())
+			}
+		}
+
+		if isCall && (t.Kind == Word || t.Kind == QuotedIdent) {
+			name := strings.ToLower(strings.Trim(t.Text, "`\""))
+			if sideEffectFunctions[d][name] {
+				return fmt.Errorf("%w: function %s() has side effects", ErrNotReadOnly, name)
+			}
+			for _, prefix := range sideEffectPrefixes[d] {
+				if strings.HasPrefix(name, prefix) {
+					return fmt.Errorf("%w: function %s() has side effects", ErrNotReadOnly, name)
+				}
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,138 @@
+package sqlguard_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/nicola-strappazzon/argos/internal/sqlguard"
+)
+
+func TestFingerprint(t *testing.T) {
+	tests := []struct {
+		dialect sqlguard.Dialect
+		sql     string
+		want    string
+	}{
+		{sqlguard.MySQL, "SELECT * FROM t WHERE id = 42", "select * from t where id = ?"},
+		{sqlguard.MySQL, "select  *\nfrom `orders`\twhere name='x' and  total > -1.5;", "select * from orders where name = ? and total > ?"},
+		{sqlguard.MySQL, "SELECT * FROM t WHERE id IN (1, 2, 3)", "select * from t where id in (?+)"},
+		{sqlguard.MySQL, "INSERT INTO t (a, b) VALUES (1, 'x'), (2, 'y'), (3, 'z')", "insert into t (a, b) values (?+)"},
+		{sqlguard.MySQL, "UPDATE accounts SET balance = balance - 10 WHERE id = 2", "update accounts set balance = balance - ? where id = ?"},
+		{sqlguard.MySQL, "SELECT /*!40001 SQL_NO_CACHE */ * FROM t # trailing", "select * from t"},
+		{sqlguard.MySQL, "SELECT a.id FROM a WHERE a.x = @v", "select a.id from a where a.x = @v"},
+		{sqlguard.MySQL, "SELECT COUNT(*), SUM( x ) FROM t WHERE a>=1 AND b <> 2 AND c IN(3)", "select count(*), sum(x) from t where a >= ? and b <> ? and c in (?+)"},
+		{sqlguard.PostgreSQL, `SELECT * FROM "Users" WHERE id = $1 AND name = 'bob'`, "select * from users where id = ? and name = ?"},
+		{sqlguard.MySQL, "SELECT 'unterminated", "select 'unterminated"},
+	}
+
+	for _, tt := range tests {
+		if got := sqlguard.Fingerprint(tt.dialect, tt.sql); got != tt.want {
+			t.Errorf("Fingerprint(%q) = %q, want %q", tt.sql, got, tt.want)
+		}
+	}
+}
+
+func TestCheckReadOnly(t *testing.T) {
+	tests := []struct {
+		dialect sqlguard.Dialect
+		sql     string
+		allowed bool
+	}{
+		// Plain reads.
+		{sqlguard.MySQL, "SELECT * FROM t WHERE id = 1", true},
+		{sqlguard.MySQL, "SELECT * FROM t;", true},
+		{sqlguard.MySQL, "(SELECT 1) UNION (SELECT 2)", true},
+		{sqlguard.MySQL, "SHOW ENGINE INNODB STATUS", true},
+		{sqlguard.MySQL, "DESCRIBE orders", true},
+		{sqlguard.MySQL, "EXPLAIN FORMAT=JSON SELECT * FROM t", true},
+		{sqlguard.MySQL, "EXPLAIN ANALYZE SELECT * FROM t", true},
+		{sqlguard.MySQL, "EXPLAIN orders", true},
+		{sqlguard.MySQL, "SELECT REPLACE(name, 'a', 'b'), INSERT(name, 1, 2, 'x') FROM t", true},
+		{sqlguard.PostgreSQL, "VALUES (1), (2)", true},
+		{sqlguard.PostgreSQL, "TABLE orders", true},
+		{sqlguard.PostgreSQL, "EXPLAIN (ANALYZE, BUFFERS) SELECT * FROM t", true},
+		{sqlguard.PostgreSQL, "SELECT pg_stat_get_live_tuples(c.oid) FROM pg_class c", true},
+
+		// Empty or several statements, with semicolons in strings and
+		// comments being part of the one statement.
+		{sqlguard.MySQL, "", false},
+		{sqlguard.MySQL, " ; ", false},
+		{sqlguard.MySQL, "SELECT 1; SELECT 2", false},
+		{sqlguard.MySQL, "SELECT 1; DROP TABLE t", false},
+		{sqlguard.PostgreSQL, "SELECT 1;;DELETE FROM t", false},
+		{sqlguard.MySQL, "SELECT 'a;b' FROM t", true},
+		{sqlguard.MySQL, `SELECT "x; DROP TABLE t" FROM t`, true},
+		{sqlguard.MySQL, "SELECT 1 /* ; DROP TABLE t */", true},
+		{sqlguard.MySQL, "SELECT 1 -- ; DROP TABLE t", true},
+		{sqlguard.MySQL, "SELECT 1 # ; DROP TABLE t", true},
+		{sqlguard.PostgreSQL, "SELECT 'it''s; fine'", true},
+		{sqlguard.PostgreSQL, "SELECT 1 /* outer /* ; */ still a comment */", true},
+		{sqlguard.MySQL, "SELECT 'unterminated; DROP TABLE t", false},
+
+		// Writes and other statements.
+		{sqlguard.MySQL, "INSERT INTO t VALUES (1)", false},
+		{sqlguard.MySQL, "update t set a = 1", false},
+		{sqlguard.MySQL, "SET GLOBAL read_only = 0", false},
+		{sqlguard.PostgreSQL, "BEGIN", false},
+		{sqlguard.PostgreSQL, "EXPLAIN ANALYZE DELETE FROM t", false},
+		{sqlguard.PostgreSQL, "EXPLAIN (ANALYZE) UPDATE t SET a = 1", false},
+		{sqlguard.MySQL, "DESCRIBE DELETE FROM t", false},
+
+		// SELECT ... INTO and locking reads.
+		{sqlguard.MySQL, "SELECT * INTO OUTFILE '/tmp/t' FROM t", false},
+		{sqlguard.MySQL, "SELECT a INTO @v FROM t", false},
+		{sqlguard.PostgreSQL, "SELECT * INTO copy_of_t FROM t", false},
+		{sqlguard.MySQL, "SELECT * FROM t FOR UPDATE", false},
+		{sqlguard.MySQL, "SELECT * FROM t FOR SHARE", false},
+		{sqlguard.MySQL, "SELECT * FROM t LOCK IN SHARE MODE", false},
+		{sqlguard.PostgreSQL, "SELECT * FROM t FOR NO KEY UPDATE", false},
+		{sqlguard.PostgreSQL, "SELECT * FROM t FOR KEY SHARE SKIP LOCKED", false},
+
+		// MySQL executable comments are code.
+		{sqlguard.MySQL, "SELECT 1 /*! ; DROP TABLE t */", false},
+		{sqlguard.MySQL, "SELECT /*!50001 SQL_NO_CACHE */ * FROM t", false},
+		{sqlguard.MySQL, "SELECT /*M!100000 1 */", false},
+		{sqlguard.PostgreSQL, "SELECT 1 /*! not special here */", true},
+
+		// PostgreSQL dollar quotes.
+		{sqlguard.PostgreSQL, "SELECT $$a; DELETE FROM t$$", true},
+		{sqlguard.PostgreSQL, "SELECT $body$ DROP TABLE t; $body$ AS s", true},
+		{sqlguard.PostgreSQL, "SELECT $a$ $b$ ; $b$ $a$", true},
+		{sqlguard.PostgreSQL, "SELECT $x$unterminated; DROP TABLE t", false},
+		{sqlguard.PostgreSQL, "SELECT * FROM t WHERE id = $1", true},
+
+		// CTEs with DML.
+		{sqlguard.PostgreSQL, "WITH recent AS (SELECT * FROM t) SELECT * FROM recent", true},
+		{sqlguard.PostgreSQL, "WITH gone AS (DELETE FROM t RETURNING *) SELECT * FROM gone", false},
+		{sqlguard.PostgreSQL, "WITH x AS (UPDATE t SET a = 1 RETURNING a) SELECT 1", false},
+		{sqlguard.PostgreSQL, "WITH x AS (SELECT 1) INSERT INTO t SELECT * FROM x", false},
+		{sqlguard.MySQL, "WITH x AS (SELECT 1) DELETE FROM t", false},
+
+		// Side-effecting functions.
+		{sqlguard.MySQL, "SELECT SLEEP(10)", false},
+		{sqlguard.MySQL, "SELECT * FROM t WHERE id = 1 AND BENCHMARK(1e9, MD5('a'))", false},
+		{sqlguard.MySQL, "SELECT GET_LOCK('l', 10)", false},
+		{sqlguard.MySQL, "SELECT LOAD_FILE('/etc/passwd')", false},
+		{sqlguard.MySQL, "SELECT `sleep`(1)", false},
+		{sqlguard.PostgreSQL, "SELECT pg_sleep(1)", false},
+		{sqlguard.PostgreSQL, `SELECT "pg_terminate_backend"(42)`, false},
+		{sqlguard.PostgreSQL, "SELECT nextval('orders_id_seq')", false},
+		{sqlguard.PostgreSQL, "SELECT set_config('work_mem', '1GB', false)", false},
+		{sqlguard.PostgreSQL, "SELECT * FROM dblink('host=x', 'DELETE FROM t') AS r(n int)", false},
+		{sqlguard.PostgreSQL, "SELECT pg_stat_reset()", false},
+		{sqlguard.PostgreSQL, "SELECT pg_stat_reset_shared('bgwriter')", false},
+		{sqlguard.PostgreSQL, "SELECT pg_stat_reset_single_table_counters('t'::regclass)", false},
+		{sqlguard.PostgreSQL, "SELECT PG_STAT_STATEMENTS_RESET()", false},
+		{sqlguard.PostgreSQL, "SELECT pg_advisory_lock(1)", false},
+	}
+
+	for _, tt := range tests {
+		err := sqlguard.CheckReadOnly(tt.dialect, tt.sql)
+		if tt.allowed && err != nil {
+			t.Errorf("CheckReadOnly(%q) rejected a read: %v", tt.sql, err)
+		}
+		if !tt.allowed && !errors.Is(err, sqlguard.ErrNotReadOnly) {
+			t.Errorf("CheckReadOnly(%q) = %v, want ErrNotReadOnly", tt.sql, err)
+		}
+	}
+}
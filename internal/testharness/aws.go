@@ -0,0 +1,89 @@
+package testharness
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/nicola-strappazzon/argos/internal/awsclient"
+	"github.com/nicola-strappazzon/argos/internal/config/aws"
+)
+
+// AWS is an awsclient.Factory returning the same fake clients for every
+// target. A fake embeds the service interface and overrides only the calls
+// the tool makes, e.g.
+//
+//	type fakeRDS struct{ awsclient.RDSAPI }
+//
+//	func (fakeRDS) DescribeEvents(...) (*rds.DescribeEventsOutput, error)
+//
+// Asking for a client that is not set fails the call. Targets records every
+// target a client was asked for.
+type AWS struct {
+	RDS            awsclient.RDSAPI
+	EC2            awsclient.EC2API
+	CloudWatch     awsclient.CloudWatchAPI
+	CloudWatchLogs awsclient.CloudWatchLogsAPI
+	PI             awsclient.PIAPI
+	Health         awsclient.HealthAPI
+	SecretsManager awsclient.SecretsManagerAPI
+
+	Targets []awsconfig.Target
+
+	mu sync.Mutex
+}
+
+// UseAWS makes the tools use the fake clients of f for the test.
+func UseAWS(t testing.TB, f *AWS) {
+	t.Helper()
+	t.Cleanup(awsclient.Use(factory{f}))
+}
+
+type factory struct{ f *AWS }
+
+func (x factory) Region(t awsconfig.Target) (string, error) {
+	if t.Region != "" {
+		return t.Region, nil
+	}
+	return "eu-west-1", nil
+}
+
+func (x factory) RDS(t awsconfig.Target) (awsclient.RDSAPI, error) {
+	return client(x.f, t, "rds", x.f.RDS)
+}
+
+func (x factory) EC2(t awsconfig.Target) (awsclient.EC2API, error) {
+	return client(x.f, t, "ec2", x.f.EC2)
+}
+
+func (x factory) CloudWatch(t awsconfig.Target) (awsclient.CloudWatchAPI, error) {
+	return client(x.f, t, "cloudwatch", x.f.CloudWatch)
+}
+
+func (x factory) CloudWatchLogs(t awsconfig.Target) (awsclient.CloudWatchLogsAPI, error) {
+	return client(x.f, t, "logs", x.f.CloudWatchLogs)
+}
+
+func (x factory) PI(t awsconfig.Target) (awsclient.PIAPI, error) {
+	return client(x.f, t, "pi", x.f.PI)
+}
+
+func (x factory) Health(t awsconfig.Target) (awsclient.HealthAPI, error) {
+	return client(x.f, t, "health", x.f.Health)
+}
+
+func (x factory) SecretsManager(t awsconfig.Target) (awsclient.SecretsManagerAPI, error) {
+	return client(x.f, t, "secretsmanager", x.f.SecretsManager)
+}
+
+func client[T comparable](f *AWS, t awsconfig.Target, service string, c T) (T, error) {
+	f.mu.Lock()
+	f.Targets = append(f.Targets, t)
+	f.mu.Unlock()
+
+	var zero T
+	if c == zero {
+		return zero, fmt.Errorf("testharness: no fake %s client", service)
+	}
+	return c, nil
+}
@@ -0,0 +1,236 @@
+package testharness
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	docdbdriver "github.com/nicola-strappazzon/argos/internal/drivers/docdb"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// Wire protocol opcodes.
+const (
+	opReply = 1
+	opQuery = 2004
+	opMsg   = 2013
+)
+
+// DocDBServer is a fake DocumentDB server speaking enough of the MongoDB
+// wire protocol for the driver: it answers the handshake and ping itself
+// and every other command with the reply set for it.
+type DocDBServer struct {
+	mu       sync.Mutex
+	replies  map[string]func(db string, cmd bson.D) (any, error)
+	commands []string
+}
+
+// DocDB makes the instance's client talk to a fake server for the test and
+// returns the server to set command replies on.
+func DocDB(t testing.TB, instanceID string) *DocDBServer {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listening: %v", err)
+	}
+	s := &DocDBServer{replies: map[string]func(string, bson.D) (any, error){}}
+	go s.serve(ln)
+
+	client, err := mongo.Connect(options.Client().
+		ApplyURI("mongodb://" + ln.Addr().String() + "/?directConnection=true").
+		SetServerSelectionTimeout(5 * time.Second))
+	if err != nil {
+		t.Fatalf("connecting to the fake server: %v", err)
+	}
+
+	restore := docdbdriver.Use(instanceID, client)
+	t.Cleanup(func() {
+		restore()
+		client.Disconnect(context.Background())
+		ln.Close()
+	})
+
+	return s
+}
+
+// Reply answers command with the document returned by reply, "ok: 1" being
+// added, or with a command failure if reply returns an error.
+func (s *DocDBServer) Reply(command string, reply func(db string, cmd bson.D) (any, error)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.replies[command] = reply
+}
+
+// Commands returns the "<db>.<command>" of every command received besides
+// the handshake and pings, in order.
+func (s *DocDBServer) Commands() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.commands...)
+}
+
+func (s *DocDBServer) serve(ln net.Listener) {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			for s.handle(conn) == nil {
+			}
+		}()
+	}
+}
+
+// handle reads one message from conn and writes its reply.
+func (s *DocDBServer) handle(conn net.Conn) error {
+	var header [16]byte
+	if _, err := io.ReadFull(conn, header[:]); err != nil {
+		return err
+	}
+	size := int(binary.LittleEndian.Uint32(header[0:]))
+	requestID := binary.LittleEndian.Uint32(header[4:])
+	opCode := binary.LittleEndian.Uint32(header[12:])
+	if size < 16 {
+		return fmt.Errorf("bad message length %d", size)
+	}
+	body := make([]byte, size-16)
+	if _, err := io.ReadFull(conn, body); err != nil {
+		return err
+	}
+
+	var doc bson.Raw
+	switch opCode {
+	case opQuery:
+		// flags, full collection name, skip, limit, query
+		rest := body[4:]
+		for len(rest) > 0 && rest[0] != 0 {
+			rest = rest[1:]
+		}
+		doc = bson.Raw(rest[1+8:])
+	case opMsg:
+		flags := binary.LittleEndian.Uint32(body)
+		if body[4] != 0 {
+			return errors.New("only body sections are supported")
+		}
+		doc = bson.Raw(body[5:])
+		if flags&2 != 0 {
+			// moreToCome: no reply expected.
+			return nil
+		}
+	default:
+		return fmt.Errorf("unsupported opcode %d", opCode)
+	}
+
+	// Document sequences may follow the body of an OP_MSG.
+	var cmd bson.D
+	if err := bson.Unmarshal(doc[:binary.LittleEndian.Uint32(doc)], &cmd); err != nil {
+		return err
+	}
+	if len(cmd) > 0 && cmd[0].Key == "$query" {
+		if inner, ok := cmd[0].Value.(bson.D); ok {
+			cmd = inner
+		}
+	}
+
+	reply, err := bson.Marshal(s.reply(cmd))
+	if err != nil {
+		return err
+	}
+
+	var out []byte
+	if opCode == opQuery {
+		// flags, cursor id, starting from, number returned
+		out = make([]byte, 16+20)
+		binary.LittleEndian.PutUint32(out[12:], opReply)
+		binary.LittleEndian.PutUint32(out[32:], 1)
+	} else {
+		// flags and the body section kind
+		out = make([]byte, 16+5)
+		binary.LittleEndian.PutUint32(out[12:], opMsg)
+	}
+	out = append(out, reply...)
+	binary.LittleEndian.PutUint32(out[0:], uint32(len(out)))
+	binary.LittleEndian.PutUint32(out[8:], requestID)
+
+	_, err = conn.Write(out)
+	return err
+}
+
+func (s *DocDBServer) reply(cmd bson.D) bson.D {
+	if len(cmd) == 0 {
+		return failure(errors.New("empty command"))
+	}
+	name := cmd[0].Key
+	db := "admin"
+	for _, e := range cmd {
+		if e.Key == "$db" {
+			db, _ = e.Value.(string)
+		}
+	}
+
+	switch name {
+	case "hello", "isMaster", "ismaster":
+		return bson.D{
+			{Key: "helloOk", Value: true},
+			{Key: "isWritablePrimary", Value: true},
+			{Key: "ismaster", Value: true},
+			{Key: "maxBsonObjectSize", Value: int32(16 * 1024 * 1024)},
+			{Key: "maxMessageSizeBytes", Value: int32(48000000)},
+			{Key: "maxWriteBatchSize", Value: int32(100000)},
+			{Key: "localTime", Value: time.Now()},
+			{Key: "minWireVersion", Value: int32(0)},
+			{Key: "maxWireVersion", Value: int32(17)},
+			{Key: "ok", Value: 1.0},
+		}
+	case "ping", "endSessions":
+		return bson.D{{Key: "ok", Value: 1.0}}
+	}
+
+	s.mu.Lock()
+	s.commands = append(s.commands, db+"."+name)
+	reply, ok := s.replies[name]
+	s.mu.Unlock()
+	if !ok {
+		return failure(fmt.Errorf("no such command: '%s'", name))
+	}
+
+	v, err := reply(db, cmd)
+	if err != nil {
+		return failure(err)
+	}
+	data, err := bson.Marshal(v)
+	if err != nil {
+		return failure(err)
+	}
+	var d bson.D
+	if err := bson.Unmarshal(data, &d); err != nil {
+		return failure(err)
+	}
+	for _, e := range d {
+		if e.Key == "ok" {
+			return d
+		}
+	}
+	return append(d, bson.E{Key: "ok", Value: 1.0})
+}
+
+func failure(err error) bson.D {
+	return bson.D{
+		{Key: "ok", Value: 0.0},
+		{Key: "errmsg", Value: err.Error()},
+		{Key: "code", Value: int32(59)},
+		{Key: "codeName", Value: "CommandNotFound"},
+	}
+}
@@ -0,0 +1,137 @@
+// Package testharness runs the registered tools end to end without network
+// access: through an in-memory MCP client session, against sqlmock stand-ins
+// for MySQL and PostgreSQL and fake AWS service clients.
+//
+// A plugin test imports its plugin so it registers, sets up the
+// backends it needs and calls the tool:
+//
+//	mock := testharness.MySQL(t, "db1")
+//	mock.ExpectQuery("SELECT 1").WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+//	res := testharness.Call(t, "mysql_ping", map[string]any{"db_instance_identifier": "db1"})
+package testharness
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/nicola-strappazzon/argos/internal/server"
+	"github.com/nicola-strappazzon/argos/internal/toolerr"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Session starts a server with every registered tool and returns a client
+// session connected to it in memory. HOME points to an empty directory and
+// AWS_REGION to eu-west-1, so no local configuration leaks into the test.
+// ARGOS_WORKSPACE points to a directory of the test unless already set, and
+// is kept by the later sessions of the test.
+func Session(t testing.TB) *mcp.ClientSession {
+	t.Helper()
+
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("AWS_REGION", "eu-west-1")
+	t.Setenv("AWS_PROFILE", "")
+	if os.Getenv("ARGOS_WORKSPACE") == "" {
+		t.Setenv("ARGOS_WORKSPACE", t.TempDir())
+	}
+
+	srv, err := server.New(nil)
+	if err != nil {
+		t.Fatalf("creating server: %v", err)
+	}
+
+	ctx := context.Background()
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+
+	ss, err := srv.Connect(ctx, serverTransport, nil)
+	if err != nil {
+		t.Fatalf("connecting server: %v", err)
+	}
+
+	client := mcp.NewClient(&mcp.Implementation{Name: "testharness"}, nil)
+	cs, err := client.Connect(ctx, clientTransport, nil)
+	if err != nil {
+		t.Fatalf("connecting client: %v", err)
+	}
+
+	t.Cleanup(func() {
+		cs.Close()
+		ss.Wait()
+	})
+
+	return cs
+}
+
+// Call runs the tool through a new session and returns its result. A
+// protocol error, including output that does not match the tool's output
+// schema, fails the test; tool failures are returned as IsError results.
+func Call(t testing.TB, tool string, args map[string]any) *mcp.CallToolResult {
+	t.Helper()
+
+	res, err := Session(t).CallTool(context.Background(), &mcp.CallToolParams{
+		Name:      tool,
+		Arguments: args,
+	})
+	if err != nil {
+		t.Fatalf("calling %s: %v", tool, err)
+	}
+
+	return res
+}
+
+// Decode checks that the call succeeded and decodes its structured content
+// into v.
+func Decode(t testing.TB, res *mcp.CallToolResult, v any) {
+	t.Helper()
+
+	if res.IsError {
+		t.Fatalf("tool failed: %s", Text(res))
+	}
+
+	data, err := json.Marshal(res.StructuredContent)
+	if err != nil {
+		t.Fatalf("encoding structured content: %v", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatalf("decoding structured content %s: %v", data, err)
+	}
+}
+
+// Text returns the text content of the result.
+func Text(res *mcp.CallToolResult) string {
+	var sb strings.Builder
+	for _, c := range res.Content {
+		if text, ok := c.(*mcp.TextContent); ok {
+			sb.WriteString(text.Text)
+		}
+	}
+	return sb.String()
+}
+
+// Failure checks that the call failed with the given error kind (see
+// toolerr), read from its structured content, and returns its text.
+func Failure(t testing.TB, res *mcp.CallToolResult, kind toolerr.Kind) string {
+	t.Helper()
+
+	text := Text(res)
+	if !res.IsError {
+		t.Fatalf("expected a %s failure, got success: %s", kind, text)
+	}
+
+	var report toolerr.Report
+	data, err := json.Marshal(res.StructuredContent)
+	if err != nil {
+		t.Fatalf("encoding structured content: %v", err)
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatalf("decoding structured content %s: %v", data, err)
+	}
+	if report.Kind != kind {
+		t.Fatalf("expected a %s failure, got %s: %s", kind, report.Kind, text)
+	}
+
+	return text
+}
@@ -0,0 +1,67 @@
+package testharness
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+
+	mysqldriver "github.com/nicola-strappazzon/argos/internal/drivers/mysql"
+	psqldriver "github.com/nicola-strappazzon/argos/internal/drivers/postgresql"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// MySQL makes the instance's connection a sqlmock database for the test and
+// returns the mock to set expectations on. Queries are matched as regular
+// expressions, in any order; unmet expectations fail the test.
+func MySQL(t testing.TB, instanceID string) sqlmock.Sqlmock {
+	t.Helper()
+
+	db, mock := newMock(t)
+	t.Cleanup(mysqldriver.Use(instanceID, db))
+
+	return mock
+}
+
+// PostgreSQL is MySQL for a PostgreSQL instance. An empty database stands
+// for the instance's default database.
+func PostgreSQL(t testing.TB, instanceID, database string) sqlmock.Sqlmock {
+	t.Helper()
+
+	db, mock := newMock(t)
+	t.Cleanup(psqldriver.Use(instanceID, database, db))
+
+	return mock
+}
+
+func newMock(t testing.TB) (*sql.DB, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherRegexp))
+	if err != nil {
+		t.Fatalf("creating sqlmock: %v", err)
+	}
+	mock.MatchExpectationsInOrder(false)
+
+	t.Cleanup(func() {
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("sqlmock: %v", err)
+		}
+		db.Close()
+	})
+
+	return db, mock
+}
+
+// Rows builds the result of a mocked query from column names and rows.
+func Rows(columns []string, rows ...[]any) *sqlmock.Rows {
+	r := sqlmock.NewRows(columns)
+	for _, row := range rows {
+		values := make([]driver.Value, len(row))
+		for i, v := range row {
+			values[i] = v
+		}
+		r.AddRow(values...)
+	}
+	return r
+}
@@ -0,0 +1,59 @@
+// Package timerange reads the time range arguments of the tools, given in
+// RFC 3339.
+package timerange
+
+import (
+	"time"
+
+	"github.com/nicola-strappazzon/argos/internal/toolerr"
+)
+
+// Parse reads the start_time and end_time arguments, see ParseNames.
+func Parse(args map[string]any, window time.Duration) (time.Time, time.Time, error) {
+	return ParseNames(args, "start_time", "end_time", window)
+}
+
+// ParseNames reads the bounds of a time range from the arguments named
+// start and end, in UTC. With a window, a missing end is now and a missing
+// start the window before the end. Without one, a missing bound is zero and
+// the range open on that side.
+func ParseNames(args map[string]any, start, end string, window time.Duration) (time.Time, time.Time, error) {
+	until, err := parse(args, end)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if until.IsZero() && window > 0 {
+		until = time.Now().UTC()
+	}
+
+	since, err := parse(args, start)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if since.IsZero() && window > 0 {
+		since = until.Add(-window)
+	}
+
+	if !since.IsZero() && !until.IsZero() && !since.Before(until) {
+		return time.Time{}, time.Time{}, toolerr.Errorf(toolerr.InvalidInput, start+" must be before "+end,
+			"empty time range: %s to %s", since.Format(time.RFC3339), until.Format(time.RFC3339))
+	}
+
+	return since, until, nil
+}
+
+// parse returns the time of an argument, zero when missing.
+func parse(args map[string]any, name string) (time.Time, error) {
+	s, _ := args[name].(string)
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, toolerr.Errorf(toolerr.InvalidInput, "use RFC 3339, e.g. 2024-05-14T09:00:00Z",
+			"invalid %s: %s", name, s)
+	}
+
+	return t.UTC(), nil
+}
@@ -0,0 +1,53 @@
+package timerange_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nicola-strappazzon/argos/internal/timerange"
+	"github.com/nicola-strappazzon/argos/internal/toolerr"
+)
+
+func TestParse(t *testing.T) {
+	start, end, err := timerange.Parse(map[string]any{"start_time": "2024-05-14T11:00:00+02:00", "end_time": "2024-05-14T10:00:00Z"}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !start.Equal(time.Date(2024, 5, 14, 9, 0, 0, 0, time.UTC)) || end.Location() != time.UTC {
+		t.Errorf("unexpected range: %s to %s", start, end)
+	}
+
+	start, end, err = timerange.Parse(map[string]any{"end_time": "2024-05-14T10:00:00Z"}, 24*time.Hour)
+	if err != nil || end.Sub(start) != 24*time.Hour {
+		t.Errorf("expected the default window before end_time, got %s to %s, %v", start, end, err)
+	}
+
+	start, end, err = timerange.Parse(map[string]any{}, time.Hour)
+	if err != nil || time.Since(end) > time.Minute || end.Sub(start) != time.Hour {
+		t.Errorf("expected the last hour, got %s to %s, %v", start, end, err)
+	}
+}
+
+func TestParseOpen(t *testing.T) {
+	since, until, err := timerange.ParseNames(map[string]any{"since": "2024-05-14T09:00:00Z"}, "since", "until", 0)
+	if err != nil || since.IsZero() || !until.IsZero() {
+		t.Errorf("expected a range open at the end, got %s to %s, %v", since, until, err)
+	}
+
+	if since, until, err := timerange.ParseNames(nil, "since", "until", 0); err != nil || !since.IsZero() || !until.IsZero() {
+		t.Errorf("expected an unbounded range, got %s to %s, %v", since, until, err)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, args := range []map[string]any{
+		{"start_time": "yesterday"},
+		{"end_time": "2024-05-14 10:00"},
+		{"start_time": "2024-05-14T10:00:00Z", "end_time": "2024-05-14T10:00:00Z"},
+		{"start_time": "2024-05-14T12:00:00Z", "end_time": "2024-05-14T08:00:00Z"},
+	} {
+		if _, _, err := timerange.Parse(args, time.Hour); toolerr.Classify(err).Kind != toolerr.InvalidInput {
+			t.Errorf("%v: expected invalid input, got %v", args, err)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package toolerr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os/exec"
+	"sync"
+)
+
+// Kind classifies a tool failure so the model can decide what to do next.
+type Kind string
+
+const (
+	Auth             Kind = "auth_failure"
+	NotFound         Kind = "not_found"
+	Timeout          Kind = "timeout"
+	PermissionDenied Kind = "permission_denied"
+	Unsupported      Kind = "unsupported_engine"
+	InvalidInput     Kind = "invalid_input"
+	Misconfigured    Kind = "misconfigured"
+	Unavailable      Kind = "unavailable"
+	Internal         Kind = "internal"
+)
+
+// Error is a tool failure with its kind and a remediation hint, such as the
+// configuration to add or the tool to call first.
+type Error struct {
+	Kind Kind
+	Hint string
+	Err  error
+}
+
+func (e *Error) Error() string { return e.Err.Error() }
+func (e *Error) Unwrap() error { return e.Err }
+
+// Report is the structured content of a failed tool call.
+type Report struct {
+	Kind    Kind   `json:"kind"`
+	Message string `json:"message"`
+	Hint    string `json:"hint"`
+}
+
+// Report returns the structured form of e.
+func (e *Error) Report() Report {
+	return Report{Kind: e.Kind, Message: e.Err.Error(), Hint: e.Hint}
+}
+
+// New wraps err with a kind and a hint. It returns nil if err is nil.
+func New(kind Kind, hint string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Kind: kind, Hint: hint, Err: err}
+}
+
+// Errorf is New with an error built by fmt.Errorf.
+func Errorf(kind Kind, hint, format string, args ...any) error {
+	return New(kind, hint, fmt.Errorf(format, args...))
+}
+
+// A Classifier recognises errors of a driver or SDK, e.g. by error code,
+// and returns their kind and hint. ok is false for errors it does not know.
+type Classifier func(err error) (kind Kind, hint string, ok bool)
+
+var (
+	mu          sync.RWMutex
+	classifiers []Classifier
+)
+
+// Register adds a classifier used by Classify. Packages that own an error
+// space (database drivers, the AWS config) register one in init().
+func Register(c Classifier) {
+	mu.Lock()
+	defer mu.Unlock()
+	classifiers = append(classifiers, c)
+}
+
+// Classify returns err as an *Error: with the kind and hint of the one
+// wrapped in err if any, keeping the context err adds to it, otherwise the
+// result of the first registered classifier that recognises it, falling back
+// to Internal.
+func Classify(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		if err != error(e) {
+			return &Error{Kind: e.Kind, Hint: e.Hint, Err: err}
+		}
+		return e
+	}
+
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, c := range classifiers {
+		if kind, hint, ok := c(err); ok {
+			return &Error{Kind: kind, Hint: hint, Err: err}
+		}
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return &Error{Kind: Timeout, Hint: "retry with a narrower request", Err: err}
+	}
+
+	if errors.Is(err, exec.ErrNotFound) {
+		return &Error{Kind: Misconfigured, Hint: "install Percona Toolkit on the server and make sure it is in PATH", Err: err}
+	}
+
+	var netErr *net.OpError
+	if errors.As(err, &netErr) {
+		return &Error{
+			Kind: Unavailable,
+			Hint: "check the host and port, the security groups, or reach the instance through a bastion with ssh_host",
+			Err:  err,
+		}
+	}
+
+	return &Error{Kind: Internal, Err: err}
+}
@@ -0,0 +1,29 @@
+package toolerr_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/nicola-strappazzon/argos/internal/toolerr"
+)
+
+func TestClassify(t *testing.T) {
+	inner := toolerr.Errorf(toolerr.NotFound, "call aws_rds_logs first", "log file %q not found", "error/mysql-error.log")
+
+	if e := toolerr.Classify(inner); e != inner {
+		t.Errorf("expected the *Error itself, got %+v", e)
+	}
+
+	e := toolerr.Classify(fmt.Errorf("downloading db1: %w", inner))
+	if e.Kind != toolerr.NotFound || e.Hint != "call aws_rds_logs first" {
+		t.Errorf("expected the kind and hint of the wrapped error, got %+v", e)
+	}
+	if e.Report().Message != `downloading db1: log file "error/mysql-error.log" not found` || !errors.Is(e, inner) {
+		t.Errorf("expected the outer context in the message, got %q", e.Report().Message)
+	}
+
+	if e := toolerr.Classify(errors.New("boom")); e.Kind != toolerr.Internal {
+		t.Errorf("expected an unknown error to be internal, got %+v", e)
+	}
+}
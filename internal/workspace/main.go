@@ -0,0 +1,407 @@
+// Package workspace manages the files tools write to disk: downloaded logs
+// and reports. They live under a single root, by instance and tool:
+//
+//	<root>/<instance>/<tool>/<file>          reused across calls, e.g. downloads
+//	<root>/<instance>/<tool>/<run>/<file>    one directory per call, e.g. reports
+//
+// Files older than the retention are pruned, and the oldest ones go first
+// when the workspace grows past its size quota.
+package workspace
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nicola-strappazzon/argos/internal/config/workspace"
+	"github.com/nicola-strappazzon/argos/internal/toolerr"
+)
+
+// URIPrefix starts the MCP resource URI of an artifact, followed by its path
+// relative to the root.
+const URIPrefix = "argos://artifacts/"
+
+// Local is the instance of artifacts not tied to a database instance, e.g.
+// reports on a log given by path.
+const Local = "local"
+
+// pruneInterval is the least time between two prunes of a workspace by Dir,
+// so that tools called in a row do not each walk the whole tree.
+const pruneInterval = time.Minute
+
+var (
+	pruneMu   sync.Mutex
+	lastPrune = map[string]time.Time{}
+)
+
+// Artifact is a file of the workspace.
+type Artifact struct {
+	// Path is relative to the workspace root.
+	Path     string    `json:"path"`
+	URI      string    `json:"uri"`
+	Instance string    `json:"instance"`
+	Tool     string    `json:"tool"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+}
+
+// Root returns the workspace root, created if needed.
+func Root() (string, error) {
+	root, err := filepath.Abs(workspaceconfig.Root())
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return "", toolerr.Errorf(toolerr.Misconfigured, "set ARGOS_WORKSPACE to a writable directory",
+			"creating workspace: %v", err)
+	}
+	return root, nil
+}
+
+// Dir returns the directory of a tool's files for an instance, created if
+// needed. The workspace is pruned first, this directory aside, at most once
+// per pruneInterval.
+func Dir(instance, tool string) (string, error) {
+	root, err := Root()
+	if err != nil {
+		return "", err
+	}
+
+	dir := filepath.Join(root, name(instance), name(tool))
+	if now := time.Now(); due(root, now) {
+		if _, err := prune(root, dir, now); err != nil {
+			log.Printf("Workspace prune failed: %v", err)
+		}
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("creating %s: %w", dir, err)
+	}
+
+	return dir, nil
+}
+
+// due reports whether the workspace at root was last pruned by Dir more than
+// pruneInterval ago, and records now as its last prune if so.
+func due(root string, now time.Time) bool {
+	pruneMu.Lock()
+	defer pruneMu.Unlock()
+
+	if now.Sub(lastPrune[root]) < pruneInterval {
+		return false
+	}
+	lastPrune[root] = now
+	return true
+}
+
+// NewRun returns a new directory for the files of a single call, named after
+// the time of the call, e.g. 20240514T090000Z-123456.
+func NewRun(instance, tool string) (string, error) {
+	dir, err := Dir(instance, tool)
+	if err != nil {
+		return "", err
+	}
+
+	run, err := os.MkdirTemp(dir, time.Now().UTC().Format("20060102T150405Z")+"-")
+	if err != nil {
+		return "", fmt.Errorf("creating run directory: %w", err)
+	}
+
+	return run, os.Chmod(run, 0755)
+}
+
+// InstanceOf returns the instance of a path in the workspace, or Local for
+// a path outside of it.
+func InstanceOf(path string) string {
+	rel, ok := relative(path)
+	if !ok {
+		return Local
+	}
+	instance, _, _ := strings.Cut(rel, "/")
+	return instance
+}
+
+// URI returns the resource URI of a path in the workspace, or "" for a path
+// outside of it.
+func URI(path string) string {
+	rel, ok := relative(path)
+	if !ok {
+		return ""
+	}
+	return URIPrefix + rel
+}
+
+// List returns the files of the workspace, newest first, optionally only
+// those of an instance and a tool.
+func List(instance, tool string) ([]Artifact, error) {
+	root, err := Root()
+	if err != nil {
+		return nil, err
+	}
+
+	out := []Artifact{}
+	err = walk(root, func(path string, info fs.FileInfo) {
+		a := artifact(root, path, info)
+		if (instance == "" || a.Instance == instance) && (tool == "" || a.Tool == tool) {
+			out = append(out, a)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortStableFunc(out, func(a, b Artifact) int { return b.Modified.Compare(a.Modified) })
+	return out, nil
+}
+
+// Resolve returns the absolute path of an artifact given by URI, by path
+// relative to the root or by absolute path, refusing anything outside the
+// workspace, symbolic links pointing out of it included.
+func Resolve(ref string) (string, error) {
+	root, err := Root()
+	if err != nil {
+		return "", err
+	}
+
+	ref = strings.TrimPrefix(ref, URIPrefix)
+	path := ref
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	path = filepath.Clean(path)
+
+	outside := toolerr.Errorf(toolerr.InvalidInput, "pass a path or URI returned by artifacts_list",
+		"%s is outside of the workspace", ref)
+	if !within(root, path) {
+		return "", outside
+	}
+
+	resolved, err := filepath.EvalSymlinks(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", toolerr.Errorf(toolerr.NotFound, "list the workspace with artifacts_list",
+			"artifact not found: %s", ref)
+	}
+	if err != nil {
+		return "", err
+	}
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", err
+	}
+	if !within(realRoot, resolved) {
+		return "", outside
+	}
+
+	return path, nil
+}
+
+// within reports whether path is root or under it.
+func within(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// Read returns up to limit bytes of an artifact from offset, and its size.
+func Read(ref string, offset, limit int64) ([]byte, int64, error) {
+	path, err := Resolve(ref)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, 0, err
+	}
+	if info.IsDir() {
+		return nil, 0, toolerr.Errorf(toolerr.InvalidInput, "pass the path of a file, see artifacts_list",
+			"%s is a directory", ref)
+	}
+
+	data, err := io.ReadAll(io.NewSectionReader(f, offset, limit))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return data, info.Size(), nil
+}
+
+// Delete removes an artifact, or a directory of artifacts, and returns the
+// number of files and bytes freed.
+func Delete(ref string) (int, int64, error) {
+	path, err := Resolve(ref)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	root, _ := Root()
+	if path == root {
+		return 0, 0, toolerr.Errorf(toolerr.InvalidInput, "pass an instance, a tool directory or a file",
+			"refusing to delete the whole workspace")
+	}
+
+	var files int
+	var bytes int64
+	if err := walk(path, func(_ string, info fs.FileInfo) {
+		files++
+		bytes += info.Size()
+	}); err != nil {
+		return 0, 0, err
+	}
+
+	if err := os.RemoveAll(path); err != nil {
+		return 0, 0, err
+	}
+	removeEmpty(root, filepath.Dir(path))
+
+	return files, bytes, nil
+}
+
+// Prune removes the files older than the retention, then the oldest ones
+// until the workspace fits its quota, and returns the number of files and
+// bytes freed.
+func Prune() (int, int64, error) {
+	root, err := Root()
+	if err != nil {
+		return 0, 0, err
+	}
+	p, err := prune(root, "", time.Now())
+	return p.files, p.bytes, err
+}
+
+type pruned struct {
+	files int
+	bytes int64
+}
+
+// prune removes files of the workspace, those under keep aside.
+func prune(root, keep string, now time.Time) (pruned, error) {
+	type file struct {
+		path string
+		info fs.FileInfo
+	}
+
+	var files []file
+	var total int64
+	err := walk(root, func(path string, info fs.FileInfo) {
+		if keep != "" && (path == keep || strings.HasPrefix(path, keep+string(filepath.Separator))) {
+			return
+		}
+		files = append(files, file{path, info})
+		total += info.Size()
+	})
+	if err != nil {
+		return pruned{}, err
+	}
+
+	slices.SortFunc(files, func(a, b file) int { return a.info.ModTime().Compare(b.info.ModTime()) })
+
+	var p pruned
+	cutoff := now.Add(-workspaceconfig.Retention())
+	quota := workspaceconfig.MaxBytes()
+	for _, f := range files {
+		if !f.info.ModTime().Before(cutoff) && total <= quota {
+			break
+		}
+		if err := os.Remove(f.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return p, err
+		}
+		total -= f.info.Size()
+		p.files++
+		p.bytes += f.info.Size()
+		removeEmpty(root, filepath.Dir(f.path))
+	}
+
+	return p, nil
+}
+
+// walk calls fn for each regular file under dir.
+func walk(dir string, fn func(path string, info fs.FileInfo)) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		fn(path, info)
+		return nil
+	})
+}
+
+// removeEmpty removes dir and its parents up to root while they are empty.
+func removeEmpty(root, dir string) {
+	for dir != root && strings.HasPrefix(dir, root) {
+		if os.Remove(dir) != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+func artifact(root, path string, info fs.FileInfo) Artifact {
+	rel, _ := filepath.Rel(root, path)
+	rel = filepath.ToSlash(rel)
+
+	a := Artifact{
+		Path:     rel,
+		URI:      URIPrefix + rel,
+		Size:     info.Size(),
+		Modified: info.ModTime().UTC(),
+	}
+	if parts := strings.SplitN(rel, "/", 3); len(parts) == 3 {
+		a.Instance, a.Tool = parts[0], parts[1]
+	}
+
+	return a
+}
+
+func relative(path string) (string, bool) {
+	root, err := filepath.Abs(workspaceconfig.Root())
+	if err != nil {
+		return "", false
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", false
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// name makes an instance or tool name safe as a single path element.
+func name(s string) string {
+	s = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		}
+		return '_'
+	}, s)
+	if strings.Trim(s, ".") == "" {
+		return Local
+	}
+	return s
+}
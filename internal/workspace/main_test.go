@@ -0,0 +1,188 @@
+package workspace_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nicola-strappazzon/argos/internal/toolerr"
+	"github.com/nicola-strappazzon/argos/internal/workspace"
+)
+
+// root points the workspace to a directory of the test.
+func root(t *testing.T) string {
+	dir := t.TempDir()
+	t.Setenv("ARGOS_WORKSPACE", dir)
+	t.Setenv("ARGOS_WORKSPACE_RETENTION", "")
+	t.Setenv("ARGOS_WORKSPACE_MAX_SIZE_MB", "")
+	return dir
+}
+
+// write creates a file of size bytes under dir, last modified age ago.
+func write(t *testing.T, path string, size int, age time.Duration) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(strings.Repeat("x", size)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mtime := time.Now().Add(-age)
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNewRun(t *testing.T) {
+	dir := root(t)
+
+	a, err := workspace.NewRun("db-1", "pt_query_digest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := workspace.NewRun("db-1", "pt_query_digest")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if a == b || filepath.Dir(a) != filepath.Join(dir, "db-1", "pt_query_digest") {
+		t.Errorf("expected two run directories under db-1/pt_query_digest, got %s and %s", a, b)
+	}
+	if got := workspace.InstanceOf(filepath.Join(a, "report.txt")); got != "db-1" {
+		t.Errorf("InstanceOf = %q, want db-1", got)
+	}
+	if got := workspace.InstanceOf("/var/log/slow.log"); got != workspace.Local {
+		t.Errorf("InstanceOf = %q, want %s", got, workspace.Local)
+	}
+
+	odd, err := workspace.Dir("../x", "t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Dir(filepath.Dir(odd)) != dir {
+		t.Errorf("expected the instance name to stay a single path element, got %s", odd)
+	}
+}
+
+func TestListAndResolve(t *testing.T) {
+	dir := root(t)
+	write(t, filepath.Join(dir, "db1", "aws_rds_log_download", "slowquery", "mysql-slowquery.log"), 10, time.Hour)
+	write(t, filepath.Join(dir, "db2", "pt_index_usage", "run", "report.txt"), 5, time.Minute)
+
+	list, err := workspace.List("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[0].Instance != "db2" || list[0].Tool != "pt_index_usage" ||
+		list[1].URI != "argos://artifacts/db1/aws_rds_log_download/slowquery/mysql-slowquery.log" {
+		t.Errorf("unexpected list: %+v", list)
+	}
+
+	if list, _ := workspace.List("db1", ""); len(list) != 1 {
+		t.Errorf("expected one file of db1, got %+v", list)
+	}
+
+	for _, ref := range []string{"db2/pt_index_usage/run/report.txt", "argos://artifacts/db2/pt_index_usage/run/report.txt"} {
+		if _, err := workspace.Resolve(ref); err != nil {
+			t.Errorf("Resolve(%q): %v", ref, err)
+		}
+	}
+
+	_, err = workspace.Resolve("../../etc/passwd")
+	if e := toolerr.Classify(err); e.Kind != toolerr.InvalidInput {
+		t.Errorf("expected a path outside of the workspace to be refused, got %v", err)
+	}
+	_, err = workspace.Resolve("db3/missing.txt")
+	if e := toolerr.Classify(err); e.Kind != toolerr.NotFound {
+		t.Errorf("expected a missing artifact to be not found, got %v", err)
+	}
+}
+
+func TestResolveSymlink(t *testing.T) {
+	dir := root(t)
+	outside := filepath.Join(t.TempDir(), "secret.txt")
+	write(t, outside, 5, 0)
+	write(t, filepath.Join(dir, "db1", "t", "report.txt"), 5, 0)
+
+	if err := os.Symlink(outside, filepath.Join(dir, "db1", "t", "link.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Dir(outside), filepath.Join(dir, "db1", "dir")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("report.txt", filepath.Join(dir, "db1", "t", "inside.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, ref := range []string{"db1/t/link.txt", "db1/dir/secret.txt"} {
+		if _, err := workspace.Resolve(ref); toolerr.Classify(err).Kind != toolerr.InvalidInput {
+			t.Errorf("Resolve(%q): expected a link out of the workspace to be refused, got %v", ref, err)
+		}
+	}
+	if _, err := workspace.Resolve("db1/t/inside.txt"); err != nil {
+		t.Errorf("expected a link within the workspace to resolve, got %v", err)
+	}
+}
+
+func TestDelete(t *testing.T) {
+	dir := root(t)
+	write(t, filepath.Join(dir, "db1", "pt_index_usage", "a", "report.txt"), 2048, 0)
+	write(t, filepath.Join(dir, "db1", "pt_index_usage", "b", "report.txt"), 1024, 0)
+
+	files, bytes, err := workspace.Delete("db1/pt_index_usage/a/report.txt")
+	if err != nil || files != 1 || bytes != 2048 {
+		t.Fatalf("Delete = %d, %d, %v", files, bytes, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "db1", "pt_index_usage", "a")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected the emptied run directory to be removed, got %v", err)
+	}
+
+	if _, _, err := workspace.Delete(""); toolerr.Classify(err).Kind != toolerr.InvalidInput {
+		t.Errorf("expected the workspace root to be refused, got %v", err)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	dir := root(t)
+	t.Setenv("ARGOS_WORKSPACE_RETENTION", "24h")
+	t.Setenv("ARGOS_WORKSPACE_MAX_SIZE_MB", "1")
+
+	expired := filepath.Join(dir, "db1", "t", "expired.log")
+	oldest := filepath.Join(dir, "db1", "t", "oldest.log")
+	newest := filepath.Join(dir, "db1", "t", "newest.log")
+	kept := filepath.Join(dir, "db2", "t", "kept.log")
+	write(t, expired, 10, 48*time.Hour)
+	write(t, oldest, 600<<10, 3*time.Hour)
+	write(t, newest, 600<<10, time.Hour)
+	write(t, kept, 600<<10, 4*time.Hour)
+
+	// The files of db2/t are kept as the directory is in use.
+	if _, err := workspace.Dir("db2", "t"); err != nil {
+		t.Fatal(err)
+	}
+
+	for path, want := range map[string]bool{expired: false, oldest: false, newest: true, kept: true} {
+		if _, err := os.Stat(path); (err == nil) != want {
+			t.Errorf("%s: expected kept=%v, got %v", filepath.Base(path), want, err)
+		}
+	}
+
+	// Another Dir right after does not walk the workspace again, while an
+	// explicit Prune does.
+	write(t, expired, 10, 48*time.Hour)
+	if _, err := workspace.Dir("db2", "t"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(expired); err != nil {
+		t.Errorf("expected the prune to be skipped within the interval, got %v", err)
+	}
+	if _, _, err := workspace.Prune(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(expired); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected Prune to remove the expired file, got %v", err)
+	}
+}
@@ -2,8 +2,17 @@ package main
 
 import (
 	"context"
+	"flag"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
 
+	"github.com/nicola-strappazzon/argos/internal/config/http"
+	"github.com/nicola-strappazzon/argos/internal/config/tools"
+	"github.com/nicola-strappazzon/argos/internal/drivers/pool"
+	"github.com/nicola-strappazzon/argos/internal/httpserver"
+	"github.com/nicola-strappazzon/argos/internal/server"
 	_ "github.com/nicola-strappazzon/argos/tools/plugins"
 	"github.com/nicola-strappazzon/argos/tools/registry"
 
@@ -11,28 +20,68 @@ import (
 )
 
 func main() {
-	server := mcp.NewServer(&mcp.Implementation{Name: "My personal assistant"}, nil)
-
-	for key := range registry.Tools {
-		if tool, ok := registry.Tools[key]; ok {
-			log.Printf("Load tool: %s", tool.Name)
-
-			inputSchema := tool.InputSchema
-			if inputSchema == nil {
-				inputSchema = map[string]any{"type": "object"}
-			}
-
-			mcp.AddTool(server, &mcp.Tool{
-				Name:        tool.Name,
-				Description: tool.Description,
-				InputSchema: inputSchema,
-			},
-				tool.Function,
-			)
-		}
+	config := flag.String("config", "", "config file with a [tools] section (default ~/.argos.cnf)")
+	enable := flag.String("enable", "", "comma separated tools, globs or groups to expose; overrides the config file")
+	disable := flag.String("disable", "", "comma separated tools, globs or groups to hide, added to the config file")
+	listen := flag.String("http", "", "serve streamable HTTP and SSE on this address (e.g. :8080) instead of stdio")
+	flag.Parse()
+
+	filter, err := loadFilter(*config, *enable, *disable)
+	if err != nil {
+		log.Fatalf("Tools config: %v", err)
 	}
 
-	if err := server.Run(context.Background(), &mcp.StdioTransport{}); err != nil {
+	srv, err := server.New(filter)
+	if err != nil {
+		log.Fatalf("Server: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if *listen != "" {
+		cfg, err := httpconfig.Load()
+		if err != nil {
+			log.Fatalf("HTTP config: %v", err)
+		}
+		if err := httpserver.Serve(ctx, *listen, srv, cfg); err != nil {
+			log.Printf("Server failed: %v", err)
+		}
+	} else if err := srv.Run(ctx, &mcp.StdioTransport{}); err != nil {
 		log.Printf("Server failed: %v", err)
 	}
+
+	pool.CloseAll()
+}
+
+// loadFilter merges the [tools] section of the config file with the
+// -enable and -disable flags, and warns about patterns matching no tool.
+func loadFilter(path, enable, disable string) (*toolsconfig.Filter, error) {
+	required := path != ""
+	if !required {
+		var err error
+		if path, err = toolsconfig.DefaultPath(); err != nil {
+			return nil, err
+		}
+	}
+
+	filter, err := toolsconfig.Load(path, required)
+	if err != nil {
+		return nil, err
+	}
+
+	if enable != "" {
+		filter.Enable = toolsconfig.Split(enable)
+	}
+	filter.Disable = append(filter.Disable, toolsconfig.Split(disable)...)
+
+	tools := map[string]string{}
+	for _, tool := range registry.Tools {
+		tools[tool.Name] = tool.Group()
+	}
+	for _, pattern := range filter.Unused(tools) {
+		log.Printf("Tools config: pattern %q matches no tool", pattern)
+	}
+
+	return filter, nil
 }
@@ -0,0 +1,51 @@
+package artifacts_delete
+
+import (
+	"context"
+	"time"
+
+	"github.com/nicola-strappazzon/argos/internal/workspace"
+	"github.com/nicola-strappazzon/argos/tools/registry"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type Result struct {
+	Path         string `json:"path"`
+	DeletedFiles int    `json:"deleted_files"`
+	FreedKB      int64  `json:"freed_kb"`
+}
+
+func init() {
+	registry.Add(registry.Property{
+		Name:        "artifacts_delete",
+		Description: "Delete a file of the workspace, or a directory of files such as all those of an instance (e.g. \"db1\") or of a tool for an instance (e.g. \"db1/pt_query_digest\"). Only local files written by Argos tools can be deleted.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"path": map[string]any{
+					"type":        "string",
+					"description": "Path relative to the workspace, absolute path or argos://artifacts/ URI of the file or directory.",
+				},
+			},
+			"required": []string{"path"},
+		},
+		Output:  Result{},
+		Timeout: time.Minute,
+		Writes:  true,
+		Function: func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
+			path, _ := args["path"].(string)
+
+			files, bytes, err := workspace.Delete(path)
+			if err != nil {
+				return &mcp.CallToolResult{}, nil, err
+			}
+
+			return &mcp.CallToolResult{}, Result{
+				Path:         path,
+				DeletedFiles: files,
+				FreedKB:      bytes / 1024,
+			}, nil
+		},
+	})
+}
@@ -0,0 +1,35 @@
+package artifacts_delete_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nicola-strappazzon/argos/internal/testharness"
+	"github.com/nicola-strappazzon/argos/internal/toolerr"
+	"github.com/nicola-strappazzon/argos/tools/plugins/artifacts/artifacts_delete"
+)
+
+func TestDelete(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("ARGOS_WORKSPACE", dir)
+	for _, name := range []string{"db1/pt_index_usage/a/report.txt", "db1/pt_index_usage/b/report.txt", "db2/pt_index_usage/a/report.txt"} {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, make([]byte, 1024), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var got artifacts_delete.Result
+	testharness.Decode(t, testharness.Call(t, "artifacts_delete", map[string]any{"path": "db1"}), &got)
+	if got.DeletedFiles != 2 || got.FreedKB != 2 {
+		t.Errorf("expected the 2 files of db1 to be deleted, got %+v", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "db2", "pt_index_usage", "a", "report.txt")); err != nil {
+		t.Errorf("expected the files of db2 to be kept: %v", err)
+	}
+
+	testharness.Failure(t, testharness.Call(t, "artifacts_delete", map[string]any{"path": ".."}), toolerr.InvalidInput)
+	testharness.Failure(t, testharness.Call(t, "artifacts_delete", map[string]any{"path": "db1"}), toolerr.NotFound)
+}
@@ -0,0 +1,71 @@
+package artifacts_list
+
+import (
+	"context"
+	"time"
+
+	"github.com/nicola-strappazzon/argos/internal/config/workspace"
+	"github.com/nicola-strappazzon/argos/internal/workspace"
+	"github.com/nicola-strappazzon/argos/tools/registry"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type Result struct {
+	Root           string               `json:"root"`
+	Files          int                  `json:"files"`
+	SizeKB         int64                `json:"size_kb"`
+	QuotaMB        int64                `json:"quota_mb"`
+	RetentionHours int                  `json:"retention_hours"`
+	Artifacts      []workspace.Artifact `json:"artifacts"`
+}
+
+func init() {
+	registry.Add(registry.Property{
+		Name:        "artifacts_list",
+		Description: "List the files written by the tools in the workspace (downloaded logs, pt_* reports), newest first, with their path and argos://artifacts/ resource URI. Files are kept per instance and tool, and pruned after the retention period or when the workspace exceeds its quota.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"db_instance_identifier": map[string]any{
+					"type":        "string",
+					"description": "Only list the files of this instance (\"local\" for reports on logs given by path).",
+				},
+				"tool": map[string]any{
+					"type":        "string",
+					"description": "Only list the files written by this tool, e.g. pt_query_digest.",
+				},
+			},
+		},
+		Output:  Result{},
+		Timeout: 30 * time.Second,
+		Function: func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
+			instance, _ := args["db_instance_identifier"].(string)
+			tool, _ := args["tool"].(string)
+
+			root, err := workspace.Root()
+			if err != nil {
+				return &mcp.CallToolResult{}, nil, err
+			}
+
+			artifacts, err := workspace.List(instance, tool)
+			if err != nil {
+				return &mcp.CallToolResult{}, nil, err
+			}
+
+			var size int64
+			for _, a := range artifacts {
+				size += a.Size
+			}
+
+			return &mcp.CallToolResult{}, Result{
+				Root:           root,
+				Files:          len(artifacts),
+				SizeKB:         size / 1024,
+				QuotaMB:        workspaceconfig.MaxBytes() >> 20,
+				RetentionHours: int(workspaceconfig.Retention().Hours()),
+				Artifacts:      artifacts,
+			}, nil
+		},
+	})
+}
@@ -0,0 +1,33 @@
+package artifacts_list_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nicola-strappazzon/argos/internal/testharness"
+	"github.com/nicola-strappazzon/argos/tools/plugins/artifacts/artifacts_list"
+)
+
+func TestList(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("ARGOS_WORKSPACE", dir)
+	for _, name := range []string{"db1/pt_query_digest/run/slow.log.txt", "db2/aws_rds_log_download/error/mysql-error.log"} {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, make([]byte, 2048), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var got artifacts_list.Result
+	testharness.Decode(t, testharness.Call(t, "artifacts_list", map[string]any{}), &got)
+	if got.Files != 2 || got.SizeKB != 4 || got.Root != dir {
+		t.Errorf("expected both files, got %+v", got)
+	}
+
+	testharness.Decode(t, testharness.Call(t, "artifacts_list", map[string]any{"db_instance_identifier": "db1"}), &got)
+	if got.Files != 1 || got.Artifacts[0].Tool != "pt_query_digest" || got.Artifacts[0].URI != "argos://artifacts/db1/pt_query_digest/run/slow.log.txt" {
+		t.Errorf("expected the report of db1, got %+v", got)
+	}
+}
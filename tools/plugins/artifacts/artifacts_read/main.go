@@ -0,0 +1,99 @@
+package artifacts_read
+
+import (
+	"context"
+	"time"
+	"unicode/utf8"
+
+	"github.com/nicola-strappazzon/argos/internal/toolerr"
+	"github.com/nicola-strappazzon/argos/internal/workspace"
+	"github.com/nicola-strappazzon/argos/tools/registry"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	defaultLimit = 64 << 10
+	maxLimit     = 1 << 20
+)
+
+type Result struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	Offset int64  `json:"offset"`
+	// NextOffset is where the next read starts, omitted at the end of the file.
+	NextOffset int64  `json:"next_offset,omitempty"`
+	Content    string `json:"content"`
+}
+
+func init() {
+	registry.Add(registry.Property{
+		Name:        "artifacts_read",
+		Description: "Read a file of the workspace, such as a downloaded log or a pt_* report, by path or argos://artifacts/ URI as returned by artifacts_list. Large files are read in chunks: pass next_offset as offset to continue.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"path": map[string]any{
+					"type":        "string",
+					"description": "Path relative to the workspace, absolute path or argos://artifacts/ URI of the file.",
+				},
+				"offset": map[string]any{
+					"type":        "integer",
+					"description": "Byte offset to start reading at (default: 0).",
+				},
+				"limit": map[string]any{
+					"type":        "integer",
+					"description": "Maximum number of bytes to read (default: 65536, maximum: 1048576).",
+				},
+			},
+			"required": []string{"path"},
+		},
+		Output:  Result{},
+		Timeout: 30 * time.Second,
+		Function: func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
+			path, _ := args["path"].(string)
+
+			var offset, limit int64 = 0, defaultLimit
+			if n, ok := args["offset"].(float64); ok {
+				offset = int64(n)
+			}
+			if n, ok := args["limit"].(float64); ok && n > 0 {
+				limit = min(int64(n), maxLimit)
+			}
+			if offset < 0 {
+				return &mcp.CallToolResult{}, nil, toolerr.Errorf(toolerr.InvalidInput, "pass an offset of 0 or more",
+					"invalid offset: %d", offset)
+			}
+
+			data, size, err := workspace.Read(path, offset, limit)
+			if err != nil {
+				return &mcp.CallToolResult{}, nil, err
+			}
+
+			// Do not cut a multi-byte character at the end of the chunk;
+			// the next read starts with it.
+			if offset+int64(len(data)) < size {
+				for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+					if utf8.RuneStart(data[i]) {
+						if !utf8.FullRune(data[i:]) {
+							data = data[:i]
+						}
+						break
+					}
+				}
+			}
+
+			result := Result{
+				Path:    path,
+				Size:    size,
+				Offset:  offset,
+				Content: string(data),
+			}
+			if next := offset + int64(len(data)); next < size {
+				result.NextOffset = next
+			}
+
+			return &mcp.CallToolResult{}, result, nil
+		},
+	})
+}
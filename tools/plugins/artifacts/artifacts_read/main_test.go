@@ -0,0 +1,41 @@
+package artifacts_read_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nicola-strappazzon/argos/internal/testharness"
+	"github.com/nicola-strappazzon/argos/internal/toolerr"
+	"github.com/nicola-strappazzon/argos/tools/plugins/artifacts/artifacts_read"
+)
+
+func TestRead(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("ARGOS_WORKSPACE", dir)
+	path := filepath.Join(dir, "db1", "pt_index_usage", "run", "report.txt")
+	os.MkdirAll(filepath.Dir(path), 0755)
+	if err := os.WriteFile(path, []byte("naïve report\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The chunk stops before the two bytes of ï instead of cutting it.
+	var got artifacts_read.Result
+	testharness.Decode(t, testharness.Call(t, "artifacts_read", map[string]any{
+		"path": "argos://artifacts/db1/pt_index_usage/run/report.txt", "limit": 3,
+	}), &got)
+	if got.Content != "na" || got.NextOffset != 2 || got.Size != 14 {
+		t.Errorf("expected the first chunk, got %+v", got)
+	}
+
+	var rest artifacts_read.Result
+	testharness.Decode(t, testharness.Call(t, "artifacts_read", map[string]any{
+		"path": "db1/pt_index_usage/run/report.txt", "offset": got.NextOffset,
+	}), &rest)
+	if rest.Content != "ïve report\n" || rest.NextOffset != 0 {
+		t.Errorf("expected the rest of the file, got %+v", rest)
+	}
+
+	testharness.Failure(t, testharness.Call(t, "artifacts_read", map[string]any{"path": "/etc/passwd"}), toolerr.InvalidInput)
+	testharness.Failure(t, testharness.Call(t, "artifacts_read", map[string]any{"path": "db1/missing.txt"}), toolerr.NotFound)
+}
@@ -38,6 +38,13 @@ type Collection struct {
 	TotalIndexSizeMB float64 `json:"total_index_size_mb"`
 }
 
+type Result struct {
+	Instance    string       `json:"instance"`
+	Database    string       `json:"database"`
+	Collections []Collection `json:"collections"`
+	Total       int          `json:"total"`
+}
+
 func init() {
 	registry.Add(registry.Property{
 		Name:        "aws_docdb_collections",
@@ -56,15 +63,15 @@ func init() {
 			},
 			"required": []string{"db_instance_identifier", "database"},
 		},
+		Output: Result{},
 		Function: func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
 			instanceID, _ := args["db_instance_identifier"].(string)
 			database, _ := args["database"].(string)
 
-			client, err := docdbdriver.Connect(instanceID)
+			client, err := docdbdriver.Connect(ctx, instanceID)
 			if err != nil {
 				return &mcp.CallToolResult{}, nil, err
 			}
-			defer client.Disconnect(ctx)
 
 			db := client.Database(database)
 
@@ -92,11 +99,11 @@ func init() {
 				})
 			}
 
-			return &mcp.CallToolResult{}, map[string]any{
-				"instance":    instanceID,
-				"database":    database,
-				"collections": collections,
-				"total":       len(collections),
+			return &mcp.CallToolResult{}, Result{
+				Instance:    instanceID,
+				Database:    database,
+				Collections: collections,
+				Total:       len(collections),
 			}, nil
 		},
 	})
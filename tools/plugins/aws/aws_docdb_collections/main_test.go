@@ -0,0 +1,54 @@
+package aws_docdb_collections_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/nicola-strappazzon/argos/internal/testharness"
+	"github.com/nicola-strappazzon/argos/tools/plugins/aws/aws_docdb_collections"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestCollections(t *testing.T) {
+	server := testharness.DocDB(t, "docdb1")
+	server.Reply("listCollections", func(db string, cmd bson.D) (any, error) {
+		return bson.M{"cursor": bson.M{
+			"id":         int64(0),
+			"ns":         db + ".$cmd.listCollections",
+			"firstBatch": bson.A{bson.M{"name": "orders"}, bson.M{"name": "locked"}},
+		}}, nil
+	})
+	server.Reply("collStats", func(db string, cmd bson.D) (any, error) {
+		if cmd[0].Value != "orders" {
+			return nil, errors.New("not authorized")
+		}
+		return bson.M{
+			"count":          int64(1000),
+			"size":           2 * 1024 * 1024.0,
+			"avgObjSize":     2097.152,
+			"nindexes":       int32(3),
+			"totalIndexSize": 1024 * 1024.0,
+		}, nil
+	})
+
+	var got aws_docdb_collections.Result
+	testharness.Decode(t, testharness.Call(t, "aws_docdb_collections", map[string]any{
+		"db_instance_identifier": "docdb1",
+		"database":               "shop",
+	}), &got)
+
+	if got.Database != "shop" || got.Total != 2 {
+		t.Fatalf("unexpected result: %+v", got)
+	}
+	if c := got.Collections[0]; c.Name != "orders" || c.Count != 1000 || c.SizeMB != 2 || c.IndexCount != 3 || c.TotalIndexSizeMB != 1 {
+		t.Errorf("unexpected collection: %+v", c)
+	}
+	// A collection whose stats cannot be read is listed without them.
+	if c := got.Collections[1]; c.Name != "locked" || c.Count != 0 {
+		t.Errorf("unexpected collection: %+v", c)
+	}
+	if len(server.Commands()) != 3 || server.Commands()[0] != "shop.listCollections" {
+		t.Errorf("unexpected commands: %v", server.Commands())
+	}
+}
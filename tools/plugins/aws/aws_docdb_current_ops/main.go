@@ -35,6 +35,12 @@ type currentOpResult struct {
 	Inprog []opEntry `bson:"inprog"`
 }
 
+type Result struct {
+	Instance string      `json:"instance"`
+	Inprog   []Operation `json:"inprog"`
+	Total    int         `json:"total"`
+}
+
 func init() {
 	registry.Add(registry.Property{
 		Name:        "aws_docdb_current_ops",
@@ -53,6 +59,7 @@ func init() {
 			},
 			"required": []string{"db_instance_identifier"},
 		},
+		Output: Result{},
 		Function: func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
 			instanceID, _ := args["db_instance_identifier"].(string)
 			minSecs := int64(0)
@@ -60,11 +67,10 @@ func init() {
 				minSecs = int64(m)
 			}
 
-			client, err := docdbdriver.Connect(instanceID)
+			client, err := docdbdriver.Connect(ctx, instanceID)
 			if err != nil {
 				return &mcp.CallToolResult{}, nil, err
 			}
-			defer client.Disconnect(ctx)
 
 			var result currentOpResult
 			err = client.Database("admin").RunCommand(ctx, bson.D{{Key: "currentOp", Value: 1}}).Decode(&result)
@@ -91,10 +97,10 @@ func init() {
 				})
 			}
 
-			return &mcp.CallToolResult{}, map[string]any{
-				"instance": instanceID,
-				"inprog":   ops,
-				"total":    len(ops),
+			return &mcp.CallToolResult{}, Result{
+				Instance: instanceID,
+				Inprog:   ops,
+				Total:    len(ops),
 			}, nil
 		},
 	})
@@ -0,0 +1,34 @@
+package aws_docdb_current_ops_test
+
+import (
+	"testing"
+
+	"github.com/nicola-strappazzon/argos/internal/testharness"
+	"github.com/nicola-strappazzon/argos/tools/plugins/aws/aws_docdb_current_ops"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestCurrentOps(t *testing.T) {
+	server := testharness.DocDB(t, "docdb1")
+	server.Reply("currentOp", func(db string, cmd bson.D) (any, error) {
+		return bson.M{"inprog": bson.A{
+			bson.M{"opid": int32(1), "secs_running": int64(0), "op": "command", "ns": "admin.$cmd", "command": bson.D{{Key: "currentOp", Value: 1}}},
+			bson.M{"opid": int32(2), "secs_running": int64(42), "op": "query", "ns": "shop.orders", "command": bson.D{{Key: "find", Value: "orders"}}, "client": "10.0.0.5:50312", "desc": "conn12"},
+			bson.M{"opid": int32(3), "secs_running": int64(1), "op": "update", "ns": "shop.stock"},
+		}}, nil
+	})
+
+	var got aws_docdb_current_ops.Result
+	testharness.Decode(t, testharness.Call(t, "aws_docdb_current_ops", map[string]any{"db_instance_identifier": "docdb1"}), &got)
+
+	// The currentOp command itself is left out.
+	if got.Total != 2 || got.Inprog[0].NS != "shop.orders" || got.Inprog[0].SecsRunning != 42 || got.Inprog[0].Client != "10.0.0.5:50312" {
+		t.Fatalf("unexpected result: %+v", got)
+	}
+
+	testharness.Decode(t, testharness.Call(t, "aws_docdb_current_ops", map[string]any{"db_instance_identifier": "docdb1", "min_secs": 10}), &got)
+	if got.Total != 1 || got.Inprog[0].Op != "query" {
+		t.Fatalf("unexpected result with min_secs: %+v", got)
+	}
+}
@@ -28,6 +28,13 @@ type Database struct {
 	Empty  bool    `json:"empty"`
 }
 
+type Result struct {
+	Instance    string     `json:"instance"`
+	Databases   []Database `json:"databases"`
+	Total       int        `json:"total"`
+	TotalSizeMB float64    `json:"total_size_mb"`
+}
+
 func init() {
 	registry.Add(registry.Property{
 		Name:        "aws_docdb_databases",
@@ -42,14 +49,14 @@ func init() {
 			},
 			"required": []string{"db_instance_identifier"},
 		},
+		Output: Result{},
 		Function: func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
 			instanceID, _ := args["db_instance_identifier"].(string)
 
-			client, err := docdbdriver.Connect(instanceID)
+			client, err := docdbdriver.Connect(ctx, instanceID)
 			if err != nil {
 				return &mcp.CallToolResult{}, nil, err
 			}
-			defer client.Disconnect(ctx)
 
 			var result listDatabasesResult
 			err = client.Database("admin").RunCommand(ctx, bson.D{{Key: "listDatabases", Value: 1}}).Decode(&result)
@@ -66,11 +73,11 @@ func init() {
 				})
 			}
 
-			return &mcp.CallToolResult{}, map[string]any{
-				"instance":      instanceID,
-				"databases":     databases,
-				"total":         len(databases),
-				"total_size_mb": result.TotalSize / 1024 / 1024,
+			return &mcp.CallToolResult{}, Result{
+				Instance:    instanceID,
+				Databases:   databases,
+				Total:       len(databases),
+				TotalSizeMB: result.TotalSize / 1024 / 1024,
 			}, nil
 		},
 	})
@@ -0,0 +1,42 @@
+package aws_docdb_databases_test
+
+import (
+	"testing"
+
+	"github.com/nicola-strappazzon/argos/internal/testharness"
+	"github.com/nicola-strappazzon/argos/tools/plugins/aws/aws_docdb_databases"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestDatabases(t *testing.T) {
+	server := testharness.DocDB(t, "docdb1")
+	server.Reply("listDatabases", func(db string, cmd bson.D) (any, error) {
+		return bson.M{
+			"databases": bson.A{
+				bson.M{"name": "shop", "sizeOnDisk": 3 * 1024 * 1024.0, "empty": false},
+				bson.M{"name": "scratch", "sizeOnDisk": 0.0, "empty": true},
+			},
+			"totalSize": 3 * 1024 * 1024.0,
+		}, nil
+	})
+
+	var got aws_docdb_databases.Result
+	testharness.Decode(t, testharness.Call(t, "aws_docdb_databases", map[string]any{"db_instance_identifier": "docdb1"}), &got)
+
+	if got.Total != 2 || got.TotalSizeMB != 3 || got.Databases[0].Name != "shop" || got.Databases[0].SizeMB != 3 || !got.Databases[1].Empty {
+		t.Fatalf("unexpected result: %+v", got)
+	}
+	if len(server.Commands()) != 1 || server.Commands()[0] != "admin.listDatabases" {
+		t.Errorf("unexpected commands: %v", server.Commands())
+	}
+}
+
+func TestDatabasesFailure(t *testing.T) {
+	testharness.DocDB(t, "docdb1")
+
+	text := testharness.Failure(t, testharness.Call(t, "aws_docdb_databases", map[string]any{"db_instance_identifier": "docdb1"}), "internal")
+	if text == "" {
+		t.Error("expected the failure to be described")
+	}
+}
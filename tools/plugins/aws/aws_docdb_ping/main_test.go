@@ -0,0 +1,28 @@
+package aws_docdb_ping_test
+
+import (
+	"testing"
+
+	"github.com/nicola-strappazzon/argos/internal/testharness"
+	"github.com/nicola-strappazzon/argos/tools/plugins/aws/aws_docdb_ping"
+)
+
+func TestPing(t *testing.T) {
+	testharness.DocDB(t, "docdb1")
+
+	var got aws_docdb_ping.Result
+	testharness.Decode(t, testharness.Call(t, "aws_docdb_ping", map[string]any{"db_instance_identifier": "docdb1"}), &got)
+
+	if !got.Success || got.Instance != "docdb1" || got.Error != "" {
+		t.Fatalf("unexpected result: %+v", got)
+	}
+}
+
+func TestPingWithoutCredentials(t *testing.T) {
+	var got aws_docdb_ping.Result
+	testharness.Decode(t, testharness.Call(t, "aws_docdb_ping", map[string]any{"db_instance_identifier": "missing"}), &got)
+
+	if got.Success || got.Error == "" {
+		t.Fatalf("unexpected result: %+v", got)
+	}
+}
@@ -57,6 +57,61 @@ type serverStatusResult struct {
 	} `bson:"globalLock"`
 }
 
+type Uptime struct {
+	Seconds float64 `json:"seconds"`
+	Hours   float64 `json:"hours"`
+}
+
+type Connections struct {
+	Current      int64 `json:"current"`
+	Available    int64 `json:"available"`
+	TotalCreated int64 `json:"total_created"`
+}
+
+type Opcounters struct {
+	Insert  int64 `json:"insert"`
+	Query   int64 `json:"query"`
+	Update  int64 `json:"update"`
+	Delete  int64 `json:"delete"`
+	Getmore int64 `json:"getmore"`
+	Command int64 `json:"command"`
+}
+
+type Memory struct {
+	ResidentMB int64 `json:"resident_mb"`
+	VirtualMB  int64 `json:"virtual_mb"`
+}
+
+type Network struct {
+	BytesIn     int64 `json:"bytes_in"`
+	BytesOut    int64 `json:"bytes_out"`
+	NumRequests int64 `json:"num_requests"`
+}
+
+type LockQueue struct {
+	Total   int64 `json:"total"`
+	Readers int64 `json:"readers"`
+	Writers int64 `json:"writers"`
+}
+
+type GlobalLock struct {
+	TotalTimeSecs int64     `json:"total_time_secs"`
+	CurrentQueue  LockQueue `json:"current_queue"`
+	ActiveClients LockQueue `json:"active_clients"`
+}
+
+type Result struct {
+	Instance    string      `json:"instance"`
+	Host        string      `json:"host"`
+	Version     string      `json:"version"`
+	Uptime      Uptime      `json:"uptime"`
+	Connections Connections `json:"connections"`
+	Opcounters  Opcounters  `json:"opcounters"`
+	Memory      Memory      `json:"memory"`
+	Network     Network     `json:"network"`
+	GlobalLock  GlobalLock  `json:"global_lock"`
+}
+
 func init() {
 	registry.Add(registry.Property{
 		Name: "aws_docdb_server_status",
@@ -72,14 +127,14 @@ func init() {
 			},
 			"required": []string{"db_instance_identifier"},
 		},
+		Output: Result{},
 		Function: func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
 			instanceID, _ := args["db_instance_identifier"].(string)
 
-			client, err := docdbdriver.Connect(instanceID)
+			client, err := docdbdriver.Connect(ctx, instanceID)
 			if err != nil {
 				return &mcp.CallToolResult{}, nil, err
 			}
-			defer client.Disconnect(ctx)
 
 			var result serverStatusResult
 			err = client.Database("admin").RunCommand(ctx, bson.D{{Key: "serverStatus", Value: 1}}).Decode(&result)
@@ -89,48 +144,40 @@ func init() {
 
 			uptimeHours := result.Uptime / 3600
 
-			return &mcp.CallToolResult{}, map[string]any{
-				"instance": instanceID,
-				"host":     result.Host,
-				"version":  result.Version,
-				"uptime": map[string]any{
-					"seconds": result.Uptime,
-					"hours":   uptimeHours,
+			return &mcp.CallToolResult{}, Result{
+				Instance: instanceID,
+				Host:     result.Host,
+				Version:  result.Version,
+				Uptime: Uptime{
+					Seconds: result.Uptime,
+					Hours:   uptimeHours,
 				},
-				"connections": map[string]any{
-					"current":       result.Connections.Current,
-					"available":     result.Connections.Available,
-					"total_created": result.Connections.TotalCreated,
+				Connections: Connections{
+					Current:      result.Connections.Current,
+					Available:    result.Connections.Available,
+					TotalCreated: result.Connections.TotalCreated,
 				},
-				"opcounters": map[string]any{
-					"insert":  result.Opcounters.Insert,
-					"query":   result.Opcounters.Query,
-					"update":  result.Opcounters.Update,
-					"delete":  result.Opcounters.Delete,
-					"getmore": result.Opcounters.Getmore,
-					"command": result.Opcounters.Command,
+				Opcounters: Opcounters{
+					Insert:  result.Opcounters.Insert,
+					Query:   result.Opcounters.Query,
+					Update:  result.Opcounters.Update,
+					Delete:  result.Opcounters.Delete,
+					Getmore: result.Opcounters.Getmore,
+					Command: result.Opcounters.Command,
 				},
-				"memory": map[string]any{
-					"resident_mb": result.Mem.ResidentMB,
-					"virtual_mb":  result.Mem.VirtualMB,
+				Memory: Memory{
+					ResidentMB: result.Mem.ResidentMB,
+					VirtualMB:  result.Mem.VirtualMB,
 				},
-				"network": map[string]any{
-					"bytes_in":     result.Network.BytesIn,
-					"bytes_out":    result.Network.BytesOut,
-					"num_requests": result.Network.NumRequests,
+				Network: Network{
+					BytesIn:     result.Network.BytesIn,
+					BytesOut:    result.Network.BytesOut,
+					NumRequests: result.Network.NumRequests,
 				},
-				"global_lock": map[string]any{
-					"total_time_secs": result.GlobalLock.TotalTimeMicros / 1_000_000,
-					"current_queue": map[string]any{
-						"total":   result.GlobalLock.CurrentQueue.Total,
-						"readers": result.GlobalLock.CurrentQueue.Readers,
-						"writers": result.GlobalLock.CurrentQueue.Writers,
-					},
-					"active_clients": map[string]any{
-						"total":   result.GlobalLock.ActiveClients.Total,
-						"readers": result.GlobalLock.ActiveClients.Readers,
-						"writers": result.GlobalLock.ActiveClients.Writers,
-					},
+				GlobalLock: GlobalLock{
+					TotalTimeSecs: result.GlobalLock.TotalTimeMicros / 1_000_000,
+					CurrentQueue:  LockQueue(result.GlobalLock.CurrentQueue),
+					ActiveClients: LockQueue(result.GlobalLock.ActiveClients),
 				},
 			}, nil
 		},
@@ -0,0 +1,40 @@
+package aws_docdb_server_status_test
+
+import (
+	"testing"
+
+	"github.com/nicola-strappazzon/argos/internal/testharness"
+	"github.com/nicola-strappazzon/argos/tools/plugins/aws/aws_docdb_server_status"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestServerStatus(t *testing.T) {
+	server := testharness.DocDB(t, "docdb1")
+	server.Reply("serverStatus", func(db string, cmd bson.D) (any, error) {
+		return bson.M{
+			"host":        "docdb1.cluster-xxxx.eu-west-1.docdb.amazonaws.com:27017",
+			"version":     "5.0.0",
+			"uptime":      7200.0,
+			"connections": bson.M{"current": int64(12), "available": int64(4500), "totalCreated": int64(340)},
+			"opcounters":  bson.M{"insert": int64(10), "query": int64(200), "update": int64(30), "delete": int64(4), "getmore": int64(5), "command": int64(600)},
+			"mem":         bson.M{"resident": int64(2048), "virtual": int64(4096)},
+			"network":     bson.M{"bytesIn": int64(1000), "bytesOut": int64(5000), "numRequests": int64(849)},
+			"globalLock": bson.M{
+				"totalTime":     int64(7_200_000_000),
+				"currentQueue":  bson.M{"total": int64(3), "readers": int64(1), "writers": int64(2)},
+				"activeClients": bson.M{"total": int64(8), "readers": int64(5), "writers": int64(3)},
+			},
+		}, nil
+	})
+
+	var got aws_docdb_server_status.Result
+	testharness.Decode(t, testharness.Call(t, "aws_docdb_server_status", map[string]any{"db_instance_identifier": "docdb1"}), &got)
+
+	if got.Version != "5.0.0" || got.Uptime.Hours != 2 || got.Connections.Current != 12 || got.Opcounters.Command != 600 {
+		t.Fatalf("unexpected result: %+v", got)
+	}
+	if got.Memory.ResidentMB != 2048 || got.Network.NumRequests != 849 || got.GlobalLock.TotalTimeSecs != 7200 || got.GlobalLock.CurrentQueue.Writers != 2 || got.GlobalLock.ActiveClients.Total != 8 {
+		t.Errorf("unexpected result: %+v", got)
+	}
+}
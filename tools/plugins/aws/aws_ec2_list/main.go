@@ -4,11 +4,13 @@ import (
 	"context"
 	"strings"
 
+	"github.com/nicola-strappazzon/argos/internal/awsclient"
 	awsconfig "github.com/nicola-strappazzon/argos/internal/config/aws"
 	"github.com/nicola-strappazzon/argos/tools/registry"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
@@ -22,82 +24,95 @@ type Instance struct {
 	State      string `json:"state"`
 }
 
-func getTag(tags []*ec2.Tag, key string) string {
+func getTag(tags []types.Tag, key string) string {
 	for _, t := range tags {
-		if aws.StringValue(t.Key) == key {
-			return aws.StringValue(t.Value)
+		if aws.ToString(t.Key) == key {
+			return aws.ToString(t.Value)
 		}
 	}
 	return ""
 }
 
+type Result struct {
+	Instances []Instance `json:"instances"`
+	Total     int        `json:"total"`
+}
+
 func init() {
 	registry.Add(registry.Property{
 		Name:        "aws_ec2_list",
 		Description: "List AWS EC2 instances. Optionally filter by name tag using a search string.",
 		InputSchema: map[string]any{
 			"type": "object",
-			"properties": map[string]any{
+			"properties": awsconfig.TargetProperties(map[string]any{
 				"filter": map[string]any{
 					"type":        "string",
 					"description": "Optional string to filter instances by Name tag (case-insensitive substring match).",
 				},
-			},
+			}),
 		},
+		Output: Result{},
 		Function: func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
 			filter, _ := args["filter"].(string)
 
-			sess, err := awsconfig.NewSession()
+			target, err := awsconfig.TargetFromArgs(args)
 			if err != nil {
 				return &mcp.CallToolResult{}, nil, err
 			}
 
-			svc := ec2.New(sess)
+			svc, err := awsclient.EC2(target)
+			if err != nil {
+				return &mcp.CallToolResult{}, nil, err
+			}
 
 			input := &ec2.DescribeInstancesInput{}
 			if filter != "" {
-				input.Filters = []*ec2.Filter{{
+				input.Filters = []types.Filter{{
 					Name:   aws.String("tag:Name"),
-					Values: []*string{aws.String("*" + filter + "*")},
+					Values: []string{"*" + filter + "*"},
 				}}
 			}
 
 			instances := make([]Instance, 0)
-			err = svc.DescribeInstancesPagesWithContext(ctx, input, func(page *ec2.DescribeInstancesOutput, _ bool) bool {
+			pages := ec2.NewDescribeInstancesPaginator(svc, input)
+			for pages.HasMorePages() {
+				page, err := pages.NextPage(ctx)
+				if err != nil {
+					return &mcp.CallToolResult{}, nil, err
+				}
+
 				for _, r := range page.Reservations {
 					for _, i := range r.Instances {
 						name := getTag(i.Tags, "Name")
 						if filter != "" && !strings.Contains(strings.ToLower(name), strings.ToLower(filter)) {
 							// AWS wildcard filter is case-sensitive on some regions; apply local filter too
-							if !strings.Contains(strings.ToLower(aws.StringValue(i.InstanceId)), strings.ToLower(filter)) {
+							if !strings.Contains(strings.ToLower(aws.ToString(i.InstanceId)), strings.ToLower(filter)) {
 								continue
 							}
 						}
 
 						inst := Instance{
 							Name:       name,
-							InstanceID: aws.StringValue(i.InstanceId),
-							PrivateIP:  aws.StringValue(i.PrivateIpAddress),
-							PublicIP:   aws.StringValue(i.PublicIpAddress),
-							Zone:       aws.StringValue(i.Placement.AvailabilityZone),
-							Type:       aws.StringValue(i.InstanceType),
+							InstanceID: aws.ToString(i.InstanceId),
+							PrivateIP:  aws.ToString(i.PrivateIpAddress),
+							PublicIP:   aws.ToString(i.PublicIpAddress),
+							Type:       string(i.InstanceType),
+						}
+						if i.Placement != nil {
+							inst.Zone = aws.ToString(i.Placement.AvailabilityZone)
 						}
 						if i.State != nil {
-							inst.State = aws.StringValue(i.State.Name)
+							inst.State = string(i.State.Name)
 						}
 
 						instances = append(instances, inst)
 					}
 				}
-				return true
-			})
-			if err != nil {
-				return &mcp.CallToolResult{}, nil, err
 			}
 
-			return &mcp.CallToolResult{}, map[string]any{
-				"instances": instances,
-				"total":     len(instances),
+			return &mcp.CallToolResult{}, Result{
+				Instances: instances,
+				Total:     len(instances),
 			}, nil
 		},
 	})
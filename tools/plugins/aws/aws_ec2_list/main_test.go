@@ -0,0 +1,53 @@
+package aws_ec2_list_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nicola-strappazzon/argos/internal/awsclient"
+	"github.com/nicola-strappazzon/argos/internal/testharness"
+	"github.com/nicola-strappazzon/argos/tools/plugins/aws/aws_ec2_list"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+type fakeEC2 struct {
+	awsclient.EC2API
+	input *ec2.DescribeInstancesInput
+}
+
+func instance(id, name string) types.Instance {
+	return types.Instance{
+		InstanceId:       aws.String(id),
+		InstanceType:     types.InstanceTypeT3Micro,
+		PrivateIpAddress: aws.String("10.0.0.1"),
+		Placement:        &types.Placement{AvailabilityZone: aws.String("eu-west-1a")},
+		State:            &types.InstanceState{Name: types.InstanceStateNameRunning},
+		Tags:             []types.Tag{{Key: aws.String("Name"), Value: aws.String(name)}},
+	}
+}
+
+func (f *fakeEC2) DescribeInstances(_ context.Context, in *ec2.DescribeInstancesInput, _ ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
+	f.input = in
+	return &ec2.DescribeInstancesOutput{Reservations: []types.Reservation{{Instances: []types.Instance{
+		instance("i-0a1", "Bastion"),
+		instance("i-0b2", "worker-1"),
+	}}}}, nil
+}
+
+func TestList(t *testing.T) {
+	fake := &fakeEC2{}
+	testharness.UseAWS(t, &testharness.AWS{EC2: fake})
+
+	var got aws_ec2_list.Result
+	testharness.Decode(t, testharness.Call(t, "aws_ec2_list", map[string]any{"filter": "bastion"}), &got)
+
+	if got.Total != 1 || got.Instances[0].InstanceID != "i-0a1" || got.Instances[0].State != "running" {
+		t.Fatalf("expected only the bastion, got %+v", got)
+	}
+	if len(fake.input.Filters) != 1 || fake.input.Filters[0].Values[0] != "*bastion*" {
+		t.Errorf("unexpected filters: %+v", fake.input.Filters)
+	}
+}
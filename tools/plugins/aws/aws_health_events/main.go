@@ -4,19 +4,25 @@ import (
 	"context"
 	"time"
 
+	"github.com/nicola-strappazzon/argos/internal/awsclient"
+	"github.com/nicola-strappazzon/argos/internal/config/aws"
 	"github.com/nicola-strappazzon/argos/tools/registry"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/aws/aws-sdk-go/service/health"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/health"
+	"github.com/aws/aws-sdk-go-v2/service/health/types"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
 // The AWS Health API endpoint is global and only available in us-east-1.
-func newHealthSession() (*session.Session, error) {
-	return session.NewSession(&aws.Config{
-		Region: aws.String("us-east-1"),
-	})
+const healthRegion = "us-east-1"
+
+// healthProperties adds the profile and role_arn arguments; the region is
+// always healthRegion.
+func healthProperties(properties map[string]any) map[string]any {
+	properties = awsconfig.TargetProperties(properties)
+	delete(properties, "region")
+	return properties
 }
 
 type HealthEvent struct {
@@ -39,13 +45,18 @@ func formatTime(t *time.Time) string {
 	return t.UTC().Format(time.RFC3339)
 }
 
+type Result struct {
+	Events []HealthEvent `json:"events"`
+	Total  int           `json:"total"`
+}
+
 func init() {
 	registry.Add(registry.Property{
 		Name:        "aws_health_events",
 		Description: "List AWS Health events (end-of-support notices, deprecations, service incidents) from the Personal Health Dashboard.",
 		InputSchema: map[string]any{
 			"type": "object",
-			"properties": map[string]any{
+			"properties": healthProperties(map[string]any{
 				"service": map[string]any{
 					"type":        "string",
 					"description": "Filter by AWS service (e.g. RDS, EC2). If omitted, returns events for all services.",
@@ -55,28 +66,33 @@ func init() {
 					"description": "Filter by event status: open, closed, or upcoming. If omitted, returns all.",
 					"enum":        []string{"open", "closed", "upcoming"},
 				},
-			},
+			}),
 		},
+		Output: Result{},
 		Function: func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
 			service, _ := args["service"].(string)
 			status, _ := args["status"].(string)
 
-			sess, err := newHealthSession()
+			target, err := awsconfig.TargetFromArgs(args)
 			if err != nil {
 				return &mcp.CallToolResult{}, nil, err
 			}
+			target.Region = healthRegion
 
-			svc := health.New(sess)
+			svc, err := awsclient.Health(target)
+			if err != nil {
+				return &mcp.CallToolResult{}, nil, err
+			}
 
-			filter := &health.EventFilter{}
+			filter := &types.EventFilter{}
 			if service != "" {
-				filter.Services = []*string{aws.String(service)}
+				filter.Services = []string{service}
 			}
 			if status != "" {
-				filter.EventStatusCodes = []*string{aws.String(status)}
+				filter.EventStatusCodes = []types.EventStatusCode{types.EventStatusCode(status)}
 			}
 
-			result, err := svc.DescribeEvents(&health.DescribeEventsInput{
+			result, err := svc.DescribeEvents(ctx, &health.DescribeEventsInput{
 				Filter: filter,
 			})
 			if err != nil {
@@ -84,41 +100,40 @@ func init() {
 			}
 
 			if len(result.Events) == 0 {
-				return &mcp.CallToolResult{}, map[string]any{
-					"events": []HealthEvent{},
-					"total":  0,
+				return &mcp.CallToolResult{}, Result{
+					Events: []HealthEvent{},
 				}, nil
 			}
 
 			// Fetch full descriptions for all events.
-			arns := make([]*string, 0, len(result.Events))
+			arns := make([]string, 0, len(result.Events))
 			for _, e := range result.Events {
-				arns = append(arns, e.Arn)
+				arns = append(arns, aws.ToString(e.Arn))
 			}
 
-			details, err := svc.DescribeEventDetails(&health.DescribeEventDetailsInput{
+			details, err := svc.DescribeEventDetails(ctx, &health.DescribeEventDetailsInput{
 				EventArns: arns,
 			})
 
 			descByARN := map[string]string{}
 			if err == nil {
 				for _, d := range details.SuccessfulSet {
-					if d.EventDescription != nil {
-						descByARN[aws.StringValue(d.Event.Arn)] = aws.StringValue(d.EventDescription.LatestDescription)
+					if d.Event != nil && d.EventDescription != nil {
+						descByARN[aws.ToString(d.Event.Arn)] = aws.ToString(d.EventDescription.LatestDescription)
 					}
 				}
 			}
 
 			events := make([]HealthEvent, 0, len(result.Events))
 			for _, e := range result.Events {
-				arn := aws.StringValue(e.Arn)
+				arn := aws.ToString(e.Arn)
 				events = append(events, HealthEvent{
 					ARN:          arn,
-					Service:      aws.StringValue(e.Service),
-					TypeCode:     aws.StringValue(e.EventTypeCode),
-					TypeCategory: aws.StringValue(e.EventTypeCategory),
-					Region:       aws.StringValue(e.Region),
-					Status:       aws.StringValue(e.StatusCode),
+					Service:      aws.ToString(e.Service),
+					TypeCode:     aws.ToString(e.EventTypeCode),
+					TypeCategory: string(e.EventTypeCategory),
+					Region:       aws.ToString(e.Region),
+					Status:       string(e.StatusCode),
 					StartTime:    formatTime(e.StartTime),
 					EndTime:      formatTime(e.EndTime),
 					LastUpdated:  formatTime(e.LastUpdatedTime),
@@ -126,9 +141,9 @@ func init() {
 				})
 			}
 
-			return &mcp.CallToolResult{}, map[string]any{
-				"events": events,
-				"total":  len(events),
+			return &mcp.CallToolResult{}, Result{
+				Events: events,
+				Total:  len(events),
 			}, nil
 		},
 	})
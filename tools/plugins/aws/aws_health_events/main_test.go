@@ -0,0 +1,58 @@
+package aws_health_events_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nicola-strappazzon/argos/internal/awsclient"
+	"github.com/nicola-strappazzon/argos/internal/testharness"
+	"github.com/nicola-strappazzon/argos/tools/plugins/aws/aws_health_events"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/health"
+	"github.com/aws/aws-sdk-go-v2/service/health/types"
+)
+
+const arn = "arn:aws:health:us-east-1::event/RDS/AWS_RDS_PLANNED_LIFECYCLE_EVENT/1"
+
+type fakeHealth struct {
+	awsclient.HealthAPI
+	filter *types.EventFilter
+}
+
+func (f *fakeHealth) DescribeEvents(_ context.Context, in *health.DescribeEventsInput, _ ...func(*health.Options)) (*health.DescribeEventsOutput, error) {
+	f.filter = in.Filter
+	return &health.DescribeEventsOutput{Events: []types.Event{{
+		Arn:           aws.String(arn),
+		Service:       aws.String("RDS"),
+		EventTypeCode: aws.String("AWS_RDS_PLANNED_LIFECYCLE_EVENT"),
+		Region:        aws.String("eu-west-1"),
+		StatusCode:    types.EventStatusCodeUpcoming,
+	}}}, nil
+}
+
+func (f *fakeHealth) DescribeEventDetails(context.Context, *health.DescribeEventDetailsInput, ...func(*health.Options)) (*health.DescribeEventDetailsOutput, error) {
+	return &health.DescribeEventDetailsOutput{SuccessfulSet: []types.EventDetails{{
+		Event:            &types.Event{Arn: aws.String(arn)},
+		EventDescription: &types.EventDescription{LatestDescription: aws.String("MySQL 5.7 reaches end of standard support")},
+	}}}, nil
+}
+
+func TestHealthEvents(t *testing.T) {
+	fake := &fakeHealth{}
+	f := &testharness.AWS{Health: fake}
+	testharness.UseAWS(t, f)
+
+	var got aws_health_events.Result
+	testharness.Decode(t, testharness.Call(t, "aws_health_events", map[string]any{"service": "RDS", "status": "upcoming"}), &got)
+
+	if got.Total != 1 || got.Events[0].Description != "MySQL 5.7 reaches end of standard support" {
+		t.Fatalf("unexpected result: %+v", got)
+	}
+	if fake.filter.Services[0] != "RDS" || fake.filter.EventStatusCodes[0] != types.EventStatusCodeUpcoming {
+		t.Errorf("unexpected filter: %+v", fake.filter)
+	}
+	if f.Targets[0].Region != "us-east-1" {
+		t.Errorf("the Health API is only served from us-east-1, got %q", f.Targets[0].Region)
+	}
+}
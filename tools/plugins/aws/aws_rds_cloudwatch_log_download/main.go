@@ -0,0 +1,173 @@
+package aws_rds_cloudwatch_log_download
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nicola-strappazzon/argos/internal/awsclient"
+	"github.com/nicola-strappazzon/argos/internal/config/aws"
+	"github.com/nicola-strappazzon/argos/internal/cwlogs"
+	"github.com/nicola-strappazzon/argos/internal/timerange"
+	"github.com/nicola-strappazzon/argos/internal/workspace"
+	"github.com/nicola-strappazzon/argos/tools/registry"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type Result struct {
+	Identifier    string `json:"identifier"`
+	LogGroup      string `json:"log_group"`
+	StartTime     string `json:"start_time"`
+	EndTime       string `json:"end_time"`
+	FilterPattern string `json:"filter_pattern,omitempty"`
+	Events        int    `json:"events"`
+	OutputPath    string `json:"output_path"`
+	// ResourceURI reads the file as an MCP resource.
+	ResourceURI string `json:"resource_uri"`
+	SizeKB      int64  `json:"size_kb"`
+}
+
+func init() {
+	registry.Add(registry.Property{
+		Name:        "aws_rds_cloudwatch_log_download",
+		Description: "Save the events a log exported to CloudWatch Logs (log group /aws/rds/instance/<id>/<log_type>) over a time range to a file of the workspace, under <instance>/aws_rds_cloudwatch_log_download/. A slowquery log saved this way is a regular slow query log: pass its output_path to pt_query_digest, pt_index_usage or mysql_slow_log_digest.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": awsconfig.TargetProperties(map[string]any{
+				"db_instance_identifier": map[string]any{
+					"type":        "string",
+					"description": "The RDS DB instance identifier.",
+				},
+				"log_type": map[string]any{
+					"type":        "string",
+					"enum":        cwlogs.Types(),
+					"description": "The exported log: slowquery, error or general (MySQL, MariaDB), postgresql (PostgreSQL).",
+				},
+				"start_time": map[string]any{
+					"type":        "string",
+					"description": "Start of the time range, RFC 3339 (e.g. 2024-05-14T09:00:00Z). Default: 24 hours before end_time.",
+				},
+				"end_time": map[string]any{
+					"type":        "string",
+					"description": "End of the time range, RFC 3339. Default: now.",
+				},
+				"filter_pattern": map[string]any{
+					"type":        "string",
+					"description": "CloudWatch Logs filter pattern keeping only the matching events. Default: every event.",
+				},
+			}),
+			"required": []string{"db_instance_identifier", "log_type"},
+		},
+		Output:  Result{},
+		Timeout: 10 * time.Minute,
+		Writes:  true,
+		Function: func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
+			instanceID, _ := args["db_instance_identifier"].(string)
+			logType, _ := args["log_type"].(string)
+			pattern, _ := args["filter_pattern"].(string)
+
+			start, end, err := timerange.Parse(args, 24*time.Hour)
+			if err != nil {
+				return &mcp.CallToolResult{}, nil, err
+			}
+
+			target, err := awsconfig.TargetFromArgs(args)
+			if err != nil {
+				return &mcp.CallToolResult{}, nil, err
+			}
+
+			svc, err := awsclient.CloudWatchLogs(target)
+			if err != nil {
+				return &mcp.CallToolResult{}, nil, err
+			}
+
+			dir, err := workspace.Dir(instanceID, "aws_rds_cloudwatch_log_download")
+			if err != nil {
+				return &mcp.CallToolResult{}, nil, err
+			}
+
+			outputPath := filepath.Join(dir, logType, fileName(start, end, pattern))
+			if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+				return &mcp.CallToolResult{}, nil, fmt.Errorf("creating output directory: %w", err)
+			}
+
+			// The file is written as .part and renamed once complete, so
+			// an interrupted call never leaves a partial log behind.
+			partPath := outputPath + ".part"
+			part, err := os.Create(partPath)
+			if err != nil {
+				return &mcp.CallToolResult{}, nil, fmt.Errorf("creating %s: %w", partPath, err)
+			}
+			defer os.Remove(partPath)
+			defer part.Close()
+
+			result := Result{
+				Identifier:    instanceID,
+				LogGroup:      cwlogs.Group(instanceID, logType),
+				StartTime:     start.Format(time.RFC3339),
+				EndTime:       end.Format(time.RFC3339),
+				FilterPattern: pattern,
+			}
+
+			w := bufio.NewWriter(part)
+			_, err = cwlogs.Filter(ctx, svc, cwlogs.Query{
+				Group:   result.LogGroup,
+				Start:   start,
+				End:     end,
+				Pattern: pattern,
+			}, func(e cwlogs.Event) error {
+				result.Events++
+				if _, err := w.WriteString(e.Message); err != nil {
+					return err
+				}
+				if !strings.HasSuffix(e.Message, "\n") {
+					return w.WriteByte('\n')
+				}
+				return nil
+			})
+			if err != nil {
+				return &mcp.CallToolResult{}, nil, err
+			}
+
+			if err := w.Flush(); err != nil {
+				return &mcp.CallToolResult{}, nil, fmt.Errorf("writing %s: %w", partPath, err)
+			}
+			if err := part.Close(); err != nil {
+				return &mcp.CallToolResult{}, nil, fmt.Errorf("writing %s: %w", partPath, err)
+			}
+			if err := os.Rename(partPath, outputPath); err != nil {
+				return &mcp.CallToolResult{}, nil, fmt.Errorf("writing log file: %w", err)
+			}
+
+			info, err := os.Stat(outputPath)
+			if err != nil {
+				return &mcp.CallToolResult{}, nil, err
+			}
+
+			result.OutputPath = outputPath
+			result.ResourceURI = workspace.URI(outputPath)
+			result.SizeKB = info.Size() / 1024
+
+			return &mcp.CallToolResult{}, result, nil
+		},
+	})
+}
+
+// fileName names the file of a time range and filter pattern, e.g.
+// "20240514T090000Z-20240514T100000Z.log", followed by a hash of the pattern
+// when there is one.
+func fileName(start, end time.Time, pattern string) string {
+	name := start.Format("20060102T150405Z") + "-" + end.Format("20060102T150405Z")
+	if pattern != "" {
+		h := fnv.New32a()
+		h.Write([]byte(pattern))
+		name += fmt.Sprintf("-%08x", h.Sum32())
+	}
+	return name + ".log"
+}
@@ -0,0 +1,71 @@
+package aws_rds_cloudwatch_log_download_test
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/nicola-strappazzon/argos/internal/awsclient"
+	"github.com/nicola-strappazzon/argos/internal/testharness"
+	"github.com/nicola-strappazzon/argos/tools/plugins/aws/aws_rds_cloudwatch_log_download"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// fakeLogs serves the entries of a slow query log, one event each, in two
+// pages.
+type fakeLogs struct {
+	awsclient.CloudWatchLogsAPI
+	group string
+}
+
+func (f *fakeLogs) FilterLogEvents(_ context.Context, in *cloudwatchlogs.FilterLogEventsInput, _ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.FilterLogEventsOutput, error) {
+	f.group = aws.ToString(in.LogGroupName)
+
+	event := func(msg string) types.FilteredLogEvent {
+		return types.FilteredLogEvent{Timestamp: aws.Int64(1715677200000), Message: aws.String(msg)}
+	}
+	if in.NextToken == nil {
+		return &cloudwatchlogs.FilterLogEventsOutput{
+			Events:    []types.FilteredLogEvent{event("# Time: 2024-05-14T09:00:00.000000Z\n# Query_time: 1.5\nSELECT 1;")},
+			NextToken: aws.String("page2"),
+		}, nil
+	}
+	return &cloudwatchlogs.FilterLogEventsOutput{
+		Events: []types.FilteredLogEvent{event("# Time: 2024-05-14T09:01:00.000000Z\n# Query_time: 2.5\nSELECT 2;\n")},
+	}, nil
+}
+
+func TestDownload(t *testing.T) {
+	fake := &fakeLogs{}
+	testharness.UseAWS(t, &testharness.AWS{CloudWatchLogs: fake})
+
+	var got aws_rds_cloudwatch_log_download.Result
+	testharness.Decode(t, testharness.Call(t, "aws_rds_cloudwatch_log_download", map[string]any{
+		"db_instance_identifier": "db1",
+		"log_type":               "slowquery",
+		"start_time":             "2024-05-14T09:00:00Z",
+		"end_time":               "2024-05-14T10:00:00Z",
+	}), &got)
+
+	if fake.group != "/aws/rds/instance/db1/slowquery" || got.Events != 2 {
+		t.Fatalf("expected the 2 events of the slowquery log group, got %+v", got)
+	}
+	if got.ResourceURI != "argos://artifacts/db1/aws_rds_cloudwatch_log_download/slowquery/20240514T090000Z-20240514T100000Z.log" {
+		t.Errorf("unexpected resource URI: %s", got.ResourceURI)
+	}
+
+	data, err := os.ReadFile(got.OutputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "SELECT 1;\n# Time: 2024-05-14T09:01:00.000000Z"; !strings.Contains(string(data), want) || !strings.HasSuffix(string(data), "SELECT 2;\n") {
+		t.Errorf("expected one entry per event, got %q", data)
+	}
+	if _, err := os.Stat(got.OutputPath + ".part"); !os.IsNotExist(err) {
+		t.Errorf("expected the partial file to be gone, got %v", err)
+	}
+}
@@ -0,0 +1,120 @@
+package aws_rds_cloudwatch_logs
+
+import (
+	"context"
+	"time"
+
+	"github.com/nicola-strappazzon/argos/internal/awsclient"
+	"github.com/nicola-strappazzon/argos/internal/config/aws"
+	"github.com/nicola-strappazzon/argos/internal/cwlogs"
+	"github.com/nicola-strappazzon/argos/internal/timerange"
+	"github.com/nicola-strappazzon/argos/tools/registry"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	defaultLimit = 100
+	maxLimit     = 1000
+)
+
+type Result struct {
+	Identifier    string         `json:"identifier"`
+	LogGroup      string         `json:"log_group"`
+	StartTime     string         `json:"start_time"`
+	EndTime       string         `json:"end_time"`
+	FilterPattern string         `json:"filter_pattern,omitempty"`
+	Events        []cwlogs.Event `json:"events"`
+	// Truncated is set when more events may match past limit.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+func init() {
+	registry.Add(registry.Property{
+		Name:        "aws_rds_cloudwatch_logs",
+		Description: "Query the logs an RDS instance exports to CloudWatch Logs (log group /aws/rds/instance/<id>/<log_type>) over a time range, optionally with a CloudWatch Logs filter pattern. Use it when aws_rds_logs finds nothing useful on the instance. To analyze a whole slow query log with pt_query_digest, save it with aws_rds_cloudwatch_log_download.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": awsconfig.TargetProperties(map[string]any{
+				"db_instance_identifier": map[string]any{
+					"type":        "string",
+					"description": "The RDS DB instance identifier.",
+				},
+				"log_type": map[string]any{
+					"type":        "string",
+					"enum":        cwlogs.Types(),
+					"description": "The exported log: slowquery, error or general (MySQL, MariaDB), postgresql (PostgreSQL).",
+				},
+				"start_time": map[string]any{
+					"type":        "string",
+					"description": "Start of the time range, RFC 3339 (e.g. 2024-05-14T09:00:00Z). Default: 1 hour before end_time.",
+				},
+				"end_time": map[string]any{
+					"type":        "string",
+					"description": "End of the time range, RFC 3339. Default: now.",
+				},
+				"filter_pattern": map[string]any{
+					"type":        "string",
+					"description": "CloudWatch Logs filter pattern, e.g. \"Deadlock\" or \"?ERROR ?FATAL\". Default: every event.",
+				},
+				"limit": map[string]any{
+					"type":        "integer",
+					"description": "Maximum number of events to return (default: 100, maximum: 1000).",
+				},
+			}),
+			"required": []string{"db_instance_identifier", "log_type"},
+		},
+		Output:  Result{},
+		Timeout: 2 * time.Minute,
+		Function: func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
+			instanceID, _ := args["db_instance_identifier"].(string)
+			logType, _ := args["log_type"].(string)
+			pattern, _ := args["filter_pattern"].(string)
+
+			limit := defaultLimit
+			if n, ok := args["limit"].(float64); ok && n > 0 {
+				limit = min(int(n), maxLimit)
+			}
+
+			start, end, err := timerange.Parse(args, time.Hour)
+			if err != nil {
+				return &mcp.CallToolResult{}, nil, err
+			}
+
+			target, err := awsconfig.TargetFromArgs(args)
+			if err != nil {
+				return &mcp.CallToolResult{}, nil, err
+			}
+
+			svc, err := awsclient.CloudWatchLogs(target)
+			if err != nil {
+				return &mcp.CallToolResult{}, nil, err
+			}
+
+			result := Result{
+				Identifier:    instanceID,
+				LogGroup:      cwlogs.Group(instanceID, logType),
+				StartTime:     start.Format(time.RFC3339),
+				EndTime:       end.Format(time.RFC3339),
+				FilterPattern: pattern,
+				Events:        []cwlogs.Event{},
+			}
+
+			result.Truncated, err = cwlogs.Filter(ctx, svc, cwlogs.Query{
+				Group:   result.LogGroup,
+				Start:   start,
+				End:     end,
+				Pattern: pattern,
+				Limit:   limit,
+			}, func(e cwlogs.Event) error {
+				result.Events = append(result.Events, e)
+				return nil
+			})
+			if err != nil {
+				return &mcp.CallToolResult{}, nil, err
+			}
+
+			return &mcp.CallToolResult{}, result, nil
+		},
+	})
+}
@@ -0,0 +1,90 @@
+package aws_rds_cloudwatch_logs_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nicola-strappazzon/argos/internal/awsclient"
+	"github.com/nicola-strappazzon/argos/internal/testharness"
+	"github.com/nicola-strappazzon/argos/internal/toolerr"
+	"github.com/nicola-strappazzon/argos/tools/plugins/aws/aws_rds_cloudwatch_logs"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// fakeLogs serves two pages of events, or fails when the group is missing.
+type fakeLogs struct {
+	awsclient.CloudWatchLogsAPI
+	inputs  []*cloudwatchlogs.FilterLogEventsInput
+	missing bool
+}
+
+func (f *fakeLogs) FilterLogEvents(_ context.Context, in *cloudwatchlogs.FilterLogEventsInput, _ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.FilterLogEventsOutput, error) {
+	copied := *in
+	f.inputs = append(f.inputs, &copied)
+	if f.missing {
+		return nil, &types.ResourceNotFoundException{Message: aws.String("The specified log group does not exist.")}
+	}
+
+	event := func(ms int64, msg string) types.FilteredLogEvent {
+		return types.FilteredLogEvent{Timestamp: aws.Int64(ms), LogStreamName: aws.String("db1"), Message: aws.String(msg)}
+	}
+	if in.NextToken == nil {
+		return &cloudwatchlogs.FilterLogEventsOutput{
+			Events:    []types.FilteredLogEvent{event(1715677200000, "first")},
+			NextToken: aws.String("page2"),
+		}, nil
+	}
+	return &cloudwatchlogs.FilterLogEventsOutput{
+		Events: []types.FilteredLogEvent{event(1715677260000, "second"), event(1715677320000, "third")},
+	}, nil
+}
+
+func TestQuery(t *testing.T) {
+	fake := &fakeLogs{}
+	testharness.UseAWS(t, &testharness.AWS{CloudWatchLogs: fake})
+
+	var got aws_rds_cloudwatch_logs.Result
+	testharness.Decode(t, testharness.Call(t, "aws_rds_cloudwatch_logs", map[string]any{
+		"db_instance_identifier": "db1",
+		"log_type":               "error",
+		"start_time":             "2024-05-14T09:00:00Z",
+		"end_time":               "2024-05-14T10:00:00Z",
+		"filter_pattern":         "ERROR",
+		"limit":                  2,
+	}), &got)
+
+	if got.LogGroup != "/aws/rds/instance/db1/error" || len(got.Events) != 2 || !got.Truncated {
+		t.Fatalf("expected the first 2 events of the error log group, got %+v", got)
+	}
+	if got.Events[1].Message != "second" || got.Events[0].Time.Format("15:04") != "09:00" {
+		t.Errorf("unexpected events: %+v", got.Events)
+	}
+
+	in := fake.inputs[0]
+	if aws.ToInt64(in.StartTime) != 1715677200000 || aws.ToInt64(in.EndTime) != 1715680800000 ||
+		aws.ToString(in.FilterPattern) != "ERROR" || aws.ToInt32(in.Limit) != 2 {
+		t.Errorf("unexpected first request: %+v", in)
+	}
+	if aws.ToInt32(fake.inputs[1].Limit) != 1 {
+		t.Errorf("expected the second page to ask for the remaining event, got %+v", fake.inputs[1])
+	}
+}
+
+func TestNotExported(t *testing.T) {
+	testharness.UseAWS(t, &testharness.AWS{CloudWatchLogs: &fakeLogs{missing: true}})
+
+	testharness.Failure(t, testharness.Call(t, "aws_rds_cloudwatch_logs", map[string]any{
+		"db_instance_identifier": "db1",
+		"log_type":               "slowquery",
+	}), toolerr.NotFound)
+
+	testharness.Failure(t, testharness.Call(t, "aws_rds_cloudwatch_logs", map[string]any{
+		"db_instance_identifier": "db1",
+		"log_type":               "slowquery",
+		"start_time":             "2024-05-14T10:00:00Z",
+		"end_time":               "2024-05-14T09:00:00Z",
+	}), toolerr.InvalidInput)
+}
@@ -0,0 +1,309 @@
+package aws_rds_deadlocks
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/nicola-strappazzon/argos/internal/awsclient"
+	"github.com/nicola-strappazzon/argos/internal/config/aws"
+	"github.com/nicola-strappazzon/argos/internal/innodb"
+	"github.com/nicola-strappazzon/argos/internal/rdslog"
+	"github.com/nicola-strappazzon/argos/internal/sqlguard"
+	"github.com/nicola-strappazzon/argos/internal/timerange"
+	"github.com/nicola-strappazzon/argos/internal/toolerr"
+	"github.com/nicola-strappazzon/argos/tools/registry"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// maxBuckets bounds the trend series of the result and of each group: a
+// month by hour, two years by day.
+const maxBuckets = 744
+
+// Bucket counts the deadlocks of an hour or a day.
+type Bucket struct {
+	Start string `json:"start"`
+	Count int    `json:"count"`
+}
+
+// Group gathers the deadlocks between the same locks and queries.
+type Group struct {
+	// Locks are the "table index" pairs the transactions waited for.
+	Locks []string `json:"locks"`
+	// Queries are the fingerprints of the transactions' queries.
+	Queries   []string `json:"queries"`
+	Count     int      `json:"count"`
+	FirstSeen string   `json:"first_seen"`
+	LastSeen  string   `json:"last_seen"`
+	// Trend compares the second half of the time range with the first.
+	Trend   string           `json:"trend"`
+	Buckets []Bucket         `json:"buckets"`
+	Latest  *innodb.Deadlock `json:"latest"`
+}
+
+type Result struct {
+	Identifier string   `json:"identifier"`
+	StartTime  string   `json:"start_time"`
+	EndTime    string   `json:"end_time"`
+	Interval   string   `json:"interval"`
+	LogFiles   []string `json:"log_files"`
+	Total      int      `json:"total"`
+	Trend      string   `json:"trend"`
+	Buckets    []Bucket `json:"buckets"`
+	Groups     []Group  `json:"groups"`
+	Note       string   `json:"note,omitempty"`
+}
+
+func init() {
+	registry.Add(registry.Property{
+		Name:        "aws_rds_deadlocks",
+		Description: "Extract every deadlock from the error logs of an RDS MySQL instance over a time range (requires innodb_print_all_deadlocks = ON in the parameter group). Deadlocks are grouped by the table/index pairs waited for and the fingerprints of the queries involved, with counts per hour or day and a trend, and the latest deadlock of each group parsed as in mysql_innodb.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": awsconfig.TargetProperties(map[string]any{
+				"db_instance_identifier": map[string]any{
+					"type":        "string",
+					"description": "The RDS DB instance identifier.",
+				},
+				"start_time": map[string]any{
+					"type":        "string",
+					"description": "Start of the time range, RFC 3339 (e.g. 2024-05-14T00:00:00Z). Default: 24 hours before end_time.",
+				},
+				"end_time": map[string]any{
+					"type":        "string",
+					"description": "End of the time range, RFC 3339. Default: now.",
+				},
+				"interval": map[string]any{
+					"type":        "string",
+					"enum":        []string{"hour", "day"},
+					"description": "Size of the trend buckets (default: hour up to 48 hours, day beyond). A range may span at most 744 buckets.",
+				},
+			}),
+			"required": []string{"db_instance_identifier"},
+		},
+		Output:  Result{},
+		Timeout: 10 * time.Minute,
+		Function: func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
+			instanceID, _ := args["db_instance_identifier"].(string)
+
+			start, end, err := timerange.Parse(args, 24*time.Hour)
+			if err != nil {
+				return &mcp.CallToolResult{}, nil, err
+			}
+
+			interval, _ := args["interval"].(string)
+			if interval == "" {
+				interval = "hour"
+				if end.Sub(start) > 48*time.Hour {
+					interval = "day"
+				}
+			}
+			if n := count(start, end, interval); n > maxBuckets {
+				hint := "narrow start_time/end_time or pass interval: day"
+				if interval == "day" {
+					hint = "narrow start_time/end_time"
+				}
+				return &mcp.CallToolResult{}, nil, toolerr.Errorf(toolerr.InvalidInput, hint,
+					"time range of %d %s buckets, more than %d", n, interval, maxBuckets)
+			}
+
+			target, err := awsconfig.TargetFromArgs(args)
+			if err != nil {
+				return &mcp.CallToolResult{}, nil, err
+			}
+
+			svc, err := awsclient.RDS(target)
+			if err != nil {
+				return &mcp.CallToolResult{}, nil, err
+			}
+
+			files, err := rdslog.List(ctx, svc, instanceID, "error", start)
+			if err != nil {
+				return &mcp.CallToolResult{}, nil, err
+			}
+
+			result := Result{
+				Identifier: instanceID,
+				StartTime:  start.Format(time.RFC3339),
+				EndTime:    end.Format(time.RFC3339),
+				Interval:   interval,
+				LogFiles:   []string{},
+				Buckets:    []Bucket{},
+				Groups:     []Group{},
+			}
+
+			var deadlocks []innodb.LoggedDeadlock
+			for _, f := range files {
+				logged, err := parse(ctx, svc, instanceID, f.Name)
+				if err != nil {
+					return &mcp.CallToolResult{}, nil, fmt.Errorf("downloading %s: %w", f.Name, err)
+				}
+				result.LogFiles = append(result.LogFiles, f.Name)
+
+				for _, dl := range logged {
+					if !dl.Time.Before(start) && !dl.Time.After(end) {
+						deadlocks = append(deadlocks, dl)
+					}
+				}
+			}
+
+			// The same deadlock can be in more than one file, e.g. in
+			// mysql-error.log and mysql-error-running.log: keep it once.
+			slices.SortFunc(deadlocks, func(a, b innodb.LoggedDeadlock) int { return a.Time.Compare(b.Time) })
+			deadlocks = slices.CompactFunc(deadlocks, func(a, b innodb.LoggedDeadlock) bool {
+				return a.Time.Equal(b.Time) && slices.EqualFunc(a.Transactions, b.Transactions, func(x, y innodb.DeadlockTx) bool {
+					return x.ID == y.ID
+				})
+			})
+
+			result.Total = len(deadlocks)
+			result.Buckets = buckets(deadlocks, start, end, interval)
+			result.Trend = trend(deadlocks, start, end)
+			result.Groups = group(deadlocks, start, end, interval)
+
+			if result.Total == 0 {
+				result.Note = "No deadlock found in the error logs of the time range. The error log only has every deadlock when innodb_print_all_deadlocks is ON in the DB parameter group; otherwise use mysql_innodb for the latest one."
+			}
+
+			return &mcp.CallToolResult{}, result, nil
+		},
+	})
+}
+
+// parse streams a log file into the deadlock parser, so that a large error
+// log is never held in memory.
+func parse(ctx context.Context, svc awsclient.RDSAPI, instanceID, name string) ([]innodb.LoggedDeadlock, error) {
+	pr, pw := io.Pipe()
+	go func() {
+		_, err := rdslog.Download(ctx, svc, instanceID, name, pw)
+		pw.CloseWithError(err)
+	}()
+
+	deadlocks, err := innodb.ParseErrorLog(pr)
+	// Unblock the download if parsing stopped early.
+	pr.CloseWithError(err)
+
+	return deadlocks, err
+}
+
+// key returns the locks and query fingerprints a deadlock is grouped by.
+func key(dl innodb.Deadlock) ([]string, []string) {
+	var locks, queries []string
+	for _, tx := range dl.Transactions {
+		if tx.WaitingFor != nil {
+			lock := tx.WaitingFor.Table
+			if tx.WaitingFor.Index != "" {
+				lock += " " + tx.WaitingFor.Index
+			}
+			locks = append(locks, lock)
+		}
+		if tx.Query != "" {
+			queries = append(queries, sqlguard.Fingerprint(sqlguard.MySQL, tx.Query))
+		}
+	}
+
+	slices.Sort(locks)
+	slices.Sort(queries)
+	return slices.Compact(locks), slices.Compact(queries)
+}
+
+func group(deadlocks []innodb.LoggedDeadlock, start, end time.Time, interval string) []Group {
+	byKey := map[string][]innodb.LoggedDeadlock{}
+	var order []string
+	for _, dl := range deadlocks {
+		locks, queries := key(dl.Deadlock)
+		k := strings.Join(locks, "\x00") + "\x01" + strings.Join(queries, "\x00")
+		if _, ok := byKey[k]; !ok {
+			order = append(order, k)
+		}
+		byKey[k] = append(byKey[k], dl)
+	}
+
+	groups := make([]Group, 0, len(order))
+	for _, k := range order {
+		members := byKey[k]
+		first, last := members[0], members[len(members)-1]
+		locks, queries := key(last.Deadlock)
+
+		groups = append(groups, Group{
+			Locks:     locks,
+			Queries:   queries,
+			Count:     len(members),
+			FirstSeen: first.Time.Format(time.RFC3339),
+			LastSeen:  last.Time.Format(time.RFC3339),
+			Trend:     trend(members, start, end),
+			Buckets:   buckets(members, start, end, interval),
+			Latest:    &last.Deadlock,
+		})
+	}
+
+	slices.SortStableFunc(groups, func(a, b Group) int { return b.Count - a.Count })
+	return groups
+}
+
+// step returns the length of the buckets of an interval.
+func step(interval string) time.Duration {
+	if interval == "day" {
+		return 24 * time.Hour
+	}
+	return time.Hour
+}
+
+// count returns the number of buckets of the range.
+func count(start, end time.Time, interval string) int {
+	step := step(interval)
+	from := start.Truncate(step)
+	return int((end.Sub(from) + step - 1) / step)
+}
+
+// buckets counts the deadlocks per hour or day of the range, empty buckets
+// included so the series can be read as is.
+func buckets(deadlocks []innodb.LoggedDeadlock, start, end time.Time, interval string) []Bucket {
+	step := step(interval)
+
+	out := []Bucket{}
+	index := map[int64]int{}
+	for t := start.Truncate(step); t.Before(end); t = t.Add(step) {
+		index[t.Unix()] = len(out)
+		out = append(out, Bucket{Start: t.Format(time.RFC3339)})
+	}
+	for _, dl := range deadlocks {
+		if i, ok := index[dl.Time.Truncate(step).Unix()]; ok {
+			out[i].Count++
+		}
+	}
+
+	return out
+}
+
+// trend compares the number of deadlocks in the second half of the range with
+// the first: "increasing" or "decreasing" past a 50% change, "stable"
+// otherwise, "none" without deadlocks.
+func trend(deadlocks []innodb.LoggedDeadlock, start, end time.Time) string {
+	if len(deadlocks) == 0 {
+		return "none"
+	}
+
+	middle := start.Add(end.Sub(start) / 2)
+	var first, second int
+	for _, dl := range deadlocks {
+		if dl.Time.Before(middle) {
+			first++
+		} else {
+			second++
+		}
+	}
+
+	switch {
+	case float64(second) > float64(first)*1.5:
+		return "increasing"
+	case float64(first) > float64(second)*1.5:
+		return "decreasing"
+	default:
+		return "stable"
+	}
+}
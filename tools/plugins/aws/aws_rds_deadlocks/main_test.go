@@ -0,0 +1,122 @@
+package aws_rds_deadlocks_test
+
+import (
+	"context"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/nicola-strappazzon/argos/internal/awsclient"
+	"github.com/nicola-strappazzon/argos/internal/testharness"
+	"github.com/nicola-strappazzon/argos/internal/toolerr"
+	"github.com/nicola-strappazzon/argos/tools/plugins/aws/aws_rds_deadlocks"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	"github.com/aws/aws-sdk-go-v2/service/rds/types"
+)
+
+// fakeRDS serves the same error log under two names, as RDS does with
+// mysql-error.log and mysql-error-running.log, in two pages.
+type fakeRDS struct {
+	awsclient.RDSAPI
+	log   string
+	since *int64
+}
+
+func (f *fakeRDS) DescribeDBLogFiles(_ context.Context, in *rds.DescribeDBLogFilesInput, _ ...func(*rds.Options)) (*rds.DescribeDBLogFilesOutput, error) {
+	f.since = in.FileLastWritten
+	return &rds.DescribeDBLogFilesOutput{DescribeDBLogFiles: []types.DescribeDBLogFilesDetails{
+		{LogFileName: aws.String("error/mysql-error-running.log")},
+		{LogFileName: aws.String("error/mysql-error.log")},
+	}}, nil
+}
+
+func (f *fakeRDS) DownloadDBLogFilePortion(_ context.Context, in *rds.DownloadDBLogFilePortionInput, _ ...func(*rds.Options)) (*rds.DownloadDBLogFilePortionOutput, error) {
+	half := len(f.log) / 2
+	if aws.ToString(in.Marker) == "0" {
+		return &rds.DownloadDBLogFilePortionOutput{LogFileData: aws.String(f.log[:half]), Marker: aws.String("1"), AdditionalDataPending: aws.Bool(true)}, nil
+	}
+	return &rds.DownloadDBLogFilePortionOutput{LogFileData: aws.String(f.log[half:]), Marker: aws.String("2")}, nil
+}
+
+func TestDeadlocks(t *testing.T) {
+	log, err := os.ReadFile("../../../../internal/innodb/testdata/error-8.0.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake := &fakeRDS{log: string(log)}
+	testharness.UseAWS(t, &testharness.AWS{RDS: fake})
+
+	var got aws_rds_deadlocks.Result
+	testharness.Decode(t, testharness.Call(t, "aws_rds_deadlocks", map[string]any{
+		"db_instance_identifier": "db1",
+		"start_time":             "2024-05-14T08:00:00Z",
+		"end_time":               "2024-05-14T12:00:00Z",
+	}), &got)
+
+	if fake.since == nil || *fake.since != 1715673600000 {
+		t.Errorf("expected log files written since the start time, got %v", fake.since)
+	}
+	if len(got.LogFiles) != 2 || got.Interval != "hour" {
+		t.Errorf("unexpected log files or interval: %+v", got)
+	}
+	if got.Total != 2 {
+		t.Fatalf("expected the 2 complete deadlocks once each, got %d", got.Total)
+	}
+
+	counts := make([]int, 0, len(got.Buckets))
+	for _, b := range got.Buckets {
+		counts = append(counts, b.Count)
+	}
+	if !slices.Equal(counts, []int{0, 1, 0, 1}) || got.Buckets[0].Start != "2024-05-14T08:00:00Z" {
+		t.Errorf("unexpected buckets: %+v", got.Buckets)
+	}
+
+	if len(got.Groups) != 1 {
+		t.Fatalf("expected one group, got %+v", got.Groups)
+	}
+	g := got.Groups[0]
+	if g.Count != 2 || g.FirstSeen != "2024-05-14T09:58:41Z" || g.LastSeen != "2024-05-14T11:20:05Z" || g.Trend != "stable" {
+		t.Errorf("unexpected group: %+v", g)
+	}
+	if !slices.Equal(g.Locks, []string{"`bank`.`accounts` PRIMARY"}) {
+		t.Errorf("unexpected locks: %v", g.Locks)
+	}
+	if !slices.Equal(g.Queries, []string{
+		"update accounts set balance = balance + ? where id = ?",
+		"update accounts set balance = balance - ? where id = ?",
+	}) {
+		t.Errorf("unexpected queries: %v", g.Queries)
+	}
+	if g.Latest == nil || g.Latest.Victim != 1 || g.Latest.Transactions[0].ID != 4719931 {
+		t.Errorf("expected the latest deadlock of the group, got %+v", g.Latest)
+	}
+}
+
+func TestNoDeadlocks(t *testing.T) {
+	testharness.UseAWS(t, &testharness.AWS{RDS: &fakeRDS{log: "2024-05-14T09:12:03.412871Z 0 [System] [MY-010931] [Server] ready for connections.\n"}})
+
+	var got aws_rds_deadlocks.Result
+	testharness.Decode(t, testharness.Call(t, "aws_rds_deadlocks", map[string]any{
+		"db_instance_identifier": "db1",
+		"end_time":               "2024-05-14T12:00:00Z",
+	}), &got)
+
+	if got.Total != 0 || got.Note == "" || got.Trend != "none" || len(got.Buckets) != 24 {
+		t.Errorf("expected an empty result with a note, got %+v", got)
+	}
+}
+
+func TestInvalidTimeRange(t *testing.T) {
+	testharness.UseAWS(t, &testharness.AWS{RDS: &fakeRDS{}})
+
+	for _, args := range []map[string]any{
+		{"db_instance_identifier": "db1", "start_time": "yesterday"},
+		{"db_instance_identifier": "db1", "start_time": "2024-05-14T12:00:00Z", "end_time": "2024-05-14T08:00:00Z"},
+		{"db_instance_identifier": "db1", "start_time": "2024-01-01T00:00:00Z", "end_time": "2024-03-01T00:00:00Z", "interval": "hour"},
+		{"db_instance_identifier": "db1", "start_time": "2020-01-01T00:00:00Z", "end_time": "2024-01-01T00:00:00Z"},
+	} {
+		testharness.Failure(t, testharness.Call(t, "aws_rds_deadlocks", args), toolerr.InvalidInput)
+	}
+}
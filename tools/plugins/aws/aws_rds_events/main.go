@@ -4,11 +4,13 @@ import (
 	"context"
 	"time"
 
+	"github.com/nicola-strappazzon/argos/internal/awsclient"
 	"github.com/nicola-strappazzon/argos/internal/config/aws"
 	"github.com/nicola-strappazzon/argos/tools/registry"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/rds"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	"github.com/aws/aws-sdk-go-v2/service/rds/types"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
@@ -19,13 +21,19 @@ type Event struct {
 	SourceIdentifier string   `json:"source_identifier"`
 }
 
+type Result struct {
+	Instance string  `json:"instance"`
+	Minutes  int     `json:"minutes"`
+	Events   []Event `json:"events"`
+}
+
 func init() {
 	registry.Add(registry.Property{
 		Name:        "aws_rds_events",
 		Description: "List recent RDS events (failovers, maintenance, reboots, storage issues) for an RDS instance.",
 		InputSchema: map[string]any{
 			"type": "object",
-			"properties": map[string]any{
+			"properties": awsconfig.TargetProperties(map[string]any{
 				"db_instance_identifier": map[string]any{
 					"type":        "string",
 					"description": "The RDS DB instance identifier to fetch events for.",
@@ -34,9 +42,10 @@ func init() {
 					"type":        "integer",
 					"description": "Time window in minutes to look back (default: 1440 = 24 hours).",
 				},
-			},
+			}),
 			"required": []string{"db_instance_identifier"},
 		},
+		Output: Result{},
 		Function: func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
 			instanceID, _ := args["db_instance_identifier"].(string)
 
@@ -45,18 +54,21 @@ func init() {
 				minutes = int(m)
 			}
 
-			sess, err := awsconfig.NewSession()
+			target, err := awsconfig.TargetFromArgs(args)
 			if err != nil {
 				return &mcp.CallToolResult{}, nil, err
 			}
 
-			svc := rds.New(sess)
+			svc, err := awsclient.RDS(target)
+			if err != nil {
+				return &mcp.CallToolResult{}, nil, err
+			}
 			now := time.Now()
 			start := now.Add(-time.Duration(minutes) * time.Minute)
 
-			result, err := svc.DescribeEvents(&rds.DescribeEventsInput{
+			result, err := svc.DescribeEvents(ctx, &rds.DescribeEventsInput{
 				SourceIdentifier: aws.String(instanceID),
-				SourceType:       aws.String("db-instance"),
+				SourceType:       types.SourceTypeDbInstance,
 				StartTime:        aws.Time(start),
 				EndTime:          aws.Time(now),
 			})
@@ -66,22 +78,22 @@ func init() {
 
 			events := make([]Event, 0, len(result.Events))
 			for _, e := range result.Events {
-				categories := make([]string, 0, len(e.EventCategories))
-				for _, c := range e.EventCategories {
-					categories = append(categories, aws.StringValue(c))
+				categories := e.EventCategories
+				if categories == nil {
+					categories = []string{}
 				}
 				events = append(events, Event{
-					Date:             aws.TimeValue(e.Date).UTC().Format(time.RFC3339),
-					Message:          aws.StringValue(e.Message),
+					Date:             aws.ToTime(e.Date).UTC().Format(time.RFC3339),
+					Message:          aws.ToString(e.Message),
 					Categories:       categories,
-					SourceIdentifier: aws.StringValue(e.SourceIdentifier),
+					SourceIdentifier: aws.ToString(e.SourceIdentifier),
 				})
 			}
 
-			return &mcp.CallToolResult{}, map[string]any{
-				"instance": instanceID,
-				"minutes":  minutes,
-				"events":   events,
+			return &mcp.CallToolResult{}, Result{
+				Instance: instanceID,
+				Minutes:  minutes,
+				Events:   events,
 			}, nil
 		},
 	})
@@ -0,0 +1,54 @@
+package aws_rds_events_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nicola-strappazzon/argos/internal/awsclient"
+	"github.com/nicola-strappazzon/argos/internal/testharness"
+	"github.com/nicola-strappazzon/argos/tools/plugins/aws/aws_rds_events"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	"github.com/aws/aws-sdk-go-v2/service/rds/types"
+)
+
+type fakeRDS struct {
+	awsclient.RDSAPI
+	input *rds.DescribeEventsInput
+}
+
+func (f *fakeRDS) DescribeEvents(_ context.Context, in *rds.DescribeEventsInput, _ ...func(*rds.Options)) (*rds.DescribeEventsOutput, error) {
+	f.input = in
+	return &rds.DescribeEventsOutput{Events: []types.Event{{
+		Date:             aws.Time(time.Date(2024, 5, 14, 9, 0, 0, 0, time.UTC)),
+		Message:          aws.String("Multi-AZ instance failover completed"),
+		EventCategories:  []string{"failover"},
+		SourceIdentifier: aws.String("db1"),
+	}}}, nil
+}
+
+func TestEvents(t *testing.T) {
+	fake := &fakeRDS{}
+	testharness.UseAWS(t, &testharness.AWS{RDS: fake})
+
+	var got aws_rds_events.Result
+	testharness.Decode(t, testharness.Call(t, "aws_rds_events", map[string]any{
+		"db_instance_identifier": "db1",
+		"minutes":                60,
+	}), &got)
+
+	if got.Minutes != 60 || len(got.Events) != 1 {
+		t.Fatalf("unexpected result: %+v", got)
+	}
+	if e := got.Events[0]; e.Date != "2024-05-14T09:00:00Z" || e.Categories[0] != "failover" {
+		t.Errorf("unexpected event: %+v", e)
+	}
+	if window := fake.input.EndTime.Sub(*fake.input.StartTime); window != time.Hour {
+		t.Errorf("expected a 1h window, got %s", window)
+	}
+	if fake.input.SourceType != types.SourceTypeDbInstance {
+		t.Errorf("unexpected source type %q", fake.input.SourceType)
+	}
+}
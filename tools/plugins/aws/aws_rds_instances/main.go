@@ -3,11 +3,12 @@ package aws_rds_instances
 import (
 	"context"
 
+	"github.com/nicola-strappazzon/argos/internal/awsclient"
 	"github.com/nicola-strappazzon/argos/internal/config/aws"
 	"github.com/nicola-strappazzon/argos/tools/registry"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/rds"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
@@ -28,6 +29,8 @@ type Instance struct {
 	Status                     string   `json:"status"`
 	Engine                     string   `json:"engine"`
 	EngineVersion              string   `json:"engine_version"`
+	Region                     string   `json:"region"`
+	Account                    string   `json:"account,omitempty"`
 	AZ                         string   `json:"availability_zone"`
 	MultiAZ                    bool     `json:"multi_az"`
 	Endpoint                   Endpoint `json:"endpoint"`
@@ -35,52 +38,95 @@ type Instance struct {
 	PerformanceInsightsEnabled bool     `json:"performance_insights_enabled"`
 }
 
+type Result struct {
+	Instances []Instance `json:"instances"`
+	// Errors lists the regions or accounts that could not be listed in a fan-out.
+	Errors []awsconfig.TargetError `json:"errors,omitempty"`
+}
+
 func init() {
 	registry.Add(registry.Property{
 		Name:        "aws_rds_instances",
-		Description: "List AWS RDS Instances.",
+		Description: "List AWS RDS Instances. With all_targets, lists them across every configured region and account in one call.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": awsconfig.TargetProperties(map[string]any{
+				"all_targets": map[string]any{
+					"type":        "boolean",
+					"description": "List instances in every region of ARGOS_AWS_REGIONS for every account of ARGOS_AWS_PROFILES and ARGOS_AWS_ROLES, ignoring region, profile and role_arn.",
+				},
+			}),
+		},
+		Output: Result{},
 		Function: func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
-			sess, err := awsconfig.NewSession()
+			if all, _ := args["all_targets"].(bool); all {
+				instances, errs := awsconfig.FanOut(ctx, awsconfig.Targets(), listInstances)
+				return &mcp.CallToolResult{}, Result{Instances: instances, Errors: errs}, nil
+			}
+
+			target, err := awsconfig.TargetFromArgs(args)
 			if err != nil {
 				return &mcp.CallToolResult{}, nil, err
 			}
 
-			svc := rds.New(sess)
-
-			result, err := svc.DescribeDBInstances(nil)
+			instances, err := listInstances(ctx, target)
 			if err != nil {
 				return &mcp.CallToolResult{}, nil, err
 			}
 
-			instances := make([]Instance, 0, len(result.DBInstances))
-			for _, db := range result.DBInstances {
-				inst := Instance{
-					Identifier:    aws.StringValue(db.DBInstanceIdentifier),
-					Class:         aws.StringValue(db.DBInstanceClass),
-					Status:        aws.StringValue(db.DBInstanceStatus),
-					Engine:        aws.StringValue(db.Engine),
-					EngineVersion: aws.StringValue(db.EngineVersion),
-					AZ:            aws.StringValue(db.AvailabilityZone),
-					MultiAZ:       aws.BoolValue(db.MultiAZ),
-					Storage: Storage{
-						Type:       aws.StringValue(db.StorageType),
-						Iops:       aws.Int64Value(db.Iops),
-						Throughput: aws.Int64Value(db.StorageThroughput),
-					},
-					PerformanceInsightsEnabled: aws.BoolValue(db.PerformanceInsightsEnabled),
-				}
+			return &mcp.CallToolResult{}, Result{Instances: instances}, nil
+		},
+	})
+}
 
-				if db.Endpoint != nil {
-					inst.Endpoint = Endpoint{
-						Address: aws.StringValue(db.Endpoint.Address),
-						Port:    aws.Int64Value(db.Endpoint.Port),
-					}
-				}
+func listInstances(ctx context.Context, target awsconfig.Target) ([]Instance, error) {
+	svc, err := awsclient.RDS(target)
+	if err != nil {
+		return nil, err
+	}
+
+	region, err := awsclient.Region(target)
+	if err != nil {
+		return nil, err
+	}
+
+	instances := []Instance{}
+	pages := rds.NewDescribeDBInstancesPaginator(svc, &rds.DescribeDBInstancesInput{})
+	for pages.HasMorePages() {
+		page, err := pages.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
 
-				instances = append(instances, inst)
+		for _, db := range page.DBInstances {
+			inst := Instance{
+				Identifier:    aws.ToString(db.DBInstanceIdentifier),
+				Class:         aws.ToString(db.DBInstanceClass),
+				Status:        aws.ToString(db.DBInstanceStatus),
+				Engine:        aws.ToString(db.Engine),
+				EngineVersion: aws.ToString(db.EngineVersion),
+				Region:        region,
+				Account:       target.Account(),
+				AZ:            aws.ToString(db.AvailabilityZone),
+				MultiAZ:       aws.ToBool(db.MultiAZ),
+				Storage: Storage{
+					Type:       aws.ToString(db.StorageType),
+					Iops:       int64(aws.ToInt32(db.Iops)),
+					Throughput: int64(aws.ToInt32(db.StorageThroughput)),
+				},
+				PerformanceInsightsEnabled: aws.ToBool(db.PerformanceInsightsEnabled),
 			}
 
-			return &mcp.CallToolResult{}, map[string]any{"instances": instances}, nil
-		},
-	})
+			if db.Endpoint != nil {
+				inst.Endpoint = Endpoint{
+					Address: aws.ToString(db.Endpoint.Address),
+					Port:    int64(aws.ToInt32(db.Endpoint.Port)),
+				}
+			}
+
+			instances = append(instances, inst)
+		}
+	}
+
+	return instances, nil
 }
@@ -0,0 +1,88 @@
+package aws_rds_instances_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nicola-strappazzon/argos/internal/awsclient"
+	"github.com/nicola-strappazzon/argos/internal/testharness"
+	"github.com/nicola-strappazzon/argos/tools/plugins/aws/aws_rds_instances"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	"github.com/aws/aws-sdk-go-v2/service/rds/types"
+)
+
+type fakeRDS struct{ awsclient.RDSAPI }
+
+// DescribeDBInstances serves two pages, the second one at marker "2".
+func (fakeRDS) DescribeDBInstances(_ context.Context, in *rds.DescribeDBInstancesInput, _ ...func(*rds.Options)) (*rds.DescribeDBInstancesOutput, error) {
+	if aws.ToString(in.Marker) != "2" {
+		return &rds.DescribeDBInstancesOutput{Marker: aws.String("2"), DBInstances: []types.DBInstance{{
+			DBInstanceIdentifier: aws.String("db1"),
+			DBInstanceClass:      aws.String("db.r6g.large"),
+			DBInstanceStatus:     aws.String("available"),
+			Engine:               aws.String("mysql"),
+			EngineVersion:        aws.String("8.0.36"),
+			MultiAZ:              aws.Bool(true),
+			Endpoint:             &types.Endpoint{Address: aws.String("db1.example.com"), Port: aws.Int32(3306)},
+		}}}, nil
+	}
+	return &rds.DescribeDBInstancesOutput{DBInstances: []types.DBInstance{{
+		DBInstanceIdentifier: aws.String("pg1"),
+		Engine:               aws.String("postgres"),
+	}}}, nil
+}
+
+func TestInstances(t *testing.T) {
+	fake := &testharness.AWS{RDS: fakeRDS{}}
+	testharness.UseAWS(t, fake)
+
+	var got aws_rds_instances.Result
+	testharness.Decode(t, testharness.Call(t, "aws_rds_instances", map[string]any{"region": "us-east-1"}), &got)
+
+	if len(got.Instances) != 2 {
+		t.Fatalf("expected instances from both pages, got %+v", got.Instances)
+	}
+	db1 := got.Instances[0]
+	if db1.Region != "us-east-1" || !db1.MultiAZ || db1.Endpoint.Port != 3306 {
+		t.Errorf("unexpected instance: %+v", db1)
+	}
+	if fake.Targets[0].Region != "us-east-1" {
+		t.Errorf("expected the requested region, got %+v", fake.Targets)
+	}
+}
+
+func TestInstancesAllTargets(t *testing.T) {
+	testharness.UseAWS(t, &testharness.AWS{RDS: fakeRDS{}})
+	t.Setenv("ARGOS_AWS_REGIONS", "eu-west-1,us-east-1")
+	t.Setenv("ARGOS_AWS_PROFILES", "prod,staging")
+
+	var got aws_rds_instances.Result
+	testharness.Decode(t, testharness.Call(t, "aws_rds_instances", map[string]any{"all_targets": true}), &got)
+
+	if len(got.Instances) != 8 || len(got.Errors) != 0 {
+		t.Fatalf("expected 2 instances for each of 4 targets, got %d (errors %+v)", len(got.Instances), got.Errors)
+	}
+	seen := map[string]int{}
+	for _, inst := range got.Instances {
+		seen[inst.Account+"/"+inst.Region]++
+	}
+	for _, target := range []string{"prod/eu-west-1", "prod/us-east-1", "staging/eu-west-1", "staging/us-east-1"} {
+		if seen[target] != 2 {
+			t.Errorf("%s: got %d instances", target, seen[target])
+		}
+	}
+}
+
+func TestInstancesAllTargetsErrors(t *testing.T) {
+	testharness.UseAWS(t, &testharness.AWS{})
+	t.Setenv("ARGOS_AWS_REGIONS", "eu-west-1,us-east-1")
+
+	var got aws_rds_instances.Result
+	testharness.Decode(t, testharness.Call(t, "aws_rds_instances", map[string]any{"all_targets": true}), &got)
+
+	if len(got.Instances) != 0 || len(got.Errors) != 2 {
+		t.Fatalf("expected an error per region, got %+v", got)
+	}
+}
@@ -2,28 +2,65 @@ package aws_rds_log_download
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
-	"strings"
+	"time"
 
+	"github.com/nicola-strappazzon/argos/internal/awsclient"
 	"github.com/nicola-strappazzon/argos/internal/config/aws"
+	"github.com/nicola-strappazzon/argos/internal/rdslog"
+	"github.com/nicola-strappazzon/argos/internal/timerange"
+	"github.com/nicola-strappazzon/argos/internal/toolerr"
+	"github.com/nicola-strappazzon/argos/internal/workspace"
 	"github.com/nicola-strappazzon/argos/tools/registry"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/rds"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
-const baseDir = "/tmp/argos/aws_rds_logs"
+type Result struct {
+	Identifier  string `json:"identifier"`
+	LogFileName string `json:"log_file_name"`
+	OutputPath  string `json:"output_path"`
+	// ResourceURI reads the file as an MCP resource.
+	ResourceURI string `json:"resource_uri"`
+	SizeKB      int    `json:"size_kb"`
+	// Mode is "full", "tail" or "range".
+	Mode  string `json:"mode"`
+	Pages int    `json:"pages"`
+	// Resumed is set when the download continued an interrupted one.
+	Resumed bool `json:"resumed,omitempty"`
+	// TruncatedPortions counts single lines larger than the 1 MB the API
+	// returns per call, whose end was lost.
+	TruncatedPortions int `json:"truncated_portions,omitempty"`
+	// Lines is the number of lines of a tail download. Shortened is set when
+	// they are fewer than tail_lines because the last tail_lines were over
+	// the 1 MB the API returns per call.
+	Lines     int64 `json:"lines,omitempty"`
+	Shortened bool  `json:"shortened,omitempty"`
+}
+
+// state is saved next to the partial file after each page, to resume an
+// interrupted download of the same range. Offset is the size of the partial
+// file when the marker was saved: anything written past it is cut on resume.
+type state struct {
+	Marker string              `json:"marker"`
+	Offset int64               `json:"offset"`
+	Since  time.Time           `json:"since"`
+	Until  time.Time           `json:"until"`
+	Window *rdslog.WindowState `json:"window,omitempty"`
+}
 
 func init() {
 	registry.Add(registry.Property{
 		Name:        "aws_rds_log_download",
-		Description: "Download the content of a specific RDS log file.",
+		Description: "Download an RDS log file to the workspace, under <instance>/aws_rds_log_download/, page by page. By default the whole file; with tail_lines only its end; with start_time/end_time only the entries logged in that range (MySQL slow and error logs, PostgreSQL logs). An interrupted download resumes where it stopped when called again with the same arguments.",
 		InputSchema: map[string]any{
 			"type": "object",
-			"properties": map[string]any{
+			"properties": awsconfig.TargetProperties(map[string]any{
 				"db_instance_identifier": map[string]any{
 					"type":        "string",
 					"description": "The RDS DB instance identifier.",
@@ -32,53 +69,227 @@ func init() {
 					"type":        "string",
 					"description": "The name of the log file to download (e.g. slowquery/mysql-slowquery.log).",
 				},
-			},
+				"tail_lines": map[string]any{
+					"type":        "integer",
+					"description": "Only download the last N lines of the file.",
+				},
+				"start_time": map[string]any{
+					"type":        "string",
+					"description": "Only keep entries logged at or after this time, RFC 3339 (e.g. 2024-05-14T09:00:00Z).",
+				},
+				"end_time": map[string]any{
+					"type":        "string",
+					"description": "Only keep entries logged at or before this time, RFC 3339. The download stops there.",
+				},
+				"resume": map[string]any{
+					"type":        "boolean",
+					"description": "Resume an interrupted download of the same file and range (default: true). False starts over.",
+				},
+			}),
 			"required": []string{"db_instance_identifier", "log_file_name"},
 		},
+		Output:  Result{},
+		Timeout: 10 * time.Minute,
+		Writes:  true,
 		Function: func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
 			instanceID, _ := args["db_instance_identifier"].(string)
 			logFileName, _ := args["log_file_name"].(string)
 
-			sess, err := awsconfig.NewSession()
+			resume := true
+			if r, ok := args["resume"].(bool); ok {
+				resume = r
+			}
+
+			var opt rdslog.Options
+			if n, ok := args["tail_lines"].(float64); ok && n > 0 {
+				opt.Tail = int64(n)
+			}
+			since, until, err := timerange.Parse(args, 0)
 			if err != nil {
 				return &mcp.CallToolResult{}, nil, err
 			}
+			opt.Since, opt.Until = since, until
 
-			svc := rds.New(sess)
+			if !filepath.IsLocal(logFileName) {
+				return &mcp.CallToolResult{}, nil, toolerr.Errorf(toolerr.InvalidInput, "pass a log file name as listed by aws_rds_logs",
+					"invalid log_file_name: %s", logFileName)
+			}
 
-			var sb strings.Builder
+			mode := "full"
+			suffix := ""
+			switch {
+			case opt.Tail > 0 && (!opt.Since.IsZero() || !opt.Until.IsZero()):
+				return &mcp.CallToolResult{}, nil, toolerr.Errorf(toolerr.InvalidInput, "pass either tail_lines or start_time/end_time",
+					"tail_lines cannot be combined with a time range")
+			case opt.Tail > 0:
+				mode = "tail"
+				suffix = ".tail"
+			case !opt.Since.IsZero() || !opt.Until.IsZero():
+				mode = "range"
+				suffix = "." + rangeSuffix(opt.Since, opt.Until)
+			}
 
-			err = svc.DownloadDBLogFilePortionPages(
-				&rds.DownloadDBLogFilePortionInput{
-					DBInstanceIdentifier: aws.String(instanceID),
-					LogFileName:          aws.String(logFileName),
-					NumberOfLines:        aws.Int64(2000),
-				},
-				func(page *rds.DownloadDBLogFilePortionOutput, lastPage bool) bool {
-					sb.WriteString(aws.StringValue(page.LogFileData))
-					return !lastPage
-				},
-			)
+			target, err := awsconfig.TargetFromArgs(args)
 			if err != nil {
 				return &mcp.CallToolResult{}, nil, err
 			}
 
-			// Build output path: /tmp/aws_rds_logs/<instance>/<log_file_name>
-			outputPath := filepath.Join(baseDir, instanceID, logFileName)
+			svc, err := awsclient.RDS(target)
+			if err != nil {
+				return &mcp.CallToolResult{}, nil, err
+			}
+
+			dir, err := workspace.Dir(instanceID, "aws_rds_log_download")
+			if err != nil {
+				return &mcp.CallToolResult{}, nil, err
+			}
+
+			outputPath := filepath.Join(dir, logFileName) + suffix
 			if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
 				return &mcp.CallToolResult{}, nil, fmt.Errorf("creating output directory: %w", err)
 			}
 
-			if err := os.WriteFile(outputPath, []byte(sb.String()), 0644); err != nil {
+			// The file is written as .part and renamed once complete; the
+			// position to resume from is saved in .part.json after each page.
+			partPath := outputPath + ".part"
+			statePath := partPath + ".json"
+
+			var offset int64
+			resumed := false
+			if prev, ok := loadState(statePath); ok && resume && mode != "tail" && size(partPath) >= prev.Offset &&
+				prev.Since.Equal(opt.Since) && prev.Until.Equal(opt.Until) {
+				opt.Marker = prev.Marker
+				opt.Window = prev.Window
+				offset = prev.Offset
+				resumed = true
+			}
+
+			part, err := os.OpenFile(partPath, os.O_CREATE|os.O_WRONLY, 0644)
+			if err != nil {
+				return &mcp.CallToolResult{}, nil, fmt.Errorf("opening %s: %w", partPath, err)
+			}
+			defer part.Close()
+
+			if err := part.Truncate(offset); err != nil {
+				return &mcp.CallToolResult{}, nil, fmt.Errorf("truncating %s: %w", partPath, err)
+			}
+			if _, err := part.Seek(offset, io.SeekStart); err != nil {
+				return &mcp.CallToolResult{}, nil, fmt.Errorf("seeking %s: %w", partPath, err)
+			}
+
+			if mode != "tail" {
+				// The page is synced before the state pointing past it is
+				// saved, so the state never runs ahead of the file.
+				opt.Checkpoint = func(pos rdslog.Position) error {
+					if err := part.Sync(); err != nil {
+						return err
+					}
+					return saveState(statePath, state{
+						Marker: pos.Marker,
+						Offset: offset + pos.Bytes,
+						Since:  opt.Since,
+						Until:  opt.Until,
+						Window: pos.Window,
+					})
+				}
+			}
+
+			stats, err := rdslog.Stream(ctx, svc, instanceID, logFileName, part, opt)
+			if err != nil {
+				if mode == "tail" {
+					return &mcp.CallToolResult{}, nil, err
+				}
+				return &mcp.CallToolResult{}, nil, fmt.Errorf("download stopped after %d KB, call again to resume: %w", stats.Bytes/1024, err)
+			}
+
+			if err := part.Close(); err != nil {
+				return &mcp.CallToolResult{}, nil, fmt.Errorf("writing %s: %w", partPath, err)
+			}
+			if err := os.Rename(partPath, outputPath); err != nil {
 				return &mcp.CallToolResult{}, nil, fmt.Errorf("writing log file: %w", err)
 			}
+			if err := os.Remove(statePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return &mcp.CallToolResult{}, nil, err
+			}
 
-			return &mcp.CallToolResult{}, map[string]any{
-				"identifier":    instanceID,
-				"log_file_name": logFileName,
-				"output_path":   outputPath,
-				"size_kb":       sb.Len() / 1024,
+			info, err := os.Stat(outputPath)
+			if err != nil {
+				return &mcp.CallToolResult{}, nil, err
+			}
+
+			return &mcp.CallToolResult{}, Result{
+				Identifier:        instanceID,
+				LogFileName:       logFileName,
+				OutputPath:        outputPath,
+				ResourceURI:       workspace.URI(outputPath),
+				SizeKB:            int(info.Size() / 1024),
+				Mode:              mode,
+				Pages:             stats.Pages,
+				Resumed:           resumed,
+				TruncatedPortions: stats.Truncated,
+				Lines:             stats.Lines,
+				Shortened:         stats.Shortened,
 			}, nil
 		},
 	})
 }
+
+// rangeSuffix names the file of a time range, e.g.
+// "20240514T090000Z-20240514T100000Z".
+func rangeSuffix(since, until time.Time) string {
+	format := func(t time.Time) string {
+		if t.IsZero() {
+			return "open"
+		}
+		return t.Format("20060102T150405Z")
+	}
+	return format(since) + "-" + format(until)
+}
+
+// size returns the size of a file, or -1 when it does not exist.
+func size(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return -1
+	}
+	return info.Size()
+}
+
+func loadState(path string) (state, bool) {
+	var s state
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return s, false
+	}
+	if err := json.Unmarshal(data, &s); err != nil || s.Marker == "" {
+		return s, false
+	}
+	return s, true
+}
+
+func saveState(path string, s state) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	// Written aside and renamed, so an interruption leaves the previous
+	// state rather than a partial one.
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,158 @@
+package aws_rds_log_download_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/nicola-strappazzon/argos/internal/awsclient"
+	"github.com/nicola-strappazzon/argos/internal/testharness"
+	"github.com/nicola-strappazzon/argos/internal/toolerr"
+	"github.com/nicola-strappazzon/argos/tools/plugins/aws/aws_rds_log_download"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+)
+
+// fakeRDS serves pages of the log, one per marker, and fails the call
+// numbered failAt.
+type fakeRDS struct {
+	awsclient.RDSAPI
+	pages   []string
+	failAt  int
+	calls   int
+	markers []string
+}
+
+func (f *fakeRDS) DownloadDBLogFilePortion(_ context.Context, in *rds.DownloadDBLogFilePortionInput, _ ...func(*rds.Options)) (*rds.DownloadDBLogFilePortionOutput, error) {
+	f.calls++
+	f.markers = append(f.markers, aws.ToString(in.Marker))
+	if f.calls == f.failAt {
+		return nil, errors.New("connection reset")
+	}
+
+	i, _ := strconv.Atoi(aws.ToString(in.Marker))
+	if in.Marker == nil {
+		i = len(f.pages) - 1
+	}
+	return &rds.DownloadDBLogFilePortionOutput{
+		LogFileData:           aws.String(f.pages[i]),
+		Marker:                aws.String(strconv.Itoa(i + 1)),
+		AdditionalDataPending: aws.Bool(i+1 < len(f.pages)),
+	}, nil
+}
+
+// instance points the workspace to a directory of the test and returns the
+// instance identifier to download from.
+func instance(t *testing.T) string {
+	t.Setenv("ARGOS_WORKSPACE", t.TempDir())
+	return "db1"
+}
+
+func TestResume(t *testing.T) {
+	id := instance(t)
+	fake := &fakeRDS{pages: []string{"a\n", "b\n", "c\n"}, failAt: 2}
+	testharness.UseAWS(t, &testharness.AWS{RDS: fake})
+
+	args := map[string]any{"db_instance_identifier": id, "log_file_name": "error/mysql-error.log"}
+	res := testharness.Call(t, "aws_rds_log_download", args)
+	if !res.IsError || !strings.Contains(testharness.Text(res), "call again to resume") {
+		t.Fatalf("expected the interrupted download to fail, got %s", testharness.Text(res))
+	}
+
+	// A write that was not followed by its state is cut on resume.
+	part := filepath.Join(os.Getenv("ARGOS_WORKSPACE"), id, "aws_rds_log_download", "error", "mysql-error.log.part")
+	f, err := os.OpenFile(part, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("lost page\n")
+	f.Close()
+
+	var got aws_rds_log_download.Result
+	testharness.Decode(t, testharness.Call(t, "aws_rds_log_download", args), &got)
+
+	if !got.Resumed || got.Mode != "full" || got.Pages != 2 {
+		t.Errorf("expected a resumed download of the 2 remaining pages, got %+v", got)
+	}
+	if strings.Join(fake.markers, ",") != "0,1,1,2" {
+		t.Errorf("expected the second call to resume from the saved marker, got %v", fake.markers)
+	}
+
+	data, err := os.ReadFile(got.OutputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "a\nb\nc\n" {
+		t.Errorf("unexpected content: %q", data)
+	}
+	if got.ResourceURI != "argos://artifacts/db1/aws_rds_log_download/error/mysql-error.log" {
+		t.Errorf("unexpected resource URI: %s", got.ResourceURI)
+	}
+	if _, err := os.Stat(got.OutputPath + ".part.json"); !os.IsNotExist(err) {
+		t.Errorf("expected the resume state to be removed, got %v", err)
+	}
+}
+
+func TestResumeRange(t *testing.T) {
+	id := instance(t)
+	// The entry of 09:00 spans the interrupted pages.
+	fake := &fakeRDS{pages: []string{
+		"# Time: 2024-05-14T08:00:00Z\nSELECT 8;\n# Time: 2024-05-14T09:00:00Z\n",
+		"SELECT 9;\n",
+		"# Time: 2024-05-14T11:00:00Z\nSELECT 11;\n",
+	}, failAt: 2}
+	testharness.UseAWS(t, &testharness.AWS{RDS: fake})
+
+	args := map[string]any{
+		"db_instance_identifier": id,
+		"log_file_name":          "slowquery/mysql-slowquery.log",
+		"start_time":             "2024-05-14T09:00:00Z",
+		"end_time":               "2024-05-14T10:00:00Z",
+	}
+	if res := testharness.Call(t, "aws_rds_log_download", args); !res.IsError {
+		t.Fatalf("expected the interrupted download to fail, got %s", testharness.Text(res))
+	}
+
+	var got aws_rds_log_download.Result
+	testharness.Decode(t, testharness.Call(t, "aws_rds_log_download", args), &got)
+
+	data, _ := os.ReadFile(got.OutputPath)
+	if !got.Resumed || got.Mode != "range" || string(data) != "# Time: 2024-05-14T09:00:00Z\nSELECT 9;\n" {
+		t.Errorf("expected the resumed range to keep the whole entry, got %+v with %q", got, data)
+	}
+}
+
+func TestTail(t *testing.T) {
+	id := instance(t)
+	fake := &fakeRDS{pages: []string{"a\n", "b\n", "c\n"}}
+	testharness.UseAWS(t, &testharness.AWS{RDS: fake})
+
+	var got aws_rds_log_download.Result
+	testharness.Decode(t, testharness.Call(t, "aws_rds_log_download", map[string]any{
+		"db_instance_identifier": id,
+		"log_file_name":          "error/mysql-error.log",
+		"tail_lines":             1,
+	}), &got)
+
+	data, _ := os.ReadFile(got.OutputPath)
+	if got.Mode != "tail" || !strings.HasSuffix(got.OutputPath, ".tail") || string(data) != "c\n" || fake.calls != 1 || got.Lines != 1 || got.Shortened {
+		t.Errorf("expected only the end of the file, got %+v with %q", got, data)
+	}
+}
+
+func TestInvalidInput(t *testing.T) {
+	testharness.UseAWS(t, &testharness.AWS{RDS: &fakeRDS{}})
+
+	for _, args := range []map[string]any{
+		{"db_instance_identifier": "db1", "log_file_name": "f", "start_time": "yesterday"},
+		{"db_instance_identifier": "db1", "log_file_name": "f", "tail_lines": 10, "end_time": "2024-05-14T10:00:00Z"},
+		{"db_instance_identifier": "db1", "log_file_name": "../../../etc/passwd"},
+	} {
+		testharness.Failure(t, testharness.Call(t, "aws_rds_log_download", args), toolerr.InvalidInput)
+	}
+}
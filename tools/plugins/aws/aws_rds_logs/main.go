@@ -4,11 +4,11 @@ import (
 	"context"
 	"time"
 
+	"github.com/nicola-strappazzon/argos/internal/awsclient"
 	"github.com/nicola-strappazzon/argos/internal/config/aws"
+	"github.com/nicola-strappazzon/argos/internal/rdslog"
 	"github.com/nicola-strappazzon/argos/tools/registry"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/rds"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
@@ -18,59 +18,62 @@ type LogFile struct {
 	LastWritten string `json:"last_written"`
 }
 
+type Result struct {
+	Identifier string    `json:"identifier"`
+	Count      int       `json:"count"`
+	Logs       []LogFile `json:"logs"`
+}
+
 func init() {
 	registry.Add(registry.Property{
 		Name:        "aws_rds_logs",
 		Description: "List available log files for an RDS instance.",
 		InputSchema: map[string]any{
 			"type": "object",
-			"properties": map[string]any{
+			"properties": awsconfig.TargetProperties(map[string]any{
 				"db_instance_identifier": map[string]any{
 					"type":        "string",
 					"description": "The RDS DB instance identifier to list logs for.",
 				},
-			},
+			}),
 			"required": []string{"db_instance_identifier"},
 		},
+		Output: Result{},
 		Function: func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
 			instanceID, _ := args["db_instance_identifier"].(string)
 
-			sess, err := awsconfig.NewSession()
+			target, err := awsconfig.TargetFromArgs(args)
 			if err != nil {
 				return &mcp.CallToolResult{}, nil, err
 			}
 
-			svc := rds.New(sess)
-
-			var logs []LogFile
+			svc, err := awsclient.RDS(target)
+			if err != nil {
+				return &mcp.CallToolResult{}, nil, err
+			}
 
-			err = svc.DescribeDBLogFilesPages(
-				&rds.DescribeDBLogFilesInput{
-					DBInstanceIdentifier: aws.String(instanceID),
-				},
-				func(page *rds.DescribeDBLogFilesOutput, lastPage bool) bool {
-					for _, f := range page.DescribeDBLogFiles {
-						lastWritten := ""
-						if f.LastWritten != nil {
-							lastWritten = time.UnixMilli(aws.Int64Value(f.LastWritten)).UTC().Format(time.RFC3339)
-						}
-						logs = append(logs, LogFile{
-							Name:        aws.StringValue(f.LogFileName),
-							SizeKB:      aws.Int64Value(f.Size) / 1024,
-							LastWritten: lastWritten,
-						})
-					}
-					return true
-				},
-			)
+			files, err := rdslog.List(ctx, svc, instanceID, "", time.Time{})
 			if err != nil {
 				return &mcp.CallToolResult{}, nil, err
 			}
 
-			return &mcp.CallToolResult{}, map[string]any{
-				"identifier": instanceID,
-				"count":      len(logs),
-				"logs":       logs,
+			var logs []LogFile
+			for _, f := range files {
+				lastWritten := ""
+				if !f.LastWritten.IsZero() {
+					lastWritten = f.LastWritten.Format(time.RFC3339)
+				}
+				logs = append(logs, LogFile{
+					Name:        f.Name,
+					SizeKB:      f.Size / 1024,
+					LastWritten: lastWritten,
+				})
+			}
+
+			return &mcp.CallToolResult{}, Result{
+				Identifier: instanceID,
+				Count:      len(logs),
+				Logs:       logs,
 			}, nil
 		},
 	})
@@ -0,0 +1,45 @@
+package aws_rds_logs_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nicola-strappazzon/argos/internal/awsclient"
+	"github.com/nicola-strappazzon/argos/internal/testharness"
+	"github.com/nicola-strappazzon/argos/tools/plugins/aws/aws_rds_logs"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	"github.com/aws/aws-sdk-go-v2/service/rds/types"
+)
+
+type fakeRDS struct{ awsclient.RDSAPI }
+
+// DescribeDBLogFiles serves two pages, the second one at marker "2".
+func (fakeRDS) DescribeDBLogFiles(_ context.Context, in *rds.DescribeDBLogFilesInput, _ ...func(*rds.Options)) (*rds.DescribeDBLogFilesOutput, error) {
+	if aws.ToString(in.Marker) != "2" {
+		return &rds.DescribeDBLogFilesOutput{Marker: aws.String("2"), DescribeDBLogFiles: []types.DescribeDBLogFilesDetails{
+			{LogFileName: aws.String("error/mysql-error.log"), Size: aws.Int64(4096), LastWritten: aws.Int64(1715677200000)},
+		}}, nil
+	}
+	return &rds.DescribeDBLogFilesOutput{DescribeDBLogFiles: []types.DescribeDBLogFilesDetails{
+		{LogFileName: aws.String("slowquery/mysql-slowquery.log"), Size: aws.Int64(1 << 20)},
+	}}, nil
+}
+
+func TestLogs(t *testing.T) {
+	testharness.UseAWS(t, &testharness.AWS{RDS: fakeRDS{}})
+
+	var got aws_rds_logs.Result
+	testharness.Decode(t, testharness.Call(t, "aws_rds_logs", map[string]any{"db_instance_identifier": "db1"}), &got)
+
+	if got.Count != 2 {
+		t.Fatalf("expected log files from both pages, got %+v", got)
+	}
+	if l := got.Logs[0]; l.SizeKB != 4 || l.LastWritten != "2024-05-14T09:00:00Z" {
+		t.Errorf("unexpected log file: %+v", l)
+	}
+	if l := got.Logs[1]; l.SizeKB != 1024 || l.LastWritten != "" {
+		t.Errorf("unexpected log file: %+v", l)
+	}
+}
@@ -4,12 +4,15 @@ import (
 	"context"
 	"time"
 
+	"github.com/nicola-strappazzon/argos/internal/awsclient"
 	"github.com/nicola-strappazzon/argos/internal/config/aws"
+	"github.com/nicola-strappazzon/argos/internal/toolerr"
 	"github.com/nicola-strappazzon/argos/tools/registry"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/cloudwatch"
-	"github.com/aws/aws-sdk-go/service/rds"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
@@ -28,65 +31,82 @@ type Metrics struct {
 	NetworkTxMBps    float64 `json:"network_tx_mbps"`
 }
 
-func latestValue(results []*cloudwatch.MetricDataResult, id string, multiplier float64) float64 {
+func latestValue(results []types.MetricDataResult, id string, multiplier float64) float64 {
 	for _, r := range results {
-		if aws.StringValue(r.Id) == id && len(r.Values) > 0 {
-			return aws.Float64Value(r.Values[0]) * multiplier
+		if aws.ToString(r.Id) == id && len(r.Values) > 0 {
+			return r.Values[0] * multiplier
 		}
 	}
 	return 0
 }
 
-func query(id, namespace, metricName, instanceID string) *cloudwatch.MetricDataQuery {
-	return &cloudwatch.MetricDataQuery{
+func query(id, namespace, metricName, instanceID string) types.MetricDataQuery {
+	return types.MetricDataQuery{
 		Id: aws.String(id),
-		MetricStat: &cloudwatch.MetricStat{
-			Metric: &cloudwatch.Metric{
+		MetricStat: &types.MetricStat{
+			Metric: &types.Metric{
 				Namespace:  aws.String(namespace),
 				MetricName: aws.String(metricName),
-				Dimensions: []*cloudwatch.Dimension{{
+				Dimensions: []types.Dimension{{
 					Name:  aws.String("DBInstanceIdentifier"),
 					Value: aws.String(instanceID),
 				}},
 			},
-			Period: aws.Int64(300),
+			Period: aws.Int32(300),
 			Stat:   aws.String("Average"),
 		},
 	}
 }
 
+type Result struct {
+	Metrics Metrics `json:"metrics"`
+}
+
 func init() {
 	registry.Add(registry.Property{
 		Name:        "aws_rds_metrics",
 		Description: "Get CloudWatch metrics (CPU, connections, memory, storage, IOPS, latency, network) for an RDS instance.",
 		InputSchema: map[string]any{
 			"type": "object",
-			"properties": map[string]any{
+			"properties": awsconfig.TargetProperties(map[string]any{
 				"db_instance_identifier": map[string]any{
 					"type":        "string",
 					"description": "The RDS DB instance identifier to fetch metrics for.",
 				},
-			},
+			}),
 			"required": []string{"db_instance_identifier"},
 		},
+		Output: Result{},
 		Function: func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
 			instanceID, _ := args["db_instance_identifier"].(string)
 
-			sess, err := awsconfig.NewSession()
+			target, err := awsconfig.TargetFromArgs(args)
+			if err != nil {
+				return &mcp.CallToolResult{}, nil, err
+			}
+
+			rdsSvc, err := awsclient.RDS(target)
+			if err != nil {
+				return &mcp.CallToolResult{}, nil, err
+			}
+
+			cwSvc, err := awsclient.CloudWatch(target)
 			if err != nil {
 				return &mcp.CallToolResult{}, nil, err
 			}
 
 			// Detect engine to pick the right CloudWatch namespace.
-			rdsSvc := rds.New(sess)
-			dbInfo, err := rdsSvc.DescribeDBInstances(&rds.DescribeDBInstancesInput{
+			dbInfo, err := rdsSvc.DescribeDBInstances(ctx, &rds.DescribeDBInstancesInput{
 				DBInstanceIdentifier: aws.String(instanceID),
 			})
 			if err != nil {
 				return &mcp.CallToolResult{}, nil, err
 			}
+			if len(dbInfo.DBInstances) == 0 {
+				return &mcp.CallToolResult{}, nil, toolerr.Errorf(toolerr.NotFound, "list the instances with aws_rds_instances", "instance %q not found", instanceID)
+			}
 
-			engine := aws.StringValue(dbInfo.DBInstances[0].Engine)
+			engine := aws.ToString(dbInfo.DBInstances[0].Engine)
 
 			namespace := "AWS/RDS"
 			storageMetric := "FreeStorageSpace"
@@ -100,14 +120,13 @@ func init() {
 				netTxMetric = "NetworkBytesOut"
 			}
 
-			cwSvc := cloudwatch.New(sess)
 			now := time.Now()
 			start := now.Add(-15 * time.Minute)
 
-			result, err := cwSvc.GetMetricData(&cloudwatch.GetMetricDataInput{
+			result, err := cwSvc.GetMetricData(ctx, &cloudwatch.GetMetricDataInput{
 				StartTime: aws.Time(start),
 				EndTime:   aws.Time(now),
-				MetricDataQueries: []*cloudwatch.MetricDataQuery{
+				MetricDataQueries: []types.MetricDataQuery{
 					query("cpu", namespace, "CPUUtilization", instanceID),
 					query("connections", namespace, "DatabaseConnections", instanceID),
 					query("memory", namespace, "FreeableMemory", instanceID),
@@ -143,7 +162,7 @@ func init() {
 				NetworkTxMBps:    latestValue(result.MetricDataResults, "net_tx", bytesToMB),
 			}
 
-			return &mcp.CallToolResult{}, map[string]any{"metrics": metrics}, nil
+			return &mcp.CallToolResult{}, Result{Metrics: metrics}, nil
 		},
 	})
 }
@@ -0,0 +1,99 @@
+package aws_rds_metrics_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/nicola-strappazzon/argos/internal/awsclient"
+	"github.com/nicola-strappazzon/argos/internal/testharness"
+	"github.com/nicola-strappazzon/argos/tools/plugins/aws/aws_rds_metrics"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	rdstypes "github.com/aws/aws-sdk-go-v2/service/rds/types"
+)
+
+type fakeRDS struct {
+	awsclient.RDSAPI
+	engine string
+}
+
+func (f fakeRDS) DescribeDBInstances(_ context.Context, in *rds.DescribeDBInstancesInput, _ ...func(*rds.Options)) (*rds.DescribeDBInstancesOutput, error) {
+	if aws.ToString(in.DBInstanceIdentifier) != "db1" {
+		return &rds.DescribeDBInstancesOutput{}, nil
+	}
+	return &rds.DescribeDBInstancesOutput{DBInstances: []rdstypes.DBInstance{{Engine: aws.String(f.engine)}}}, nil
+}
+
+type fakeCloudWatch struct {
+	awsclient.CloudWatchAPI
+	metrics map[string]string
+}
+
+func (f *fakeCloudWatch) GetMetricData(_ context.Context, in *cloudwatch.GetMetricDataInput, _ ...func(*cloudwatch.Options)) (*cloudwatch.GetMetricDataOutput, error) {
+	values := map[string]float64{
+		"cpu":          42.5,
+		"memory":       2 << 30,
+		"storage":      50 << 30,
+		"read_latency": 0.002,
+	}
+
+	f.metrics = map[string]string{}
+	out := &cloudwatch.GetMetricDataOutput{}
+	for _, q := range in.MetricDataQueries {
+		id := aws.ToString(q.Id)
+		f.metrics[id] = aws.ToString(q.MetricStat.Metric.Namespace) + "/" + aws.ToString(q.MetricStat.Metric.MetricName)
+		if v, ok := values[id]; ok {
+			out.MetricDataResults = append(out.MetricDataResults, types.MetricDataResult{
+				Id:     q.Id,
+				Values: []float64{v},
+			})
+		}
+	}
+	return out, nil
+}
+
+func TestMetrics(t *testing.T) {
+	cw := &fakeCloudWatch{}
+	testharness.UseAWS(t, &testharness.AWS{RDS: fakeRDS{engine: "mysql"}, CloudWatch: cw})
+
+	var got aws_rds_metrics.Result
+	testharness.Decode(t, testharness.Call(t, "aws_rds_metrics", map[string]any{"db_instance_identifier": "db1"}), &got)
+
+	m := got.Metrics
+	if m.CPUPercent != 42.5 || m.FreeableMemoryMB != 2048 || m.FreeStorageGB != 50 || m.ReadLatencyMS != 2 {
+		t.Errorf("unexpected metrics: %+v", m)
+	}
+	if cw.metrics["storage"] != "AWS/RDS/FreeStorageSpace" {
+		t.Errorf("unexpected storage metric %q", cw.metrics["storage"])
+	}
+}
+
+func TestMetricsDocumentDB(t *testing.T) {
+	cw := &fakeCloudWatch{}
+	testharness.UseAWS(t, &testharness.AWS{RDS: fakeRDS{engine: "docdb"}, CloudWatch: cw})
+
+	testharness.Call(t, "aws_rds_metrics", map[string]any{"db_instance_identifier": "db1"})
+
+	if cw.metrics["storage"] != "AWS/DocDB/FreeLocalStorage" || cw.metrics["net_rx"] != "AWS/DocDB/NetworkBytesIn" {
+		t.Errorf("expected the DocumentDB metrics, got %+v", cw.metrics)
+	}
+}
+
+// TestMetricsUnknownInstance checks that an empty DescribeDBInstances answer
+// is reported as not found instead of indexing past its end.
+func TestMetricsUnknownInstance(t *testing.T) {
+	cw := &fakeCloudWatch{}
+	testharness.UseAWS(t, &testharness.AWS{RDS: fakeRDS{}, CloudWatch: cw})
+
+	text := testharness.Failure(t, testharness.Call(t, "aws_rds_metrics", map[string]any{"db_instance_identifier": "missing"}), "not_found")
+	if !strings.Contains(text, `instance "missing" not found`) || !strings.Contains(text, "aws_rds_instances") {
+		t.Errorf("unexpected failure: %s", text)
+	}
+	if cw.metrics != nil {
+		t.Errorf("CloudWatch was queried for an unknown instance: %v", cw.metrics)
+	}
+}
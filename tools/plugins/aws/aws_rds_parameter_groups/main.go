@@ -2,13 +2,14 @@ package aws_rds_parameter_groups
 
 import (
 	"context"
-	"fmt"
 
+	"github.com/nicola-strappazzon/argos/internal/awsclient"
 	"github.com/nicola-strappazzon/argos/internal/config/aws"
+	"github.com/nicola-strappazzon/argos/internal/toolerr"
 	"github.com/nicola-strappazzon/argos/tools/registry"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/rds"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
@@ -22,31 +23,42 @@ type Parameter struct {
 	Description  string `json:"description"`
 }
 
+type Result struct {
+	Identifier     string      `json:"identifier"`
+	ParameterGroup string      `json:"parameter_group"`
+	Count          int         `json:"count"`
+	Parameters     []Parameter `json:"parameters"`
+}
+
 func init() {
 	registry.Add(registry.Property{
 		Name:        "aws_rds_parameter_groups",
 		Description: "List all parameters of the parameter group associated with a given RDS DB instance.",
 		InputSchema: map[string]any{
 			"type": "object",
-			"properties": map[string]any{
+			"properties": awsconfig.TargetProperties(map[string]any{
 				"db_instance_identifier": map[string]any{
 					"type":        "string",
 					"description": "The RDS DB instance identifier.",
 				},
-			},
+			}),
 			"required": []string{"db_instance_identifier"},
 		},
+		Output: Result{},
 		Function: func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
 			instanceID, _ := args["db_instance_identifier"].(string)
 
-			sess, err := awsconfig.NewSession()
+			target, err := awsconfig.TargetFromArgs(args)
 			if err != nil {
 				return &mcp.CallToolResult{}, nil, err
 			}
 
-			svc := rds.New(sess)
+			svc, err := awsclient.RDS(target)
+			if err != nil {
+				return &mcp.CallToolResult{}, nil, err
+			}
 
-			dbOutput, err := svc.DescribeDBInstances(&rds.DescribeDBInstancesInput{
+			dbOutput, err := svc.DescribeDBInstances(ctx, &rds.DescribeDBInstancesInput{
 				DBInstanceIdentifier: aws.String(instanceID),
 			})
 			if err != nil {
@@ -54,47 +66,46 @@ func init() {
 			}
 
 			if len(dbOutput.DBInstances) == 0 {
-				return &mcp.CallToolResult{}, nil, fmt.Errorf("instance %q not found", instanceID)
+				return &mcp.CallToolResult{}, nil, toolerr.Errorf(toolerr.NotFound, "list the instances with aws_rds_instances", "instance %q not found", instanceID)
 			}
 
 			dbInstance := dbOutput.DBInstances[0]
 			if len(dbInstance.DBParameterGroups) == 0 {
-				return &mcp.CallToolResult{}, nil, fmt.Errorf("no parameter groups found for instance %q", instanceID)
+				return &mcp.CallToolResult{}, nil, toolerr.Errorf(toolerr.NotFound, "", "no parameter groups found for instance %q", instanceID)
 			}
 
-			pgName := aws.StringValue(dbInstance.DBParameterGroups[0].DBParameterGroupName)
+			pgName := aws.ToString(dbInstance.DBParameterGroups[0].DBParameterGroupName)
 
 			var parameters []Parameter
 
-			err = svc.DescribeDBParametersPages(
-				&rds.DescribeDBParametersInput{
-					DBParameterGroupName: aws.String(pgName),
-					Source:               aws.String("user"),
-				},
-				func(page *rds.DescribeDBParametersOutput, lastPage bool) bool {
-					for _, p := range page.Parameters {
-						parameters = append(parameters, Parameter{
-							Name:         aws.StringValue(p.ParameterName),
-							Value:        aws.StringValue(p.ParameterValue),
-							Source:       aws.StringValue(p.Source),
-							DataType:     aws.StringValue(p.DataType),
-							ApplyType:    aws.StringValue(p.ApplyType),
-							IsModifiable: aws.BoolValue(p.IsModifiable),
-							Description:  aws.StringValue(p.Description),
-						})
-					}
-					return true
-				},
-			)
-			if err != nil {
-				return &mcp.CallToolResult{}, nil, err
+			pages := rds.NewDescribeDBParametersPaginator(svc, &rds.DescribeDBParametersInput{
+				DBParameterGroupName: aws.String(pgName),
+				Source:               aws.String("user"),
+			})
+			for pages.HasMorePages() {
+				page, err := pages.NextPage(ctx)
+				if err != nil {
+					return &mcp.CallToolResult{}, nil, err
+				}
+
+				for _, p := range page.Parameters {
+					parameters = append(parameters, Parameter{
+						Name:         aws.ToString(p.ParameterName),
+						Value:        aws.ToString(p.ParameterValue),
+						Source:       aws.ToString(p.Source),
+						DataType:     aws.ToString(p.DataType),
+						ApplyType:    aws.ToString(p.ApplyType),
+						IsModifiable: aws.ToBool(p.IsModifiable),
+						Description:  aws.ToString(p.Description),
+					})
+				}
 			}
 
-			return &mcp.CallToolResult{}, map[string]any{
-				"identifier":      instanceID,
-				"parameter_group": pgName,
-				"count":           len(parameters),
-				"parameters":      parameters,
+			return &mcp.CallToolResult{}, Result{
+				Identifier:     instanceID,
+				ParameterGroup: pgName,
+				Count:          len(parameters),
+				Parameters:     parameters,
 			}, nil
 		},
 	})
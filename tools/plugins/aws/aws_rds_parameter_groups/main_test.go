@@ -0,0 +1,49 @@
+package aws_rds_parameter_groups_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nicola-strappazzon/argos/internal/awsclient"
+	"github.com/nicola-strappazzon/argos/internal/testharness"
+	"github.com/nicola-strappazzon/argos/tools/plugins/aws/aws_rds_parameter_groups"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	"github.com/aws/aws-sdk-go-v2/service/rds/types"
+)
+
+type fakeRDS struct{ awsclient.RDSAPI }
+
+func (fakeRDS) DescribeDBInstances(_ context.Context, in *rds.DescribeDBInstancesInput, _ ...func(*rds.Options)) (*rds.DescribeDBInstancesOutput, error) {
+	if aws.ToString(in.DBInstanceIdentifier) != "db1" {
+		return nil, &types.DBInstanceNotFoundFault{Message: aws.String("DBInstance not found")}
+	}
+	return &rds.DescribeDBInstancesOutput{DBInstances: []types.DBInstance{{
+		DBInstanceIdentifier: aws.String("db1"),
+		DBParameterGroups:    []types.DBParameterGroupStatus{{DBParameterGroupName: aws.String("mysql80-custom")}},
+	}}}, nil
+}
+
+func (fakeRDS) DescribeDBParameters(_ context.Context, in *rds.DescribeDBParametersInput, _ ...func(*rds.Options)) (*rds.DescribeDBParametersOutput, error) {
+	if aws.ToString(in.DBParameterGroupName) != "mysql80-custom" || aws.ToString(in.Source) != "user" {
+		return nil, &types.DBParameterGroupNotFoundFault{Message: aws.String("unexpected parameter group")}
+	}
+	return &rds.DescribeDBParametersOutput{Parameters: []types.Parameter{
+		{ParameterName: aws.String("long_query_time"), ParameterValue: aws.String("1"), Source: aws.String("user"), IsModifiable: aws.Bool(true)},
+		{ParameterName: aws.String("slow_query_log"), ParameterValue: aws.String("1"), Source: aws.String("user"), IsModifiable: aws.Bool(true)},
+	}}, nil
+}
+
+func TestParameterGroups(t *testing.T) {
+	testharness.UseAWS(t, &testharness.AWS{RDS: fakeRDS{}})
+
+	var got aws_rds_parameter_groups.Result
+	testharness.Decode(t, testharness.Call(t, "aws_rds_parameter_groups", map[string]any{"db_instance_identifier": "db1"}), &got)
+
+	if got.ParameterGroup != "mysql80-custom" || got.Count != 2 || got.Parameters[0].Name != "long_query_time" {
+		t.Fatalf("unexpected result: %+v", got)
+	}
+
+	testharness.Failure(t, testharness.Call(t, "aws_rds_parameter_groups", map[string]any{"db_instance_identifier": "missing"}), "not_found")
+}
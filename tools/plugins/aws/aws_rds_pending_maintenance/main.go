@@ -4,11 +4,12 @@ import (
 	"context"
 	"time"
 
+	"github.com/nicola-strappazzon/argos/internal/awsclient"
 	"github.com/nicola-strappazzon/argos/internal/config/aws"
 	"github.com/nicola-strappazzon/argos/tools/registry"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/rds"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
@@ -28,23 +29,32 @@ func formatTime(t *time.Time) string {
 	return t.UTC().Format(time.RFC3339)
 }
 
+type Result struct {
+	PendingActions []PendingAction `json:"pending_actions"`
+	Total          int             `json:"total"`
+}
+
 func init() {
 	registry.Add(registry.Property{
 		Name:        "aws_rds_pending_maintenance",
 		Description: "List pending maintenance actions for RDS instances (engine upgrades, OS patches, security updates).",
 		InputSchema: map[string]any{
 			"type":       "object",
-			"properties": map[string]any{},
+			"properties": awsconfig.TargetProperties(nil),
 		},
+		Output: Result{},
 		Function: func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
-			sess, err := awsconfig.NewSession()
+			target, err := awsconfig.TargetFromArgs(args)
 			if err != nil {
 				return &mcp.CallToolResult{}, nil, err
 			}
 
-			svc := rds.New(sess)
+			svc, err := awsclient.RDS(target)
+			if err != nil {
+				return &mcp.CallToolResult{}, nil, err
+			}
 
-			result, err := svc.DescribePendingMaintenanceActions(&rds.DescribePendingMaintenanceActionsInput{})
+			result, err := svc.DescribePendingMaintenanceActions(ctx, &rds.DescribePendingMaintenanceActionsInput{})
 			if err != nil {
 				return &mcp.CallToolResult{}, nil, err
 			}
@@ -53,19 +63,19 @@ func init() {
 			for _, resource := range result.PendingMaintenanceActions {
 				for _, a := range resource.PendingMaintenanceActionDetails {
 					actions = append(actions, PendingAction{
-						ResourceIdentifier: aws.StringValue(resource.ResourceIdentifier),
-						Action:             aws.StringValue(a.Action),
+						ResourceIdentifier: aws.ToString(resource.ResourceIdentifier),
+						Action:             aws.ToString(a.Action),
 						AutoAppliedAfter:   formatTime(a.AutoAppliedAfterDate),
 						CurrentApplyDate:   formatTime(a.CurrentApplyDate),
-						Description:        aws.StringValue(a.Description),
-						OptInStatus:        aws.StringValue(a.OptInStatus),
+						Description:        aws.ToString(a.Description),
+						OptInStatus:        aws.ToString(a.OptInStatus),
 					})
 				}
 			}
 
-			return &mcp.CallToolResult{}, map[string]any{
-				"pending_actions": actions,
-				"total":           len(actions),
+			return &mcp.CallToolResult{}, Result{
+				PendingActions: actions,
+				Total:          len(actions),
 			}, nil
 		},
 	})
@@ -0,0 +1,41 @@
+package aws_rds_pending_maintenance_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nicola-strappazzon/argos/internal/awsclient"
+	"github.com/nicola-strappazzon/argos/internal/testharness"
+	"github.com/nicola-strappazzon/argos/tools/plugins/aws/aws_rds_pending_maintenance"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	"github.com/aws/aws-sdk-go-v2/service/rds/types"
+)
+
+type fakeRDS struct{ awsclient.RDSAPI }
+
+func (fakeRDS) DescribePendingMaintenanceActions(context.Context, *rds.DescribePendingMaintenanceActionsInput, ...func(*rds.Options)) (*rds.DescribePendingMaintenanceActionsOutput, error) {
+	return &rds.DescribePendingMaintenanceActionsOutput{PendingMaintenanceActions: []types.ResourcePendingMaintenanceActions{{
+		ResourceIdentifier: aws.String("arn:aws:rds:eu-west-1:123456789012:db:db1"),
+		PendingMaintenanceActionDetails: []types.PendingMaintenanceAction{
+			{Action: aws.String("system-update"), OptInStatus: aws.String("next-maintenance"), AutoAppliedAfterDate: aws.Time(time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC))},
+			{Action: aws.String("db-upgrade"), OptInStatus: aws.String("immediate")},
+		},
+	}}}, nil
+}
+
+func TestPendingMaintenance(t *testing.T) {
+	testharness.UseAWS(t, &testharness.AWS{RDS: fakeRDS{}})
+
+	var got aws_rds_pending_maintenance.Result
+	testharness.Decode(t, testharness.Call(t, "aws_rds_pending_maintenance", nil), &got)
+
+	if got.Total != 2 {
+		t.Fatalf("expected an entry per action, got %+v", got)
+	}
+	if a := got.PendingActions[0]; a.Action != "system-update" || a.AutoAppliedAfter != "2024-06-01T00:00:00Z" {
+		t.Errorf("unexpected action: %+v", a)
+	}
+}
@@ -2,15 +2,17 @@ package aws_rds_performance_insights
 
 import (
 	"context"
-	"fmt"
 	"time"
 
+	"github.com/nicola-strappazzon/argos/internal/awsclient"
 	"github.com/nicola-strappazzon/argos/internal/config/aws"
+	"github.com/nicola-strappazzon/argos/internal/toolerr"
 	"github.com/nicola-strappazzon/argos/tools/registry"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/pi"
-	"github.com/aws/aws-sdk-go/service/rds"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/pi"
+	"github.com/aws/aws-sdk-go-v2/service/pi/types"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
@@ -25,13 +27,20 @@ type WaitEvent struct {
 	Load float64 `json:"db_load_avg"`
 }
 
+type Result struct {
+	Identifier string      `json:"identifier"`
+	PeriodMin  int         `json:"period_min"`
+	TopQueries []TopQuery  `json:"top_queries"`
+	WaitEvents []WaitEvent `json:"wait_events"`
+}
+
 func init() {
 	registry.Add(registry.Property{
 		Name:        "aws_rds_performance_insights",
 		Description: "Get top SQL queries and wait events by DB load from Performance Insights for MySQL and PostgreSQL RDS instances.",
 		InputSchema: map[string]any{
 			"type": "object",
-			"properties": map[string]any{
+			"properties": awsconfig.TargetProperties(map[string]any{
 				"db_instance_identifier": map[string]any{
 					"type":        "string",
 					"description": "The RDS DB instance identifier.",
@@ -40,9 +49,10 @@ func init() {
 					"type":        "integer",
 					"description": "Time window in minutes to analyze (default: 60).",
 				},
-			},
+			}),
 			"required": []string{"db_instance_identifier"},
 		},
+		Output: Result{},
 		Function: func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
 			instanceID, _ := args["db_instance_identifier"].(string)
 			minutes := 60
@@ -50,53 +60,61 @@ func init() {
 				minutes = int(m)
 			}
 
-			sess, err := awsconfig.NewSession()
+			target, err := awsconfig.TargetFromArgs(args)
+			if err != nil {
+				return &mcp.CallToolResult{}, nil, err
+			}
+
+			rdsSvc, err := awsclient.RDS(target)
+			if err != nil {
+				return &mcp.CallToolResult{}, nil, err
+			}
+
+			piSvc, err := awsclient.PI(target)
 			if err != nil {
 				return &mcp.CallToolResult{}, nil, err
 			}
 
-			rdsSvc := rds.New(sess)
-			dbOutput, err := rdsSvc.DescribeDBInstances(&rds.DescribeDBInstancesInput{
+			dbOutput, err := rdsSvc.DescribeDBInstances(ctx, &rds.DescribeDBInstancesInput{
 				DBInstanceIdentifier: aws.String(instanceID),
 			})
 			if err != nil {
 				return &mcp.CallToolResult{}, nil, err
 			}
 			if len(dbOutput.DBInstances) == 0 {
-				return &mcp.CallToolResult{}, nil, fmt.Errorf("instance %q not found", instanceID)
+				return &mcp.CallToolResult{}, nil, toolerr.Errorf(toolerr.NotFound, "list the instances with aws_rds_instances", "instance %q not found", instanceID)
 			}
 
 			db := dbOutput.DBInstances[0]
 
-			if aws.StringValue(db.Engine) == "docdb" {
-				return &mcp.CallToolResult{}, nil, fmt.Errorf(
-					"Performance Insights dimension groups are not supported for DocumentDB. Use docdb_* tools instead.")
+			if aws.ToString(db.Engine) == "docdb" {
+				return &mcp.CallToolResult{}, nil, toolerr.Errorf(toolerr.Unsupported, "use the aws_docdb_* tools for DocumentDB instances",
+					"Performance Insights dimension groups are not supported for DocumentDB")
 			}
 
-			if !aws.BoolValue(db.PerformanceInsightsEnabled) {
-				return &mcp.CallToolResult{}, nil, fmt.Errorf("Performance Insights is not enabled for instance %q", instanceID)
+			if !aws.ToBool(db.PerformanceInsightsEnabled) {
+				return &mcp.CallToolResult{}, nil, toolerr.Errorf(toolerr.Unsupported, "enable Performance Insights on the instance, or use aws_rds_metrics",
+					"Performance Insights is not enabled for instance %q", instanceID)
 			}
 
-			dbiResourceID := aws.StringValue(db.DbiResourceId)
-			serviceType := "RDS"
+			dbiResourceID := aws.ToString(db.DbiResourceId)
 
-			piSvc := pi.New(sess)
 			endTime := time.Now()
 			startTime := endTime.Add(-time.Duration(minutes) * time.Minute)
 
 			// Top queries by DB load
-			sqlOutput, err := piSvc.DescribeDimensionKeys(&pi.DescribeDimensionKeysInput{
-				ServiceType: aws.String(serviceType),
+			sqlOutput, err := piSvc.DescribeDimensionKeys(ctx, &pi.DescribeDimensionKeysInput{
+				ServiceType: types.ServiceTypeRds,
 				Identifier:  aws.String(dbiResourceID),
 				StartTime:   &startTime,
 				EndTime:     &endTime,
 				Metric:      aws.String("db.load.avg"),
-				GroupBy: &pi.DimensionGroup{
+				GroupBy: &types.DimensionGroup{
 					Group: aws.String("db.sql_tokenized"),
-					Dimensions: []*string{
-						aws.String("db.sql_tokenized.statement"),
+					Dimensions: []string{
+						"db.sql_tokenized.statement",
 					},
-					Limit: aws.Int64(10),
+					Limit: aws.Int32(10),
 				},
 			})
 			if err != nil {
@@ -105,30 +123,26 @@ func init() {
 
 			var topQueries []TopQuery
 			for _, key := range sqlOutput.Keys {
-				stmt := ""
-				if v, ok := key.Dimensions["db.sql_tokenized.statement"]; ok {
-					stmt = aws.StringValue(v)
-				}
 				topQueries = append(topQueries, TopQuery{
-					Statement: stmt,
-					Load:      aws.Float64Value(key.Total),
+					Statement: key.Dimensions["db.sql_tokenized.statement"],
+					Load:      aws.ToFloat64(key.Total),
 				})
 			}
 
 			// Top wait events by DB load
-			waitOutput, err := piSvc.DescribeDimensionKeys(&pi.DescribeDimensionKeysInput{
-				ServiceType: aws.String(serviceType),
+			waitOutput, err := piSvc.DescribeDimensionKeys(ctx, &pi.DescribeDimensionKeysInput{
+				ServiceType: types.ServiceTypeRds,
 				Identifier:  aws.String(dbiResourceID),
 				StartTime:   &startTime,
 				EndTime:     &endTime,
 				Metric:      aws.String("db.load.avg"),
-				GroupBy: &pi.DimensionGroup{
+				GroupBy: &types.DimensionGroup{
 					Group: aws.String("db.wait_event"),
-					Dimensions: []*string{
-						aws.String("db.wait_event.type"),
-						aws.String("db.wait_event.name"),
+					Dimensions: []string{
+						"db.wait_event.type",
+						"db.wait_event.name",
 					},
-					Limit: aws.Int64(10),
+					Limit: aws.Int32(10),
 				},
 			})
 			if err != nil {
@@ -137,26 +151,18 @@ func init() {
 
 			var waitEvents []WaitEvent
 			for _, key := range waitOutput.Keys {
-				eventType := ""
-				eventName := ""
-				if v, ok := key.Dimensions["db.wait_event.type"]; ok {
-					eventType = aws.StringValue(v)
-				}
-				if v, ok := key.Dimensions["db.wait_event.name"]; ok {
-					eventName = aws.StringValue(v)
-				}
 				waitEvents = append(waitEvents, WaitEvent{
-					Type: eventType,
-					Name: eventName,
-					Load: aws.Float64Value(key.Total),
+					Type: key.Dimensions["db.wait_event.type"],
+					Name: key.Dimensions["db.wait_event.name"],
+					Load: aws.ToFloat64(key.Total),
 				})
 			}
 
-			return &mcp.CallToolResult{}, map[string]any{
-				"identifier":  instanceID,
-				"period_min":  minutes,
-				"top_queries": topQueries,
-				"wait_events": waitEvents,
+			return &mcp.CallToolResult{}, Result{
+				Identifier: instanceID,
+				PeriodMin:  minutes,
+				TopQueries: topQueries,
+				WaitEvents: waitEvents,
 			}, nil
 		},
 	})
@@ -0,0 +1,73 @@
+package aws_rds_performance_insights_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nicola-strappazzon/argos/internal/awsclient"
+	"github.com/nicola-strappazzon/argos/internal/testharness"
+	"github.com/nicola-strappazzon/argos/tools/plugins/aws/aws_rds_performance_insights"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/pi"
+	"github.com/aws/aws-sdk-go-v2/service/pi/types"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	rdstypes "github.com/aws/aws-sdk-go-v2/service/rds/types"
+)
+
+type fakeRDS struct{ awsclient.RDSAPI }
+
+func (fakeRDS) DescribeDBInstances(_ context.Context, in *rds.DescribeDBInstancesInput, _ ...func(*rds.Options)) (*rds.DescribeDBInstancesOutput, error) {
+	instances := map[string]rdstypes.DBInstance{
+		"db1":   {Engine: aws.String("mysql"), DbiResourceId: aws.String("db-ABC123"), PerformanceInsightsEnabled: aws.Bool(true)},
+		"db2":   {Engine: aws.String("mysql")},
+		"docdb": {Engine: aws.String("docdb"), PerformanceInsightsEnabled: aws.Bool(true)},
+	}
+	if db, ok := instances[aws.ToString(in.DBInstanceIdentifier)]; ok {
+		return &rds.DescribeDBInstancesOutput{DBInstances: []rdstypes.DBInstance{db}}, nil
+	}
+	return &rds.DescribeDBInstancesOutput{}, nil
+}
+
+type fakePI struct{ awsclient.PIAPI }
+
+func (fakePI) DescribeDimensionKeys(_ context.Context, in *pi.DescribeDimensionKeysInput, _ ...func(*pi.Options)) (*pi.DescribeDimensionKeysOutput, error) {
+	if aws.ToString(in.Identifier) != "db-ABC123" {
+		return &pi.DescribeDimensionKeysOutput{}, nil
+	}
+	switch aws.ToString(in.GroupBy.Group) {
+	case "db.sql_tokenized":
+		return &pi.DescribeDimensionKeysOutput{Keys: []types.DimensionKeyDescription{{
+			Dimensions: map[string]string{"db.sql_tokenized.statement": "SELECT * FROM orders WHERE id = ?"},
+			Total:      aws.Float64(1.25),
+		}}}, nil
+	default:
+		return &pi.DescribeDimensionKeysOutput{Keys: []types.DimensionKeyDescription{{
+			Dimensions: map[string]string{"db.wait_event.type": "io", "db.wait_event.name": "wait/io/table/sql/handler"},
+			Total:      aws.Float64(0.75),
+		}}}, nil
+	}
+}
+
+func TestPerformanceInsights(t *testing.T) {
+	testharness.UseAWS(t, &testharness.AWS{RDS: fakeRDS{}, PI: fakePI{}})
+
+	var got aws_rds_performance_insights.Result
+	testharness.Decode(t, testharness.Call(t, "aws_rds_performance_insights", map[string]any{"db_instance_identifier": "db1"}), &got)
+
+	if got.PeriodMin != 60 || len(got.TopQueries) != 1 || len(got.WaitEvents) != 1 {
+		t.Fatalf("unexpected result: %+v", got)
+	}
+	if got.TopQueries[0].Load != 1.25 || got.WaitEvents[0].Type != "io" {
+		t.Errorf("unexpected keys: %+v", got)
+	}
+}
+
+func TestPerformanceInsightsUnsupported(t *testing.T) {
+	testharness.UseAWS(t, &testharness.AWS{RDS: fakeRDS{}, PI: fakePI{}})
+
+	for _, id := range []string{"db2", "docdb"} {
+		testharness.Failure(t, testharness.Call(t, "aws_rds_performance_insights", map[string]any{"db_instance_identifier": id}), "unsupported_engine")
+	}
+	testharness.Failure(t, testharness.Call(t, "aws_rds_performance_insights", map[string]any{"db_instance_identifier": "missing"}), "not_found")
+}
@@ -4,12 +4,14 @@ import (
 	"context"
 	"time"
 
+	"github.com/nicola-strappazzon/argos/internal/awsclient"
 	"github.com/nicola-strappazzon/argos/internal/config/aws"
 	"github.com/nicola-strappazzon/argos/tools/registry"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/cloudwatch"
-	"github.com/aws/aws-sdk-go/service/rds"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
@@ -25,23 +27,23 @@ type Replica struct {
 	ReplicaLagS   float64 `json:"replica_lag_seconds"`
 }
 
-func replicaLag(cwSvc *cloudwatch.CloudWatch, instanceID string) float64 {
+func replicaLag(ctx context.Context, cwSvc awsclient.CloudWatchAPI, instanceID string) float64 {
 	now := time.Now()
-	result, err := cwSvc.GetMetricData(&cloudwatch.GetMetricDataInput{
+	result, err := cwSvc.GetMetricData(ctx, &cloudwatch.GetMetricDataInput{
 		StartTime: aws.Time(now.Add(-5 * time.Minute)),
 		EndTime:   aws.Time(now),
-		MetricDataQueries: []*cloudwatch.MetricDataQuery{{
+		MetricDataQueries: []types.MetricDataQuery{{
 			Id: aws.String("lag"),
-			MetricStat: &cloudwatch.MetricStat{
-				Metric: &cloudwatch.Metric{
+			MetricStat: &types.MetricStat{
+				Metric: &types.Metric{
 					Namespace:  aws.String("AWS/RDS"),
 					MetricName: aws.String("ReplicaLag"),
-					Dimensions: []*cloudwatch.Dimension{{
+					Dimensions: []types.Dimension{{
 						Name:  aws.String("DBInstanceIdentifier"),
 						Value: aws.String(instanceID),
 					}},
 				},
-				Period: aws.Int64(60),
+				Period: aws.Int32(60),
 				Stat:   aws.String("Average"),
 			},
 		}},
@@ -49,7 +51,12 @@ func replicaLag(cwSvc *cloudwatch.CloudWatch, instanceID string) float64 {
 	if err != nil || len(result.MetricDataResults) == 0 || len(result.MetricDataResults[0].Values) == 0 {
 		return -1
 	}
-	return aws.Float64Value(result.MetricDataResults[0].Values[0])
+	return result.MetricDataResults[0].Values[0]
+}
+
+type Result struct {
+	Replicas []Replica `json:"replicas"`
+	Total    int       `json:"total"`
 }
 
 func init() {
@@ -58,32 +65,40 @@ func init() {
 		Description: "List RDS read replicas and their replication lag in seconds.",
 		InputSchema: map[string]any{
 			"type": "object",
-			"properties": map[string]any{
+			"properties": awsconfig.TargetProperties(map[string]any{
 				"db_instance_identifier": map[string]any{
 					"type":        "string",
 					"description": "Filter by source instance identifier. If omitted, returns all read replicas.",
 				},
-			},
+			}),
 		},
+		Output: Result{},
 		Function: func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
 			filterSource, _ := args["db_instance_identifier"].(string)
 
-			sess, err := awsconfig.NewSession()
+			target, err := awsconfig.TargetFromArgs(args)
+			if err != nil {
+				return &mcp.CallToolResult{}, nil, err
+			}
+
+			rdsSvc, err := awsclient.RDS(target)
 			if err != nil {
 				return &mcp.CallToolResult{}, nil, err
 			}
 
-			rdsSvc := rds.New(sess)
-			cwSvc := cloudwatch.New(sess)
+			cwSvc, err := awsclient.CloudWatch(target)
+			if err != nil {
+				return &mcp.CallToolResult{}, nil, err
+			}
 
-			result, err := rdsSvc.DescribeDBInstances(nil)
+			result, err := rdsSvc.DescribeDBInstances(ctx, &rds.DescribeDBInstancesInput{})
 			if err != nil {
 				return &mcp.CallToolResult{}, nil, err
 			}
 
 			replicas := make([]Replica, 0)
 			for _, db := range result.DBInstances {
-				source := aws.StringValue(db.ReadReplicaSourceDBInstanceIdentifier)
+				source := aws.ToString(db.ReadReplicaSourceDBInstanceIdentifier)
 				if source == "" {
 					continue
 				}
@@ -91,23 +106,23 @@ func init() {
 					continue
 				}
 
-				id := aws.StringValue(db.DBInstanceIdentifier)
+				id := aws.ToString(db.DBInstanceIdentifier)
 				replicas = append(replicas, Replica{
 					Identifier:    id,
 					Source:        source,
-					Status:        aws.StringValue(db.DBInstanceStatus),
-					Class:         aws.StringValue(db.DBInstanceClass),
-					AZ:            aws.StringValue(db.AvailabilityZone),
-					MultiAZ:       aws.BoolValue(db.MultiAZ),
-					Engine:        aws.StringValue(db.Engine),
-					EngineVersion: aws.StringValue(db.EngineVersion),
-					ReplicaLagS:   replicaLag(cwSvc, id),
+					Status:        aws.ToString(db.DBInstanceStatus),
+					Class:         aws.ToString(db.DBInstanceClass),
+					AZ:            aws.ToString(db.AvailabilityZone),
+					MultiAZ:       aws.ToBool(db.MultiAZ),
+					Engine:        aws.ToString(db.Engine),
+					EngineVersion: aws.ToString(db.EngineVersion),
+					ReplicaLagS:   replicaLag(ctx, cwSvc, id),
 				})
 			}
 
-			return &mcp.CallToolResult{}, map[string]any{
-				"replicas": replicas,
-				"total":    len(replicas),
+			return &mcp.CallToolResult{}, Result{
+				Replicas: replicas,
+				Total:    len(replicas),
 			}, nil
 		},
 	})
@@ -0,0 +1,57 @@
+package aws_rds_read_replicas_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nicola-strappazzon/argos/internal/awsclient"
+	"github.com/nicola-strappazzon/argos/internal/testharness"
+	"github.com/nicola-strappazzon/argos/tools/plugins/aws/aws_rds_read_replicas"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	rdstypes "github.com/aws/aws-sdk-go-v2/service/rds/types"
+)
+
+type fakeRDS struct{ awsclient.RDSAPI }
+
+func (fakeRDS) DescribeDBInstances(context.Context, *rds.DescribeDBInstancesInput, ...func(*rds.Options)) (*rds.DescribeDBInstancesOutput, error) {
+	return &rds.DescribeDBInstancesOutput{DBInstances: []rdstypes.DBInstance{
+		{DBInstanceIdentifier: aws.String("db1")},
+		{DBInstanceIdentifier: aws.String("db1-replica"), ReadReplicaSourceDBInstanceIdentifier: aws.String("db1")},
+		{DBInstanceIdentifier: aws.String("db1-lagless"), ReadReplicaSourceDBInstanceIdentifier: aws.String("db1")},
+		{DBInstanceIdentifier: aws.String("db2-replica"), ReadReplicaSourceDBInstanceIdentifier: aws.String("db2")},
+	}}, nil
+}
+
+type fakeCloudWatch struct{ awsclient.CloudWatchAPI }
+
+func (fakeCloudWatch) GetMetricData(_ context.Context, in *cloudwatch.GetMetricDataInput, _ ...func(*cloudwatch.Options)) (*cloudwatch.GetMetricDataOutput, error) {
+	if aws.ToString(in.MetricDataQueries[0].MetricStat.Metric.Dimensions[0].Value) == "db1-lagless" {
+		return nil, errors.New("no datapoints")
+	}
+	return &cloudwatch.GetMetricDataOutput{MetricDataResults: []types.MetricDataResult{{
+		Id:     aws.String("lag"),
+		Values: []float64{7},
+	}}}, nil
+}
+
+func TestReadReplicas(t *testing.T) {
+	testharness.UseAWS(t, &testharness.AWS{RDS: fakeRDS{}, CloudWatch: fakeCloudWatch{}})
+
+	var got aws_rds_read_replicas.Result
+	testharness.Decode(t, testharness.Call(t, "aws_rds_read_replicas", map[string]any{"db_instance_identifier": "db1"}), &got)
+
+	if got.Total != 2 {
+		t.Fatalf("expected the replicas of db1, got %+v", got)
+	}
+	if got.Replicas[0].ReplicaLagS != 7 {
+		t.Errorf("unexpected lag: %+v", got.Replicas[0])
+	}
+	if got.Replicas[1].ReplicaLagS != -1 {
+		t.Errorf("expected -1 when the lag is unknown, got %+v", got.Replicas[1])
+	}
+}
@@ -4,11 +4,12 @@ import (
 	"context"
 	"time"
 
+	"github.com/nicola-strappazzon/argos/internal/awsclient"
 	"github.com/nicola-strappazzon/argos/internal/config/aws"
 	"github.com/nicola-strappazzon/argos/tools/registry"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/rds"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
@@ -25,13 +26,18 @@ type Snapshot struct {
 	PercentProgress    float64 `json:"percent_progress"`
 }
 
+type Result struct {
+	Snapshots []Snapshot `json:"snapshots"`
+	Total     int        `json:"total"`
+}
+
 func init() {
 	registry.Add(registry.Property{
 		Name:        "aws_rds_snapshots",
 		Description: "List RDS snapshots (automated and manual) for an instance or all instances.",
 		InputSchema: map[string]any{
 			"type": "object",
-			"properties": map[string]any{
+			"properties": awsconfig.TargetProperties(map[string]any{
 				"db_instance_identifier": map[string]any{
 					"type":        "string",
 					"description": "Filter snapshots by RDS instance identifier. If omitted, returns snapshots for all instances.",
@@ -41,18 +47,22 @@ func init() {
 					"description": "Filter by snapshot type: automated or manual. If omitted, returns both.",
 					"enum":        []string{"automated", "manual"},
 				},
-			},
+			}),
 		},
+		Output: Result{},
 		Function: func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
 			instanceID, _ := args["db_instance_identifier"].(string)
 			snapshotType, _ := args["snapshot_type"].(string)
 
-			sess, err := awsconfig.NewSession()
+			target, err := awsconfig.TargetFromArgs(args)
 			if err != nil {
 				return &mcp.CallToolResult{}, nil, err
 			}
 
-			svc := rds.New(sess)
+			svc, err := awsclient.RDS(target)
+			if err != nil {
+				return &mcp.CallToolResult{}, nil, err
+			}
 
 			input := &rds.DescribeDBSnapshotsInput{}
 			if instanceID != "" {
@@ -62,7 +72,7 @@ func init() {
 				input.SnapshotType = aws.String(snapshotType)
 			}
 
-			result, err := svc.DescribeDBSnapshots(input)
+			result, err := svc.DescribeDBSnapshots(ctx, input)
 			if err != nil {
 				return &mcp.CallToolResult{}, nil, err
 			}
@@ -74,22 +84,22 @@ func init() {
 					createdAt = s.SnapshotCreateTime.UTC().Format(time.RFC3339)
 				}
 				snapshots = append(snapshots, Snapshot{
-					Identifier:         aws.StringValue(s.DBSnapshotIdentifier),
-					DBInstanceID:       aws.StringValue(s.DBInstanceIdentifier),
-					Status:             aws.StringValue(s.Status),
-					Type:               aws.StringValue(s.SnapshotType),
-					Engine:             aws.StringValue(s.Engine),
-					EngineVersion:      aws.StringValue(s.EngineVersion),
+					Identifier:         aws.ToString(s.DBSnapshotIdentifier),
+					DBInstanceID:       aws.ToString(s.DBInstanceIdentifier),
+					Status:             aws.ToString(s.Status),
+					Type:               aws.ToString(s.SnapshotType),
+					Engine:             aws.ToString(s.Engine),
+					EngineVersion:      aws.ToString(s.EngineVersion),
 					CreatedAt:          createdAt,
-					AllocatedStorageGB: int64(aws.Int64Value(s.AllocatedStorage)),
-					Encrypted:          aws.BoolValue(s.Encrypted),
-					PercentProgress:    float64(aws.Int64Value(s.PercentProgress)),
+					AllocatedStorageGB: int64(aws.ToInt32(s.AllocatedStorage)),
+					Encrypted:          aws.ToBool(s.Encrypted),
+					PercentProgress:    float64(aws.ToInt32(s.PercentProgress)),
 				})
 			}
 
-			return &mcp.CallToolResult{}, map[string]any{
-				"snapshots": snapshots,
-				"total":     len(snapshots),
+			return &mcp.CallToolResult{}, Result{
+				Snapshots: snapshots,
+				Total:     len(snapshots),
 			}, nil
 		},
 	})
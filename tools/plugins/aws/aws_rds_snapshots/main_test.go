@@ -0,0 +1,55 @@
+package aws_rds_snapshots_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nicola-strappazzon/argos/internal/awsclient"
+	"github.com/nicola-strappazzon/argos/internal/testharness"
+	"github.com/nicola-strappazzon/argos/tools/plugins/aws/aws_rds_snapshots"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	"github.com/aws/aws-sdk-go-v2/service/rds/types"
+)
+
+type fakeRDS struct {
+	awsclient.RDSAPI
+	input *rds.DescribeDBSnapshotsInput
+}
+
+func (f *fakeRDS) DescribeDBSnapshots(_ context.Context, in *rds.DescribeDBSnapshotsInput, _ ...func(*rds.Options)) (*rds.DescribeDBSnapshotsOutput, error) {
+	f.input = in
+	return &rds.DescribeDBSnapshotsOutput{DBSnapshots: []types.DBSnapshot{{
+		DBSnapshotIdentifier: aws.String("rds:db1-2024-05-14-03-00"),
+		DBInstanceIdentifier: aws.String("db1"),
+		Status:               aws.String("available"),
+		SnapshotType:         aws.String("automated"),
+		SnapshotCreateTime:   aws.Time(time.Date(2024, 5, 14, 3, 0, 0, 0, time.UTC)),
+		AllocatedStorage:     aws.Int32(100),
+		Encrypted:            aws.Bool(true),
+		PercentProgress:      aws.Int32(100),
+	}}}, nil
+}
+
+func TestSnapshots(t *testing.T) {
+	fake := &fakeRDS{}
+	testharness.UseAWS(t, &testharness.AWS{RDS: fake})
+
+	var got aws_rds_snapshots.Result
+	testharness.Decode(t, testharness.Call(t, "aws_rds_snapshots", map[string]any{
+		"db_instance_identifier": "db1",
+		"snapshot_type":          "automated",
+	}), &got)
+
+	if got.Total != 1 {
+		t.Fatalf("unexpected result: %+v", got)
+	}
+	if s := got.Snapshots[0]; s.CreatedAt != "2024-05-14T03:00:00Z" || s.AllocatedStorageGB != 100 || !s.Encrypted {
+		t.Errorf("unexpected snapshot: %+v", s)
+	}
+	if aws.ToString(fake.input.DBInstanceIdentifier) != "db1" || aws.ToString(fake.input.SnapshotType) != "automated" {
+		t.Errorf("unexpected input: %+v", fake.input)
+	}
+}
@@ -3,24 +3,31 @@ package aws_secrets_get
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"strings"
 
+	"github.com/nicola-strappazzon/argos/internal/awsclient"
 	"github.com/nicola-strappazzon/argos/internal/config/aws"
 	"github.com/nicola-strappazzon/argos/tools/registry"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/secretsmanager"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
+// Result holds either the raw value of the secret or, for JSON secrets,
+// its key-value pairs.
+type Result struct {
+	Name   string         `json:"name"`
+	Value  string         `json:"value,omitempty"`
+	Values map[string]any `json:"values,omitempty"`
+	Total  int            `json:"total,omitempty"`
+}
+
 func init() {
 	registry.Add(registry.Property{
 		Name:        "aws_secrets_get",
 		Description: "Get the value of an AWS Secrets Manager secret. If the secret is JSON, returns key-value pairs with optional filtering by key name.",
 		InputSchema: map[string]any{
 			"type": "object",
-			"properties": map[string]any{
+			"properties": awsconfig.TargetProperties(map[string]any{
 				"name": map[string]any{
 					"type":        "string",
 					"description": "The secret name or ARN to retrieve.",
@@ -29,36 +36,31 @@ func init() {
 					"type":        "string",
 					"description": "Optional partial match to filter keys within the secret (e.g. 'password', 'db_').",
 				},
-			},
+			}),
 			"required": []string{"name"},
 		},
+		Output: Result{},
 		Function: func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
 			name, _ := args["name"].(string)
 			filter, _ := args["filter"].(string)
 
-			sess, err := awsconfig.NewSession()
+			target, err := awsconfig.TargetFromArgs(args)
 			if err != nil {
 				return &mcp.CallToolResult{}, nil, err
 			}
 
-			svc := secretsmanager.New(sess)
-
-			result, err := svc.GetSecretValueWithContext(ctx, &secretsmanager.GetSecretValueInput{
-				SecretId: aws.String(name),
-			})
+			secretValue, err := awsclient.GetSecretString(ctx, target, name)
 			if err != nil {
-				return &mcp.CallToolResult{}, nil, fmt.Errorf("getting secret %s: %w", name, err)
+				return &mcp.CallToolResult{}, nil, err
 			}
 
-			secretValue := aws.StringValue(result.SecretString)
-
 			// Try to parse as JSON for structured output.
 			var parsed map[string]any
 			if err := json.Unmarshal([]byte(secretValue), &parsed); err != nil {
 				// Not JSON — return raw string value.
-				return &mcp.CallToolResult{}, map[string]any{
-					"name":  name,
-					"value": secretValue,
+				return &mcp.CallToolResult{}, Result{
+					Name:  name,
+					Value: secretValue,
 				}, nil
 			}
 
@@ -74,10 +76,10 @@ func init() {
 				parsed = filtered
 			}
 
-			return &mcp.CallToolResult{}, map[string]any{
-				"name":   name,
-				"values": parsed,
-				"total":  len(parsed),
+			return &mcp.CallToolResult{}, Result{
+				Name:   name,
+				Values: parsed,
+				Total:  len(parsed),
 			}, nil
 		},
 	})
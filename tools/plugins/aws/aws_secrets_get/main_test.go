@@ -0,0 +1,48 @@
+package aws_secrets_get_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nicola-strappazzon/argos/internal/awsclient"
+	"github.com/nicola-strappazzon/argos/internal/testharness"
+	"github.com/nicola-strappazzon/argos/tools/plugins/aws/aws_secrets_get"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+)
+
+type fakeSecrets struct {
+	awsclient.SecretsManagerAPI
+	values map[string]string
+}
+
+func (f fakeSecrets) GetSecretValue(_ context.Context, in *secretsmanager.GetSecretValueInput, _ ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error) {
+	v, ok := f.values[aws.ToString(in.SecretId)]
+	if !ok {
+		return nil, &types.ResourceNotFoundException{Message: aws.String("secret not found")}
+	}
+	return &secretsmanager.GetSecretValueOutput{SecretString: aws.String(v)}, nil
+}
+
+func TestGet(t *testing.T) {
+	testharness.UseAWS(t, &testharness.AWS{SecretsManager: fakeSecrets{values: map[string]string{
+		"prod/db1":  `{"username":"admin","password":"s3cret","host":"db1.example.com"}`,
+		"prod/note": "plain text",
+	}}})
+
+	var got aws_secrets_get.Result
+	testharness.Decode(t, testharness.Call(t, "aws_secrets_get", map[string]any{"name": "prod/db1", "filter": "USER"}), &got)
+	if got.Total != 1 || got.Values["username"] != "admin" {
+		t.Errorf("expected the filtered JSON keys, got %+v", got)
+	}
+
+	got = aws_secrets_get.Result{}
+	testharness.Decode(t, testharness.Call(t, "aws_secrets_get", map[string]any{"name": "prod/note"}), &got)
+	if got.Value != "plain text" || got.Values != nil {
+		t.Errorf("expected the raw value, got %+v", got)
+	}
+
+	testharness.Failure(t, testharness.Call(t, "aws_secrets_get", map[string]any{"name": "missing"}), "not_found")
+}
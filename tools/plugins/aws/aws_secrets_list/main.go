@@ -4,11 +4,13 @@ import (
 	"context"
 	"time"
 
+	"github.com/nicola-strappazzon/argos/internal/awsclient"
 	"github.com/nicola-strappazzon/argos/internal/config/aws"
 	"github.com/nicola-strappazzon/argos/tools/registry"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
@@ -28,58 +30,69 @@ func formatTime(t *time.Time) string {
 	return t.UTC().Format(time.RFC3339)
 }
 
+type Result struct {
+	Secrets []Secret `json:"secrets"`
+	Total   int      `json:"total"`
+}
+
 func init() {
 	registry.Add(registry.Property{
 		Name:        "aws_secrets_list",
 		Description: "List AWS Secrets Manager secrets. Optionally filter by name using a search string.",
 		InputSchema: map[string]any{
 			"type": "object",
-			"properties": map[string]any{
+			"properties": awsconfig.TargetProperties(map[string]any{
 				"filter": map[string]any{
 					"type":        "string",
 					"description": "Optional string to filter secrets by name.",
 				},
-			},
+			}),
 		},
+		Output: Result{},
 		Function: func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
 			filter, _ := args["filter"].(string)
 
-			sess, err := awsconfig.NewSession()
+			target, err := awsconfig.TargetFromArgs(args)
 			if err != nil {
 				return &mcp.CallToolResult{}, nil, err
 			}
 
-			svc := secretsmanager.New(sess)
+			svc, err := awsclient.SecretsManager(target)
+			if err != nil {
+				return &mcp.CallToolResult{}, nil, err
+			}
 
 			input := &secretsmanager.ListSecretsInput{}
 			if filter != "" {
-				input.Filters = []*secretsmanager.Filter{{
-					Key:    aws.String("name"),
-					Values: []*string{aws.String(filter)},
+				input.Filters = []types.Filter{{
+					Key:    types.FilterNameStringTypeName,
+					Values: []string{filter},
 				}}
 			}
 
 			secrets := make([]Secret, 0)
-			err = svc.ListSecretsPagesWithContext(ctx, input, func(page *secretsmanager.ListSecretsOutput, _ bool) bool {
+			pages := secretsmanager.NewListSecretsPaginator(svc, input)
+			for pages.HasMorePages() {
+				page, err := pages.NextPage(ctx)
+				if err != nil {
+					return &mcp.CallToolResult{}, nil, err
+				}
+
 				for _, s := range page.SecretList {
 					secrets = append(secrets, Secret{
-						Name:            aws.StringValue(s.Name),
-						ARN:             aws.StringValue(s.ARN),
-						Description:     aws.StringValue(s.Description),
+						Name:            aws.ToString(s.Name),
+						ARN:             aws.ToString(s.ARN),
+						Description:     aws.ToString(s.Description),
 						LastChanged:     formatTime(s.LastChangedDate),
 						LastAccessed:    formatTime(s.LastAccessedDate),
-						RotationEnabled: aws.BoolValue(s.RotationEnabled),
+						RotationEnabled: aws.ToBool(s.RotationEnabled),
 					})
 				}
-				return true
-			})
-			if err != nil {
-				return &mcp.CallToolResult{}, nil, err
 			}
 
-			return &mcp.CallToolResult{}, map[string]any{
-				"secrets": secrets,
-				"total":   len(secrets),
+			return &mcp.CallToolResult{}, Result{
+				Secrets: secrets,
+				Total:   len(secrets),
 			}, nil
 		},
 	})
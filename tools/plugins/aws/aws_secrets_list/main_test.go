@@ -0,0 +1,41 @@
+package aws_secrets_list_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nicola-strappazzon/argos/internal/awsclient"
+	"github.com/nicola-strappazzon/argos/internal/testharness"
+	"github.com/nicola-strappazzon/argos/tools/plugins/aws/aws_secrets_list"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+)
+
+type fakeSecrets struct {
+	awsclient.SecretsManagerAPI
+}
+
+// ListSecrets serves two pages, the second one at token "2".
+func (fakeSecrets) ListSecrets(_ context.Context, in *secretsmanager.ListSecretsInput, _ ...func(*secretsmanager.Options)) (*secretsmanager.ListSecretsOutput, error) {
+	if aws.ToString(in.NextToken) != "2" {
+		return &secretsmanager.ListSecretsOutput{NextToken: aws.String("2"), SecretList: []types.SecretListEntry{
+			{Name: aws.String("prod/db1"), ARN: aws.String("arn:aws:secretsmanager:eu-west-1:123456789012:secret:prod/db1"), RotationEnabled: aws.Bool(true)},
+		}}, nil
+	}
+	return &secretsmanager.ListSecretsOutput{SecretList: []types.SecretListEntry{
+		{Name: aws.String("prod/db2")},
+	}}, nil
+}
+
+func TestList(t *testing.T) {
+	testharness.UseAWS(t, &testharness.AWS{SecretsManager: fakeSecrets{}})
+
+	var got aws_secrets_list.Result
+	testharness.Decode(t, testharness.Call(t, "aws_secrets_list", nil), &got)
+
+	if got.Total != 2 || !got.Secrets[0].RotationEnabled || got.Secrets[1].Name != "prod/db2" {
+		t.Fatalf("unexpected result: %+v", got)
+	}
+}
@@ -1,6 +1,9 @@
 package plugins
 
 import (
+	_ "github.com/nicola-strappazzon/argos/tools/plugins/artifacts/artifacts_delete"
+	_ "github.com/nicola-strappazzon/argos/tools/plugins/artifacts/artifacts_list"
+	_ "github.com/nicola-strappazzon/argos/tools/plugins/artifacts/artifacts_read"
 	_ "github.com/nicola-strappazzon/argos/tools/plugins/aws/aws_docdb_collections"
 	_ "github.com/nicola-strappazzon/argos/tools/plugins/aws/aws_docdb_current_ops"
 	_ "github.com/nicola-strappazzon/argos/tools/plugins/aws/aws_docdb_databases"
@@ -8,6 +11,9 @@ import (
 	_ "github.com/nicola-strappazzon/argos/tools/plugins/aws/aws_docdb_server_status"
 	_ "github.com/nicola-strappazzon/argos/tools/plugins/aws/aws_ec2_list"
 	_ "github.com/nicola-strappazzon/argos/tools/plugins/aws/aws_health_events"
+	_ "github.com/nicola-strappazzon/argos/tools/plugins/aws/aws_rds_cloudwatch_log_download"
+	_ "github.com/nicola-strappazzon/argos/tools/plugins/aws/aws_rds_cloudwatch_logs"
+	_ "github.com/nicola-strappazzon/argos/tools/plugins/aws/aws_rds_deadlocks"
 	_ "github.com/nicola-strappazzon/argos/tools/plugins/aws/aws_rds_events"
 	_ "github.com/nicola-strappazzon/argos/tools/plugins/aws/aws_rds_instances"
 	_ "github.com/nicola-strappazzon/argos/tools/plugins/aws/aws_rds_log_download"
@@ -32,6 +38,7 @@ import (
 	_ "github.com/nicola-strappazzon/argos/tools/plugins/mysql/mysql_process_detail"
 	_ "github.com/nicola-strappazzon/argos/tools/plugins/mysql/mysql_processlist"
 	_ "github.com/nicola-strappazzon/argos/tools/plugins/mysql/mysql_schema_check"
+	_ "github.com/nicola-strappazzon/argos/tools/plugins/mysql/mysql_slow_log_digest"
 	_ "github.com/nicola-strappazzon/argos/tools/plugins/mysql/mysql_status"
 	_ "github.com/nicola-strappazzon/argos/tools/plugins/mysql/mysql_table_foreign_keys"
 	_ "github.com/nicola-strappazzon/argos/tools/plugins/mysql/mysql_table_indexes"
@@ -40,7 +47,12 @@ import (
 	_ "github.com/nicola-strappazzon/argos/tools/plugins/percona/pt_index_usage"
 	_ "github.com/nicola-strappazzon/argos/tools/plugins/percona/pt_query_digest"
 	_ "github.com/nicola-strappazzon/argos/tools/plugins/percona/pt_variable_advisor"
+	_ "github.com/nicola-strappazzon/argos/tools/plugins/postgresql/postgresql_activity"
+	_ "github.com/nicola-strappazzon/argos/tools/plugins/postgresql/postgresql_blocking"
 	_ "github.com/nicola-strappazzon/argos/tools/plugins/postgresql/postgresql_databases"
+	_ "github.com/nicola-strappazzon/argos/tools/plugins/postgresql/postgresql_explain"
+	_ "github.com/nicola-strappazzon/argos/tools/plugins/postgresql/postgresql_log_digest"
 	_ "github.com/nicola-strappazzon/argos/tools/plugins/postgresql/postgresql_ping"
 	_ "github.com/nicola-strappazzon/argos/tools/plugins/postgresql/postgresql_tables"
+	_ "github.com/nicola-strappazzon/argos/tools/plugins/postgresql/postgresql_top_queries"
 )
@@ -2,7 +2,6 @@ package mysql_databases
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
 
 	mysqldriver "github.com/nicola-strappazzon/argos/internal/drivers/mysql"
@@ -19,6 +18,13 @@ type Database struct {
 	Tables    int64   `json:"tables"`
 }
 
+type Result struct {
+	Instance    string     `json:"instance"`
+	Databases   []Database `json:"databases"`
+	Total       int        `json:"total"`
+	TotalSizeMB float64    `json:"total_size_mb"`
+}
+
 func init() {
 	registry.Add(registry.Property{
 		Name:        "mysql_databases",
@@ -33,14 +39,14 @@ func init() {
 			},
 			"required": []string{"db_instance_identifier"},
 		},
+		Output: Result{},
 		Function: func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
 			instanceID, _ := args["db_instance_identifier"].(string)
 
-			db, err := mysqldriver.Connect(instanceID)
+			db, err := mysqldriver.Connect(ctx, instanceID)
 			if err != nil {
 				return &mcp.CallToolResult{}, nil, err
 			}
-			defer db.Close()
 
 			query := `
 				SELECT
@@ -78,11 +84,11 @@ func init() {
 				totalMB += d.SizeMB
 			}
 
-			return &mcp.CallToolResult{}, map[string]any{
-				"instance":      instanceID,
-				"databases":     databases,
-				"total":         len(databases),
-				"total_size_mb": sql.NullFloat64{Float64: totalMB, Valid: true}.Float64,
+			return &mcp.CallToolResult{}, Result{
+				Instance:    instanceID,
+				Databases:   databases,
+				Total:       len(databases),
+				TotalSizeMB: totalMB,
 			}, nil
 		},
 	})
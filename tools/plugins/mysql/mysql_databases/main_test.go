@@ -0,0 +1,28 @@
+package mysql_databases_test
+
+import (
+	"testing"
+
+	"github.com/nicola-strappazzon/argos/internal/testharness"
+	"github.com/nicola-strappazzon/argos/tools/plugins/mysql/mysql_databases"
+)
+
+func TestDatabases(t *testing.T) {
+	mock := testharness.MySQL(t, "db1")
+	mock.ExpectQuery(`FROM information_schema.schemata s\s+LEFT JOIN information_schema.tables`).
+		WillReturnRows(testharness.Rows(
+			[]string{"schema_name", "default_character_set_name", "default_collation_name", "size_mb", "tables"},
+			[]any{"shop", "utf8mb4", "utf8mb4_0900_ai_ci", 120.5, 12},
+			[]any{"mysql", "utf8mb4", "utf8mb4_0900_ai_ci", 2.25, 38},
+		))
+
+	var got mysql_databases.Result
+	testharness.Decode(t, testharness.Call(t, "mysql_databases", map[string]any{"db_instance_identifier": "db1"}), &got)
+
+	if got.Total != 2 || got.TotalSizeMB != 122.75 {
+		t.Fatalf("unexpected totals: %+v", got)
+	}
+	if got.Databases[0].Name != "shop" || got.Databases[0].Tables != 12 {
+		t.Fatalf("unexpected first database: %+v", got.Databases[0])
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	mysqldriver "github.com/nicola-strappazzon/argos/internal/drivers/mysql"
+	"github.com/nicola-strappazzon/argos/internal/toolerr"
 	"github.com/nicola-strappazzon/argos/tools/registry"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
@@ -25,6 +26,14 @@ type Column struct {
 	Comment   string `json:"comment,omitempty"`
 }
 
+type Result struct {
+	Instance string   `json:"instance"`
+	Database string   `json:"database"`
+	Table    string   `json:"table"`
+	Columns  []Column `json:"columns"`
+	Total    int      `json:"total"`
+}
+
 func init() {
 	registry.Add(registry.Property{
 		Name:        "mysql_describe_table",
@@ -47,16 +56,16 @@ func init() {
 			},
 			"required": []string{"db_instance_identifier", "database", "table"},
 		},
+		Output: Result{},
 		Function: func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
 			instanceID, _ := args["db_instance_identifier"].(string)
 			database, _ := args["database"].(string)
 			table, _ := args["table"].(string)
 
-			db, err := mysqldriver.Connect(instanceID)
+			db, err := mysqldriver.Connect(ctx, instanceID)
 			if err != nil {
 				return &mcp.CallToolResult{}, nil, err
 			}
-			defer db.Close()
 
 			query := `
 				SELECT
@@ -102,15 +111,15 @@ func init() {
 			}
 
 			if len(columns) == 0 {
-				return &mcp.CallToolResult{}, nil, fmt.Errorf("table %s.%s not found", database, table)
+				return &mcp.CallToolResult{}, nil, toolerr.Errorf(toolerr.NotFound, "list the tables with mysql_tables", "table %s.%s not found", database, table)
 			}
 
-			return &mcp.CallToolResult{}, map[string]any{
-				"instance": instanceID,
-				"database": database,
-				"table":    table,
-				"columns":  columns,
-				"total":    len(columns),
+			return &mcp.CallToolResult{}, Result{
+				Instance: instanceID,
+				Database: database,
+				Table:    table,
+				Columns:  columns,
+				Total:    len(columns),
 			}, nil
 		},
 	})
@@ -0,0 +1,50 @@
+package mysql_describe_table_test
+
+import (
+	"testing"
+
+	"github.com/nicola-strappazzon/argos/internal/testharness"
+	"github.com/nicola-strappazzon/argos/tools/plugins/mysql/mysql_describe_table"
+)
+
+var columns = []string{"ordinal_position", "column_name", "column_type", "is_nullable", "column_default",
+	"character_set_name", "collation_name", "column_key", "extra", "column_comment"}
+
+func TestDescribeTable(t *testing.T) {
+	mock := testharness.MySQL(t, "db1")
+	mock.ExpectQuery(`FROM information_schema.columns c`).
+		WithArgs("shop", "orders").
+		WillReturnRows(testharness.Rows(columns,
+			[]any{1, "id", "bigint unsigned", "NO", "", "", "", "PRI", "auto_increment", ""},
+			[]any{2, "note", "varchar(255)", "YES", "", "utf8mb4", "utf8mb4_0900_ai_ci", "", "", "free text"},
+		))
+
+	var got mysql_describe_table.Result
+	testharness.Decode(t, testharness.Call(t, "mysql_describe_table", map[string]any{
+		"db_instance_identifier": "db1",
+		"database":               "shop",
+		"table":                  "orders",
+	}), &got)
+
+	if got.Total != 2 {
+		t.Fatalf("expected 2 columns, got %+v", got)
+	}
+	if id := got.Columns[0]; !id.Unsigned || id.Nullable || id.Key != "PRI" {
+		t.Fatalf("unexpected id column: %+v", id)
+	}
+	if note := got.Columns[1]; !note.Nullable || note.Unsigned || note.Comment != "free text" {
+		t.Fatalf("unexpected note column: %+v", note)
+	}
+}
+
+func TestDescribeMissingTable(t *testing.T) {
+	mock := testharness.MySQL(t, "db1")
+	mock.ExpectQuery(`FROM information_schema.columns c`).
+		WillReturnRows(testharness.Rows(columns))
+
+	testharness.Failure(t, testharness.Call(t, "mysql_describe_table", map[string]any{
+		"db_instance_identifier": "db1",
+		"database":               "shop",
+		"table":                  "missing",
+	}), "not_found")
+}
@@ -26,6 +26,13 @@ type Table struct {
 	Columns []Column `json:"columns"`
 }
 
+type Result struct {
+	Instance string  `json:"instance"`
+	Database string  `json:"database"`
+	Tables   []Table `json:"tables"`
+	Total    int     `json:"total"`
+}
+
 func init() {
 	registry.Add(registry.Property{
 		Name:        "mysql_documentation",
@@ -44,15 +51,15 @@ func init() {
 			},
 			"required": []string{"db_instance_identifier", "database"},
 		},
+		Output: Result{},
 		Function: func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
 			instanceID, _ := args["db_instance_identifier"].(string)
 			database, _ := args["database"].(string)
 
-			db, err := mysqldriver.Connect(instanceID)
+			db, err := mysqldriver.Connect(ctx, instanceID)
 			if err != nil {
 				return &mcp.CallToolResult{}, nil, err
 			}
-			defer db.Close()
 
 			query := `
 				SELECT
@@ -114,11 +121,11 @@ func init() {
 				tables = append(tables, *tableMap[name])
 			}
 
-			return &mcp.CallToolResult{}, map[string]any{
-				"instance": instanceID,
-				"database": database,
-				"tables":   tables,
-				"total":    len(tables),
+			return &mcp.CallToolResult{}, Result{
+				Instance: instanceID,
+				Database: database,
+				Tables:   tables,
+				Total:    len(tables),
 			}, nil
 		},
 	})
@@ -0,0 +1,37 @@
+package mysql_documentation_test
+
+import (
+	"testing"
+
+	"github.com/nicola-strappazzon/argos/internal/testharness"
+	"github.com/nicola-strappazzon/argos/tools/plugins/mysql/mysql_documentation"
+)
+
+func TestDocumentation(t *testing.T) {
+	mock := testharness.MySQL(t, "db1")
+	mock.ExpectQuery(`FROM information_schema.columns c\s+INNER JOIN information_schema.tables t`).
+		WithArgs("shop").
+		WillReturnRows(testharness.Rows(
+			[]string{"table_name", "table_comment", "column_name", "column_type", "is_nullable", "column_default", "column_comment"},
+			[]any{"customers", "people who order", "id", "int unsigned", "NO", "", ""},
+			[]any{"customers", "people who order", "email", "varchar(255)", "NO", "", "login"},
+			[]any{"orders", "", "id", "bigint", "NO", "", ""},
+		))
+
+	var got mysql_documentation.Result
+	testharness.Decode(t, testharness.Call(t, "mysql_documentation", map[string]any{
+		"db_instance_identifier": "db1",
+		"database":               "shop",
+	}), &got)
+
+	if got.Total != 2 {
+		t.Fatalf("expected 2 tables, got %+v", got)
+	}
+	customers := got.Tables[0]
+	if customers.Comment != "people who order" || len(customers.Columns) != 2 || !customers.Columns[0].Unsigned {
+		t.Fatalf("unexpected customers table: %+v", customers)
+	}
+	if customers.Columns[1].Comment != "login" {
+		t.Fatalf("unexpected email column: %+v", customers.Columns[1])
+	}
+}
@@ -4,8 +4,11 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
+	"time"
 
 	mysqldriver "github.com/nicola-strappazzon/argos/internal/drivers/mysql"
+	"github.com/nicola-strappazzon/argos/internal/sqlguard"
 	"github.com/nicola-strappazzon/argos/tools/registry"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
@@ -26,10 +29,21 @@ type ExplainRow struct {
 	Extra        string  `json:"extra,omitempty"`
 }
 
+// Result holds the tabular plan of EXPLAIN, or the tree of EXPLAIN ANALYZE.
+type Result struct {
+	Instance string       `json:"instance"`
+	Database string       `json:"database"`
+	Query    string       `json:"query"`
+	Analyze  bool         `json:"analyze"`
+	Plan     []ExplainRow `json:"plan,omitempty"`
+	Tree     string       `json:"tree,omitempty"`
+	Total    int          `json:"total"`
+}
+
 func init() {
 	registry.Add(registry.Property{
 		Name:        "mysql_explain",
-		Description: "Run EXPLAIN on a MySQL query and return the execution plan. Optionally run EXPLAIN ANALYZE to include actual execution metrics (WARNING: EXPLAIN ANALYZE executes the query).",
+		Description: "Run EXPLAIN on a MySQL query and return the execution plan. Optionally run EXPLAIN ANALYZE to include actual execution metrics (WARNING: EXPLAIN ANALYZE executes the query, in a read-only session).",
 		InputSchema: map[string]any{
 			"type": "object",
 			"properties": map[string]any{
@@ -43,7 +57,7 @@ func init() {
 				},
 				"query": map[string]any{
 					"type":        "string",
-					"description": "The SELECT query to explain.",
+					"description": "The SELECT query to explain. Only a single read-only statement is accepted: data-modifying statements, locking reads and side-effecting functions (e.g. SLEEP) are rejected.",
 				},
 				"analyze": map[string]any{
 					"type":        "boolean",
@@ -52,28 +66,41 @@ func init() {
 			},
 			"required": []string{"db_instance_identifier", "database", "query"},
 		},
+		Output:  Result{},
+		Timeout: 2 * time.Minute,
 		Function: func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
 			instanceID, _ := args["db_instance_identifier"].(string)
 			database, _ := args["database"].(string)
 			query, _ := args["query"].(string)
 			analyze, _ := args["analyze"].(bool)
 
-			db, err := mysqldriver.Connect(instanceID)
+			prefix := "EXPLAIN"
+			if analyze {
+				prefix = "EXPLAIN ANALYZE"
+			}
+
+			statement := prefix + " " + query
+			if err := sqlguard.CheckReadOnly(sqlguard.MySQL, statement); err != nil {
+				return &mcp.CallToolResult{}, nil, err
+			}
+
+			db, err := mysqldriver.Connect(ctx, instanceID)
 			if err != nil {
 				return &mcp.CallToolResult{}, nil, err
 			}
-			defer db.Close()
 
-			if _, err := db.ExecContext(ctx, "USE `"+database+"`"); err != nil {
-				return &mcp.CallToolResult{}, nil, fmt.Errorf("selecting database: %w", err)
+			// USE only affects one session, so pin a connection of the pool.
+			conn, err := db.Conn(ctx)
+			if err != nil {
+				return &mcp.CallToolResult{}, nil, fmt.Errorf("acquiring connection: %w", err)
 			}
+			defer conn.Close()
 
-			prefix := "EXPLAIN"
-			if analyze {
-				prefix = "EXPLAIN ANALYZE"
+			if _, err := conn.ExecContext(ctx, "USE `"+strings.ReplaceAll(database, "`", "``")+"`"); err != nil {
+				return &mcp.CallToolResult{}, nil, fmt.Errorf("selecting database: %w", err)
 			}
 
-			rows, err := db.QueryContext(ctx, prefix+" "+query)
+			rows, err := conn.QueryContext(ctx, statement)
 			if err != nil {
 				return &mcp.CallToolResult{}, nil, fmt.Errorf("executing explain: %w", err)
 			}
@@ -87,12 +114,12 @@ func init() {
 						return &mcp.CallToolResult{}, nil, fmt.Errorf("scanning analyze result: %w", err)
 					}
 				}
-				return &mcp.CallToolResult{}, map[string]any{
-					"instance": instanceID,
-					"database": database,
-					"query":    query,
-					"analyze":  true,
-					"plan":     treeOutput,
+				return &mcp.CallToolResult{}, Result{
+					Instance: instanceID,
+					Database: database,
+					Query:    query,
+					Analyze:  true,
+					Tree:     treeOutput,
 				}, nil
 			}
 
@@ -129,13 +156,12 @@ func init() {
 				return &mcp.CallToolResult{}, nil, fmt.Errorf("reading explain rows: %w", err)
 			}
 
-			return &mcp.CallToolResult{}, map[string]any{
-				"instance": instanceID,
-				"database": database,
-				"query":    query,
-				"analyze":  false,
-				"plan":     plan,
-				"total":    len(plan),
+			return &mcp.CallToolResult{}, Result{
+				Instance: instanceID,
+				Database: database,
+				Query:    query,
+				Plan:     plan,
+				Total:    len(plan),
 			}, nil
 		},
 	})
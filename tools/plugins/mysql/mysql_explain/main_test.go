@@ -0,0 +1,59 @@
+package mysql_explain_test
+
+import (
+	"testing"
+
+	"github.com/nicola-strappazzon/argos/internal/testharness"
+	"github.com/nicola-strappazzon/argos/tools/plugins/mysql/mysql_explain"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestExplain(t *testing.T) {
+	mock := testharness.MySQL(t, "db1")
+	mock.ExpectExec("^USE `shop`$").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`^EXPLAIN SELECT \* FROM orders WHERE customer_id = 1$`).
+		WillReturnRows(testharness.Rows(
+			[]string{"id", "select_type", "table", "partitions", "type", "possible_keys", "key", "key_len", "ref", "rows", "filtered", "Extra"},
+			[]any{1, "SIMPLE", "orders", nil, "ref", "idx_customer", "idx_customer", "8", "const", 12, 100.0, nil},
+		))
+
+	var got mysql_explain.Result
+	testharness.Decode(t, testharness.Call(t, "mysql_explain", map[string]any{
+		"db_instance_identifier": "db1",
+		"database":               "shop",
+		"query":                  "SELECT * FROM orders WHERE customer_id = 1",
+	}), &got)
+
+	if got.Total != 1 || got.Plan[0].Key != "idx_customer" || got.Plan[0].AccessType != "ref" {
+		t.Fatalf("unexpected plan: %+v", got)
+	}
+}
+
+func TestExplainAnalyze(t *testing.T) {
+	mock := testharness.MySQL(t, "db1")
+	mock.ExpectExec("^USE `shop`$").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`^EXPLAIN ANALYZE SELECT 1$`).
+		WillReturnRows(testharness.Rows([]string{"EXPLAIN"}, []any{"-> Rows fetched before execution  (cost=0..0 rows=1)"}))
+
+	var got mysql_explain.Result
+	testharness.Decode(t, testharness.Call(t, "mysql_explain", map[string]any{
+		"db_instance_identifier": "db1",
+		"database":               "shop",
+		"query":                  "SELECT 1",
+		"analyze":                true,
+	}), &got)
+
+	if !got.Analyze || got.Tree == "" || got.Plan != nil {
+		t.Fatalf("unexpected analyze result: %+v", got)
+	}
+}
+
+func TestExplainRejectsWrites(t *testing.T) {
+	testharness.Failure(t, testharness.Call(t, "mysql_explain", map[string]any{
+		"db_instance_identifier": "db1",
+		"database":               "shop",
+		"query":                  "DELETE FROM orders",
+		"analyze":                true,
+	}), "invalid_input")
+}
@@ -4,21 +4,21 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"regexp"
 	"strconv"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/rds"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	"github.com/nicola-strappazzon/argos/internal/awsclient"
 	"github.com/nicola-strappazzon/argos/internal/config/aws"
 	mysqldriver "github.com/nicola-strappazzon/argos/internal/drivers/mysql"
+	"github.com/nicola-strappazzon/argos/internal/innodb"
 	awsmeta "github.com/nicola-strappazzon/argos/internal/meta/aws"
+	"github.com/nicola-strappazzon/argos/internal/toolerr"
 	"github.com/nicola-strappazzon/argos/tools/registry"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
-var reHistoryLength = regexp.MustCompile(`History list length (\d+)`)
-
 type Check struct {
 	Name        string  `json:"name"`
 	Value       float64 `json:"value"`
@@ -28,6 +28,12 @@ type Check struct {
 	Threshold   string  `json:"threshold"`
 }
 
+type Result struct {
+	Instance string  `json:"instance"`
+	Checks   []Check `json:"checks"`
+	Total    int     `json:"total"`
+}
+
 // queryStatusVars runs SHOW GLOBAL STATUS with the given WHERE/LIKE clause
 // and returns a map of variable name → float64 value.
 func queryStatusVars(ctx context.Context, db *sql.DB, query string) (map[string]float64, error) {
@@ -246,28 +252,28 @@ func checkHistoryListLength(ctx context.Context, db *sql.DB) (*Check, error) {
 		return nil, fmt.Errorf("history list length: %w", err)
 	}
 
-	m := reHistoryLength.FindStringSubmatch(raw)
-	if m == nil {
+	status := innodb.Parse(raw)
+	if !status.Found("transactions.history_list_length") {
 		return nil, nil
 	}
 
-	hll, _ := strconv.ParseInt(m[1], 10, 64)
-	var status, description string
+	hll := status.Transactions.HistoryLength
+	var level, description string
 	switch {
 	case hll > 1_000_000:
-		status = "critical"
+		level = "critical"
 		description = fmt.Sprintf("History List Length is %d. EMERGENCY: long-running transactions are severely bloating the undo log. Identify and kill blocking transactions immediately.", hll)
 	case hll > 100_000:
-		status = "critical"
+		level = "critical"
 		description = fmt.Sprintf("History List Length is %d. Serious problem: undo log is growing uncontrolled. Find and close long-running or idle open transactions.", hll)
 	case hll > 10_000:
-		status = "warning"
+		level = "warning"
 		description = fmt.Sprintf("History List Length is %d. Open or slow transactions are holding back InnoDB purge. Review long-running transactions.", hll)
 	case hll > 1_000:
-		status = "ok"
+		level = "ok"
 		description = fmt.Sprintf("History List Length is %d. Normal under load.", hll)
 	default:
-		status = "ok"
+		level = "ok"
 		description = fmt.Sprintf("History List Length is %d. Excellent.", hll)
 	}
 
@@ -275,7 +281,7 @@ func checkHistoryListLength(ctx context.Context, db *sql.DB) (*Check, error) {
 		Name:        "history_list_length",
 		Value:       float64(hll),
 		Unit:        "rows",
-		Status:      status,
+		Status:      level,
 		Description: description,
 		Threshold:   "ok < 10,000 | warning 10,000–100,000 | critical > 100,000 | emergency > 1,000,000",
 	}, nil
@@ -522,30 +528,29 @@ func init() {
 			},
 			"required": []string{"db_instance_identifier"},
 		},
+		Output: Result{},
 		Function: func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
 			instanceID, _ := args["db_instance_identifier"].(string)
 
-			sess, err := awsconfig.NewSession()
+			svc, err := awsclient.RDS(awsconfig.Target{})
 			if err != nil {
 				return &mcp.CallToolResult{}, nil, err
 			}
-			svc := rds.New(sess)
-			rdsResult, err := svc.DescribeDBInstances(&rds.DescribeDBInstancesInput{
+			rdsResult, err := svc.DescribeDBInstances(ctx, &rds.DescribeDBInstancesInput{
 				DBInstanceIdentifier: aws.String(instanceID),
 			})
 			if err != nil {
 				return &mcp.CallToolResult{}, nil, fmt.Errorf("describing RDS instance: %w", err)
 			}
 			if len(rdsResult.DBInstances) == 0 {
-				return &mcp.CallToolResult{}, nil, fmt.Errorf("instance %s not found", instanceID)
+				return &mcp.CallToolResult{}, nil, toolerr.Errorf(toolerr.NotFound, "list the instances with aws_rds_instances", "instance %s not found", instanceID)
 			}
-			instanceClass := aws.StringValue(rdsResult.DBInstances[0].DBInstanceClass)
+			instanceClass := aws.ToString(rdsResult.DBInstances[0].DBInstanceClass)
 
-			db, err := mysqldriver.Connect(instanceID)
+			db, err := mysqldriver.Connect(ctx, instanceID)
 			if err != nil {
 				return &mcp.CallToolResult{}, nil, err
 			}
-			defer db.Close()
 
 			type checkFn func() (*Check, error)
 			runners := []checkFn{
@@ -574,10 +579,10 @@ func init() {
 				}
 			}
 
-			return &mcp.CallToolResult{}, map[string]any{
-				"instance": instanceID,
-				"checks":   checks,
-				"total":    len(checks),
+			return &mcp.CallToolResult{}, Result{
+				Instance: instanceID,
+				Checks:   checks,
+				Total:    len(checks),
 			}, nil
 		},
 	})
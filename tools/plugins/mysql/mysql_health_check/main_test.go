@@ -0,0 +1,87 @@
+package mysql_health_check_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/nicola-strappazzon/argos/internal/awsclient"
+	"github.com/nicola-strappazzon/argos/internal/testharness"
+	"github.com/nicola-strappazzon/argos/internal/toolerr"
+	"github.com/nicola-strappazzon/argos/tools/plugins/mysql/mysql_health_check"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	"github.com/aws/aws-sdk-go-v2/service/rds/types"
+)
+
+type fakeRDS struct {
+	awsclient.RDSAPI
+	instances []types.DBInstance
+}
+
+func (f fakeRDS) DescribeDBInstances(_ context.Context, in *rds.DescribeDBInstancesInput, _ ...func(*rds.Options)) (*rds.DescribeDBInstancesOutput, error) {
+	return &rds.DescribeDBInstancesOutput{DBInstances: f.instances}, nil
+}
+
+func TestHealthCheck(t *testing.T) {
+	testharness.UseAWS(t, &testharness.AWS{RDS: fakeRDS{instances: []types.DBInstance{{
+		DBInstanceIdentifier: aws.String("db1"),
+		DBInstanceClass:      aws.String("db.r6g.large"),
+	}}}})
+
+	vars := []string{"Variable_name", "Value"}
+	results := map[string][][]any{
+		"SHOW GLOBAL STATUS WHERE Variable_name IN ('Innodb_buffer_pool_read_requests', 'Innodb_buffer_pool_reads')": {
+			{"Innodb_buffer_pool_read_requests", "100000"},
+			{"Innodb_buffer_pool_reads", "50"},
+		},
+		"SHOW GLOBAL STATUS WHERE Variable_name = 'Threads_connected'":                                                   {{"Threads_connected", "90"}},
+		"SHOW GLOBAL VARIABLES WHERE Variable_name = 'max_connections'":                                                  {{"max_connections", "100"}},
+		"SHOW GLOBAL VARIABLES LIKE 'innodb_buffer_pool_size'":                                                           nil,
+		"SHOW GLOBAL STATUS WHERE Variable_name IN ('Threads_created', 'Connections')":                                   nil,
+		"SHOW GLOBAL STATUS WHERE Variable_name IN ('Threads_cached', 'Threads_created')":                                nil,
+		"SHOW GLOBAL STATUS WHERE Variable_name IN ('Created_tmp_disk_tables', 'Created_tmp_tables')":                    nil,
+		"SHOW GLOBAL STATUS WHERE Variable_name IN ('Innodb_buffer_pool_pages_dirty', 'Innodb_buffer_pool_pages_total')": nil,
+		"SHOW GLOBAL STATUS WHERE Variable_name = 'Open_files'":                                                          nil,
+		"SHOW GLOBAL VARIABLES WHERE Variable_name = 'open_files_limit'":                                                 nil,
+		"SHOW GLOBAL STATUS WHERE Variable_name IN ('Innodb_log_waits', 'Innodb_log_writes')":                            nil,
+		"SHOW GLOBAL STATUS WHERE Variable_name IN ('Sort_merge_passes', 'Sort_scan', 'Sort_range')":                     nil,
+		"SHOW GLOBAL STATUS WHERE Variable_name IN ('Uptime', 'Innodb_os_log_written')":                                  nil,
+		"SHOW GLOBAL VARIABLES WHERE Variable_name = 'innodb_redo_log_capacity'":                                         nil,
+	}
+
+	mock := testharness.MySQL(t, "db1")
+	for query, rows := range results {
+		mock.ExpectQuery("^" + regexp.QuoteMeta(query) + "$").
+			WillReturnRows(testharness.Rows(vars, rows...))
+	}
+	mock.ExpectQuery(`^SHOW ENGINE INNODB STATUS$`).
+		WillReturnRows(testharness.Rows([]string{"Type", "Name", "Status"}, []any{"InnoDB", "", "------------\nTRANSACTIONS\n------------\nTrx id counter 9120\nHistory list length 120\n"}))
+
+	var got mysql_health_check.Result
+	testharness.Decode(t, testharness.Call(t, "mysql_health_check", map[string]any{"db_instance_identifier": "db1"}), &got)
+
+	status := map[string]string{}
+	for _, c := range got.Checks {
+		status[c.Name] = c.Status
+	}
+	if len(status) != 3 {
+		t.Fatalf("expected 3 checks, got %+v", got.Checks)
+	}
+	if status["max_connections_usage"] != "critical" {
+		t.Errorf("max_connections_usage: got %q", status["max_connections_usage"])
+	}
+	for name, s := range status {
+		if name != "max_connections_usage" && s != "ok" {
+			t.Errorf("%s: got %q, want ok", name, s)
+		}
+	}
+}
+
+func TestHealthCheckUnknownInstance(t *testing.T) {
+	testharness.UseAWS(t, &testharness.AWS{RDS: fakeRDS{}})
+
+	res := testharness.Call(t, "mysql_health_check", map[string]any{"db_instance_identifier": "missing"})
+	testharness.Failure(t, res, toolerr.NotFound)
+}
@@ -0,0 +1,50 @@
+package mysql_innodb_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/nicola-strappazzon/argos/internal/testharness"
+	"github.com/nicola-strappazzon/argos/tools/plugins/mysql/mysql_innodb"
+)
+
+// The parser itself is covered by the golden files of internal/innodb.
+func TestInnoDB(t *testing.T) {
+	status, err := os.ReadFile("../../../../internal/innodb/testdata/mysql-8.4.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock := testharness.MySQL(t, "db1")
+	mock.ExpectQuery(`^SHOW ENGINE INNODB STATUS$`).
+		WillReturnRows(testharness.Rows([]string{"Type", "Name", "Status"}, []any{"InnoDB", "", string(status)}))
+
+	var got mysql_innodb.Result
+	testharness.Decode(t, testharness.Call(t, "mysql_innodb", map[string]any{"db_instance_identifier": "db1"}), &got)
+
+	if got.Instance != "db1" || got.Timestamp != "2025-02-03 16:44:52" {
+		t.Errorf("unexpected header: %+v", got)
+	}
+	if len(got.BufferPool.Instances) != 2 || got.BufferPool.SizePages != 262112 {
+		t.Errorf("unexpected buffer pool: %+v", got.BufferPool)
+	}
+	if got.ForeignKeyError == nil || got.ForeignKeyError.Constraint != "fk_order_items_order" {
+		t.Errorf("unexpected foreign key error: %+v", got.ForeignKeyError)
+	}
+	if len(got.Missing) != 0 || len(got.Unparsed) != 0 || got.Truncated {
+		t.Errorf("expected a complete parse, got missing %v, unparsed %v", got.Missing, got.Unparsed)
+	}
+}
+
+func TestInnoDBMissingSections(t *testing.T) {
+	mock := testharness.MySQL(t, "db1")
+	mock.ExpectQuery(`^SHOW ENGINE INNODB STATUS$`).
+		WillReturnRows(testharness.Rows([]string{"Type", "Name", "Status"}, []any{"InnoDB", "", "------------\nTRANSACTIONS\n------------\nHistory list length 7\n"}))
+
+	var got mysql_innodb.Result
+	testharness.Decode(t, testharness.Call(t, "mysql_innodb", map[string]any{"db_instance_identifier": "db1"}), &got)
+
+	if !got.Truncated || len(got.Missing) != 7 || got.Transactions.HistoryLength != 7 {
+		t.Errorf("unexpected result: %+v", got)
+	}
+}
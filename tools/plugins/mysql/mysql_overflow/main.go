@@ -59,6 +59,13 @@ func maxValueForType(columnType string) uint64 {
 	}
 }
 
+type Result struct {
+	Instance string           `json:"instance"`
+	Database string           `json:"database"`
+	Columns  []OverflowColumn `json:"columns"`
+	Total    int              `json:"total"`
+}
+
 func init() {
 	registry.Add(registry.Property{
 		Name:        "mysql_overflow",
@@ -77,15 +84,15 @@ func init() {
 			},
 			"required": []string{"db_instance_identifier", "database"},
 		},
+		Output: Result{},
 		Function: func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
 			instanceID, _ := args["db_instance_identifier"].(string)
 			database, _ := args["database"].(string)
 
-			db, err := mysqldriver.Connect(instanceID)
+			db, err := mysqldriver.Connect(ctx, instanceID)
 			if err != nil {
 				return &mcp.CallToolResult{}, nil, err
 			}
-			defer db.Close()
 
 			query := `
 				SELECT
@@ -136,11 +143,11 @@ func init() {
 				}
 			}
 
-			return &mcp.CallToolResult{}, map[string]any{
-				"instance": instanceID,
-				"database": database,
-				"columns":  columns,
-				"total":    len(columns),
+			return &mcp.CallToolResult{}, Result{
+				Instance: instanceID,
+				Database: database,
+				Columns:  columns,
+				Total:    len(columns),
 			}, nil
 		},
 	})
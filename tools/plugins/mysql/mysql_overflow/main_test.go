@@ -0,0 +1,38 @@
+package mysql_overflow_test
+
+import (
+	"testing"
+
+	"github.com/nicola-strappazzon/argos/internal/testharness"
+	"github.com/nicola-strappazzon/argos/tools/plugins/mysql/mysql_overflow"
+)
+
+func TestOverflow(t *testing.T) {
+	mock := testharness.MySQL(t, "db1")
+	mock.ExpectQuery(`AND c.extra LIKE '%auto_increment%'`).
+		WithArgs("shop").
+		WillReturnRows(testharness.Rows([]string{"table_name", "column_name", "column_type", "auto_increment"},
+			[]any{"customers", "id", "int unsigned", 1000},
+			[]any{"events", "id", "int", 2000000000},
+			[]any{"flags", "id", "tinyint", 127},
+		))
+
+	var got mysql_overflow.Result
+	testharness.Decode(t, testharness.Call(t, "mysql_overflow", map[string]any{
+		"db_instance_identifier": "db1",
+		"database":               "shop",
+	}), &got)
+
+	if got.Total != 3 {
+		t.Fatalf("expected 3 columns, got %+v", got)
+	}
+	if flags := got.Columns[0]; flags.Table != "flags" || flags.PctUsed != 100 || flags.RemainingValue != 0 {
+		t.Fatalf("expected the exhausted tinyint first, got %+v", flags)
+	}
+	if events := got.Columns[1]; events.Table != "events" || events.MaxValue != 2147483647 || events.Unsigned {
+		t.Fatalf("unexpected events column: %+v", events)
+	}
+	if customers := got.Columns[2]; customers.MaxValue != 4294967295 || !customers.Unsigned {
+		t.Fatalf("unexpected customers column: %+v", customers)
+	}
+}
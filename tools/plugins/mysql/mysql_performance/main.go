@@ -21,6 +21,12 @@ type Recommendation struct {
 	Description      string `json:"description"`
 }
 
+type Result struct {
+	Instance        string           `json:"instance"`
+	Recommendations []Recommendation `json:"recommendations"`
+	Total           int              `json:"total"`
+}
+
 func queryVars(ctx context.Context, db *sql.DB, query string) (map[string]float64, error) {
 	rows, err := db.QueryContext(ctx, query)
 	if err != nil {
@@ -193,14 +199,14 @@ func init() {
 			},
 			"required": []string{"db_instance_identifier"},
 		},
+		Output: Result{},
 		Function: func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
 			instanceID, _ := args["db_instance_identifier"].(string)
 
-			db, err := mysqldriver.Connect(instanceID)
+			db, err := mysqldriver.Connect(ctx, instanceID)
 			if err != nil {
 				return &mcp.CallToolResult{}, nil, err
 			}
-			defer db.Close()
 
 			type checkFn func() (*Recommendation, error)
 			runners := []checkFn{
@@ -218,10 +224,10 @@ func init() {
 				}
 			}
 
-			return &mcp.CallToolResult{}, map[string]any{
-				"instance":        instanceID,
-				"recommendations": recommendations,
-				"total":           len(recommendations),
+			return &mcp.CallToolResult{}, Result{
+				Instance:        instanceID,
+				Recommendations: recommendations,
+				Total:           len(recommendations),
 			}, nil
 		},
 	})
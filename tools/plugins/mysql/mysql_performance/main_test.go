@@ -0,0 +1,43 @@
+package mysql_performance_test
+
+import (
+	"testing"
+
+	"github.com/nicola-strappazzon/argos/internal/testharness"
+	"github.com/nicola-strappazzon/argos/tools/plugins/mysql/mysql_performance"
+)
+
+func TestPerformance(t *testing.T) {
+	tests := []struct {
+		name   string
+		pool   string
+		chunk  string
+		status string
+	}{
+		{"aligned", "4294967296", "134217728", "ok"},
+		{"misaligned", "4300000000", "134217728", "warning"},
+		{"small chunks", "68719476736", "134217728", "warning"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := testharness.MySQL(t, "db1")
+			mock.ExpectQuery(`^SHOW GLOBAL VARIABLES WHERE Variable_name IN`).
+				WillReturnRows(testharness.Rows([]string{"Variable_name", "Value"},
+					[]any{"innodb_buffer_pool_size", tt.pool},
+					[]any{"innodb_buffer_pool_instances", "1"},
+					[]any{"innodb_buffer_pool_chunk_size", tt.chunk},
+				))
+
+			var got mysql_performance.Result
+			testharness.Decode(t, testharness.Call(t, "mysql_performance", map[string]any{"db_instance_identifier": "db1"}), &got)
+
+			if got.Total != 1 || got.Recommendations[0].Status != tt.status {
+				t.Fatalf("expected %s, got %+v", tt.status, got.Recommendations)
+			}
+			if tt.status != "ok" && got.Recommendations[0].RecommendedValue == "" {
+				t.Error("expected a recommended value")
+			}
+		})
+	}
+}
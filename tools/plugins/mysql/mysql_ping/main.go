@@ -10,6 +10,13 @@ import (
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
+type Result struct {
+	Instance  string `json:"instance"`
+	Success   bool   `json:"success"`
+	Error     string `json:"error,omitempty"`
+	LatencyMS int64  `json:"latency_ms"`
+}
+
 func init() {
 	registry.Add(registry.Property{
 		Name:        "mysql_ping",
@@ -24,35 +31,31 @@ func init() {
 			},
 			"required": []string{"db_instance_identifier"},
 		},
+		Output: Result{},
 		Function: func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
 			instanceID, _ := args["db_instance_identifier"].(string)
 
-			db, err := mysqldriver.Connect(instanceID)
+			db, err := mysqldriver.Connect(ctx, instanceID)
 			if err != nil {
-				return &mcp.CallToolResult{}, map[string]any{
-					"instance":   instanceID,
-					"success":    false,
-					"error":      err.Error(),
-					"latency_ms": 0,
+				return &mcp.CallToolResult{}, Result{
+					Instance: instanceID,
+					Error:    err.Error(),
 				}, nil
 			}
-			defer db.Close()
 
 			start := time.Now()
 			if err := db.PingContext(ctx); err != nil {
-				return &mcp.CallToolResult{}, map[string]any{
-					"instance":   instanceID,
-					"success":    false,
-					"error":      err.Error(),
-					"latency_ms": 0,
+				return &mcp.CallToolResult{}, Result{
+					Instance: instanceID,
+					Error:    err.Error(),
 				}, nil
 			}
 			latency := time.Since(start).Milliseconds()
 
-			return &mcp.CallToolResult{}, map[string]any{
-				"instance":   instanceID,
-				"success":    true,
-				"latency_ms": latency,
+			return &mcp.CallToolResult{}, Result{
+				Instance:  instanceID,
+				Success:   true,
+				LatencyMS: latency,
 			}, nil
 		},
 	})
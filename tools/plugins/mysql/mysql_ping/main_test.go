@@ -0,0 +1,28 @@
+package mysql_ping_test
+
+import (
+	"testing"
+
+	"github.com/nicola-strappazzon/argos/internal/testharness"
+	"github.com/nicola-strappazzon/argos/tools/plugins/mysql/mysql_ping"
+)
+
+func TestPing(t *testing.T) {
+	testharness.MySQL(t, "db1")
+
+	var got mysql_ping.Result
+	testharness.Decode(t, testharness.Call(t, "mysql_ping", map[string]any{"db_instance_identifier": "db1"}), &got)
+
+	if !got.Success || got.Instance != "db1" {
+		t.Fatalf("unexpected result: %+v", got)
+	}
+}
+
+func TestPingUnknownInstance(t *testing.T) {
+	var got mysql_ping.Result
+	testharness.Decode(t, testharness.Call(t, "mysql_ping", map[string]any{"db_instance_identifier": "missing"}), &got)
+
+	if got.Success || got.Error == "" {
+		t.Fatalf("expected a failed ping, got %+v", got)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	mysqldriver "github.com/nicola-strappazzon/argos/internal/drivers/mysql"
+	"github.com/nicola-strappazzon/argos/internal/toolerr"
 	"github.com/nicola-strappazzon/argos/tools/registry"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
@@ -32,6 +33,11 @@ type ProcessDetail struct {
 	Statements []Statement `json:"statements"`
 }
 
+type Result struct {
+	Instance string        `json:"instance"`
+	Process  ProcessDetail `json:"process"`
+}
+
 func init() {
 	registry.Add(registry.Property{
 		Name:        "mysql_process_detail",
@@ -50,15 +56,15 @@ func init() {
 			},
 			"required": []string{"db_instance_identifier", "process_id"},
 		},
+		Output: Result{},
 		Function: func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
 			instanceID, _ := args["db_instance_identifier"].(string)
 			processID, _ := args["process_id"].(float64)
 
-			db, err := mysqldriver.Connect(instanceID)
+			db, err := mysqldriver.Connect(ctx, instanceID)
 			if err != nil {
 				return &mcp.CallToolResult{}, nil, err
 			}
-			defer db.Close()
 
 			var p ProcessDetail
 			var (
@@ -73,7 +79,8 @@ func init() {
 				WHERE ID = ?`, int64(processID)).
 				Scan(&p.ID, &p.User, &p.Host, &dbName, &p.Command, &p.TimeSec, &state, &info)
 			if err == sql.ErrNoRows {
-				return &mcp.CallToolResult{}, nil, fmt.Errorf("process %d not found", int64(processID))
+				return &mcp.CallToolResult{}, nil, toolerr.Errorf(toolerr.NotFound, "the process may have finished: list the running ones with mysql_processlist",
+					"process %d not found", int64(processID))
 			}
 			if err != nil {
 				return &mcp.CallToolResult{}, nil, fmt.Errorf("querying process: %w", err)
@@ -115,9 +122,9 @@ func init() {
 				return &mcp.CallToolResult{}, nil, fmt.Errorf("reading statements: %w", err)
 			}
 
-			return &mcp.CallToolResult{}, map[string]any{
-				"instance": instanceID,
-				"process":  p,
+			return &mcp.CallToolResult{}, Result{
+				Instance: instanceID,
+				Process:  p,
 			}, nil
 		},
 	})
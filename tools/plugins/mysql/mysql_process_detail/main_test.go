@@ -0,0 +1,47 @@
+package mysql_process_detail_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/nicola-strappazzon/argos/internal/testharness"
+	"github.com/nicola-strappazzon/argos/tools/plugins/mysql/mysql_process_detail"
+)
+
+func TestProcessDetail(t *testing.T) {
+	mock := testharness.MySQL(t, "db1")
+	mock.ExpectQuery(`FROM information_schema.PROCESSLIST\s+WHERE ID = \?`).
+		WithArgs(10).
+		WillReturnRows(testharness.Rows([]string{"ID", "USER", "HOST", "DB", "COMMAND", "TIME", "STATE", "INFO"},
+			[]any{10, "app", "10.0.0.1:5000", "shop", "Query", 42, "Sending data", "SELECT * FROM orders"},
+		))
+	mock.ExpectQuery(`FROM performance_schema.threads thr\s+JOIN performance_schema.events_statements_history`).
+		WithArgs(10).
+		WillReturnRows(testharness.Rows([]string{"sql_text", "duration_ms", "rows_affected", "rows_sent", "rows_examined", "errors"},
+			[]any{"BEGIN", 0.01, 0, 0, 0, 0},
+			[]any{"SELECT * FROM orders", 41000.5, 0, 10, 500000, 0},
+		))
+
+	var got mysql_process_detail.Result
+	testharness.Decode(t, testharness.Call(t, "mysql_process_detail", map[string]any{
+		"db_instance_identifier": "db1",
+		"process_id":             10,
+	}), &got)
+
+	if got.Process.Info != "SELECT * FROM orders" || len(got.Process.Statements) != 2 {
+		t.Fatalf("unexpected process: %+v", got.Process)
+	}
+	if s := got.Process.Statements[1]; s.RowsExamined != 500000 {
+		t.Fatalf("unexpected statement: %+v", s)
+	}
+}
+
+func TestProcessDetailNotFound(t *testing.T) {
+	mock := testharness.MySQL(t, "db1")
+	mock.ExpectQuery(`FROM information_schema.PROCESSLIST`).WillReturnError(sql.ErrNoRows)
+
+	testharness.Failure(t, testharness.Call(t, "mysql_process_detail", map[string]any{
+		"db_instance_identifier": "db1",
+		"process_id":             99,
+	}), "not_found")
+}
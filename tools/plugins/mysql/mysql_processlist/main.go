@@ -22,6 +22,14 @@ type Process struct {
 	Info    string `json:"info,omitempty"`
 }
 
+type Result struct {
+	Instance    string    `json:"instance"`
+	Processes   []Process `json:"processes"`
+	Total       int       `json:"total"`
+	TotalIdle   int       `json:"total_idle"`
+	IncludeIdle bool      `json:"include_idle"`
+}
+
 func init() {
 	registry.Add(registry.Property{
 		Name:        "mysql_processlist",
@@ -48,17 +56,17 @@ func init() {
 			},
 			"required": []string{"db_instance_identifier"},
 		},
+		Output: Result{},
 		Function: func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
 			instanceID, _ := args["db_instance_identifier"].(string)
 			includeIdle, _ := args["include_idle"].(bool)
 			minTimeSec, _ := args["min_time_sec"].(float64)
 			includeNoStatement, _ := args["include_no_statement"].(bool)
 
-			db, err := mysqldriver.Connect(instanceID)
+			db, err := mysqldriver.Connect(ctx, instanceID)
 			if err != nil {
 				return &mcp.CallToolResult{}, nil, err
 			}
-			defer db.Close()
 
 			rows, err := db.QueryContext(ctx, `
 				SELECT ID, USER, HOST, DB, COMMAND, TIME, STATE, INFO
@@ -108,12 +116,12 @@ func init() {
 				return &mcp.CallToolResult{}, nil, fmt.Errorf("reading rows: %w", err)
 			}
 
-			return &mcp.CallToolResult{}, map[string]any{
-				"instance":     instanceID,
-				"processes":    processes,
-				"total":        len(processes),
-				"total_idle":   totalIdle,
-				"include_idle": includeIdle,
+			return &mcp.CallToolResult{}, Result{
+				Instance:    instanceID,
+				Processes:   processes,
+				Total:       len(processes),
+				TotalIdle:   totalIdle,
+				IncludeIdle: includeIdle,
 			}, nil
 		},
 	})
@@ -0,0 +1,51 @@
+package mysql_processlist_test
+
+import (
+	"testing"
+
+	"github.com/nicola-strappazzon/argos/internal/testharness"
+	"github.com/nicola-strappazzon/argos/tools/plugins/mysql/mysql_processlist"
+)
+
+func processes(t *testing.T) {
+	mock := testharness.MySQL(t, "db1")
+	mock.ExpectQuery(`FROM information_schema.PROCESSLIST\s+WHERE ID <> CONNECTION_ID\(\)`).
+		WillReturnRows(testharness.Rows([]string{"ID", "USER", "HOST", "DB", "COMMAND", "TIME", "STATE", "INFO"},
+			[]any{10, "app", "10.0.0.1:5000", "shop", "Query", 42, "Sending data", "SELECT * FROM orders"},
+			[]any{11, "app", "10.0.0.1:5001", "shop", "Query", 1, "executing", "SELECT 1"},
+			[]any{12, "app", "10.0.0.1:5002", nil, "Sleep", 300, nil, nil},
+			[]any{13, "event_scheduler", "localhost", nil, "Daemon", 9000, "Waiting on empty queue", nil},
+		))
+}
+
+func TestProcesslist(t *testing.T) {
+	processes(t)
+
+	var got mysql_processlist.Result
+	testharness.Decode(t, testharness.Call(t, "mysql_processlist", map[string]any{"db_instance_identifier": "db1"}), &got)
+
+	if got.Total != 2 || got.TotalIdle != 1 {
+		t.Fatalf("expected the 2 running statements and 1 idle connection, got %+v", got)
+	}
+}
+
+func TestProcesslistFilters(t *testing.T) {
+	processes(t)
+
+	var got mysql_processlist.Result
+	testharness.Decode(t, testharness.Call(t, "mysql_processlist", map[string]any{
+		"db_instance_identifier": "db1",
+		"include_idle":           true,
+		"include_no_statement":   true,
+		"min_time_sec":           10,
+	}), &got)
+
+	if got.Total != 3 {
+		t.Fatalf("expected processes 10, 12 and 13, got %+v", got.Processes)
+	}
+	for _, p := range got.Processes {
+		if p.ID == 11 {
+			t.Fatalf("process 11 runs for less than min_time_sec: %+v", p)
+		}
+	}
+}
@@ -19,6 +19,12 @@ type Check struct {
 	Tables      []string `json:"tables,omitempty"`
 }
 
+type Result struct {
+	Instance string  `json:"instance"`
+	Checks   []Check `json:"checks"`
+	Total    int     `json:"total"`
+}
+
 func checkDeprecatedEngine(ctx context.Context, db *sql.DB) (*Check, error) {
 	rows, err := db.QueryContext(ctx, `
 		SELECT TABLE_SCHEMA, TABLE_NAME
@@ -134,18 +140,19 @@ Checks include:
 			},
 			"required": []string{"db_instance_identifier"},
 		},
+		Output: Result{},
 		Function: func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
 			instanceID, _ := args["db_instance_identifier"].(string)
 
-			db, err := mysqldriver.Connect(instanceID)
+			db, err := mysqldriver.Connect(ctx, instanceID)
 			if err != nil {
 				return &mcp.CallToolResult{}, nil, err
 			}
-			defer db.Close()
 
 			type checkFn func() (*Check, error)
 			runners := []checkFn{
 				func() (*Check, error) { return checkDeprecatedEngine(ctx, db) },
+				func() (*Check, error) { return checkMissingPrimaryKey(ctx, db) },
 			}
 
 			var checks []Check
@@ -159,10 +166,10 @@ Checks include:
 				}
 			}
 
-			return &mcp.CallToolResult{}, map[string]any{
-				"instance": instanceID,
-				"checks":   checks,
-				"total":    len(checks),
+			return &mcp.CallToolResult{}, Result{
+				Instance: instanceID,
+				Checks:   checks,
+				Total:    len(checks),
 			}, nil
 		},
 	})
@@ -0,0 +1,57 @@
+package mysql_schema_check_test
+
+import (
+	"testing"
+
+	"github.com/nicola-strappazzon/argos/internal/testharness"
+	"github.com/nicola-strappazzon/argos/tools/plugins/mysql/mysql_schema_check"
+)
+
+func TestSchemaCheck(t *testing.T) {
+	mock := testharness.MySQL(t, "db1")
+	mock.ExpectQuery(`WHERE ENGINE = 'MyISAM'`).
+		WillReturnRows(testharness.Rows([]string{"TABLE_SCHEMA", "TABLE_NAME"},
+			[]any{"legacy", "sessions"},
+		))
+	mock.ExpectQuery(`CONSTRAINT_TYPE = 'PRIMARY KEY'`).
+		WillReturnRows(testharness.Rows([]string{"TABLE_SCHEMA", "TABLE_NAME"}))
+
+	var got mysql_schema_check.Result
+	testharness.Decode(t, testharness.Call(t, "mysql_schema_check", map[string]any{"db_instance_identifier": "db1"}), &got)
+
+	status := map[string]mysql_schema_check.Check{}
+	for _, c := range got.Checks {
+		status[c.Name] = c
+	}
+
+	if c := status["deprecated_table_engine"]; c.Status != "warning" || len(c.Tables) != 1 || c.Tables[0] != "legacy.sessions" {
+		t.Fatalf("unexpected engine check: %+v", c)
+	}
+	if c := status["missing_primary_key"]; c.Status != "ok" {
+		t.Fatalf("unexpected primary key check: %+v", c)
+	}
+}
+
+func TestSchemaCheckMissingPrimaryKey(t *testing.T) {
+	mock := testharness.MySQL(t, "db1")
+	mock.ExpectQuery(`WHERE ENGINE = 'MyISAM'`).
+		WillReturnRows(testharness.Rows([]string{"TABLE_SCHEMA", "TABLE_NAME"}))
+	mock.ExpectQuery(`CONSTRAINT_TYPE = 'PRIMARY KEY'`).
+		WillReturnRows(testharness.Rows([]string{"TABLE_SCHEMA", "TABLE_NAME"},
+			[]any{"shop", "audit_log"},
+			[]any{"shop", "events"},
+		))
+
+	var got mysql_schema_check.Result
+	testharness.Decode(t, testharness.Call(t, "mysql_schema_check", map[string]any{"db_instance_identifier": "db1"}), &got)
+
+	for _, c := range got.Checks {
+		if c.Name == "missing_primary_key" {
+			if c.Status != "warning" || len(c.Tables) != 2 || c.Tables[1] != "shop.events" {
+				t.Fatalf("unexpected primary key check: %+v", c)
+			}
+			return
+		}
+	}
+	t.Fatalf("the missing primary key check did not run: %+v", got.Checks)
+}
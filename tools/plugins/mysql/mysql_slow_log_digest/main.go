@@ -0,0 +1,122 @@
+package mysql_slow_log_digest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	logdigest "github.com/nicola-strappazzon/argos/internal/digest"
+	"github.com/nicola-strappazzon/argos/internal/slowlog"
+	"github.com/nicola-strappazzon/argos/internal/timerange"
+	"github.com/nicola-strappazzon/argos/internal/toolerr"
+	"github.com/nicola-strappazzon/argos/tools/registry"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type Result struct {
+	LogFilePath string `json:"log_file_path"`
+	// Queries is the number of queries digested, Skipped those the time
+	// window or database filter excluded.
+	Queries      int             `json:"queries"`
+	Skipped      int             `json:"skipped"`
+	Unique       int             `json:"unique_fingerprints"`
+	QueryTimeSec float64         `json:"total_query_time_sec"`
+	FirstSeen    string          `json:"first_seen,omitempty"`
+	LastSeen     string          `json:"last_seen,omitempty"`
+	OrderBy      string          `json:"order_by"`
+	Classes      []slowlog.Class `json:"classes"`
+}
+
+func init() {
+	registry.Add(registry.Property{
+		Name:        "mysql_slow_log_digest",
+		Description: "Parse a downloaded MySQL slow query log (e.g. from aws_rds_log_download or aws_rds_cloudwatch_log_download) and digest it by query fingerprint, like pt-query-digest but without Percona Toolkit: per fingerprint the count, share of the total time, query time (total/avg/min/max/p95), lock time, rows sent and examined, databases, users, first/last seen and the slowest sample query. Optionally restricted to a time window and a database.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"log_file_path": map[string]any{
+					"type":        "string",
+					"description": "Absolute path to the slow query log file to analyze.",
+				},
+				"since": map[string]any{
+					"type":        "string",
+					"description": "Only digest queries logged at or after this time, RFC 3339 (e.g. 2024-05-14T09:00:00Z).",
+				},
+				"until": map[string]any{
+					"type":        "string",
+					"description": "Only digest queries logged at or before this time, RFC 3339.",
+				},
+				"database": map[string]any{
+					"type":        "string",
+					"description": "Only digest queries run in this database.",
+				},
+				"order_by": map[string]any{
+					"type":        "string",
+					"enum":        slowlog.Orders(),
+					"description": "Metric the fingerprints are ranked by (default: total_time).",
+				},
+				"limit": map[string]any{
+					"type":        "integer",
+					"description": "Maximum number of fingerprints to return (default: 20).",
+				},
+			},
+			"required": []string{"log_file_path"},
+		},
+		Output:  Result{},
+		Timeout: 10 * time.Minute,
+		Function: func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
+			logFilePath, _ := args["log_file_path"].(string)
+			database, _ := args["database"].(string)
+
+			orderBy, _ := args["order_by"].(string)
+			if orderBy == "" {
+				orderBy = slowlog.ByTotalTime
+			}
+
+			limit := 20
+			if l, ok := args["limit"].(float64); ok && l > 0 {
+				limit = int(l)
+			}
+
+			filter := slowlog.Filter{Database: database}
+			since, until, err := timerange.ParseNames(args, "since", "until", 0)
+			if err != nil {
+				return &mcp.CallToolResult{}, nil, err
+			}
+			filter.Since, filter.Until = since, until
+
+			f, err := os.Open(logFilePath)
+			if os.IsNotExist(err) {
+				return &mcp.CallToolResult{}, nil, toolerr.Errorf(toolerr.NotFound, "download the log first with aws_rds_log_download or aws_rds_cloudwatch_log_download and pass its output_path",
+					"log file not found: %s", logFilePath)
+			}
+			if err != nil {
+				return &mcp.CallToolResult{}, nil, err
+			}
+			defer f.Close()
+
+			digest := slowlog.NewDigest(filter)
+			if err := slowlog.Parse(logdigest.NewReader(ctx, f), digest.Add); err != nil {
+				return &mcp.CallToolResult{}, nil, fmt.Errorf("reading %s: %w", logFilePath, err)
+			}
+
+			result := Result{
+				LogFilePath:  logFilePath,
+				Queries:      digest.Queries,
+				Skipped:      digest.Skipped,
+				Unique:       digest.Unique(),
+				QueryTimeSec: digest.QueryTime,
+				OrderBy:      orderBy,
+				Classes:      digest.Classes(orderBy, limit),
+			}
+			if !digest.First.IsZero() {
+				result.FirstSeen = digest.First.Format(time.RFC3339)
+				result.LastSeen = digest.Last.Format(time.RFC3339)
+			}
+
+			return &mcp.CallToolResult{}, result, nil
+		},
+	})
+}
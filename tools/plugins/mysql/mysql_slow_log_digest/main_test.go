@@ -0,0 +1,49 @@
+package mysql_slow_log_digest_test
+
+import (
+	"testing"
+
+	"github.com/nicola-strappazzon/argos/internal/testharness"
+	"github.com/nicola-strappazzon/argos/internal/toolerr"
+	"github.com/nicola-strappazzon/argos/tools/plugins/mysql/mysql_slow_log_digest"
+)
+
+const slowLog = "../../../../internal/slowlog/testdata/slow-8.0.log"
+
+func TestDigest(t *testing.T) {
+	var got mysql_slow_log_digest.Result
+	testharness.Decode(t, testharness.Call(t, "mysql_slow_log_digest", map[string]any{
+		"log_file_path": slowLog,
+		"order_by":      "count",
+		"limit":         2,
+	}), &got)
+
+	if got.Queries != 6 || got.Unique != 4 || got.QueryTimeSec != 21.5 || len(got.Classes) != 2 {
+		t.Fatalf("unexpected digest: %+v", got)
+	}
+	if got.FirstSeen != "2024-05-14T09:00:01Z" || got.LastSeen != "2024-05-14T10:30:00Z" {
+		t.Errorf("unexpected time range: %s to %s", got.FirstSeen, got.LastSeen)
+	}
+	if c := got.Classes[0]; c.Fingerprint != "select * from accounts where email = ?" || c.Count != 3 || c.QueryTime.P95 != 2.5 {
+		t.Errorf("unexpected top class: %+v", c)
+	}
+}
+
+func TestFilters(t *testing.T) {
+	var got mysql_slow_log_digest.Result
+	testharness.Decode(t, testharness.Call(t, "mysql_slow_log_digest", map[string]any{
+		"log_file_path": slowLog,
+		"since":         "2024-05-14T09:15:00Z",
+		"until":         "2024-05-14T10:00:00Z",
+		"database":      "shop",
+	}), &got)
+
+	if got.Queries != 2 || got.Skipped != 4 || got.Unique != 2 {
+		t.Errorf("expected the 2 shop queries of the window, got %+v", got)
+	}
+}
+
+func TestInvalidInput(t *testing.T) {
+	testharness.Failure(t, testharness.Call(t, "mysql_slow_log_digest", map[string]any{"log_file_path": "/nonexistent/slow.log"}), toolerr.NotFound)
+	testharness.Failure(t, testharness.Call(t, "mysql_slow_log_digest", map[string]any{"log_file_path": slowLog, "since": "today"}), toolerr.InvalidInput)
+}
@@ -0,0 +1,27 @@
+package mysql_status_test
+
+import (
+	"testing"
+
+	"github.com/nicola-strappazzon/argos/internal/testharness"
+	"github.com/nicola-strappazzon/argos/tools/plugins/mysql/mysql_status"
+)
+
+func TestStatus(t *testing.T) {
+	mock := testharness.MySQL(t, "db1")
+	mock.ExpectQuery(`^SHOW GLOBAL STATUS LIKE 'Threads%'$`).
+		WillReturnRows(testharness.Rows([]string{"Variable_name", "Value"},
+			[]any{"Threads_connected", "12"},
+			[]any{"Threads_running", "3"},
+		))
+
+	var got mysql_status.Result
+	testharness.Decode(t, testharness.Call(t, "mysql_status", map[string]any{
+		"db_instance_identifier": "db1",
+		"like":                   "Threads%",
+	}), &got)
+
+	if got.Total != 2 || got.Status["Threads_running"] != "3" {
+		t.Fatalf("unexpected result: %+v", got)
+	}
+}
@@ -19,6 +19,16 @@ type ForeignKey struct {
 	OnDelete         string `json:"on_delete"`
 }
 
+type Result struct {
+	Instance            string       `json:"instance"`
+	Database            string       `json:"database"`
+	Table               string       `json:"table"`
+	OutgoingForeignKeys []ForeignKey `json:"outgoing_foreign_keys"`
+	IncomingForeignKeys []ForeignKey `json:"incoming_foreign_keys"`
+	TotalOutgoing       int          `json:"total_outgoing"`
+	TotalIncoming       int          `json:"total_incoming"`
+}
+
 func init() {
 	registry.Add(registry.Property{
 		Name:        "mysql_table_foreign_keys",
@@ -41,16 +51,16 @@ func init() {
 			},
 			"required": []string{"db_instance_identifier", "database", "table"},
 		},
+		Output: Result{},
 		Function: func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
 			instanceID, _ := args["db_instance_identifier"].(string)
 			database, _ := args["database"].(string)
 			table, _ := args["table"].(string)
 
-			db, err := mysqldriver.Connect(instanceID)
+			db, err := mysqldriver.Connect(ctx, instanceID)
 			if err != nil {
 				return &mcp.CallToolResult{}, nil, err
 			}
-			defer db.Close()
 
 			// Outgoing: this table references other tables.
 			outRows, err := db.QueryContext(ctx, `
@@ -121,14 +131,14 @@ func init() {
 				return &mcp.CallToolResult{}, nil, err
 			}
 
-			return &mcp.CallToolResult{}, map[string]any{
-				"instance":              instanceID,
-				"database":              database,
-				"table":                 table,
-				"outgoing_foreign_keys": outgoing,
-				"incoming_foreign_keys": incoming,
-				"total_outgoing":        len(outgoing),
-				"total_incoming":        len(incoming),
+			return &mcp.CallToolResult{}, Result{
+				Instance:            instanceID,
+				Database:            database,
+				Table:               table,
+				OutgoingForeignKeys: outgoing,
+				IncomingForeignKeys: incoming,
+				TotalOutgoing:       len(outgoing),
+				TotalIncoming:       len(incoming),
 			}, nil
 		},
 	})
@@ -0,0 +1,39 @@
+package mysql_table_foreign_keys_test
+
+import (
+	"testing"
+
+	"github.com/nicola-strappazzon/argos/internal/testharness"
+	"github.com/nicola-strappazzon/argos/tools/plugins/mysql/mysql_table_foreign_keys"
+)
+
+var columns = []string{"constraint_name", "column_name", "table_name", "referenced_column_name", "update_rule", "delete_rule"}
+
+func TestTableForeignKeys(t *testing.T) {
+	mock := testharness.MySQL(t, "db1")
+	mock.ExpectQuery(`AND kcu.table_name = \?\s+AND kcu.referenced_table_name IS NOT NULL`).
+		WithArgs("shop", "orders").
+		WillReturnRows(testharness.Rows(columns,
+			[]any{"fk_orders_customer", "customer_id", "customers", "id", "RESTRICT", "CASCADE"},
+		))
+	mock.ExpectQuery(`AND kcu.referenced_table_name = \?`).
+		WithArgs("shop", "orders").
+		WillReturnRows(testharness.Rows(columns,
+			[]any{"fk_items_order", "order_id", "order_items", "id", "RESTRICT", "CASCADE"},
+			[]any{"fk_refunds_order", "order_id", "refunds", "id", "RESTRICT", "RESTRICT"},
+		))
+
+	var got mysql_table_foreign_keys.Result
+	testharness.Decode(t, testharness.Call(t, "mysql_table_foreign_keys", map[string]any{
+		"db_instance_identifier": "db1",
+		"database":               "shop",
+		"table":                  "orders",
+	}), &got)
+
+	if got.TotalOutgoing != 1 || got.TotalIncoming != 2 {
+		t.Fatalf("unexpected totals: %+v", got)
+	}
+	if fk := got.OutgoingForeignKeys[0]; fk.ReferencedTable != "customers" || fk.OnDelete != "CASCADE" {
+		t.Fatalf("unexpected outgoing foreign key: %+v", fk)
+	}
+}
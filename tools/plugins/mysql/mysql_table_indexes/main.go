@@ -27,6 +27,14 @@ type Index struct {
 	SizeMB      float64       `json:"size_mb"`
 }
 
+type Result struct {
+	Instance string  `json:"instance"`
+	Database string  `json:"database"`
+	Table    string  `json:"table"`
+	Indexes  []Index `json:"indexes"`
+	Total    int     `json:"total"`
+}
+
 func init() {
 	registry.Add(registry.Property{
 		Name:        "mysql_table_indexes",
@@ -49,16 +57,16 @@ func init() {
 			},
 			"required": []string{"db_instance_identifier", "database", "table"},
 		},
+		Output: Result{},
 		Function: func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
 			instanceID, _ := args["db_instance_identifier"].(string)
 			database, _ := args["database"].(string)
 			table, _ := args["table"].(string)
 
-			db, err := mysqldriver.Connect(instanceID)
+			db, err := mysqldriver.Connect(ctx, instanceID)
 			if err != nil {
 				return &mcp.CallToolResult{}, nil, err
 			}
-			defer db.Close()
 
 			// Fetch index columns from information_schema.statistics.
 			colQuery := `
@@ -154,12 +162,12 @@ func init() {
 				indexes = append(indexes, *indexMap[name])
 			}
 
-			return &mcp.CallToolResult{}, map[string]any{
-				"instance": instanceID,
-				"database": database,
-				"table":    table,
-				"indexes":  indexes,
-				"total":    len(indexes),
+			return &mcp.CallToolResult{}, Result{
+				Instance: instanceID,
+				Database: database,
+				Table:    table,
+				Indexes:  indexes,
+				Total:    len(indexes),
 			}, nil
 		},
 	})
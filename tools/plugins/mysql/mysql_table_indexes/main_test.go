@@ -0,0 +1,43 @@
+package mysql_table_indexes_test
+
+import (
+	"testing"
+
+	"github.com/nicola-strappazzon/argos/internal/testharness"
+	"github.com/nicola-strappazzon/argos/tools/plugins/mysql/mysql_table_indexes"
+)
+
+func TestTableIndexes(t *testing.T) {
+	mock := testharness.MySQL(t, "db1")
+	mock.ExpectQuery(`FROM information_schema.statistics`).
+		WithArgs("shop", "orders").
+		WillReturnRows(testharness.Rows(
+			[]string{"index_name", "index_type", "non_unique", "is_visible", "cardinality", "seq_in_index", "column_name", "sub_part", "nullable"},
+			[]any{"PRIMARY", "BTREE", 0, "YES", 1000, 1, "id", 0, ""},
+			[]any{"idx_customer_date", "BTREE", 1, "YES", 50, 1, "customer_id", 0, ""},
+			[]any{"idx_customer_date", "BTREE", 1, "YES", 900, 2, "created_at", 0, "YES"},
+		))
+	mock.ExpectQuery(`FROM mysql.innodb_index_stats`).
+		WithArgs("shop", "orders").
+		WillReturnRows(testharness.Rows([]string{"index_name", "size_mb"},
+			[]any{"PRIMARY", 16.0},
+			[]any{"idx_customer_date", 4.5},
+		))
+
+	var got mysql_table_indexes.Result
+	testharness.Decode(t, testharness.Call(t, "mysql_table_indexes", map[string]any{
+		"db_instance_identifier": "db1",
+		"database":               "shop",
+		"table":                  "orders",
+	}), &got)
+
+	if got.Total != 2 {
+		t.Fatalf("expected 2 indexes, got %+v", got)
+	}
+	if pk := got.Indexes[0]; pk.Name != "PRIMARY" || !pk.Unique || pk.SizeMB != 16 {
+		t.Fatalf("unexpected primary key: %+v", pk)
+	}
+	if idx := got.Indexes[1]; idx.Unique || len(idx.Columns) != 2 || !idx.Columns[1].Nullable || idx.SizeMB != 4.5 {
+		t.Fatalf("unexpected secondary index: %+v", idx)
+	}
+}
@@ -28,6 +28,15 @@ type Table struct {
 	UpdatedAt     string  `json:"updated_at,omitempty"`
 }
 
+type Result struct {
+	Instance    string  `json:"instance"`
+	Database    string  `json:"database"`
+	Tables      []Table `json:"tables"`
+	Total       int     `json:"total"`
+	TotalSizeMB float64 `json:"total_size_mb"`
+	TotalFreeMB float64 `json:"total_free_mb"`
+}
+
 func init() {
 	registry.Add(registry.Property{
 		Name:        "mysql_tables",
@@ -46,15 +55,15 @@ func init() {
 			},
 			"required": []string{"db_instance_identifier", "database"},
 		},
+		Output: Result{},
 		Function: func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
 			instanceID, _ := args["db_instance_identifier"].(string)
 			database, _ := args["database"].(string)
 
-			db, err := mysqldriver.Connect(instanceID)
+			db, err := mysqldriver.Connect(ctx, instanceID)
 			if err != nil {
 				return &mcp.CallToolResult{}, nil, err
 			}
-			defer db.Close()
 
 			query := `
 				SELECT
@@ -107,13 +116,13 @@ func init() {
 				return &mcp.CallToolResult{}, nil, fmt.Errorf("reading rows: %w", err)
 			}
 
-			return &mcp.CallToolResult{}, map[string]any{
-				"instance":      instanceID,
-				"database":      database,
-				"tables":        tables,
-				"total":         len(tables),
-				"total_size_mb": totalMB,
-				"total_free_mb": totalFreeMB,
+			return &mcp.CallToolResult{}, Result{
+				Instance:    instanceID,
+				Database:    database,
+				Tables:      tables,
+				Total:       len(tables),
+				TotalSizeMB: totalMB,
+				TotalFreeMB: totalFreeMB,
 			}, nil
 		},
 	})
@@ -0,0 +1,33 @@
+package mysql_tables_test
+
+import (
+	"testing"
+
+	"github.com/nicola-strappazzon/argos/internal/testharness"
+	"github.com/nicola-strappazzon/argos/tools/plugins/mysql/mysql_tables"
+)
+
+func TestTables(t *testing.T) {
+	mock := testharness.MySQL(t, "db1")
+	mock.ExpectQuery(`FROM information_schema.tables t\s+LEFT JOIN information_schema.collation_character_set_applicability`).
+		WithArgs("shop").
+		WillReturnRows(testharness.Rows(
+			[]string{"table_name", "engine", "row_format", "charset", "collation", "row_count", "data_mb", "index_mb",
+				"total_mb", "free_mb", "frag_pct", "auto_increment", "comment", "created_at", "updated_at"},
+			[]any{"orders", "InnoDB", "Dynamic", "utf8mb4", "utf8mb4_0900_ai_ci", 1000, 10.0, 5.0, 15.0, 1.0, 6.3, 1001, "", "2024-01-01T00:00:00Z", ""},
+			[]any{"customers", "InnoDB", "Dynamic", "utf8mb4", "utf8mb4_0900_ai_ci", 10, 0.5, 0.25, 0.75, 0.0, 0.0, 0, "people", "", ""},
+		))
+
+	var got mysql_tables.Result
+	testharness.Decode(t, testharness.Call(t, "mysql_tables", map[string]any{
+		"db_instance_identifier": "db1",
+		"database":               "shop",
+	}), &got)
+
+	if got.Total != 2 || got.TotalSizeMB != 15.75 || got.TotalFreeMB != 1 {
+		t.Fatalf("unexpected totals: %+v", got)
+	}
+	if orders := got.Tables[0]; orders.Name != "orders" || orders.AutoIncrement != 1001 || orders.FragPct != 6.3 {
+		t.Fatalf("unexpected orders table: %+v", orders)
+	}
+}
@@ -10,6 +10,12 @@ import (
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
+type Result struct {
+	Instance  string            `json:"instance"`
+	Variables map[string]string `json:"variables"`
+	Total     int               `json:"total"`
+}
+
 func init() {
 	registry.Add(registry.Property{
 		Name:        "mysql_variables",
@@ -28,15 +34,15 @@ func init() {
 			},
 			"required": []string{"db_instance_identifier"},
 		},
+		Output: Result{},
 		Function: func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
 			instanceID, _ := args["db_instance_identifier"].(string)
 			like, _ := args["like"].(string)
 
-			db, err := mysqldriver.Connect(instanceID)
+			db, err := mysqldriver.Connect(ctx, instanceID)
 			if err != nil {
 				return &mcp.CallToolResult{}, nil, err
 			}
-			defer db.Close()
 
 			query := "SHOW GLOBAL VARIABLES"
 			if like != "" {
@@ -62,10 +68,10 @@ func init() {
 				return &mcp.CallToolResult{}, nil, fmt.Errorf("reading rows: %w", err)
 			}
 
-			return &mcp.CallToolResult{}, map[string]any{
-				"instance":  instanceID,
-				"variables": variables,
-				"total":     len(variables),
+			return &mcp.CallToolResult{}, Result{
+				Instance:  instanceID,
+				Variables: variables,
+				Total:     len(variables),
 			}, nil
 		},
 	})
@@ -0,0 +1,24 @@
+package mysql_variables_test
+
+import (
+	"testing"
+
+	"github.com/nicola-strappazzon/argos/internal/testharness"
+	"github.com/nicola-strappazzon/argos/tools/plugins/mysql/mysql_variables"
+)
+
+func TestVariables(t *testing.T) {
+	mock := testharness.MySQL(t, "db1")
+	mock.ExpectQuery(`^SHOW GLOBAL VARIABLES$`).
+		WillReturnRows(testharness.Rows([]string{"Variable_name", "Value"},
+			[]any{"max_connections", "151"},
+			[]any{"innodb_buffer_pool_size", "134217728"},
+		))
+
+	var got mysql_variables.Result
+	testharness.Decode(t, testharness.Call(t, "mysql_variables", map[string]any{"db_instance_identifier": "db1"}), &got)
+
+	if got.Total != 2 || got.Variables["max_connections"] != "151" {
+		t.Fatalf("unexpected result: %+v", got)
+	}
+}
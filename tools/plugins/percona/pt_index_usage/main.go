@@ -7,19 +7,31 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/nicola-strappazzon/argos/internal/config/credentials"
 	"github.com/nicola-strappazzon/argos/internal/config/mysql"
+	"github.com/nicola-strappazzon/argos/internal/toolerr"
+	"github.com/nicola-strappazzon/argos/internal/workspace"
 	"github.com/nicola-strappazzon/argos/tools/registry"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
-const outputDir = "/tmp/argos/pt-index-usage"
+type Result struct {
+	Instance    string `json:"instance"`
+	LogFilePath string `json:"log_file_path"`
+	Database    string `json:"database"`
+	ReportPath  string `json:"report_path"`
+	// ResourceURI reads the report as an MCP resource.
+	ResourceURI string `json:"resource_uri"`
+	SizeKB      int64  `json:"size_kb"`
+}
 
 func init() {
 	registry.Add(registry.Property{
 		Name:        "pt_index_usage",
-		Description: "Run pt-index-usage on a downloaded slow query log file to find unused indexes. Saves the report to /tmp/argos/pt-index-usage/. The slow query log can be obtained with aws_rds_log_download.",
+		Description: "Run pt-index-usage on a downloaded slow query log file to find unused indexes. Saves the report to a new directory of the workspace, under <instance>/pt_index_usage/. The slow query log can be obtained with aws_rds_log_download or, when exported to CloudWatch Logs, aws_rds_cloudwatch_log_download.",
 		InputSchema: map[string]any{
 			"type": "object",
 			"properties": map[string]any{
@@ -29,7 +41,7 @@ func init() {
 				},
 				"log_file_path": map[string]any{
 					"type":        "string",
-					"description": "Absolute path to the slow query log file to analyze (e.g. /tmp/argos/aws_rds_logs/instance/slowquery/mysql-slowquery.log).",
+					"description": "Absolute path to the slow query log file to analyze (e.g. the output_path of aws_rds_log_download or aws_rds_cloudwatch_log_download).",
 				},
 				"database": map[string]any{
 					"type":        "string",
@@ -38,29 +50,38 @@ func init() {
 			},
 			"required": []string{"db_instance_identifier", "log_file_path"},
 		},
+		Output:  Result{},
+		Timeout: 10 * time.Minute,
+		Writes:  true,
 		Function: func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
 			instanceID, _ := args["db_instance_identifier"].(string)
 			logFilePath, _ := args["log_file_path"].(string)
 			database, _ := args["database"].(string)
 
 			if _, err := os.Stat(logFilePath); os.IsNotExist(err) {
-				return &mcp.CallToolResult{}, nil, fmt.Errorf("log file not found: %s", logFilePath)
+				return &mcp.CallToolResult{}, nil, toolerr.Errorf(toolerr.NotFound, "download the log first with aws_rds_log_download or aws_rds_cloudwatch_log_download and pass its output_path",
+					"log file not found: %s", logFilePath)
 			}
 
-			creds, err := mysqlconfig.Load(instanceID)
+			creds, err := mysqlconfig.Load(ctx, instanceID)
 			if err != nil {
 				return &mcp.CallToolResult{}, nil, err
 			}
+			if creds.Auth == credentials.AuthIAM {
+				return &mcp.CallToolResult{}, nil, toolerr.Errorf(toolerr.Unsupported, "configure a password for the instance to use this tool",
+					"pt-index-usage does not support IAM authentication (section [%s] uses auth=iam)", instanceID)
+			}
 
-			if err := os.MkdirAll(outputDir, 0755); err != nil {
-				return &mcp.CallToolResult{}, nil, fmt.Errorf("creating output directory: %w", err)
+			runDir, err := workspace.NewRun(instanceID, "pt_index_usage")
+			if err != nil {
+				return &mcp.CallToolResult{}, nil, err
 			}
 
-			reportName := fmt.Sprintf("%s.txt", strings.ReplaceAll(instanceID, "-", "_"))
-			reportPath := filepath.Join(outputDir, reportName)
+			reportPath := filepath.Join(runDir, "report.txt")
 
 			reportFile, err := os.Create(reportPath)
 			if err != nil {
+				os.RemoveAll(runDir)
 				return &mcp.CallToolResult{}, nil, fmt.Errorf("creating report file: %w", err)
 			}
 			defer reportFile.Close()
@@ -81,7 +102,9 @@ func init() {
 			var stderr strings.Builder
 			cmd.Stderr = &stderr
 
+			// A failed run leaves no empty report behind in the workspace.
 			if err := cmd.Run(); err != nil {
+				os.RemoveAll(runDir)
 				return &mcp.CallToolResult{}, nil, fmt.Errorf("pt-index-usage failed: %w — %s", err, stderr.String())
 			}
 
@@ -91,12 +114,13 @@ func init() {
 				sizeKB = info.Size() / 1024
 			}
 
-			return &mcp.CallToolResult{}, map[string]any{
-				"instance":      instanceID,
-				"log_file_path": logFilePath,
-				"database":      database,
-				"report_path":   reportPath,
-				"size_kb":       sizeKB,
+			return &mcp.CallToolResult{}, Result{
+				Instance:    instanceID,
+				LogFilePath: logFilePath,
+				Database:    database,
+				ReportPath:  reportPath,
+				ResourceURI: workspace.URI(reportPath),
+				SizeKB:      sizeKB,
 			}, nil
 		},
 	})
@@ -0,0 +1,102 @@
+package pt_index_usage_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nicola-strappazzon/argos/internal/testharness"
+	"github.com/nicola-strappazzon/argos/internal/workspace"
+	"github.com/nicola-strappazzon/argos/tools/plugins/percona/pt_index_usage"
+)
+
+// fakeIndexUsage puts a pt-index-usage running script first in PATH and the
+// credentials of db1 in the environment.
+func fakeIndexUsage(t *testing.T, script string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "pt-index-usage"), []byte("#!/bin/sh\n"+script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("ARGOS_CREDENTIAL_PROVIDERS", "env")
+	t.Setenv("ARGOS_MYSQL_DB1_HOST", "db1.example.com")
+	t.Setenv("ARGOS_MYSQL_DB1_USER", "admin")
+	t.Setenv("ARGOS_MYSQL_DB1_PASSWORD", "secret")
+	t.Setenv("ARGOS_MYSQL_DB1_PORT", "3307")
+}
+
+// slowLog writes an empty slow query log outside the workspace.
+func slowLog(t *testing.T) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "mysql-slowquery.log")
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestIndexUsage(t *testing.T) {
+	fakeIndexUsage(t, `echo "$@"; echo "ALTER TABLE shop.orders DROP KEY idx_status;"`)
+	log := slowLog(t)
+
+	var got pt_index_usage.Result
+	testharness.Decode(t, testharness.Call(t, "pt_index_usage", map[string]any{
+		"db_instance_identifier": "db1",
+		"log_file_path":          log,
+		"database":               "shop",
+	}), &got)
+
+	if got.Instance != "db1" || got.Database != "shop" || filepath.Base(got.ReportPath) != "report.txt" ||
+		got.ResourceURI != workspace.URI(got.ReportPath) || !strings.HasPrefix(got.ResourceURI, "argos://artifacts/db1/pt_index_usage/") {
+		t.Fatalf("unexpected result: %+v", got)
+	}
+	report, err := os.ReadFile(got.ReportPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "--host=db1.example.com --port=3307 --user=admin --password=secret --databases=shop " + log + "\n" +
+		"ALTER TABLE shop.orders DROP KEY idx_status;\n"
+	if string(report) != want {
+		t.Errorf("unexpected report: %s", report)
+	}
+}
+
+func TestIndexUsageFailure(t *testing.T) {
+	fakeIndexUsage(t, `echo "DBI connect failed: Access denied" >&2; exit 1`)
+
+	text := testharness.Failure(t, testharness.Call(t, "pt_index_usage", map[string]any{"db_instance_identifier": "db1", "log_file_path": slowLog(t)}), "internal")
+	if !strings.Contains(text, "Access denied") {
+		t.Errorf("expected the error output in the failure: %s", text)
+	}
+	if files, err := workspace.List("db1", "pt_index_usage"); err != nil || len(files) != 0 {
+		t.Errorf("expected no report left in the workspace, got %v, %v", files, err)
+	}
+}
+
+func TestIndexUsageMissingBinary(t *testing.T) {
+	fakeIndexUsage(t, "exit 0")
+	t.Setenv("PATH", t.TempDir())
+
+	text := testharness.Failure(t, testharness.Call(t, "pt_index_usage", map[string]any{"db_instance_identifier": "db1", "log_file_path": slowLog(t)}), "misconfigured")
+	if !strings.Contains(text, "install Percona Toolkit") {
+		t.Errorf("expected a hint to install the toolkit: %s", text)
+	}
+	if files, err := workspace.List("db1", "pt_index_usage"); err != nil || len(files) != 0 {
+		t.Errorf("expected no report left in the workspace, got %v, %v", files, err)
+	}
+}
+
+func TestIndexUsageIAM(t *testing.T) {
+	fakeIndexUsage(t, "exit 0")
+	t.Setenv("ARGOS_MYSQL_DB1_AUTH", "iam")
+
+	testharness.Failure(t, testharness.Call(t, "pt_index_usage", map[string]any{"db_instance_identifier": "db1", "log_file_path": slowLog(t)}), "unsupported_engine")
+}
+
+func TestMissingLogFile(t *testing.T) {
+	testharness.Failure(t, testharness.Call(t, "pt_index_usage", map[string]any{"db_instance_identifier": "db1", "log_file_path": "/nonexistent/slow.log"}), "not_found")
+}
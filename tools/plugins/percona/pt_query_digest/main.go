@@ -7,45 +7,58 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/nicola-strappazzon/argos/internal/toolerr"
+	"github.com/nicola-strappazzon/argos/internal/workspace"
 	"github.com/nicola-strappazzon/argos/tools/registry"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
-const outputDir = "/tmp/argos/pt-query-digest"
+type Result struct {
+	LogFilePath string `json:"log_file_path"`
+	ReportPath  string `json:"report_path"`
+	// ResourceURI reads the report as an MCP resource.
+	ResourceURI string `json:"resource_uri"`
+	SizeKB      int64  `json:"size_kb"`
+}
 
 func init() {
 	registry.Add(registry.Property{
 		Name:        "pt_query_digest",
-		Description: "Run pt-query-digest on a downloaded RDS slow query log file and save the report to /tmp/argos/pt-query-digest.",
+		Description: "Run pt-query-digest on a downloaded RDS slow query log file and save the report to a new directory of the workspace, under <instance>/pt_query_digest/ for a log downloaded with aws_rds_log_download or aws_rds_cloudwatch_log_download, local/pt_query_digest/ otherwise.",
 		InputSchema: map[string]any{
 			"type": "object",
 			"properties": map[string]any{
 				"log_file_path": map[string]any{
 					"type":        "string",
-					"description": "Absolute path to the slow query log file to analyze (e.g. /tmp/aws_rds_logs/instance/slowquery/mysql-slowquery.log).",
+					"description": "Absolute path to the slow query log file to analyze (e.g. the output_path of aws_rds_log_download or aws_rds_cloudwatch_log_download).",
 				},
 			},
 			"required": []string{"log_file_path"},
 		},
+		Output:  Result{},
+		Timeout: 10 * time.Minute,
+		Writes:  true,
 		Function: func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
 			logFilePath, _ := args["log_file_path"].(string)
 
 			if _, err := os.Stat(logFilePath); os.IsNotExist(err) {
-				return &mcp.CallToolResult{}, nil, fmt.Errorf("log file not found: %s", logFilePath)
+				return &mcp.CallToolResult{}, nil, toolerr.Errorf(toolerr.NotFound, "download the log first with aws_rds_log_download or aws_rds_cloudwatch_log_download and pass its output_path",
+					"log file not found: %s", logFilePath)
 			}
 
-			if err := os.MkdirAll(outputDir, 0755); err != nil {
-				return &mcp.CallToolResult{}, nil, fmt.Errorf("creating output directory: %w", err)
+			runDir, err := workspace.NewRun(workspace.InstanceOf(logFilePath), "pt_query_digest")
+			if err != nil {
+				return &mcp.CallToolResult{}, nil, err
 			}
 
-			// Derive report filename from log file path.
-			reportName := strings.ReplaceAll(filepath.Base(logFilePath), "/", "_") + ".txt"
-			reportPath := filepath.Join(outputDir, reportName)
+			reportPath := filepath.Join(runDir, filepath.Base(logFilePath)+".txt")
 
 			reportFile, err := os.Create(reportPath)
 			if err != nil {
+				os.RemoveAll(runDir)
 				return &mcp.CallToolResult{}, nil, fmt.Errorf("creating report file: %w", err)
 			}
 			defer reportFile.Close()
@@ -55,7 +68,9 @@ func init() {
 			var stderr strings.Builder
 			cmd.Stderr = &stderr
 
+			// A failed run leaves no empty report behind in the workspace.
 			if err := cmd.Run(); err != nil {
+				os.RemoveAll(runDir)
 				return &mcp.CallToolResult{}, nil, fmt.Errorf("pt-query-digest failed: %w — %s", err, stderr.String())
 			}
 
@@ -65,10 +80,11 @@ func init() {
 				sizeKB = info.Size() / 1024
 			}
 
-			return &mcp.CallToolResult{}, map[string]any{
-				"log_file_path": logFilePath,
-				"report_path":   reportPath,
-				"size_kb":       sizeKB,
+			return &mcp.CallToolResult{}, Result{
+				LogFilePath: logFilePath,
+				ReportPath:  reportPath,
+				ResourceURI: workspace.URI(reportPath),
+				SizeKB:      sizeKB,
 			}, nil
 		},
 	})
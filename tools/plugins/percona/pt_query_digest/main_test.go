@@ -0,0 +1,107 @@
+package pt_query_digest_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nicola-strappazzon/argos/internal/testharness"
+	"github.com/nicola-strappazzon/argos/internal/workspace"
+	"github.com/nicola-strappazzon/argos/tools/plugins/percona/pt_query_digest"
+)
+
+// fakeDigest puts a pt-query-digest running script first in PATH.
+func fakeDigest(t *testing.T, script string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "pt-query-digest"), []byte("#!/bin/sh\n"+script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+// slowLog writes a slow query log where aws_rds_log_download saves those of
+// db1, in a workspace of the test.
+func slowLog(t *testing.T) string {
+	t.Helper()
+
+	root := t.TempDir()
+	t.Setenv("ARGOS_WORKSPACE", root)
+
+	path := filepath.Join(root, "db1", "aws_rds_log_download", "slowquery", "mysql-slowquery.log")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("# Query_time: 2.5\nSELECT SLEEP(2);\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestQueryDigest(t *testing.T) {
+	fakeDigest(t, `echo "# Profile of $1"; cat "$1"`)
+	log := slowLog(t)
+
+	var got pt_query_digest.Result
+	testharness.Decode(t, testharness.Call(t, "pt_query_digest", map[string]any{"log_file_path": log}), &got)
+
+	if got.LogFilePath != log || filepath.Base(got.ReportPath) != "mysql-slowquery.log.txt" ||
+		got.ResourceURI != workspace.URI(got.ReportPath) || !strings.HasPrefix(got.ResourceURI, "argos://artifacts/db1/pt_query_digest/") {
+		t.Fatalf("unexpected result: %+v", got)
+	}
+	report, err := os.ReadFile(got.ReportPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "# Profile of " + log + "\n# Query_time: 2.5\nSELECT SLEEP(2);\n"; string(report) != want {
+		t.Errorf("unexpected report: %s", report)
+	}
+}
+
+func TestQueryDigestLocalLog(t *testing.T) {
+	fakeDigest(t, `echo "# Profile"`)
+	slowLog(t)
+	log := filepath.Join(t.TempDir(), "slow.log")
+	if err := os.WriteFile(log, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var got pt_query_digest.Result
+	testharness.Decode(t, testharness.Call(t, "pt_query_digest", map[string]any{"log_file_path": log}), &got)
+
+	if !strings.HasPrefix(got.ResourceURI, "argos://artifacts/local/pt_query_digest/") {
+		t.Errorf("expected the report of a log outside the workspace under local, got %+v", got)
+	}
+}
+
+func TestQueryDigestFailure(t *testing.T) {
+	fakeDigest(t, `echo "Cannot parse $1" >&2; exit 1`)
+	log := slowLog(t)
+
+	text := testharness.Failure(t, testharness.Call(t, "pt_query_digest", map[string]any{"log_file_path": log}), "internal")
+	if !strings.Contains(text, "Cannot parse") {
+		t.Errorf("expected the error output in the failure: %s", text)
+	}
+	if files, err := workspace.List("db1", "pt_query_digest"); err != nil || len(files) != 0 {
+		t.Errorf("expected no report left in the workspace, got %v, %v", files, err)
+	}
+}
+
+func TestQueryDigestMissingBinary(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	log := slowLog(t)
+
+	text := testharness.Failure(t, testharness.Call(t, "pt_query_digest", map[string]any{"log_file_path": log}), "misconfigured")
+	if !strings.Contains(text, "install Percona Toolkit") {
+		t.Errorf("expected a hint to install the toolkit: %s", text)
+	}
+	if files, err := workspace.List("db1", "pt_query_digest"); err != nil || len(files) != 0 {
+		t.Errorf("expected no report left in the workspace, got %v, %v", files, err)
+	}
+}
+
+func TestMissingLogFile(t *testing.T) {
+	testharness.Failure(t, testharness.Call(t, "pt_query_digest", map[string]any{"log_file_path": "/nonexistent/slow.log"}), "not_found")
+}
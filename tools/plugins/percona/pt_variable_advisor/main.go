@@ -7,19 +7,29 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/nicola-strappazzon/argos/internal/config/credentials"
 	"github.com/nicola-strappazzon/argos/internal/config/mysql"
+	"github.com/nicola-strappazzon/argos/internal/toolerr"
+	"github.com/nicola-strappazzon/argos/internal/workspace"
 	"github.com/nicola-strappazzon/argos/tools/registry"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
-const outputDir = "/tmp/argos/pt-variable-advisor"
+type Result struct {
+	Instance   string `json:"instance"`
+	ReportPath string `json:"report_path"`
+	// ResourceURI reads the report as an MCP resource.
+	ResourceURI string `json:"resource_uri"`
+	SizeKB      int64  `json:"size_kb"`
+}
 
 func init() {
 	registry.Add(registry.Property{
 		Name:        "pt_variable_advisor",
-		Description: "Run pt-variable-advisor against a MySQL/RDS instance and save the report to /tmp/argos/pt-variable-advisor. The host and port can be obtained from aws_rds_instances.",
+		Description: "Run pt-variable-advisor against a MySQL/RDS instance and save the report to a new directory of the workspace, under <instance>/pt_variable_advisor/. The host and port can be obtained from aws_rds_instances.",
 		InputSchema: map[string]any{
 			"type": "object",
 			"properties": map[string]any{
@@ -30,23 +40,31 @@ func init() {
 			},
 			"required": []string{"db_instance_identifier"},
 		},
+		Output:  Result{},
+		Timeout: 2 * time.Minute,
+		Writes:  true,
 		Function: func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
 			instanceID, _ := args["db_instance_identifier"].(string)
 
-			creds, err := mysqlconfig.Load(instanceID)
+			creds, err := mysqlconfig.Load(ctx, instanceID)
 			if err != nil {
 				return &mcp.CallToolResult{}, nil, err
 			}
+			if creds.Auth == credentials.AuthIAM {
+				return &mcp.CallToolResult{}, nil, toolerr.Errorf(toolerr.Unsupported, "configure a password for the instance to use this tool",
+					"pt-variable-advisor does not support IAM authentication (section [%s] uses auth=iam)", instanceID)
+			}
 
-			if err := os.MkdirAll(outputDir, 0755); err != nil {
-				return &mcp.CallToolResult{}, nil, fmt.Errorf("creating output directory: %w", err)
+			runDir, err := workspace.NewRun(instanceID, "pt_variable_advisor")
+			if err != nil {
+				return &mcp.CallToolResult{}, nil, err
 			}
 
-			reportName := fmt.Sprintf("%s.txt", strings.ReplaceAll(instanceID, "-", "_"))
-			reportPath := filepath.Join(outputDir, reportName)
+			reportPath := filepath.Join(runDir, "report.txt")
 
 			reportFile, err := os.Create(reportPath)
 			if err != nil {
+				os.RemoveAll(runDir)
 				return &mcp.CallToolResult{}, nil, fmt.Errorf("creating report file: %w", err)
 			}
 			defer reportFile.Close()
@@ -57,7 +75,9 @@ func init() {
 			var stderr strings.Builder
 			cmd.Stderr = &stderr
 
+			// A failed run leaves no empty report behind in the workspace.
 			if err := cmd.Run(); err != nil {
+				os.RemoveAll(runDir)
 				return &mcp.CallToolResult{}, nil, fmt.Errorf("pt-variable-advisor failed: %w — %s", err, stderr.String())
 			}
 
@@ -67,10 +87,11 @@ func init() {
 				sizeKB = info.Size() / 1024
 			}
 
-			return &mcp.CallToolResult{}, map[string]any{
-				"instance":    instanceID,
-				"report_path": reportPath,
-				"size_kb":     sizeKB,
+			return &mcp.CallToolResult{}, Result{
+				Instance:    instanceID,
+				ReportPath:  reportPath,
+				ResourceURI: workspace.URI(reportPath),
+				SizeKB:      sizeKB,
 			}, nil
 		},
 	})
@@ -0,0 +1,70 @@
+package pt_variable_advisor_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nicola-strappazzon/argos/internal/testharness"
+	"github.com/nicola-strappazzon/argos/internal/workspace"
+	"github.com/nicola-strappazzon/argos/tools/plugins/percona/pt_variable_advisor"
+)
+
+// fakeAdvisor puts a pt-variable-advisor running script first in PATH and
+// the credentials of db1 in the environment.
+func fakeAdvisor(t *testing.T, script string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "pt-variable-advisor"), []byte("#!/bin/sh\n"+script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("ARGOS_CREDENTIAL_PROVIDERS", "env")
+	t.Setenv("ARGOS_MYSQL_DB1_HOST", "db1.example.com")
+	t.Setenv("ARGOS_MYSQL_DB1_USER", "admin")
+	t.Setenv("ARGOS_MYSQL_DB1_PASSWORD", "secret")
+	t.Setenv("ARGOS_MYSQL_DB1_PORT", "3307")
+}
+
+func TestVariableAdvisor(t *testing.T) {
+	fakeAdvisor(t, `echo "dsn: $1"; echo "# WARN innodb_flush_log_at_trx_commit-1: InnoDB is not configured in strictly ACID mode."`)
+
+	var got pt_variable_advisor.Result
+	testharness.Decode(t, testharness.Call(t, "pt_variable_advisor", map[string]any{"db_instance_identifier": "db1"}), &got)
+
+	if got.Instance != "db1" || !strings.HasPrefix(got.ResourceURI, "argos://artifacts/db1/pt_variable_advisor/") {
+		t.Fatalf("unexpected result: %+v", got)
+	}
+	report, err := os.ReadFile(got.ReportPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(report), "dsn: h=db1.example.com,u=admin,p=secret,P=3307\n# WARN innodb_flush_log_at_trx_commit") {
+		t.Errorf("unexpected report: %s", report)
+	}
+}
+
+func TestVariableAdvisorFailure(t *testing.T) {
+	fakeAdvisor(t, `echo "DBI connect failed: Access denied" >&2; exit 1`)
+
+	text := testharness.Failure(t, testharness.Call(t, "pt_variable_advisor", map[string]any{"db_instance_identifier": "db1"}), "internal")
+	if !strings.Contains(text, "Access denied") {
+		t.Errorf("expected the error output in the failure: %s", text)
+	}
+	if files, err := workspace.List("db1", "pt_variable_advisor"); err != nil || len(files) != 0 {
+		t.Errorf("expected no report left in the workspace, got %v, %v", files, err)
+	}
+}
+
+func TestVariableAdvisorIAM(t *testing.T) {
+	fakeAdvisor(t, "exit 0")
+	t.Setenv("ARGOS_MYSQL_DB1_AUTH", "iam")
+
+	testharness.Failure(t, testharness.Call(t, "pt_variable_advisor", map[string]any{"db_instance_identifier": "db1"}), "unsupported_engine")
+}
+
+func TestVariableAdvisorWithoutCredentials(t *testing.T) {
+	testharness.Failure(t, testharness.Call(t, "pt_variable_advisor", map[string]any{"db_instance_identifier": "db1"}), "not_found")
+}
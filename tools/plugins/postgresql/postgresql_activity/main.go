@@ -0,0 +1,204 @@
+package postgresql_activity
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/nicola-strappazzon/argos/internal/display"
+	psqldriver "github.com/nicola-strappazzon/argos/internal/drivers/postgresql"
+	"github.com/nicola-strappazzon/argos/tools/registry"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const defaultLongTransaction = 60
+
+type Session struct {
+	PID           int64   `json:"pid"`
+	User          string  `json:"user,omitempty"`
+	Database      string  `json:"database,omitempty"`
+	Application   string  `json:"application,omitempty"`
+	Client        string  `json:"client,omitempty"`
+	BackendType   string  `json:"backend_type"`
+	State         string  `json:"state"`
+	WaitEventType string  `json:"wait_event_type,omitempty"`
+	WaitEvent     string  `json:"wait_event,omitempty"`
+	XactSec       float64 `json:"xact_sec,omitempty"`
+	QuerySec      float64 `json:"query_sec,omitempty"`
+	StateSec      float64 `json:"state_sec,omitempty"`
+	BlockedBy     []int64 `json:"blocked_by,omitempty"`
+	Query         string  `json:"query,omitempty"`
+}
+
+type WaitEvent struct {
+	Type     string `json:"type"`
+	Event    string `json:"event"`
+	Sessions int    `json:"sessions"`
+}
+
+type Result struct {
+	Instance    string    `json:"instance"`
+	Sessions    []Session `json:"sessions"`
+	Total       int       `json:"total"`
+	TotalIdle   int       `json:"total_idle"`
+	IncludeIdle bool      `json:"include_idle"`
+	// States counts the sessions of every state, idle ones included.
+	States     map[string]int `json:"states"`
+	WaitEvents []WaitEvent    `json:"wait_events"`
+	// LongTransactions, IdleInTransaction and Blocked are the PIDs of the
+	// sessions of Sessions with a transaction open for at least
+	// long_transaction_sec, idle in a transaction, or waiting for a lock.
+	LongTransactions  []int64 `json:"long_transactions"`
+	IdleInTransaction []int64 `json:"idle_in_transaction"`
+	Blocked           []int64 `json:"blocked"`
+}
+
+func init() {
+	registry.Add(registry.Property{
+		Name:        "postgresql_activity",
+		Description: "List the sessions of a PostgreSQL instance from pg_stat_activity: state, wait event, transaction and query age, the PIDs blocking each one (pg_blocking_pids) and the current query. Also counts the sessions per state and wait event, and points out long transactions, sessions idle in transaction and blocked sessions. Idle sessions are excluded by default. For the chain of lock waits, use postgresql_blocking.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"db_instance_identifier": map[string]any{
+					"type":        "string",
+					"description": "The RDS DB instance identifier. Credentials are read from ~/.pgpass matching the instance identifier against the hostname.",
+				},
+				"include_idle": map[string]any{
+					"type":        "boolean",
+					"description": "Include idle sessions (state idle). Default: false.",
+				},
+				"min_duration_sec": map[string]any{
+					"type":        "integer",
+					"description": "Only include sessions whose transaction, or query outside a transaction, runs for at least this many seconds. Default: 0 (no filter).",
+				},
+				"long_transaction_sec": map[string]any{
+					"type":        "integer",
+					"description": fmt.Sprintf("Age from which a transaction counts as long. Default: %d.", defaultLongTransaction),
+				},
+			},
+			"required": []string{"db_instance_identifier"},
+		},
+		Output: Result{},
+		Function: func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
+			instanceID, _ := args["db_instance_identifier"].(string)
+			includeIdle, _ := args["include_idle"].(bool)
+			minDuration, _ := args["min_duration_sec"].(float64)
+
+			longTransaction := float64(defaultLongTransaction)
+			if l, ok := args["long_transaction_sec"].(float64); ok && l > 0 {
+				longTransaction = l
+			}
+
+			db, err := psqldriver.Connect(ctx, instanceID)
+			if err != nil {
+				return &mcp.CallToolResult{}, nil, err
+			}
+
+			rows, err := db.QueryContext(ctx, `
+				SELECT
+					pid,
+					COALESCE(usename, ''),
+					COALESCE(datname, ''),
+					COALESCE(application_name, ''),
+					COALESCE(host(client_addr), ''),
+					COALESCE(backend_type, ''),
+					COALESCE(state, ''),
+					COALESCE(wait_event_type, ''),
+					COALESCE(wait_event, ''),
+					COALESCE(EXTRACT(EPOCH FROM now() - xact_start), 0)::float8,
+					COALESCE(EXTRACT(EPOCH FROM now() - query_start), 0)::float8,
+					COALESCE(EXTRACT(EPOCH FROM now() - state_change), 0)::float8,
+					pg_blocking_pids(pid),
+					COALESCE(query, '')
+				FROM pg_stat_activity
+				WHERE pid <> pg_backend_pid()
+				ORDER BY xact_start NULLS LAST, query_start NULLS LAST`)
+			if err != nil {
+				return &mcp.CallToolResult{}, nil, fmt.Errorf("executing query: %w", err)
+			}
+			defer rows.Close()
+
+			result := Result{
+				Instance:          instanceID,
+				Sessions:          make([]Session, 0),
+				IncludeIdle:       includeIdle,
+				States:            map[string]int{},
+				WaitEvents:        make([]WaitEvent, 0),
+				LongTransactions:  make([]int64, 0),
+				IdleInTransaction: make([]int64, 0),
+				Blocked:           make([]int64, 0),
+			}
+			waits := map[WaitEvent]int{}
+
+			for rows.Next() {
+				var s Session
+				var blockedBy pq.Int64Array
+				if err := rows.Scan(
+					&s.PID, &s.User, &s.Database, &s.Application, &s.Client, &s.BackendType,
+					&s.State, &s.WaitEventType, &s.WaitEvent,
+					&s.XactSec, &s.QuerySec, &s.StateSec,
+					&blockedBy, &s.Query,
+				); err != nil {
+					return &mcp.CallToolResult{}, nil, fmt.Errorf("scanning row: %w", err)
+				}
+
+				// Background processes such as the checkpointer have no state.
+				if s.State == "" {
+					continue
+				}
+				s.BlockedBy = blockedBy
+				s.XactSec = display.Round(s.XactSec, 3)
+				s.QuerySec = display.Round(s.QuerySec, 3)
+				s.StateSec = display.Round(s.StateSec, 3)
+				s.Query = display.Cut(s.Query, 500)
+
+				result.States[s.State]++
+				if s.State == "idle" {
+					result.TotalIdle++
+					if !includeIdle {
+						continue
+					}
+				}
+				if minDuration > 0 && max(s.XactSec, s.QuerySec) < minDuration {
+					continue
+				}
+
+				if s.WaitEventType != "" {
+					waits[WaitEvent{Type: s.WaitEventType, Event: s.WaitEvent}]++
+				}
+				if s.XactSec >= longTransaction {
+					result.LongTransactions = append(result.LongTransactions, s.PID)
+				}
+				if strings.HasPrefix(s.State, "idle in transaction") {
+					result.IdleInTransaction = append(result.IdleInTransaction, s.PID)
+				}
+				if len(s.BlockedBy) > 0 {
+					result.Blocked = append(result.Blocked, s.PID)
+				}
+				result.Sessions = append(result.Sessions, s)
+			}
+			if err := rows.Err(); err != nil {
+				return &mcp.CallToolResult{}, nil, fmt.Errorf("reading rows: %w", err)
+			}
+
+			for w, n := range waits {
+				w.Sessions = n
+				result.WaitEvents = append(result.WaitEvents, w)
+			}
+			sort.Slice(result.WaitEvents, func(i, j int) bool {
+				a, b := result.WaitEvents[i], result.WaitEvents[j]
+				if a.Sessions != b.Sessions {
+					return a.Sessions > b.Sessions
+				}
+				return a.Type+a.Event < b.Type+b.Event
+			})
+			result.Total = len(result.Sessions)
+
+			return &mcp.CallToolResult{}, result, nil
+		},
+	})
+}
@@ -0,0 +1,85 @@
+package postgresql_activity_test
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/nicola-strappazzon/argos/internal/testharness"
+	"github.com/nicola-strappazzon/argos/tools/plugins/postgresql/postgresql_activity"
+)
+
+var columns = []string{
+	"pid", "usename", "datname", "application_name", "client_addr", "backend_type", "state",
+	"wait_event_type", "wait_event", "xact_sec", "query_sec", "state_sec", "blocked_by", "query",
+}
+
+func expectActivity(t *testing.T) {
+	mock := testharness.PostgreSQL(t, "pg1", "")
+	mock.ExpectQuery(`FROM pg_stat_activity\s+WHERE pid <> pg_backend_pid\(\)`).
+		WillReturnRows(testharness.Rows(columns,
+			[]any{100, "app", "shop", "api", "10.0.0.1", "client backend", "idle in transaction", "Client", "ClientRead", 320.5, 310.0, 310.0, "{}", "UPDATE orders SET status = 'paid' WHERE id = 7"},
+			[]any{101, "app", "shop", "api", "10.0.0.2", "client backend", "active", "Lock", "transactionid", 45.0, 45.0, 45.0, "{100}", "UPDATE orders SET status = 'sent' WHERE id = 7"},
+			[]any{102, "rdsadmin", "shop", "", "", "autovacuum worker", "active", "", "", 2.0, 2.0, 2.0, "{}", "autovacuum: VACUUM public.events"},
+			[]any{103, "app", "shop", "api", "10.0.0.3", "client backend", "idle", "Client", "ClientRead", 0.0, 900.0, 900.0, "{}", "SELECT 1"},
+			[]any{104, "", "", "", "", "checkpointer", "", "Activity", "CheckpointerMain", 0.0, 0.0, 0.0, "{}", ""},
+		))
+}
+
+func TestActivity(t *testing.T) {
+	expectActivity(t)
+
+	var got postgresql_activity.Result
+	testharness.Decode(t, testharness.Call(t, "postgresql_activity", map[string]any{"db_instance_identifier": "pg1"}), &got)
+
+	if got.Total != 3 || got.TotalIdle != 1 || got.States["active"] != 2 || got.States["idle"] != 1 {
+		t.Fatalf("unexpected sessions: %+v", got)
+	}
+	if len(got.LongTransactions) != 1 || got.LongTransactions[0] != 100 {
+		t.Errorf("unexpected long transactions: %v", got.LongTransactions)
+	}
+	if len(got.IdleInTransaction) != 1 || got.IdleInTransaction[0] != 100 {
+		t.Errorf("unexpected idle in transaction: %v", got.IdleInTransaction)
+	}
+	if len(got.Blocked) != 1 || got.Blocked[0] != 101 || got.Sessions[1].BlockedBy[0] != 100 {
+		t.Errorf("unexpected blocked sessions: %v", got.Blocked)
+	}
+	if len(got.WaitEvents) != 2 || got.WaitEvents[0].Event != "ClientRead" || got.WaitEvents[1].Event != "transactionid" {
+		t.Errorf("unexpected wait events: %+v", got.WaitEvents)
+	}
+}
+
+func TestActivityFilters(t *testing.T) {
+	expectActivity(t)
+
+	var got postgresql_activity.Result
+	testharness.Decode(t, testharness.Call(t, "postgresql_activity", map[string]any{
+		"db_instance_identifier": "pg1",
+		"include_idle":           true,
+		"min_duration_sec":       300,
+		"long_transaction_sec":   30,
+	}), &got)
+
+	if got.Total != 2 || got.Sessions[0].PID != 100 || got.Sessions[1].PID != 103 {
+		t.Fatalf("unexpected sessions: %+v", got.Sessions)
+	}
+	if len(got.LongTransactions) != 1 || len(got.Blocked) != 0 {
+		t.Errorf("unexpected result: %+v", got)
+	}
+}
+
+func TestActivityMultibyteQuery(t *testing.T) {
+	query := "SELECT 'x" + strings.Repeat("日本", 100) + "'"
+	mock := testharness.PostgreSQL(t, "pg1", "")
+	mock.ExpectQuery(`FROM pg_stat_activity`).
+		WillReturnRows(testharness.Rows(columns,
+			[]any{100, "app", "shop", "api", "10.0.0.1", "client backend", "active", "", "", 1.0, 1.0, 1.0, "{}", query},
+		))
+
+	var got postgresql_activity.Result
+	testharness.Decode(t, testharness.Call(t, "postgresql_activity", map[string]any{"db_instance_identifier": "pg1"}), &got)
+
+	if q := got.Sessions[0].Query; len(q) > 500 || !utf8.ValidString(q) || !strings.HasPrefix(query, q) {
+		t.Errorf("expected the query cut on a rune boundary, got %d bytes", len(q))
+	}
+}
@@ -0,0 +1,317 @@
+package postgresql_blocking
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/nicola-strappazzon/argos/internal/display"
+	psqldriver "github.com/nicola-strappazzon/argos/internal/drivers/postgresql"
+	"github.com/nicola-strappazzon/argos/tools/registry"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Session is a line of the blocking tree: a session and, at Depth + 1
+// below it, the sessions waiting for it. A session blocked by several
+// others appears under each of them.
+type Session struct {
+	PID           int64   `json:"pid"`
+	Depth         int     `json:"depth"`
+	User          string  `json:"user,omitempty"`
+	Database      string  `json:"database,omitempty"`
+	Application   string  `json:"application,omitempty"`
+	State         string  `json:"state,omitempty"`
+	WaitEventType string  `json:"wait_event_type,omitempty"`
+	WaitEvent     string  `json:"wait_event,omitempty"`
+	XactSec       float64 `json:"xact_sec,omitempty"`
+	StateSec      float64 `json:"state_sec,omitempty"`
+	// Lock is the lock the session waits for, e.g. "ShareLock on
+	// transactionid" or "AccessExclusiveLock on relation orders".
+	Lock      string  `json:"lock,omitempty"`
+	BlockedBy []int64 `json:"blocked_by,omitempty"`
+	Query     string  `json:"query,omitempty"`
+	// TotalBlocked is the number of sessions waiting for this one,
+	// directly or not.
+	TotalBlocked int `json:"total_blocked"`
+}
+
+type Result struct {
+	Instance        string `json:"instance"`
+	BlockedSessions int    `json:"blocked_sessions"`
+	// RootBlockers are the sessions that block others without waiting for
+	// any, the one blocking the most sessions first.
+	RootBlockers []int64 `json:"root_blockers"`
+	// Tree lists the sessions of the trees of RootBlockers depth first, and
+	// Rendered draws it, one session per line.
+	Tree     []Session `json:"tree"`
+	Rendered string    `json:"rendered,omitempty"`
+}
+
+func init() {
+	registry.Add(registry.Property{
+		Name:        "postgresql_blocking",
+		Description: "Build the lock wait tree of a PostgreSQL instance from pg_stat_activity, pg_locks and pg_blocking_pids(): each root blocker (a session blocking others without waiting itself, often idle in transaction) with the sessions waiting for it, the lock each one waits for, its state, transaction age and query. The tree is returned nested and rendered as text, the root blocking the most sessions first.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"db_instance_identifier": map[string]any{
+					"type":        "string",
+					"description": "The RDS DB instance identifier. Credentials are read from ~/.pgpass matching the instance identifier against the hostname.",
+				},
+			},
+			"required": []string{"db_instance_identifier"},
+		},
+		Output: Result{},
+		Function: func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
+			instanceID, _ := args["db_instance_identifier"].(string)
+
+			db, err := psqldriver.Connect(ctx, instanceID)
+			if err != nil {
+				return &mcp.CallToolResult{}, nil, err
+			}
+
+			rows, err := db.QueryContext(ctx, `
+				WITH blocked AS (
+					SELECT pid, blocked_by
+					FROM (SELECT pid, pg_blocking_pids(pid) AS blocked_by FROM pg_stat_activity) b
+					WHERE cardinality(blocked_by) > 0
+				)
+				SELECT
+					a.pid,
+					COALESCE(a.usename, ''),
+					COALESCE(a.datname, ''),
+					COALESCE(a.application_name, ''),
+					COALESCE(a.state, ''),
+					COALESCE(a.wait_event_type, ''),
+					COALESCE(a.wait_event, ''),
+					COALESCE(EXTRACT(EPOCH FROM now() - a.xact_start), 0)::float8,
+					COALESCE(EXTRACT(EPOCH FROM now() - a.state_change), 0)::float8,
+					COALESCE(l.mode || ' on ' || l.locktype || COALESCE(' ' || CASE
+						WHEN l.database IN (0, (SELECT oid FROM pg_database WHERE datname = current_database()))
+							THEN l.relation::regclass::text
+						ELSE l.relation || ' in db ' || COALESCE((SELECT datname FROM pg_database WHERE oid = l.database), l.database::text)
+					END, ''), ''),
+					COALESCE(b.blocked_by, '{}'),
+					COALESCE(a.query, '')
+				FROM pg_stat_activity a
+				LEFT JOIN blocked b ON b.pid = a.pid
+				LEFT JOIN LATERAL (
+					SELECT mode, locktype, database, relation
+					FROM pg_locks
+					WHERE pid = a.pid AND NOT granted
+					LIMIT 1
+				) l ON true
+				WHERE b.pid IS NOT NULL
+					OR a.pid IN (SELECT unnest(blocked_by) FROM blocked)`)
+			if err != nil {
+				return &mcp.CallToolResult{}, nil, fmt.Errorf("executing query: %w", err)
+			}
+			defer rows.Close()
+
+			sessions := map[int64]*Session{}
+			for rows.Next() {
+				var s Session
+				var blockedBy pq.Int64Array
+				if err := rows.Scan(
+					&s.PID, &s.User, &s.Database, &s.Application,
+					&s.State, &s.WaitEventType, &s.WaitEvent,
+					&s.XactSec, &s.StateSec, &s.Lock,
+					&blockedBy, &s.Query,
+				); err != nil {
+					return &mcp.CallToolResult{}, nil, fmt.Errorf("scanning row: %w", err)
+				}
+				s.BlockedBy = blockedBy
+				s.XactSec = display.Round(s.XactSec, 3)
+				s.StateSec = display.Round(s.StateSec, 3)
+				s.Query = display.Cut(s.Query, 500)
+				sessions[s.PID] = &s
+			}
+			if err := rows.Err(); err != nil {
+				return &mcp.CallToolResult{}, nil, fmt.Errorf("reading rows: %w", err)
+			}
+
+			trees := build(sessions)
+			result := Result{
+				Instance:     instanceID,
+				RootBlockers: make([]int64, 0, len(trees)),
+				Tree:         make([]Session, 0),
+			}
+			for _, s := range sessions {
+				if len(s.BlockedBy) > 0 {
+					result.BlockedSessions++
+				}
+			}
+			for _, t := range trees {
+				result.RootBlockers = append(result.RootBlockers, t.session.PID)
+				result.Tree = flatten(t, 0, result.Tree)
+			}
+			result.Rendered = render(trees)
+
+			return &mcp.CallToolResult{}, result, nil
+		},
+	})
+}
+
+// node is a session of the tree with the sessions waiting for it.
+type node struct {
+	session Session
+	blocked []*node
+}
+
+// build builds the trees of the blocked sessions. A PID of 0 stands for a
+// prepared transaction, which has no session. Sessions waiting for each
+// other in a cycle, a deadlock about to be detected, have no root blocker:
+// the lowest PID of the cycle is taken as root.
+func build(sessions map[int64]*Session) []*node {
+	waiters := map[int64][]int64{}
+	for _, pid := range sorted(sessions) {
+		for _, blocker := range sessions[pid].BlockedBy {
+			if _, ok := sessions[blocker]; !ok {
+				// The blocker ended since pg_blocking_pids() ran, or is a
+				// prepared transaction.
+				sessions[blocker] = &Session{PID: blocker}
+				if blocker == 0 {
+					sessions[blocker].State = "prepared transaction"
+				}
+			}
+			waiters[blocker] = append(waiters[blocker], pid)
+		}
+	}
+	pids := sorted(sessions)
+
+	var grow func(pid int64, path map[int64]bool) *node
+	grow = func(pid int64, path map[int64]bool) *node {
+		n := &node{session: *sessions[pid]}
+		path[pid] = true
+		for _, w := range waiters[pid] {
+			if !path[w] {
+				n.blocked = append(n.blocked, grow(w, path))
+			}
+		}
+		delete(path, pid)
+
+		waiting := map[int64]bool{}
+		for _, c := range n.blocked {
+			collect(c, waiting)
+		}
+		delete(waiting, pid)
+		n.session.TotalBlocked = len(waiting)
+
+		return n
+	}
+
+	reached := map[int64]bool{}
+	trees := make([]*node, 0)
+	for _, pid := range pids {
+		if len(sessions[pid].BlockedBy) == 0 && len(waiters[pid]) > 0 {
+			t := grow(pid, map[int64]bool{})
+			collect(t, reached)
+			trees = append(trees, t)
+		}
+	}
+	for _, pid := range pids {
+		if !reached[pid] && len(waiters[pid]) > 0 {
+			t := grow(pid, map[int64]bool{})
+			collect(t, reached)
+			trees = append(trees, t)
+		}
+	}
+
+	sort.SliceStable(trees, func(i, j int) bool {
+		return trees[i].session.TotalBlocked > trees[j].session.TotalBlocked
+	})
+
+	return trees
+}
+
+// collect adds the PIDs of a tree to pids.
+func collect(n *node, pids map[int64]bool) {
+	pids[n.session.PID] = true
+	for _, c := range n.blocked {
+		collect(c, pids)
+	}
+}
+
+func flatten(n *node, depth int, sessions []Session) []Session {
+	s := n.session
+	s.Depth = depth
+	sessions = append(sessions, s)
+	for _, c := range n.blocked {
+		sessions = flatten(c, depth+1, sessions)
+	}
+	return sessions
+}
+
+func sorted(sessions map[int64]*Session) []int64 {
+	pids := make([]int64, 0, len(sessions))
+	for pid := range sessions {
+		pids = append(pids, pid)
+	}
+	sort.Slice(pids, func(i, j int) bool { return pids[i] < pids[j] })
+	return pids
+}
+
+// render draws the trees like tree(1):
+//
+//	[100] app@shop idle in transaction, xact 320s: UPDATE orders ...
+//	└── [101] app@shop active, waits ShareLock on transactionid: UPDATE ...
+func render(trees []*node) string {
+	var sb strings.Builder
+
+	var draw func(n *node, indent, branch string)
+	draw = func(n *node, indent, branch string) {
+		sb.WriteString(indent + branch + describe(n.session) + "\n")
+		switch branch {
+		case "├── ":
+			indent += "│   "
+		case "└── ":
+			indent += "    "
+		}
+		for i, c := range n.blocked {
+			if i == len(n.blocked)-1 {
+				draw(c, indent, "└── ")
+			} else {
+				draw(c, indent, "├── ")
+			}
+		}
+	}
+	for _, t := range trees {
+		draw(t, "", "")
+	}
+
+	return sb.String()
+}
+
+// describe returns the line of a session in the tree.
+func describe(s Session) string {
+	parts := []string{fmt.Sprintf("[%d]", s.PID)}
+	if s.User != "" || s.Database != "" {
+		parts = append(parts, s.User+"@"+s.Database)
+	}
+
+	var details []string
+	if s.State != "" {
+		details = append(details, s.State)
+	}
+	if s.Lock != "" {
+		details = append(details, "waits "+s.Lock)
+	}
+	if s.XactSec > 0 {
+		details = append(details, fmt.Sprintf("xact %.0fs", s.XactSec))
+	}
+	if len(details) > 0 {
+		parts = append(parts, strings.Join(details, ", "))
+	}
+
+	line := strings.Join(parts, " ")
+	if q := strings.Join(strings.Fields(s.Query), " "); q != "" {
+		if len(q) > 80 {
+			q = display.Cut(q, 80) + "..."
+		}
+		line += ": " + q
+	}
+	return line
+}
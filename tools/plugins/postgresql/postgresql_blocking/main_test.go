@@ -0,0 +1,115 @@
+package postgresql_blocking_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/nicola-strappazzon/argos/internal/testharness"
+	"github.com/nicola-strappazzon/argos/tools/plugins/postgresql/postgresql_blocking"
+)
+
+var columns = []string{
+	"pid", "usename", "datname", "application_name", "state", "wait_event_type", "wait_event",
+	"xact_sec", "state_sec", "lock", "blocked_by", "query",
+}
+
+func TestBlocking(t *testing.T) {
+	mock := testharness.PostgreSQL(t, "pg1", "")
+	mock.ExpectQuery(`pg_blocking_pids\(pid\).*FROM pg_stat_activity a.*FROM pg_locks`).
+		WillReturnRows(testharness.Rows(columns,
+			[]any{100, "app", "shop", "api", "idle in transaction", "Client", "ClientRead", 320.4, 300.0, "", "{}", "UPDATE orders\n   SET status = 'paid' WHERE id = 7"},
+			[]any{101, "app", "shop", "api", "active", "Lock", "transactionid", 45.0, 45.0, "ShareLock on transactionid", "{100}", "UPDATE orders SET status = 'sent' WHERE id = 7"},
+			[]any{102, "app", "shop", "api", "active", "Lock", "relation", 10.0, 10.0, "AccessShareLock on relation orders", "{101}", "SELECT * FROM orders"},
+			[]any{103, "app", "shop", "api", "active", "Lock", "transactionid", 5.0, 5.0, "ShareLock on transactionid", "{100,101}", "DELETE FROM orders WHERE id = 7"},
+			[]any{201, "etl", "shop", "etl", "active", "Lock", "transactionid", 2.0, 2.0, "ShareLock on transactionid", "{0}", "UPDATE stock SET n = 0"},
+		))
+
+	var got postgresql_blocking.Result
+	testharness.Decode(t, testharness.Call(t, "postgresql_blocking", map[string]any{"db_instance_identifier": "pg1"}), &got)
+
+	if got.BlockedSessions != 4 || len(got.RootBlockers) != 2 || got.RootBlockers[0] != 100 || got.RootBlockers[1] != 0 {
+		t.Fatalf("unexpected result: %+v", got)
+	}
+
+	var pids []int64
+	var depths []int
+	for _, s := range got.Tree {
+		pids = append(pids, s.PID)
+		depths = append(depths, s.Depth)
+	}
+	if fmt.Sprint(pids) != "[100 101 102 103 103 0 201]" || fmt.Sprint(depths) != "[0 1 2 2 1 0 1]" {
+		t.Fatalf("unexpected tree: %v %v", pids, depths)
+	}
+	if root := got.Tree[0]; root.TotalBlocked != 3 || root.State != "idle in transaction" {
+		t.Errorf("unexpected root blocker: %+v", root)
+	}
+	if s := got.Tree[1]; s.TotalBlocked != 2 || s.Lock != "ShareLock on transactionid" || s.BlockedBy[0] != 100 {
+		t.Errorf("unexpected waiter: %+v", s)
+	}
+	if prepared := got.Tree[5]; prepared.State != "prepared transaction" || prepared.TotalBlocked != 1 {
+		t.Errorf("unexpected prepared transaction: %+v", prepared)
+	}
+
+	want := `[100] app@shop idle in transaction, xact 320s: UPDATE orders SET status = 'paid' WHERE id = 7
+├── [101] app@shop active, waits ShareLock on transactionid, xact 45s: UPDATE orders SET status = 'sent' WHERE id = 7
+│   ├── [102] app@shop active, waits AccessShareLock on relation orders, xact 10s: SELECT * FROM orders
+│   └── [103] app@shop active, waits ShareLock on transactionid, xact 5s: DELETE FROM orders WHERE id = 7
+└── [103] app@shop active, waits ShareLock on transactionid, xact 5s: DELETE FROM orders WHERE id = 7
+[0] prepared transaction
+└── [201] etl@shop active, waits ShareLock on transactionid, xact 2s: UPDATE stock SET n = 0
+`
+	if got.Rendered != want {
+		t.Errorf("unexpected rendering:\n%s\nwant:\n%s", got.Rendered, want)
+	}
+}
+
+func TestBlockingCycle(t *testing.T) {
+	mock := testharness.PostgreSQL(t, "pg1", "")
+	mock.ExpectQuery(`FROM pg_stat_activity a`).
+		WillReturnRows(testharness.Rows(columns,
+			[]any{300, "app", "shop", "", "active", "Lock", "transactionid", 1.0, 1.0, "ShareLock on transactionid", "{301}", "UPDATE a"},
+			[]any{301, "app", "shop", "", "active", "Lock", "transactionid", 1.0, 1.0, "ShareLock on transactionid", "{300}", "UPDATE b"},
+		))
+
+	var got postgresql_blocking.Result
+	testharness.Decode(t, testharness.Call(t, "postgresql_blocking", map[string]any{"db_instance_identifier": "pg1"}), &got)
+
+	if len(got.RootBlockers) != 1 || got.RootBlockers[0] != 300 || len(got.Tree) != 2 || got.Tree[0].TotalBlocked != 1 || got.Tree[1].PID != 301 {
+		t.Fatalf("unexpected result: %+v", got)
+	}
+}
+
+func TestBlockingMultibyteQuery(t *testing.T) {
+	query := "UPDATE cliente SET nome = '" + strings.Repeat("ã", 300) + "'"
+	mock := testharness.PostgreSQL(t, "pg1", "")
+	// Relations of other databases cannot be named with regclass.
+	mock.ExpectQuery(`WHEN l\.database IN \(0, \(SELECT oid FROM pg_database WHERE datname = current_database\(\)\)\)`).
+		WillReturnRows(testharness.Rows(columns,
+			[]any{400, "app", "shop", "", "idle in transaction", "Client", "ClientRead", 1.0, 1.0, "", "{}", query},
+			[]any{401, "app", "crm", "", "active", "Lock", "relation", 1.0, 1.0, "AccessShareLock on relation 16390 in db crm", "{400}", "SELECT 1"},
+		))
+
+	var got postgresql_blocking.Result
+	testharness.Decode(t, testharness.Call(t, "postgresql_blocking", map[string]any{"db_instance_identifier": "pg1"}), &got)
+
+	if root := got.Tree[0]; len(root.Query) > 500 || !utf8.ValidString(root.Query) || !strings.HasPrefix(query, root.Query) {
+		t.Errorf("expected the query cut on a rune boundary, got %d bytes", len(root.Query))
+	}
+	if !utf8.ValidString(got.Rendered) || !strings.Contains(got.Rendered, "...") {
+		t.Errorf("expected the rendered query cut on a rune boundary, got %q", got.Rendered)
+	}
+}
+
+func TestNoBlocking(t *testing.T) {
+	mock := testharness.PostgreSQL(t, "pg1", "")
+	mock.ExpectQuery(`FROM pg_stat_activity a`).WillReturnRows(testharness.Rows(columns))
+
+	var got postgresql_blocking.Result
+	testharness.Decode(t, testharness.Call(t, "postgresql_blocking", map[string]any{"db_instance_identifier": "pg1"}), &got)
+
+	if got.BlockedSessions != 0 || len(got.RootBlockers) != 0 || len(got.Tree) != 0 || got.Rendered != "" {
+		t.Fatalf("unexpected result: %+v", got)
+	}
+}
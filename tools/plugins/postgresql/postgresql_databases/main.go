@@ -19,6 +19,13 @@ type Database struct {
 	SizeMB          float64 `json:"size_mb"`
 }
 
+type Result struct {
+	Instance    string     `json:"instance"`
+	Databases   []Database `json:"databases"`
+	Total       int        `json:"total"`
+	TotalSizeMB float64    `json:"total_size_mb"`
+}
+
 func init() {
 	registry.Add(registry.Property{
 		Name:        "postgresql_databases",
@@ -33,14 +40,14 @@ func init() {
 			},
 			"required": []string{"db_instance_identifier"},
 		},
+		Output: Result{},
 		Function: func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
 			instanceID, _ := args["db_instance_identifier"].(string)
 
-			db, err := psqldriver.Connect(instanceID)
+			db, err := psqldriver.Connect(ctx, instanceID)
 			if err != nil {
 				return &mcp.CallToolResult{}, nil, err
 			}
-			defer db.Close()
 
 			query := `
 				SELECT
@@ -79,11 +86,11 @@ func init() {
 				totalMB += d.SizeMB
 			}
 
-			return &mcp.CallToolResult{}, map[string]any{
-				"instance":      instanceID,
-				"databases":     databases,
-				"total":         len(databases),
-				"total_size_mb": totalMB,
+			return &mcp.CallToolResult{}, Result{
+				Instance:    instanceID,
+				Databases:   databases,
+				Total:       len(databases),
+				TotalSizeMB: totalMB,
 			}, nil
 		},
 	})
@@ -0,0 +1,24 @@
+package postgresql_databases_test
+
+import (
+	"testing"
+
+	"github.com/nicola-strappazzon/argos/internal/testharness"
+	"github.com/nicola-strappazzon/argos/tools/plugins/postgresql/postgresql_databases"
+)
+
+func TestDatabases(t *testing.T) {
+	mock := testharness.PostgreSQL(t, "pg1", "")
+	mock.ExpectQuery(`FROM pg_database d`).
+		WillReturnRows(testharness.Rows([]string{"name", "owner", "encoding", "collation", "connection_limit", "size_mb"},
+			[]any{"app", "app", "UTF8", "en_US.UTF-8", -1, 812.5},
+			[]any{"postgres", "rdsadmin", "UTF8", "en_US.UTF-8", -1, 7.5},
+		))
+
+	var got postgresql_databases.Result
+	testharness.Decode(t, testharness.Call(t, "postgresql_databases", map[string]any{"db_instance_identifier": "pg1"}), &got)
+
+	if got.Total != 2 || got.TotalSizeMB != 820 || got.Databases[0].Name != "app" {
+		t.Fatalf("unexpected result: %+v", got)
+	}
+}
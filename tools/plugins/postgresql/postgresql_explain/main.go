@@ -0,0 +1,452 @@
+package postgresql_explain
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	psqldriver "github.com/nicola-strappazzon/argos/internal/drivers/postgresql"
+	"github.com/nicola-strappazzon/argos/internal/sqlguard"
+	"github.com/nicola-strappazzon/argos/tools/registry"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	// misestimateFactor is how far off the estimated rows of a node must be
+	// from the actual ones to be reported.
+	misestimateFactor = 10
+	// largeTable is the estimated number of rows from which a sequential
+	// scan is reported.
+	largeTable = 100000
+)
+
+// Node is a node of the plan. Nodes are listed depth first: the children of
+// a node follow it, with a Depth one higher and its ID as Parent. The root
+// has no parent: -1.
+type Node struct {
+	ID                 int     `json:"id"`
+	Parent             int     `json:"parent"`
+	Depth              int     `json:"depth"`
+	NodeType           string  `json:"node_type"`
+	ParentRelationship string  `json:"parent_relationship,omitempty"`
+	SubplanName        string  `json:"subplan_name,omitempty"`
+	Relation           string  `json:"relation,omitempty"`
+	Schema             string  `json:"schema,omitempty"`
+	Alias              string  `json:"alias,omitempty"`
+	Index              string  `json:"index,omitempty"`
+	JoinType           string  `json:"join_type,omitempty"`
+	StartupCost        float64 `json:"startup_cost"`
+	TotalCost          float64 `json:"total_cost"`
+	PlanRows           float64 `json:"plan_rows"`
+	PlanWidth          int64   `json:"plan_width"`
+	// The actual figures are those of EXPLAIN ANALYZE, per loop.
+	ActualStartupTimeMs float64  `json:"actual_startup_time_ms,omitempty"`
+	ActualTotalTimeMs   float64  `json:"actual_total_time_ms,omitempty"`
+	ActualRows          float64  `json:"actual_rows,omitempty"`
+	ActualLoops         float64  `json:"actual_loops,omitempty"`
+	RowsRemovedByFilter float64  `json:"rows_removed_by_filter,omitempty"`
+	Filter              string   `json:"filter,omitempty"`
+	IndexCond           string   `json:"index_cond,omitempty"`
+	HashCond            string   `json:"hash_cond,omitempty"`
+	SortKey             []string `json:"sort_key,omitempty"`
+	SortMethod          string   `json:"sort_method,omitempty"`
+	SortSpaceUsedKB     int64    `json:"sort_space_used_kb,omitempty"`
+	SortSpaceType       string   `json:"sort_space_type,omitempty"`
+	SharedHitBlocks     int64    `json:"shared_hit_blocks,omitempty"`
+	SharedReadBlocks    int64    `json:"shared_read_blocks,omitempty"`
+	TempWrittenBlocks   int64    `json:"temp_written_blocks,omitempty"`
+	// Workers are the figures of the parallel workers that ran the node,
+	// with EXPLAIN ANALYZE.
+	Workers []Worker `json:"workers,omitempty"`
+}
+
+// Worker is what a parallel worker did for a node; only sorts report it.
+type Worker struct {
+	Number          int    `json:"worker_number"`
+	SortMethod      string `json:"sort_method,omitempty"`
+	SortSpaceUsedKB int64  `json:"sort_space_used_kb,omitempty"`
+	SortSpaceType   string `json:"sort_space_type,omitempty"`
+}
+
+// Finding is a problem spotted in a node of the plan.
+type Finding struct {
+	Node   int    `json:"node"`
+	Kind   string `json:"kind"`
+	Detail string `json:"detail"`
+}
+
+type Result struct {
+	Instance        string  `json:"instance"`
+	Database        string  `json:"database"`
+	Query           string  `json:"query"`
+	Analyze         bool    `json:"analyze"`
+	TotalCost       float64 `json:"total_cost"`
+	PlanningTimeMs  float64 `json:"planning_time_ms,omitempty"`
+	ExecutionTimeMs float64 `json:"execution_time_ms,omitempty"`
+	// Settings are the planner settings that differ from their default.
+	Settings map[string]string `json:"settings,omitempty"`
+	Nodes    []Node            `json:"nodes"`
+	Total    int               `json:"total"`
+	Findings []Finding         `json:"findings"`
+	// Tree renders Nodes like the text format of EXPLAIN.
+	Tree string `json:"tree"`
+}
+
+func init() {
+	registry.Add(registry.Property{
+		Name:        "postgresql_explain",
+		Description: "Run EXPLAIN (FORMAT JSON, VERBOSE, BUFFERS, SETTINGS) on a PostgreSQL query and return the plan as a list of nodes with their costs, estimated rows and, with analyze, actual rows, time and buffers, rendered as a tree too. Flags estimated rows off by 10 times or more, sequential scans on tables of more than 100000 rows and sorts spilling to disk, in parallel workers too. Optionally runs EXPLAIN ANALYZE (WARNING: this executes the query, in a read-only transaction that is rolled back).",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"db_instance_identifier": map[string]any{
+					"type":        "string",
+					"description": "The RDS DB instance identifier. Credentials are read from ~/.pgpass matching the instance identifier against the hostname.",
+				},
+				"database": map[string]any{
+					"type":        "string",
+					"description": "The database to run the query in.",
+				},
+				"query": map[string]any{
+					"type":        "string",
+					"description": "The SELECT query to explain. Only a single read-only statement is accepted: data-modifying statements, locking reads and side-effecting functions (e.g. pg_sleep) are rejected.",
+				},
+				"analyze": map[string]any{
+					"type":        "boolean",
+					"description": "Run EXPLAIN ANALYZE instead of EXPLAIN. This actually executes the query. Default: false.",
+				},
+			},
+			"required": []string{"db_instance_identifier", "database", "query"},
+		},
+		Output:  Result{},
+		Timeout: 2 * time.Minute,
+		Function: func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
+			instanceID, _ := args["db_instance_identifier"].(string)
+			database, _ := args["database"].(string)
+			query, _ := args["query"].(string)
+			analyze, _ := args["analyze"].(bool)
+
+			if err := sqlguard.CheckReadOnly(sqlguard.PostgreSQL, "EXPLAIN "+query); err != nil {
+				return &mcp.CallToolResult{}, nil, err
+			}
+
+			db, err := psqldriver.ConnectDB(ctx, instanceID, database)
+			if err != nil {
+				return &mcp.CallToolResult{}, nil, err
+			}
+
+			// EXPLAIN ANALYZE runs the query: it does so in a read-only
+			// transaction that is never committed.
+			tx, err := db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+			if err != nil {
+				return &mcp.CallToolResult{}, nil, fmt.Errorf("starting transaction: %w", err)
+			}
+			defer tx.Rollback()
+
+			var version int
+			if err := tx.QueryRowContext(ctx, `SELECT current_setting('server_version_num')::int`).Scan(&version); err != nil {
+				return &mcp.CallToolResult{}, nil, fmt.Errorf("reading server version: %w", err)
+			}
+
+			// BUFFERS needs ANALYZE before PostgreSQL 13. VERBOSE adds the
+			// schema of the relations.
+			options := []string{"FORMAT JSON", "VERBOSE", "SETTINGS"}
+			if analyze || version >= 130000 {
+				options = append(options, "BUFFERS")
+			}
+			if analyze {
+				options = append(options, "ANALYZE")
+			}
+
+			var output []byte
+			if err := tx.QueryRowContext(ctx, "EXPLAIN ("+strings.Join(options, ", ")+") "+query).Scan(&output); err != nil {
+				return &mcp.CallToolResult{}, nil, fmt.Errorf("executing explain: %w", err)
+			}
+
+			var explains []explain
+			if err := json.Unmarshal(output, &explains); err != nil {
+				return &mcp.CallToolResult{}, nil, fmt.Errorf("parsing explain output: %w", err)
+			}
+			if len(explains) == 0 {
+				return &mcp.CallToolResult{}, nil, errors.New("parsing explain output: no plan")
+			}
+			e := explains[0]
+
+			result := Result{
+				Instance:        instanceID,
+				Database:        database,
+				Query:           query,
+				Analyze:         analyze,
+				TotalCost:       e.Plan.TotalCost,
+				PlanningTimeMs:  e.PlanningTime,
+				ExecutionTimeMs: e.ExecutionTime,
+				Settings:        e.Settings,
+				Nodes:           flatten(e.Plan, -1, 0, make([]Node, 0)),
+				Findings:        make([]Finding, 0),
+			}
+			result.Total = len(result.Nodes)
+
+			sizes, err := tableSizes(ctx, tx, result.Nodes)
+			if err != nil {
+				return &mcp.CallToolResult{}, nil, err
+			}
+			for _, n := range result.Nodes {
+				result.Findings = append(result.Findings, check(n, result.Nodes, sizes)...)
+			}
+			result.Tree = render(result.Nodes, analyze)
+
+			return &mcp.CallToolResult{}, result, nil
+		},
+	})
+}
+
+// explain is an element of the output of EXPLAIN (FORMAT JSON).
+type explain struct {
+	Plan          plan              `json:"Plan"`
+	PlanningTime  float64           `json:"Planning Time"`
+	ExecutionTime float64           `json:"Execution Time"`
+	Settings      map[string]string `json:"Settings"`
+}
+
+type plan struct {
+	NodeType            string   `json:"Node Type"`
+	ParentRelationship  string   `json:"Parent Relationship"`
+	SubplanName         string   `json:"Subplan Name"`
+	RelationName        string   `json:"Relation Name"`
+	Schema              string   `json:"Schema"`
+	Alias               string   `json:"Alias"`
+	IndexName           string   `json:"Index Name"`
+	JoinType            string   `json:"Join Type"`
+	StartupCost         float64  `json:"Startup Cost"`
+	TotalCost           float64  `json:"Total Cost"`
+	PlanRows            float64  `json:"Plan Rows"`
+	PlanWidth           int64    `json:"Plan Width"`
+	ActualStartupTime   float64  `json:"Actual Startup Time"`
+	ActualTotalTime     float64  `json:"Actual Total Time"`
+	ActualRows          float64  `json:"Actual Rows"`
+	ActualLoops         float64  `json:"Actual Loops"`
+	RowsRemovedByFilter float64  `json:"Rows Removed by Filter"`
+	Filter              string   `json:"Filter"`
+	IndexCond           string   `json:"Index Cond"`
+	HashCond            string   `json:"Hash Cond"`
+	SortKey             []string `json:"Sort Key"`
+	SortMethod          string   `json:"Sort Method"`
+	SortSpaceUsed       int64    `json:"Sort Space Used"`
+	SortSpaceType       string   `json:"Sort Space Type"`
+	SharedHitBlocks     int64    `json:"Shared Hit Blocks"`
+	SharedReadBlocks    int64    `json:"Shared Read Blocks"`
+	TempWrittenBlocks   int64    `json:"Temp Written Blocks"`
+	Workers             []worker `json:"Workers"`
+	Plans               []plan   `json:"Plans"`
+}
+
+type worker struct {
+	WorkerNumber  int    `json:"Worker Number"`
+	SortMethod    string `json:"Sort Method"`
+	SortSpaceUsed int64  `json:"Sort Space Used"`
+	SortSpaceType string `json:"Sort Space Type"`
+}
+
+func flatten(p plan, parent, depth int, nodes []Node) []Node {
+	var workers []Worker
+	for _, w := range p.Workers {
+		workers = append(workers, Worker{
+			Number:          w.WorkerNumber,
+			SortMethod:      w.SortMethod,
+			SortSpaceUsedKB: w.SortSpaceUsed,
+			SortSpaceType:   w.SortSpaceType,
+		})
+	}
+
+	id := len(nodes)
+	nodes = append(nodes, Node{
+		ID:                  id,
+		Parent:              parent,
+		Depth:               depth,
+		NodeType:            p.NodeType,
+		ParentRelationship:  p.ParentRelationship,
+		SubplanName:         p.SubplanName,
+		Relation:            p.RelationName,
+		Schema:              p.Schema,
+		Alias:               p.Alias,
+		Index:               p.IndexName,
+		JoinType:            p.JoinType,
+		StartupCost:         p.StartupCost,
+		TotalCost:           p.TotalCost,
+		PlanRows:            p.PlanRows,
+		PlanWidth:           p.PlanWidth,
+		ActualStartupTimeMs: p.ActualStartupTime,
+		ActualTotalTimeMs:   p.ActualTotalTime,
+		ActualRows:          p.ActualRows,
+		ActualLoops:         p.ActualLoops,
+		RowsRemovedByFilter: p.RowsRemovedByFilter,
+		Filter:              p.Filter,
+		IndexCond:           p.IndexCond,
+		HashCond:            p.HashCond,
+		SortKey:             p.SortKey,
+		SortMethod:          p.SortMethod,
+		SortSpaceUsedKB:     p.SortSpaceUsed,
+		SortSpaceType:       p.SortSpaceType,
+		SharedHitBlocks:     p.SharedHitBlocks,
+		SharedReadBlocks:    p.SharedReadBlocks,
+		TempWrittenBlocks:   p.TempWrittenBlocks,
+		Workers:             workers,
+	})
+	for _, c := range p.Plans {
+		nodes = flatten(c, id, depth+1, nodes)
+	}
+	return nodes
+}
+
+// tableSizes returns the estimated number of rows of the tables scanned
+// sequentially, by schema and name: tables of the same name in different
+// schemas are told apart with the schema VERBOSE adds to the plan.
+func tableSizes(ctx context.Context, tx *sql.Tx, nodes []Node) (map[string]int64, error) {
+	sizes := map[string]int64{}
+
+	var schemas, names []string
+	for _, n := range nodes {
+		if n.NodeType == "Seq Scan" && n.Relation != "" {
+			schemas = append(schemas, n.Schema)
+			names = append(names, n.Relation)
+		}
+	}
+	if len(names) == 0 {
+		return sizes, nil
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT n.nspname, c.relname, GREATEST(c.reltuples::bigint, 0)
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		JOIN unnest($1::text[], $2::text[]) AS t(nspname, relname) ON t.nspname = n.nspname AND t.relname = c.relname
+		WHERE c.relkind IN ('r', 'p', 'm')`, pq.Array(schemas), pq.Array(names))
+	if err != nil {
+		return nil, fmt.Errorf("reading table sizes: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var schema, name string
+		var n int64
+		if err := rows.Scan(&schema, &name, &n); err != nil {
+			return nil, fmt.Errorf("scanning table size: %w", err)
+		}
+		sizes[schema+"."+name] = n
+	}
+
+	return sizes, rows.Err()
+}
+
+func check(n Node, nodes []Node, sizes map[string]int64) []Finding {
+	var findings []Finding
+
+	// Below a Limit, fewer rows than estimated are expected: the scan
+	// stops early.
+	if n.ActualLoops > 0 {
+		actual, estimated := math.Max(n.ActualRows, 1), math.Max(n.PlanRows, 1)
+		factor := math.Max(actual/estimated, estimated/actual)
+		direction := "under"
+		if estimated > actual {
+			direction = "over"
+		}
+		if factor >= misestimateFactor && (direction == "under" || !belowLimit(n, nodes)) {
+			findings = append(findings, Finding{
+				Node: n.ID,
+				Kind: "misestimate",
+				Detail: fmt.Sprintf("%s: estimated %g rows, got %g (%.0fx %sestimate): check the statistics with ANALYZE, or extended statistics on correlated columns",
+					label(n), n.PlanRows, n.ActualRows, factor, direction),
+			})
+		}
+	}
+
+	if rows, ok := sizes[n.Schema+"."+n.Relation]; ok && n.NodeType == "Seq Scan" && rows > largeTable {
+		findings = append(findings, Finding{
+			Node: n.ID,
+			Kind: "seq_scan",
+			Detail: fmt.Sprintf("%s reads all of about %d rows: an index on the columns of the filter may avoid it",
+				label(n), rows),
+		})
+	}
+
+	if n.SortSpaceType == "Disk" {
+		findings = append(findings, Finding{
+			Node: n.ID,
+			Kind: "sort_on_disk",
+			Detail: fmt.Sprintf("%s spilled %d kB to disk (%s): raise work_mem above that, or sort fewer rows",
+				label(n), n.SortSpaceUsedKB, n.SortMethod),
+		})
+	}
+	for _, w := range n.Workers {
+		if w.SortSpaceType == "Disk" {
+			findings = append(findings, Finding{
+				Node: n.ID,
+				Kind: "sort_on_disk",
+				Detail: fmt.Sprintf("%s spilled %d kB to disk in worker %d (%s): raise work_mem above that, or sort fewer rows",
+					label(n), w.SortSpaceUsedKB, w.Number, w.SortMethod),
+			})
+		}
+	}
+
+	return findings
+}
+
+func belowLimit(n Node, nodes []Node) bool {
+	for p := n.Parent; p >= 0; p = nodes[p].Parent {
+		if nodes[p].NodeType == "Limit" {
+			return true
+		}
+	}
+	return false
+}
+
+// label names a node like the text format of EXPLAIN, e.g. "Index Scan
+// using orders_pkey on orders o".
+func label(n Node) string {
+	s := n.NodeType
+	if n.JoinType != "" && n.JoinType != "Inner" {
+		s += " (" + n.JoinType + ")"
+	}
+	if n.Index != "" {
+		s += " using " + n.Index
+	}
+	if n.Relation != "" {
+		s += " on " + n.Relation
+		if n.Alias != "" && n.Alias != n.Relation {
+			s += " " + n.Alias
+		}
+	}
+	return s
+}
+
+// render draws the plan like the text format of EXPLAIN:
+//
+//	Sort  (cost=1.10..1.12 rows=10 width=8)
+//	  ->  Seq Scan on orders  (cost=0.00..1.01 rows=10 width=8)
+func render(nodes []Node, analyze bool) string {
+	var sb strings.Builder
+	for _, n := range nodes {
+		if n.Depth > 0 {
+			sb.WriteString(strings.Repeat("      ", n.Depth-1) + "  ->  ")
+		}
+		if n.SubplanName != "" {
+			sb.WriteString(n.SubplanName + ": ")
+		}
+		fmt.Fprintf(&sb, "%s  (cost=%.2f..%.2f rows=%g width=%d)", label(n), n.StartupCost, n.TotalCost, n.PlanRows, n.PlanWidth)
+		switch {
+		case analyze && n.ActualLoops > 0:
+			fmt.Fprintf(&sb, " (actual time=%.3f..%.3f rows=%g loops=%g)", n.ActualStartupTimeMs, n.ActualTotalTimeMs, n.ActualRows, n.ActualLoops)
+		case analyze:
+			sb.WriteString(" (never executed)")
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
@@ -0,0 +1,169 @@
+package postgresql_explain_test
+
+import (
+	"testing"
+
+	"github.com/nicola-strappazzon/argos/internal/testharness"
+	"github.com/nicola-strappazzon/argos/tools/plugins/postgresql/postgresql_explain"
+)
+
+const analyzed = `[{
+  "Plan": {
+    "Node Type": "Limit", "Startup Cost": 9000.5, "Total Cost": 9000.53, "Plan Rows": 10, "Plan Width": 16,
+    "Actual Startup Time": 812.1, "Actual Total Time": 812.2, "Actual Rows": 10, "Actual Loops": 1,
+    "Plans": [{
+      "Node Type": "Sort", "Parent Relationship": "Outer",
+      "Startup Cost": 9000.5, "Total Cost": 9013.0, "Plan Rows": 5000, "Plan Width": 16,
+      "Actual Startup Time": 812.1, "Actual Total Time": 812.1, "Actual Rows": 10, "Actual Loops": 1,
+      "Sort Key": ["o.created_at DESC"], "Sort Method": "external merge", "Sort Space Used": 25600, "Sort Space Type": "Disk",
+      "Temp Written Blocks": 3200,
+      "Plans": [{
+        "Node Type": "Seq Scan", "Parent Relationship": "Outer", "Relation Name": "orders", "Schema": "public", "Alias": "o",
+        "Startup Cost": 0.0, "Total Cost": 8500.0, "Plan Rows": 5000, "Plan Width": 16,
+        "Actual Startup Time": 0.02, "Actual Total Time": 640.5, "Actual Rows": 250000, "Actual Loops": 1,
+        "Filter": "(status = 'paid'::text)", "Rows Removed by Filter": 750000,
+        "Shared Hit Blocks": 120, "Shared Read Blocks": 4880
+      }]
+    }]
+  },
+  "Settings": {"work_mem": "4MB"},
+  "Planning Time": 0.25,
+  "Execution Time": 815.75
+}]`
+
+func TestExplainAnalyze(t *testing.T) {
+	mock := testharness.PostgreSQL(t, "pg1", "shop")
+	mock.MatchExpectationsInOrder(true)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`server_version_num`).
+		WillReturnRows(testharness.Rows([]string{"current_setting"}, []any{160003}))
+	mock.ExpectQuery(`^EXPLAIN \(FORMAT JSON, VERBOSE, SETTINGS, BUFFERS, ANALYZE\) SELECT \* FROM orders o WHERE status = 'paid' ORDER BY created_at DESC LIMIT 10$`).
+		WillReturnRows(testharness.Rows([]string{"QUERY PLAN"}, []any{analyzed}))
+	mock.ExpectQuery(`FROM pg_class c\s+JOIN pg_namespace n`).
+		WithArgs(`{"public"}`, `{"orders"}`).
+		WillReturnRows(testharness.Rows([]string{"nspname", "relname", "reltuples"}, []any{"public", "orders", 1000000}))
+	mock.ExpectRollback()
+
+	var got postgresql_explain.Result
+	testharness.Decode(t, testharness.Call(t, "postgresql_explain", map[string]any{
+		"db_instance_identifier": "pg1",
+		"database":               "shop",
+		"query":                  "SELECT * FROM orders o WHERE status = 'paid' ORDER BY created_at DESC LIMIT 10",
+		"analyze":                true,
+	}), &got)
+
+	if !got.Analyze || got.Total != 3 || got.ExecutionTimeMs != 815.75 || got.Settings["work_mem"] != "4MB" {
+		t.Fatalf("unexpected result: %+v", got)
+	}
+	if scan := got.Nodes[2]; scan.Parent != 1 || scan.Depth != 2 || scan.Relation != "orders" || scan.SharedReadBlocks != 4880 {
+		t.Errorf("unexpected node: %+v", scan)
+	}
+
+	// The Sort below the Limit returns fewer rows than estimated: that is
+	// not a misestimate.
+	kinds := map[string]int{}
+	for _, f := range got.Findings {
+		kinds[f.Kind] = f.Node
+	}
+	if len(got.Findings) != 3 || kinds["sort_on_disk"] != 1 || kinds["misestimate"] != 2 || kinds["seq_scan"] != 2 {
+		t.Errorf("unexpected findings: %+v", got.Findings)
+	}
+
+	want := `Limit  (cost=9000.50..9000.53 rows=10 width=16) (actual time=812.100..812.200 rows=10 loops=1)
+  ->  Sort  (cost=9000.50..9013.00 rows=5000 width=16) (actual time=812.100..812.100 rows=10 loops=1)
+        ->  Seq Scan on orders o  (cost=0.00..8500.00 rows=5000 width=16) (actual time=0.020..640.500 rows=250000 loops=1)
+`
+	if got.Tree != want {
+		t.Errorf("unexpected tree:\n%s\nwant:\n%s", got.Tree, want)
+	}
+}
+
+func TestExplainParallelSort(t *testing.T) {
+	mock := testharness.PostgreSQL(t, "pg1", "shop")
+	mock.MatchExpectationsInOrder(true)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`server_version_num`).
+		WillReturnRows(testharness.Rows([]string{"current_setting"}, []any{160003}))
+	mock.ExpectQuery(`^EXPLAIN `).
+		WillReturnRows(testharness.Rows([]string{"QUERY PLAN"}, []any{`[{"Plan": {
+			"Node Type": "Gather Merge", "Startup Cost": 1000, "Total Cost": 2000, "Plan Rows": 100, "Plan Width": 16,
+			"Actual Startup Time": 50, "Actual Total Time": 60, "Actual Rows": 100, "Actual Loops": 1,
+			"Plans": [{
+				"Node Type": "Sort", "Parent Relationship": "Outer",
+				"Startup Cost": 1000, "Total Cost": 1100, "Plan Rows": 50, "Plan Width": 16,
+				"Actual Startup Time": 40, "Actual Total Time": 45, "Actual Rows": 33, "Actual Loops": 3,
+				"Sort Key": ["created_at"], "Sort Method": "quicksort", "Sort Space Used": 30, "Sort Space Type": "Memory",
+				"Workers": [
+					{"Worker Number": 0, "Sort Method": "external merge", "Sort Space Used": 2048, "Sort Space Type": "Disk"},
+					{"Worker Number": 1, "Sort Method": "quicksort", "Sort Space Used": 28, "Sort Space Type": "Memory"}
+				],
+				"Plans": [{
+					"Node Type": "Seq Scan", "Parent Relationship": "Outer", "Relation Name": "orders", "Schema": "archive", "Alias": "orders",
+					"Startup Cost": 0, "Total Cost": 900, "Plan Rows": 50, "Plan Width": 16,
+					"Actual Startup Time": 0.01, "Actual Total Time": 30, "Actual Rows": 33, "Actual Loops": 3
+				}]
+			}]
+		}}]`}))
+	// The orders of the public schema are large, those scanned are not.
+	mock.ExpectQuery(`FROM pg_class c`).
+		WithArgs(`{"archive"}`, `{"orders"}`).
+		WillReturnRows(testharness.Rows([]string{"nspname", "relname", "reltuples"},
+			[]any{"archive", "orders", 100},
+			[]any{"public", "orders", 1000000},
+		))
+	mock.ExpectRollback()
+
+	var got postgresql_explain.Result
+	testharness.Decode(t, testharness.Call(t, "postgresql_explain", map[string]any{
+		"db_instance_identifier": "pg1",
+		"database":               "shop",
+		"query":                  "SELECT * FROM archive.orders ORDER BY created_at",
+		"analyze":                true,
+	}), &got)
+
+	if len(got.Findings) != 1 || got.Findings[0].Kind != "sort_on_disk" || got.Findings[0].Node != 1 {
+		t.Fatalf("expected only the sort spilled by worker 0, got %+v", got.Findings)
+	}
+	if sort := got.Nodes[1]; len(sort.Workers) != 2 || sort.Workers[0].SortSpaceUsedKB != 2048 {
+		t.Errorf("unexpected workers: %+v", sort.Workers)
+	}
+	if scan := got.Nodes[2]; scan.Schema != "archive" {
+		t.Errorf("unexpected scan: %+v", scan)
+	}
+}
+
+func TestExplainPostgreSQL12(t *testing.T) {
+	mock := testharness.PostgreSQL(t, "pg1", "shop")
+	mock.ExpectBegin()
+	mock.ExpectQuery(`server_version_num`).
+		WillReturnRows(testharness.Rows([]string{"current_setting"}, []any{120019}))
+	mock.ExpectQuery(`^EXPLAIN \(FORMAT JSON, VERBOSE, SETTINGS\) SELECT \* FROM users WHERE id = 1$`).
+		WillReturnRows(testharness.Rows([]string{"QUERY PLAN"}, []any{`[{"Plan": {
+			"Node Type": "Index Scan", "Index Name": "users_pkey", "Relation Name": "users", "Alias": "users",
+			"Startup Cost": 0.29, "Total Cost": 8.31, "Plan Rows": 1, "Plan Width": 64, "Index Cond": "(id = 1)"
+		}}]`}))
+	mock.ExpectRollback()
+
+	var got postgresql_explain.Result
+	testharness.Decode(t, testharness.Call(t, "postgresql_explain", map[string]any{
+		"db_instance_identifier": "pg1",
+		"database":               "shop",
+		"query":                  "SELECT * FROM users WHERE id = 1",
+	}), &got)
+
+	if got.Analyze || got.Total != 1 || got.Nodes[0].Parent != -1 || got.Nodes[0].IndexCond != "(id = 1)" || len(got.Findings) != 0 {
+		t.Fatalf("unexpected result: %+v", got)
+	}
+	if want := "Index Scan using users_pkey on users  (cost=0.29..8.31 rows=1 width=64)\n"; got.Tree != want {
+		t.Errorf("unexpected tree: %q", got.Tree)
+	}
+}
+
+func TestExplainRejectsWrites(t *testing.T) {
+	testharness.Failure(t, testharness.Call(t, "postgresql_explain", map[string]any{
+		"db_instance_identifier": "pg1",
+		"database":               "shop",
+		"query":                  "DELETE FROM orders",
+		"analyze":                true,
+	}), "invalid_input")
+}
@@ -0,0 +1,199 @@
+package postgresql_log_digest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	logdigest "github.com/nicola-strappazzon/argos/internal/digest"
+	"github.com/nicola-strappazzon/argos/internal/pglog"
+	"github.com/nicola-strappazzon/argos/internal/timerange"
+	"github.com/nicola-strappazzon/argos/internal/toolerr"
+	"github.com/nicola-strappazzon/argos/tools/registry"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Autovacuum counts the automatic vacuum and analyze runs.
+type Autovacuum struct {
+	Vacuums    int `json:"vacuums"`
+	Analyzes   int `json:"analyzes"`
+	Wraparound int `json:"to_prevent_wraparound"`
+}
+
+type Result struct {
+	LogFilePath   string `json:"log_file_path"`
+	LogLinePrefix string `json:"log_line_prefix"`
+	// Messages is the number of messages analyzed, Skipped those the time
+	// window or database filter excluded.
+	Messages     int                `json:"messages"`
+	Skipped      int                `json:"skipped"`
+	FirstSeen    string             `json:"first_seen,omitempty"`
+	LastSeen     string             `json:"last_seen,omitempty"`
+	Queries      int                `json:"queries"`
+	Unique       int                `json:"unique_fingerprints"`
+	DurationMs   float64            `json:"total_duration_ms"`
+	OrderBy      string             `json:"order_by"`
+	Classes      []pglog.Class      `json:"classes"`
+	Errors       int                `json:"errors"`
+	Deadlocks    int                `json:"deadlocks"`
+	ErrorClasses []pglog.ErrorClass `json:"error_classes"`
+	LockWaits    int                `json:"lock_waits"`
+	// LongestLockWaits, SlowestAutovacuums and SlowestCheckpoints are the
+	// events that took the longest, at most limit of each.
+	LongestLockWaits   []pglog.LockWait        `json:"longest_lock_waits"`
+	Autovacuum         Autovacuum              `json:"autovacuum"`
+	SlowestAutovacuums []pglog.Vacuum          `json:"slowest_autovacuums"`
+	Checkpoints        pglog.CheckpointSummary `json:"checkpoints"`
+	SlowestCheckpoints []pglog.Checkpoint      `json:"slowest_checkpoints"`
+	Interval           string                  `json:"interval"`
+	Timeline           []pglog.Bucket          `json:"timeline"`
+	Notes              []string                `json:"notes,omitempty"`
+}
+
+func init() {
+	registry.Add(registry.Property{
+		Name:        "postgresql_log_digest",
+		Description: "Analyze a downloaded PostgreSQL log (e.g. from aws_rds_log_download or aws_rds_cloudwatch_log_download) like pgBadger: statements logged by log_min_duration_statement digested by fingerprint (count, share of the total time, duration total/avg/min/max/p95, databases, users, slowest sample), errors grouped by message, deadlocks, lock waits (log_lock_waits), autovacuum runs (log_autovacuum_min_duration) and checkpoints (log_checkpoints), with a timeline of those events per minute, hour or day. Lines are parsed with the instance's log_line_prefix.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"log_file_path": map[string]any{
+					"type":        "string",
+					"description": "Absolute path to the PostgreSQL log file to analyze.",
+				},
+				"log_line_prefix": map[string]any{
+					"type":        "string",
+					"description": "The log_line_prefix the log was written with (SHOW log_line_prefix). Default: " + pglog.DefaultPrefix + ", the one of RDS and Aurora.",
+				},
+				"since": map[string]any{
+					"type":        "string",
+					"description": "Only analyze messages logged at or after this time, RFC 3339 (e.g. 2024-05-14T09:00:00Z).",
+				},
+				"until": map[string]any{
+					"type":        "string",
+					"description": "Only analyze messages logged at or before this time, RFC 3339.",
+				},
+				"database": map[string]any{
+					"type":        "string",
+					"description": "Only analyze messages of this database; those of background processes, such as checkpoints, are kept.",
+				},
+				"order_by": map[string]any{
+					"type":        "string",
+					"enum":        pglog.Orders(),
+					"description": "Metric the fingerprints are ranked by (default: total_time).",
+				},
+				"interval": map[string]any{
+					"type":        "string",
+					"enum":        []string{"minute", "hour", "day"},
+					"description": "Size of the timeline buckets (default: minute up to 2 hours of log, hour up to 3 days, day beyond). A coarser one is used past 1000 buckets.",
+				},
+				"limit": map[string]any{
+					"type":        "integer",
+					"description": "Maximum number of fingerprints, error classes and events of each kind to return (default: 20).",
+				},
+			},
+			"required": []string{"log_file_path"},
+		},
+		Output:  Result{},
+		Timeout: 10 * time.Minute,
+		Function: func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
+			logFilePath, _ := args["log_file_path"].(string)
+			database, _ := args["database"].(string)
+			interval, _ := args["interval"].(string)
+
+			prefix, _ := args["log_line_prefix"].(string)
+			if prefix == "" {
+				prefix = pglog.DefaultPrefix
+			}
+
+			orderBy, _ := args["order_by"].(string)
+			if orderBy == "" {
+				orderBy = pglog.ByTotalTime
+			}
+
+			limit := 20
+			if l, ok := args["limit"].(float64); ok && l > 0 {
+				limit = int(l)
+			}
+
+			filter := pglog.Filter{Database: database}
+			since, until, err := timerange.ParseNames(args, "since", "until", 0)
+			if err != nil {
+				return &mcp.CallToolResult{}, nil, err
+			}
+			filter.Since, filter.Until = since, until
+
+			parser, err := pglog.NewParser(prefix)
+			if err != nil {
+				return &mcp.CallToolResult{}, nil, toolerr.New(toolerr.InvalidInput, "pass the value of SHOW log_line_prefix", err)
+			}
+
+			f, err := os.Open(logFilePath)
+			if os.IsNotExist(err) {
+				return &mcp.CallToolResult{}, nil, toolerr.Errorf(toolerr.NotFound, "download the log first with aws_rds_log_download or aws_rds_cloudwatch_log_download and pass its output_path",
+					"log file not found: %s", logFilePath)
+			}
+			if err != nil {
+				return &mcp.CallToolResult{}, nil, err
+			}
+			defer f.Close()
+
+			digest := pglog.NewDigest(filter)
+			if err := parser.Parse(logdigest.NewReader(ctx, f), digest.Add); err != nil {
+				return &mcp.CallToolResult{}, nil, fmt.Errorf("reading %s: %w", logFilePath, err)
+			}
+
+			if interval == "" {
+				interval = digest.Interval()
+			}
+			interval, timeline := digest.Timeline(interval)
+
+			result := Result{
+				LogFilePath:        logFilePath,
+				LogLinePrefix:      prefix,
+				Messages:           digest.Entries,
+				Skipped:            digest.Skipped,
+				Queries:            digest.Queries,
+				Unique:             digest.Unique(),
+				DurationMs:         digest.Duration,
+				OrderBy:            orderBy,
+				Classes:            digest.Classes(orderBy, limit),
+				Errors:             digest.Errors,
+				Deadlocks:          digest.Deadlocks,
+				ErrorClasses:       digest.ErrorClasses(limit),
+				LockWaits:          len(digest.LockWaits),
+				LongestLockWaits:   digest.LongestLockWaits(limit),
+				SlowestAutovacuums: digest.SlowestVacuums(limit),
+				Checkpoints:        digest.CheckpointSummary(),
+				SlowestCheckpoints: digest.SlowestCheckpoints(limit),
+				Interval:           interval,
+				Timeline:           timeline,
+			}
+			for _, v := range digest.Vacuums {
+				if v.Kind == "analyze" {
+					result.Autovacuum.Analyzes++
+				} else {
+					result.Autovacuum.Vacuums++
+				}
+				if v.Wraparound {
+					result.Autovacuum.Wraparound++
+				}
+			}
+			if !digest.First.IsZero() {
+				result.FirstSeen = digest.First.Format(time.RFC3339)
+				result.LastSeen = digest.Last.Format(time.RFC3339)
+			}
+
+			switch {
+			case digest.Entries == 0 && digest.Skipped == 0:
+				result.Notes = append(result.Notes, fmt.Sprintf("No line matched the log_line_prefix %q: pass the instance's value of SHOW log_line_prefix.", prefix))
+			case digest.Queries == 0:
+				result.Notes = append(result.Notes, "No statement duration found: set log_min_duration_statement (e.g. 1000 ms) in the parameter group to log slow statements.")
+			}
+
+			return &mcp.CallToolResult{}, result, nil
+		},
+	})
+}
@@ -0,0 +1,58 @@
+package postgresql_log_digest_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nicola-strappazzon/argos/internal/testharness"
+	"github.com/nicola-strappazzon/argos/internal/toolerr"
+	"github.com/nicola-strappazzon/argos/tools/plugins/postgresql/postgresql_log_digest"
+)
+
+const fixture = "../../../../internal/pglog/testdata/postgresql.log"
+
+func TestDigest(t *testing.T) {
+	var got postgresql_log_digest.Result
+	testharness.Decode(t, testharness.Call(t, "postgresql_log_digest", map[string]any{
+		"log_file_path": fixture,
+		"limit":         1,
+	}), &got)
+
+	if got.Queries != 3 || got.Unique != 2 || len(got.Classes) != 1 || got.Classes[0].Fingerprint != "select status, count(*) from orders group by status" {
+		t.Errorf("expected the slowest fingerprint first, got %+v", got.Classes)
+	}
+	if got.Errors != 4 || got.Deadlocks != 1 || got.LockWaits != 1 || got.Autovacuum.Vacuums != 1 || got.Autovacuum.Analyzes != 1 || got.Checkpoints.Count != 2 {
+		t.Errorf("unexpected event counts: %+v", got)
+	}
+	if got.Interval != "minute" || len(got.Timeline) != 5 || got.FirstSeen != "2024-05-14T09:00:01Z" {
+		t.Errorf("unexpected timeline: %s %+v", got.Interval, got.Timeline)
+	}
+	if len(got.Notes) != 0 {
+		t.Errorf("unexpected notes: %v", got.Notes)
+	}
+}
+
+func TestPrefixMismatch(t *testing.T) {
+	var got postgresql_log_digest.Result
+	testharness.Decode(t, testharness.Call(t, "postgresql_log_digest", map[string]any{
+		"log_file_path":   fixture,
+		"log_line_prefix": "%m [%p] ",
+	}), &got)
+
+	if got.Messages != 0 || len(got.Notes) != 1 || !strings.Contains(got.Notes[0], "SHOW log_line_prefix") {
+		t.Errorf("expected a note on the log_line_prefix, got %+v", got)
+	}
+}
+
+func TestInvalidInput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "postgresql.log")
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	testharness.Failure(t, testharness.Call(t, "postgresql_log_digest", map[string]any{"log_file_path": "/nonexistent/postgresql.log"}), toolerr.NotFound)
+	testharness.Failure(t, testharness.Call(t, "postgresql_log_digest", map[string]any{"log_file_path": path, "log_line_prefix": "%Z"}), toolerr.InvalidInput)
+	testharness.Failure(t, testharness.Call(t, "postgresql_log_digest", map[string]any{"log_file_path": path, "since": "today"}), toolerr.InvalidInput)
+}
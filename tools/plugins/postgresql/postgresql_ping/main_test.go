@@ -0,0 +1,28 @@
+package postgresql_ping_test
+
+import (
+	"testing"
+
+	"github.com/nicola-strappazzon/argos/internal/testharness"
+	"github.com/nicola-strappazzon/argos/tools/plugins/postgresql/postgresql_ping"
+)
+
+func TestPing(t *testing.T) {
+	testharness.PostgreSQL(t, "pg1", "")
+
+	var got postgresql_ping.Result
+	testharness.Decode(t, testharness.Call(t, "postgresql_ping", map[string]any{"db_instance_identifier": "pg1"}), &got)
+
+	if !got.Success || got.Instance != "pg1" {
+		t.Fatalf("unexpected result: %+v", got)
+	}
+}
+
+func TestPingUnknownInstance(t *testing.T) {
+	var got postgresql_ping.Result
+	testharness.Decode(t, testharness.Call(t, "postgresql_ping", map[string]any{"db_instance_identifier": "missing"}), &got)
+
+	if got.Success || got.Error == "" {
+		t.Fatalf("expected a failed ping, got %+v", got)
+	}
+}
@@ -26,6 +26,14 @@ type Table struct {
 	LastAnalyze  string  `json:"last_analyze,omitempty"`
 }
 
+type Result struct {
+	Instance    string  `json:"instance"`
+	Database    string  `json:"database"`
+	Tables      []Table `json:"tables"`
+	Total       int     `json:"total"`
+	TotalSizeMB float64 `json:"total_size_mb"`
+}
+
 func init() {
 	registry.Add(registry.Property{
 		Name:        "postgresql_tables",
@@ -44,15 +52,15 @@ func init() {
 			},
 			"required": []string{"db_instance_identifier", "database"},
 		},
+		Output: Result{},
 		Function: func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
 			instanceID, _ := args["db_instance_identifier"].(string)
 			database, _ := args["database"].(string)
 
-			db, err := psqldriver.ConnectDB(instanceID, database)
+			db, err := psqldriver.ConnectDB(ctx, instanceID, database)
 			if err != nil {
 				return &mcp.CallToolResult{}, nil, err
 			}
-			defer db.Close()
 
 			query := `
 				SELECT
@@ -108,12 +116,12 @@ func init() {
 				return &mcp.CallToolResult{}, nil, fmt.Errorf("reading rows: %w", err)
 			}
 
-			return &mcp.CallToolResult{}, map[string]any{
-				"instance":      instanceID,
-				"database":      database,
-				"tables":        tables,
-				"total":         len(tables),
-				"total_size_mb": totalMB,
+			return &mcp.CallToolResult{}, Result{
+				Instance:    instanceID,
+				Database:    database,
+				Tables:      tables,
+				Total:       len(tables),
+				TotalSizeMB: totalMB,
 			}, nil
 		},
 	})
@@ -0,0 +1,36 @@
+package postgresql_tables_test
+
+import (
+	"testing"
+
+	"github.com/nicola-strappazzon/argos/internal/testharness"
+	"github.com/nicola-strappazzon/argos/tools/plugins/postgresql/postgresql_tables"
+)
+
+func TestTables(t *testing.T) {
+	mock := testharness.PostgreSQL(t, "pg1", "app")
+	mock.ExpectQuery(`FROM pg_class c`).
+		WillReturnRows(testharness.Rows([]string{
+			"schema", "name", "owner", "access_method", "row_count", "dead_tuples",
+			"data_mb", "index_mb", "total_mb", "comment", "last_vacuum", "last_analyze",
+		},
+			[]any{"public", "orders", "app", "heap", 120000, 3400, 96.0, 24.0, 120.0, "", "2024-05-14T08:00:00Z", nil},
+			[]any{"public", "users", "app", "heap", 5000, 0, 2.5, 0.5, 3.0, "Registered users", nil, nil},
+		))
+
+	var got postgresql_tables.Result
+	testharness.Decode(t, testharness.Call(t, "postgresql_tables", map[string]any{
+		"db_instance_identifier": "pg1",
+		"database":               "app",
+	}), &got)
+
+	if got.Total != 2 || got.TotalSizeMB != 123 || got.Database != "app" {
+		t.Fatalf("unexpected result: %+v", got)
+	}
+	if got.Tables[0].LastVacuum != "2024-05-14T08:00:00Z" || got.Tables[0].LastAnalyze != "" {
+		t.Errorf("unexpected maintenance timestamps: %+v", got.Tables[0])
+	}
+	if got.Tables[1].Comment != "Registered users" {
+		t.Errorf("unexpected comment: %+v", got.Tables[1])
+	}
+}
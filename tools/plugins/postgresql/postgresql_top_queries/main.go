@@ -0,0 +1,398 @@
+package postgresql_top_queries
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/nicola-strappazzon/argos/internal/display"
+	psqldriver "github.com/nicola-strappazzon/argos/internal/drivers/postgresql"
+	"github.com/nicola-strappazzon/argos/internal/toolerr"
+	"github.com/nicola-strappazzon/argos/tools/registry"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	defaultLimit = 20
+	maxInterval  = 300
+)
+
+// Orders are the metrics statements can be ranked by, see metrics.
+var orders = []string{
+	"total_time",
+	"mean_time",
+	"calls",
+	"rows",
+	"shared_blks_hit",
+	"shared_blks_read",
+	"temp_blks_written",
+}
+
+// metrics returns the value statements are ranked by, by order.
+var metrics = map[string]func(Statement) float64{
+	"total_time": func(s Statement) float64 { return s.TotalTimeMs },
+	"mean_time": func(s Statement) float64 {
+		if s.Calls == 0 {
+			return 0
+		}
+		return s.TotalTimeMs / float64(s.Calls)
+	},
+	"calls":             func(s Statement) float64 { return float64(s.Calls) },
+	"rows":              func(s Statement) float64 { return float64(s.Rows) },
+	"shared_blks_hit":   func(s Statement) float64 { return float64(s.SharedBlksHit) },
+	"shared_blks_read":  func(s Statement) float64 { return float64(s.SharedBlksRead) },
+	"temp_blks_written": func(s Statement) float64 { return float64(s.TempBlksWritten) },
+}
+
+type Statement struct {
+	QueryID         int64   `json:"query_id"`
+	Database        string  `json:"database"`
+	User            string  `json:"user"`
+	Query           string  `json:"query"`
+	Calls           int64   `json:"calls"`
+	TotalTimeMs     float64 `json:"total_time_ms"`
+	MeanTimeMs      float64 `json:"mean_time_ms"`
+	TimePct         float64 `json:"time_pct"`
+	Rows            int64   `json:"rows"`
+	SharedBlksHit   int64   `json:"shared_blks_hit"`
+	SharedBlksRead  int64   `json:"shared_blks_read"`
+	HitRatioPct     float64 `json:"hit_ratio_pct"`
+	TempBlksRead    int64   `json:"temp_blks_read"`
+	TempBlksWritten int64   `json:"temp_blks_written"`
+	BlkReadTimeMs   float64 `json:"blk_read_time_ms"`
+	// Toplevel is unset for the statements run inside functions, tracked
+	// with pg_stat_statements.track = all; their time is also counted in
+	// that of the calling statement.
+	Toplevel bool `json:"toplevel"`
+
+	key string
+}
+
+type Result struct {
+	Instance         string `json:"instance"`
+	Database         string `json:"database"`
+	ServerVersion    string `json:"server_version"`
+	ExtensionVersion string `json:"extension_version"`
+	StatsReset       string `json:"stats_reset,omitempty"`
+	// IntervalSeconds is set in delta mode: the counters are then those of
+	// the interval between the two snapshots, not those since stats_reset.
+	IntervalSeconds int    `json:"interval_seconds,omitempty"`
+	OrderBy         string `json:"order_by"`
+	Statements      int    `json:"statements"`
+	// Calls and TotalTimeMs sum the top-level statements, which time_pct is
+	// the share of.
+	Calls       int64   `json:"calls"`
+	TotalTimeMs float64 `json:"total_time_ms"`
+	// Hidden counts the statements of other users whose queryid and text
+	// the connected user is not allowed to see; they are left out.
+	Hidden int         `json:"hidden,omitempty"`
+	Top    []Statement `json:"top"`
+	Notes  []string    `json:"notes,omitempty"`
+}
+
+func init() {
+	registry.Add(registry.Property{
+		Name:        "postgresql_top_queries",
+		Description: "Return the top statements of pg_stat_statements ranked by total or mean execution time, calls, rows, shared blocks hit or read, or temporary blocks written, with their share of the total time and buffer cache hit ratio. Counters are cumulative since stats_reset, or, with interval_seconds, the difference between two snapshots taken that far apart to see what runs right now. Works with the column names of PostgreSQL 12 to 17. Requires the pg_stat_statements extension; the statements of other users need pg_read_all_stats.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"db_instance_identifier": map[string]any{
+					"type":        "string",
+					"description": "The RDS DB instance identifier. Credentials are read from ~/.pgpass matching the instance identifier against the hostname.",
+				},
+				"database": map[string]any{
+					"type":        "string",
+					"description": "The database where the pg_stat_statements extension is installed (default: the one configured for the instance). Statements of every database are returned.",
+				},
+				"order_by": map[string]any{
+					"type":        "string",
+					"enum":        orders,
+					"description": "Metric the statements are ranked by (default: total_time).",
+				},
+				"interval_seconds": map[string]any{
+					"type":        "integer",
+					"description": fmt.Sprintf("Delta mode: take two snapshots this many seconds apart and rank the statements by what they did in between (maximum: %d). Default: cumulative counters.", maxInterval),
+				},
+				"limit": map[string]any{
+					"type":        "integer",
+					"description": fmt.Sprintf("Maximum number of statements to return (default: %d).", defaultLimit),
+				},
+			},
+			"required": []string{"db_instance_identifier"},
+		},
+		Output:  Result{},
+		Timeout: maxInterval*time.Second + time.Minute,
+		Function: func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
+			instanceID, _ := args["db_instance_identifier"].(string)
+			database, _ := args["database"].(string)
+
+			orderBy, _ := args["order_by"].(string)
+			if orderBy == "" {
+				orderBy = "total_time"
+			}
+			metric, ok := metrics[orderBy]
+			if !ok {
+				return &mcp.CallToolResult{}, nil, toolerr.Errorf(toolerr.InvalidInput, "pass one of "+strings.Join(orders, ", "),
+					"unknown order_by %q", orderBy)
+			}
+
+			limit := defaultLimit
+			if l, ok := args["limit"].(float64); ok && l > 0 {
+				limit = int(l)
+			}
+
+			interval := 0
+			if i, ok := args["interval_seconds"].(float64); ok && i > 0 {
+				interval = min(int(i), maxInterval)
+			}
+
+			// An empty database selects the one configured for the instance.
+			db, err := psqldriver.ConnectDB(ctx, instanceID, database)
+			if err != nil {
+				return &mcp.CallToolResult{}, nil, err
+			}
+
+			result := Result{
+				Instance:        instanceID,
+				Database:        database,
+				IntervalSeconds: interval,
+				OrderBy:         orderBy,
+			}
+
+			var schema string
+			err = db.QueryRowContext(ctx, `
+				SELECT current_setting('server_version'), e.extversion, n.nspname
+				FROM pg_extension e
+				JOIN pg_namespace n ON n.oid = e.extnamespace
+				WHERE e.extname = 'pg_stat_statements'`).Scan(&result.ServerVersion, &result.ExtensionVersion, &schema)
+			if errors.Is(err, sql.ErrNoRows) {
+				return &mcp.CallToolResult{}, nil, toolerr.Errorf(toolerr.NotFound, "run CREATE EXTENSION pg_stat_statements in the database, or pass the database where it is installed; the library must be in shared_preload_libraries, as in the RDS default parameter group",
+					"pg_stat_statements is not installed in database %q", database)
+			}
+			if err != nil {
+				return &mcp.CallToolResult{}, nil, fmt.Errorf("reading pg_stat_statements version: %w", err)
+			}
+
+			q := newQuery(schema, result.ExtensionVersion)
+
+			if q.info {
+				var reset sql.NullTime
+				if err := db.QueryRowContext(ctx, `SELECT stats_reset FROM `+q.relation("pg_stat_statements_info")).Scan(&reset); err != nil {
+					return &mcp.CallToolResult{}, nil, snapshotError(err)
+				}
+				if reset.Valid {
+					result.StatsReset = reset.Time.UTC().Format(time.RFC3339)
+				}
+			}
+
+			statements, hidden, err := q.snapshot(ctx, db)
+			if err != nil {
+				return &mcp.CallToolResult{}, nil, err
+			}
+
+			if interval > 0 {
+				select {
+				case <-ctx.Done():
+					return &mcp.CallToolResult{}, nil, ctx.Err()
+				case <-time.After(time.Duration(interval) * time.Second):
+				}
+
+				after, hiddenAfter, err := q.snapshot(ctx, db)
+				if err != nil {
+					return &mcp.CallToolResult{}, nil, err
+				}
+				statements = delta(statements, after)
+				hidden = hiddenAfter
+			}
+
+			for _, s := range statements {
+				if s.Toplevel {
+					result.Calls += s.Calls
+					result.TotalTimeMs += s.TotalTimeMs
+				}
+			}
+			result.Statements = len(statements)
+			result.TotalTimeMs = display.Round(result.TotalTimeMs, 3)
+			result.Hidden = hidden
+			if hidden > 0 {
+				result.Notes = append(result.Notes, fmt.Sprintf("%d statements of other users are hidden: grant pg_read_all_stats to the user to see them.", hidden))
+			}
+
+			rank(statements, metric)
+			if len(statements) > limit {
+				statements = statements[:limit]
+			}
+
+			result.Top = make([]Statement, 0, len(statements))
+			for _, s := range statements {
+				if result.TotalTimeMs > 0 {
+					s.TimePct = display.Round(s.TotalTimeMs*100/result.TotalTimeMs, 3)
+				}
+				if s.Calls > 0 {
+					s.MeanTimeMs = display.Round(s.TotalTimeMs/float64(s.Calls), 3)
+				}
+				if blks := s.SharedBlksHit + s.SharedBlksRead; blks > 0 {
+					s.HitRatioPct = display.Round(float64(s.SharedBlksHit)*100/float64(blks), 3)
+				}
+				s.TotalTimeMs = display.Round(s.TotalTimeMs, 3)
+				s.BlkReadTimeMs = display.Round(s.BlkReadTimeMs, 3)
+				result.Top = append(result.Top, s)
+			}
+
+			return &mcp.CallToolResult{}, result, nil
+		},
+	})
+}
+
+// query reads pg_stat_statements with the column names of its version:
+// 1.8 (PostgreSQL 13) renamed total_time to total_exec_time, 1.9
+// (PostgreSQL 14) added toplevel and pg_stat_statements_info, and 1.11
+// (PostgreSQL 17) renamed blk_read_time to shared_blk_read_time.
+type query struct {
+	schema      string
+	totalTime   string
+	blkReadTime string
+	toplevel    string
+	info        bool
+}
+
+func newQuery(schema, version string) query {
+	q := query{
+		schema:      schema,
+		totalTime:   "s.total_time",
+		blkReadTime: "s.blk_read_time",
+		toplevel:    "true",
+	}
+
+	_, minor, _ := strings.Cut(version, ".")
+	n, _ := strconv.Atoi(minor)
+	if n >= 8 {
+		q.totalTime = "s.total_exec_time"
+	}
+	if n >= 9 {
+		q.toplevel = "s.toplevel"
+		q.info = true
+	}
+	if n >= 11 {
+		q.blkReadTime = "s.shared_blk_read_time"
+	}
+
+	return q
+}
+
+func (q query) relation(name string) string {
+	return pq.QuoteIdentifier(q.schema) + "." + name
+}
+
+// snapshot reads the statements, and counts those hidden from the user: their
+// queryid is NULL, so they cannot be told apart.
+func (q query) snapshot(ctx context.Context, db *sql.DB) ([]Statement, int, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT
+			s.queryid,
+			s.dbid,
+			s.userid,
+			`+q.toplevel+`,
+			COALESCE(d.datname, ''),
+			COALESCE(r.rolname, ''),
+			COALESCE(s.query, ''),
+			s.calls,
+			`+q.totalTime+`,
+			s.rows,
+			s.shared_blks_hit,
+			s.shared_blks_read,
+			s.temp_blks_read,
+			s.temp_blks_written,
+			`+q.blkReadTime+`
+		FROM `+q.relation("pg_stat_statements")+` s
+		LEFT JOIN pg_database d ON d.oid = s.dbid
+		LEFT JOIN pg_roles r ON r.oid = s.userid`)
+	if err != nil {
+		return nil, 0, snapshotError(err)
+	}
+	defer rows.Close()
+
+	statements := make([]Statement, 0)
+	hidden := 0
+	for rows.Next() {
+		var s Statement
+		var queryID sql.NullInt64
+		var dbid, userid int64
+		if err := rows.Scan(
+			&queryID, &dbid, &userid, &s.Toplevel,
+			&s.Database, &s.User, &s.Query,
+			&s.Calls, &s.TotalTimeMs, &s.Rows,
+			&s.SharedBlksHit, &s.SharedBlksRead,
+			&s.TempBlksRead, &s.TempBlksWritten,
+			&s.BlkReadTimeMs,
+		); err != nil {
+			return nil, 0, fmt.Errorf("scanning row: %w", err)
+		}
+		if !queryID.Valid {
+			hidden++
+			continue
+		}
+		s.QueryID = queryID.Int64
+		s.key = fmt.Sprintf("%d/%d/%d/%t", dbid, userid, s.QueryID, s.Toplevel)
+		statements = append(statements, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("reading rows: %w", err)
+	}
+
+	return statements, hidden, nil
+}
+
+// snapshotError explains the error pg_stat_statements raises when its
+// library is not loaded.
+func snapshotError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "55000" { // object_not_in_prerequisite_state
+		return toolerr.New(toolerr.Misconfigured, "add pg_stat_statements to shared_preload_libraries in the parameter group and reboot the instance", err)
+	}
+	return fmt.Errorf("reading pg_stat_statements: %w", err)
+}
+
+// delta returns what each statement of after did since before. Statements
+// new to after, or evicted and tracked again since, count from zero; those
+// that did not run in between are dropped.
+func delta(before, after []Statement) []Statement {
+	previous := make(map[string]Statement, len(before))
+	for _, s := range before {
+		previous[s.key] = s
+	}
+
+	statements := make([]Statement, 0)
+	for _, s := range after {
+		if p, ok := previous[s.key]; ok && s.Calls >= p.Calls {
+			s.Calls -= p.Calls
+			s.TotalTimeMs -= p.TotalTimeMs
+			s.Rows -= p.Rows
+			s.SharedBlksHit -= p.SharedBlksHit
+			s.SharedBlksRead -= p.SharedBlksRead
+			s.TempBlksRead -= p.TempBlksRead
+			s.TempBlksWritten -= p.TempBlksWritten
+			s.BlkReadTimeMs -= p.BlkReadTimeMs
+		}
+		if s.Calls > 0 {
+			statements = append(statements, s)
+		}
+	}
+
+	return statements
+}
+
+func rank(statements []Statement, metric func(Statement) float64) {
+	sort.SliceStable(statements, func(i, j int) bool {
+		return metric(statements[i]) > metric(statements[j])
+	})
+}
@@ -0,0 +1,182 @@
+package postgresql_top_queries_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nicola-strappazzon/argos/internal/testharness"
+	"github.com/nicola-strappazzon/argos/internal/toolerr"
+	"github.com/nicola-strappazzon/argos/tools/plugins/postgresql/postgresql_top_queries"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+var columns = []string{
+	"queryid", "dbid", "userid", "toplevel", "datname", "rolname", "query", "calls", "total_time",
+	"rows", "shared_blks_hit", "shared_blks_read", "temp_blks_read", "temp_blks_written", "blk_read_time",
+}
+
+func TestTopQueries(t *testing.T) {
+	mock := testharness.PostgreSQL(t, "pg1", "")
+	mock.ExpectQuery(`FROM pg_extension e`).
+		WillReturnRows(testharness.Rows([]string{"server_version", "extversion", "nspname"},
+			[]any{"17.2", "1.11", "public"},
+		))
+	mock.ExpectQuery(`SELECT stats_reset FROM "public"\.pg_stat_statements_info`).
+		WillReturnRows(testharness.Rows([]string{"stats_reset"},
+			[]any{time.Date(2024, 5, 14, 8, 0, 0, 0, time.UTC)},
+		))
+	mock.ExpectQuery(`s\.total_exec_time,.*s\.shared_blk_read_time\s+FROM "public"\.pg_stat_statements s`).
+		WillReturnRows(testharness.Rows(columns,
+			[]any{101, 16384, 10, true, "app", "app", "SELECT * FROM orders WHERE id = $1", 1000, 500.0, 1000, 3000, 1000, 0, 0, 12.5},
+			[]any{102, 16384, 10, true, "app", "app", "SELECT count(*) FROM events", 2, 1500.0, 2, 10, 90, 40, 40, 80.0},
+			[]any{103, 16384, 10, true, "app", "app", "UPDATE users SET seen = now()", 50, 0.5, 50, 100, 0, 0, 0, 0.0},
+			// Run by the statement above inside a function: not counted twice.
+			[]any{104, 16384, 10, false, "app", "app", "SELECT 1 FROM sessions", 50, 0.25, 50, 50, 0, 0, 0, 0.0},
+			// Statements of another user without pg_read_all_stats.
+			[]any{nil, 16384, 11, true, "app", "batch", "<insufficient privilege>", 7, 70.0, 7, 0, 0, 0, 0, 0.0},
+			[]any{nil, 16384, 11, true, "app", "batch", "<insufficient privilege>", 3, 30.0, 3, 0, 0, 0, 0, 0.0},
+		))
+
+	var got postgresql_top_queries.Result
+	testharness.Decode(t, testharness.Call(t, "postgresql_top_queries", map[string]any{
+		"db_instance_identifier": "pg1",
+		"limit":                  2,
+	}), &got)
+
+	if got.ServerVersion != "17.2" || got.ExtensionVersion != "1.11" || got.StatsReset != "2024-05-14T08:00:00Z" {
+		t.Errorf("unexpected versions: %+v", got)
+	}
+	if got.Statements != 4 || got.Calls != 1052 || got.TotalTimeMs != 2000.5 || got.OrderBy != "total_time" {
+		t.Errorf("unexpected totals: %+v", got)
+	}
+	if got.Hidden != 2 || len(got.Notes) != 1 {
+		t.Errorf("expected the statements without queryid to be reported as hidden, got %d %v", got.Hidden, got.Notes)
+	}
+	if len(got.Top) != 2 || got.Top[0].QueryID != 102 || got.Top[1].QueryID != 101 {
+		t.Fatalf("unexpected ranking: %+v", got.Top)
+	}
+	if s := got.Top[0]; s.MeanTimeMs != 750 || s.TimePct != 74.981 || s.HitRatioPct != 10 || s.BlkReadTimeMs != 80 {
+		t.Errorf("unexpected statement: %+v", s)
+	}
+}
+
+func TestTopQueriesPostgreSQL12(t *testing.T) {
+	mock := testharness.PostgreSQL(t, "pg1", "app")
+	mock.ExpectQuery(`FROM pg_extension e`).
+		WillReturnRows(testharness.Rows([]string{"server_version", "extversion", "nspname"},
+			[]any{"12.19", "1.7", "public"},
+		))
+	mock.ExpectQuery(`true,.*s\.total_time,.*s\.blk_read_time\s+FROM`).
+		WillReturnRows(testharness.Rows(columns,
+			[]any{101, 16384, 10, true, "app", "app", "SELECT 1", 10, 1.0, 10, 0, 0, 0, 0, 0.0},
+			[]any{102, 16384, 10, true, "app", "app", "SELECT 2", 5, 10.0, 5, 0, 0, 0, 0, 0.0},
+		))
+
+	var got postgresql_top_queries.Result
+	testharness.Decode(t, testharness.Call(t, "postgresql_top_queries", map[string]any{
+		"db_instance_identifier": "pg1",
+		"database":               "app",
+		"order_by":               "calls",
+	}), &got)
+
+	if got.StatsReset != "" || len(got.Top) != 2 || got.Top[0].QueryID != 101 {
+		t.Fatalf("unexpected result: %+v", got)
+	}
+}
+
+func TestTopQueriesDelta(t *testing.T) {
+	mock := testharness.PostgreSQL(t, "pg1", "")
+	mock.MatchExpectationsInOrder(true)
+	mock.ExpectQuery(`FROM pg_extension e`).
+		WillReturnRows(testharness.Rows([]string{"server_version", "extversion", "nspname"},
+			[]any{"15.6", "1.10", "public"},
+		))
+	mock.ExpectQuery(`pg_stat_statements_info`).
+		WillReturnRows(testharness.Rows([]string{"stats_reset"}, []any{nil}))
+	mock.ExpectQuery(`s\.toplevel,.*s\.total_exec_time,.*s\.blk_read_time\s+FROM`).
+		WillReturnRows(testharness.Rows(columns,
+			[]any{101, 16384, 10, true, "app", "app", "SELECT busy", 1000, 5000.0, 1000, 100, 10, 0, 0, 1.0},
+			[]any{102, 16384, 10, true, "app", "app", "SELECT idle", 10, 900.0, 10, 0, 0, 0, 0, 0.0},
+			[]any{nil, 16384, 11, true, "app", "batch", "<insufficient privilege>", 5, 50.0, 5, 0, 0, 0, 0, 0.0},
+		))
+	mock.ExpectQuery(`FROM "public"\.pg_stat_statements s`).
+		WillReturnRows(testharness.Rows(columns,
+			[]any{101, 16384, 10, true, "app", "app", "SELECT busy", 1100, 5100.0, 1100, 150, 10, 0, 0, 1.0},
+			[]any{102, 16384, 10, true, "app", "app", "SELECT idle", 10, 900.0, 10, 0, 0, 0, 0, 0.0},
+			[]any{103, 16384, 10, true, "app", "app", "SELECT new", 3, 30.0, 3, 0, 0, 0, 6, 0.0},
+			[]any{nil, 16384, 11, true, "app", "batch", "<insufficient privilege>", 9, 90.0, 9, 0, 0, 0, 0, 0.0},
+		))
+
+	var got postgresql_top_queries.Result
+	testharness.Decode(t, testharness.Call(t, "postgresql_top_queries", map[string]any{
+		"db_instance_identifier": "pg1",
+		"interval_seconds":       1,
+	}), &got)
+
+	if got.IntervalSeconds != 1 || got.Statements != 2 || got.Calls != 103 || got.TotalTimeMs != 130 || got.Hidden != 1 {
+		t.Fatalf("unexpected totals: %+v", got)
+	}
+	if busy := got.Top[0]; busy.QueryID != 101 || busy.Calls != 100 || busy.MeanTimeMs != 1 || busy.SharedBlksHit != 50 {
+		t.Errorf("unexpected delta: %+v", busy)
+	}
+	if added := got.Top[1]; added.QueryID != 103 || added.Calls != 3 || added.TempBlksWritten != 6 {
+		t.Errorf("unexpected new statement: %+v", added)
+	}
+}
+
+func TestTopQueriesOrders(t *testing.T) {
+	for order, want := range map[string]int64{
+		"total_time":        102,
+		"mean_time":         102,
+		"calls":             101,
+		"rows":              102,
+		"shared_blks_hit":   101,
+		"shared_blks_read":  102,
+		"temp_blks_written": 102,
+	} {
+		t.Run(order, func(t *testing.T) {
+			mock := testharness.PostgreSQL(t, "pg1", "")
+			mock.ExpectQuery(`FROM pg_extension e`).
+				WillReturnRows(testharness.Rows([]string{"server_version", "extversion", "nspname"},
+					[]any{"12.19", "1.7", "public"},
+				))
+			mock.ExpectQuery(`true,.*s\.total_time,.*s\.blk_read_time\s+FROM`).
+				WillReturnRows(testharness.Rows(columns,
+					[]any{101, 16384, 10, true, "app", "app", "SELECT 1", 10, 1.0, 1, 5, 0, 0, 0, 0.0},
+					[]any{102, 16384, 10, true, "app", "app", "SELECT 2", 5, 10.0, 5, 0, 3, 0, 2, 0.0},
+				))
+
+			var got postgresql_top_queries.Result
+			testharness.Decode(t, testharness.Call(t, "postgresql_top_queries", map[string]any{
+				"db_instance_identifier": "pg1",
+				"order_by":               order,
+			}), &got)
+
+			if got.OrderBy != order || len(got.Top) != 2 || got.Top[0].QueryID != want {
+				t.Errorf("unexpected ranking: %+v", got)
+			}
+		})
+	}
+}
+
+func TestTopQueriesUnknownOrder(t *testing.T) {
+	_, err := testharness.Session(t).CallTool(context.Background(), &mcp.CallToolParams{
+		Name:      "postgresql_top_queries",
+		Arguments: map[string]any{"db_instance_identifier": "pg1", "order_by": "latency"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "order_by") {
+		t.Errorf("expected an unknown order_by to be rejected, got %v", err)
+	}
+}
+
+func TestTopQueriesWithoutExtension(t *testing.T) {
+	mock := testharness.PostgreSQL(t, "pg1", "")
+	mock.ExpectQuery(`FROM pg_extension e`).
+		WillReturnRows(testharness.Rows([]string{"server_version", "extversion", "nspname"}))
+
+	res := testharness.Call(t, "postgresql_top_queries", map[string]any{"db_instance_identifier": "pg1"})
+	testharness.Failure(t, res, toolerr.NotFound)
+}
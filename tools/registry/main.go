@@ -2,6 +2,8 @@ package registry
 
 import (
 	"context"
+	"strings"
+	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -12,9 +14,39 @@ type Property struct {
 	Name        string
 	Description string
 	InputSchema map[string]any
-	Function    func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error)
+	// Output is the zero value of the struct returned by Function, e.g.
+	// Result{}. Its JSON schema is advertised as the tool's output schema.
+	Output any
+	// Timeout is the default deadline of a call; zero uses the global one.
+	// It can be overridden with ARGOS_TIMEOUT_<NAME>.
+	Timeout time.Duration
+	// Writes is set for the tools that write files to the workspace or
+	// delete them; the artifact resources are refreshed after their calls.
+	Writes   bool
+	Function func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error)
 }
 
 func Add(p Property) {
 	Tools[p.Name] = p
 }
+
+// groups maps tool name prefixes to the group used to enable or disable
+// tools as a whole.
+var groups = []struct{ prefix, group string }{
+	{"artifacts_", "artifacts"},
+	{"aws_", "aws"},
+	{"mysql_", "mysql"},
+	{"postgresql_", "postgresql"},
+	{"pt_", "percona"},
+}
+
+// Group returns the group of the tool: artifacts, aws, mysql, postgresql or
+// percona.
+func (p Property) Group() string {
+	for _, g := range groups {
+		if strings.HasPrefix(p.Name, g.prefix) {
+			return g.group
+		}
+	}
+	return ""
+}
@@ -0,0 +1,269 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Texter is implemented by results that render their own text content.
+type Texter interface {
+	Text() string
+}
+
+// Text renders a tool result as compact text for clients that ignore
+// structured content: scalars as "name: value" lines, nested objects as
+// indented sections and lists of objects as aligned tables. Field names are
+// the JSON names, so text and structured content read the same.
+func Text(v any) string {
+	if t, ok := v.(Texter); ok {
+		return t.Text()
+	}
+
+	var b strings.Builder
+	writeValue(&b, "", indirect(reflect.ValueOf(v)))
+
+	return strings.TrimRight(b.String(), "\n")
+}
+
+type field struct {
+	name  string
+	value reflect.Value
+}
+
+func writeValue(b *strings.Builder, indent string, v reflect.Value) {
+	switch {
+	case !v.IsValid():
+	case isScalar(v):
+		b.WriteString(indent + scalar(v) + "\n")
+	case v.Kind() == reflect.Slice || v.Kind() == reflect.Array:
+		writeList(b, indent, v)
+	case v.Kind() == reflect.Struct || v.Kind() == reflect.Map:
+		writeObject(b, indent, v)
+	default:
+		b.WriteString(indent + compactJSON(v) + "\n")
+	}
+}
+
+func writeObject(b *strings.Builder, indent string, v reflect.Value) {
+	for _, f := range fields(v, false) {
+		value := indirect(f.value)
+
+		switch {
+		case !value.IsValid():
+		case isScalar(value):
+			s := scalar(value)
+			if strings.Contains(s, "\n") {
+				b.WriteString(indent + f.name + ":\n")
+				for _, line := range strings.Split(strings.TrimRight(s, "\n"), "\n") {
+					b.WriteString(indent + "  " + line + "\n")
+				}
+				continue
+			}
+			b.WriteString(indent + f.name + ": " + s + "\n")
+		case value.Kind() == reflect.Slice || value.Kind() == reflect.Array:
+			if value.Len() == 0 {
+				b.WriteString(indent + f.name + ": none\n")
+				continue
+			}
+			if isScalar(indirect(value.Index(0))) {
+				b.WriteString(indent + f.name + ": " + joinScalars(value) + "\n")
+				continue
+			}
+			b.WriteString(indent + f.name + ":\n")
+			writeList(b, indent+"  ", value)
+		case value.Kind() == reflect.Struct || value.Kind() == reflect.Map:
+			b.WriteString(indent + f.name + ":\n")
+			writeObject(b, indent+"  ", value)
+		default:
+			b.WriteString(indent + f.name + ": " + compactJSON(value) + "\n")
+		}
+	}
+}
+
+// writeList renders a list of objects as a table whose columns are the
+// union of their fields, in order of appearance.
+func writeList(b *strings.Builder, indent string, v reflect.Value) {
+	if v.Len() == 0 || isScalar(indirect(v.Index(0))) {
+		b.WriteString(indent + joinScalars(v) + "\n")
+		return
+	}
+
+	columns := []string{}
+	seen := map[string]bool{}
+	rows := make([]map[string]string, 0, v.Len())
+
+	for i := 0; i < v.Len(); i++ {
+		item := indirect(v.Index(i))
+		itemFields := []field{{name: "value", value: item}}
+		if item.Kind() == reflect.Struct || item.Kind() == reflect.Map {
+			itemFields = fields(item, true)
+		}
+
+		row := map[string]string{}
+		for _, f := range itemFields {
+			row[f.name] = cell(indirect(f.value))
+			if !seen[f.name] {
+				seen[f.name] = true
+				columns = append(columns, f.name)
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	// Drop columns that are empty in every row.
+	nonEmpty := columns[:0]
+	for _, c := range columns {
+		for _, row := range rows {
+			if row[c] != "" {
+				nonEmpty = append(nonEmpty, c)
+				break
+			}
+		}
+	}
+	columns = nonEmpty
+
+	tw := tabwriter.NewWriter(b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, indent+strings.Join(columns, "\t"))
+	for _, row := range rows {
+		cells := make([]string, len(columns))
+		for i, c := range columns {
+			cells[i] = row[c]
+			if cells[i] == "" {
+				cells[i] = "-"
+			}
+		}
+		fmt.Fprintln(tw, indent+strings.Join(cells, "\t"))
+	}
+	tw.Flush()
+}
+
+// fields returns the JSON fields of a struct, skipping empty omitempty
+// ones unless all is set, or the entries of a map sorted by key.
+func fields(v reflect.Value, all bool) []field {
+	out := []field{}
+
+	if v.Kind() == reflect.Map {
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j]) })
+		for _, k := range keys {
+			out = append(out, field{name: fmt.Sprint(k), value: v.MapIndex(k)})
+		}
+		return out
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if name == "-" && opts == "" {
+			continue
+		}
+
+		fv := v.Field(i)
+		if sf.Anonymous && name == "" && indirect(fv).Kind() == reflect.Struct {
+			out = append(out, fields(indirect(fv), all)...)
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		if !all && (strings.Contains(opts, "omitempty") || strings.Contains(opts, "omitzero")) && isEmpty(fv) {
+			continue
+		}
+
+		out = append(out, field{name: name, value: fv})
+	}
+
+	return out
+}
+
+func indirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map, reflect.String, reflect.Array:
+		return v.Len() == 0
+	}
+	return v.IsZero()
+}
+
+func isScalar(v reflect.Value) bool {
+	if !v.IsValid() {
+		return false
+	}
+	if v.Type() == reflect.TypeFor[time.Time]() {
+		return true
+	}
+	switch v.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+func scalar(v reflect.Value) string {
+	if t, ok := v.Interface().(time.Time); ok {
+		return t.Format(time.RFC3339)
+	}
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	case reflect.String:
+		return v.String()
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// cell renders a table cell on a single line.
+func cell(v reflect.Value) string {
+	switch {
+	case !v.IsValid():
+		return ""
+	case isScalar(v):
+		return strings.Join(strings.Fields(scalar(v)), " ")
+	case (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) && v.Len() > 0 && isScalar(indirect(v.Index(0))):
+		return joinScalars(v)
+	case isEmpty(v):
+		return ""
+	}
+	return compactJSON(v)
+}
+
+func joinScalars(v reflect.Value) string {
+	items := make([]string, 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		if item := indirect(v.Index(i)); item.IsValid() {
+			items = append(items, scalar(item))
+		}
+	}
+	return strings.Join(items, ", ")
+}
+
+func compactJSON(v reflect.Value) string {
+	data, err := json.Marshal(v.Interface())
+	if err != nil {
+		return fmt.Sprint(v.Interface())
+	}
+	return string(data)
+}